@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"syscall"
+	"time"
+
+	"agent-trace/internal/cli"
+	"agent-trace/internal/config"
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+	"agent-trace/internal/llm"
+	"agent-trace/internal/logging"
+	"agent-trace/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func main() {
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintln(out, "Usage: agent-trace [command] [flags]")
+		fmt.Fprintln(out, "\nCommands:")
+		fmt.Fprintln(out, "  open ID   start the terminal UI on one session (ID, unique prefix or alias)")
+		fmt.Fprintln(out, "  status    print index freshness and session counts, then exit")
+		fmt.Fprintln(out, "  report    summarize sessions, tokens and estimated cost per week or month")
+		fmt.Fprintln(out, "            (--period week|month, --format markdown|csv, --since YYYY-MM-DD, --top N)")
+		fmt.Fprintln(out, "  sessions  dump session metadata as CSV for spreadsheets (--format csv|tsv)")
+		fmt.Fprintln(out, "  prune     delete sessions older than a cutoff and vacuum the index")
+		fmt.Fprintln(out, "            (--older-than 90d, --dry-run)")
+		fmt.Fprintln(out, "  daemon    keep the index fresh in the background so the UI starts instantly")
+		fmt.Fprintln(out, "            (--interval 5s)")
+		fmt.Fprintln(out, "  doctor    index, then list files that were skipped or had unparseable lines")
+		fmt.Fprintln(out, "  import    merge sessions from another machine's index (import OTHER.sqlite)")
+		fmt.Fprintln(out, "  summarize have the --summarizer model summarize new and grown sessions")
+		fmt.Fprintln(out, "            (--limit 50, 0 for all)")
+		fmt.Fprintln(out, "  embed     store --embedder vectors of new messages for semantic (about:) search")
+		fmt.Fprintln(out, "            (--limit N, default all)")
+		fmt.Fprintln(out, "\nWith no command, the terminal UI starts.\n\nFlags:")
+		flag.PrintDefaults()
+	}
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "agent-trace:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	cfg, err := config.Parse()
+	if err != nil {
+		return err
+	}
+	logger, closeLog, err := logging.Open(cfg.LogFile, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	logger.Info("starting", "command", cfg.Command, "db", cfg.DBPath, "pid", os.Getpid())
+
+	err = runCommand(cfg, logger)
+	if err != nil {
+		logger.Error("exiting with error", "command", cfg.Command, "err", err)
+	}
+	return err
+}
+
+func runCommand(cfg config.AppConfig, logger *slog.Logger) error {
+	idx, err := index.New(cfg.CodexHomes, cfg.ClaudeHomes, cfg.DBPath, cfg.Reindex)
+	if err != nil {
+		return err
+	}
+	defer idx.Close()
+	idx.SetLogger(logger)
+	idx.SetSyncFile(cfg.AnnotationsFile)
+	idx.SetCompression(cfg.CompressContent)
+	idx.SetSources(!cfg.NoCodex, !cfg.NoClaude)
+	idx.SetShowEmpty(cfg.ShowEmpty)
+	index.SetTimeDisplay(cfg.TimeZone, cfg.TimeFormat)
+	if err := idx.SetExclude(cfg.Exclude); err != nil {
+		return err
+	}
+	if err := idx.SetScanRoots(cfg.ScanRoots); err != nil {
+		return err
+	}
+	if err := idx.SetRemotes(cfg.Remotes); err != nil {
+		return err
+	}
+	// Every command that indexes must redact, not only the UI: the daemon
+	// and report runs store content the UI later shows.
+	normalizer, closeNormalizer := buildNormalizer(cfg.Normalize)
+	defer closeNormalizer()
+	if err := idx.SetNormalizer(normalizer, cfg.Normalize.Fingerprint()); err != nil {
+		return err
+	}
+
+	switch cfg.Command {
+	case "":
+	case "open":
+		if cfg.Session == "" || len(cfg.CommandArgs) > 0 {
+			return fmt.Errorf("usage: agent-trace open <session-id|prefix|alias> [flags]")
+		}
+	case "status":
+		if cfg.InMemory() {
+			// A fresh in-memory index has nothing to report until it is built.
+			if _, err := idx.BuildIndex(context.Background()); err != nil {
+				return err
+			}
+		}
+		return cli.Status(context.Background(), os.Stdout, idx, cfg.DBPath)
+	case "report":
+		opts, err := cli.ParseReportArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.BuildIndex(context.Background()); err != nil {
+			return err
+		}
+		sessions, err := idx.AllSessions()
+		if err != nil {
+			return err
+		}
+		return cli.Report(os.Stdout, sessions, cfg.Pricing, opts)
+	case "sessions":
+		format, err := cli.ParseSessionsArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.BuildIndex(context.Background()); err != nil {
+			return err
+		}
+		sessions, err := idx.AllSessions()
+		if err != nil {
+			return err
+		}
+		first, err := idx.FirstActivity()
+		if err != nil {
+			return err
+		}
+		annotations, err := idx.Annotations()
+		if err != nil {
+			return err
+		}
+		return cli.Sessions(os.Stdout, format, sessions, first, annotations)
+	case "prune":
+		opts, err := cli.ParsePruneArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		return cli.Prune(context.Background(), os.Stdout, idx, opts, time.Now())
+	case "daemon":
+		opts, err := cli.ParseDaemonArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		if cfg.InMemory() {
+			return fmt.Errorf("daemon needs an on-disk index; drop --ephemeral")
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return cli.Daemon(ctx, os.Stdout, idx, cli.DaemonPIDPath(cfg.DBPath), opts)
+	case "doctor":
+		return cli.Doctor(context.Background(), os.Stdout, idx)
+	case "import":
+		return cli.Import(context.Background(), os.Stdout, idx, cfg.CommandArgs)
+	case "summarize":
+		opts, err := cli.ParseSummarizeArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		if cfg.Summarizer == "" {
+			return fmt.Errorf("summarize needs a model: set --summarizer, e.g. --summarizer ollama:llama3.2")
+		}
+		model, err := llm.New(cfg.Summarizer, cfg.SummarizerURL)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.BuildIndex(context.Background()); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return cli.Summarize(ctx, os.Stdout, idx, model, opts)
+	case "embed":
+		opts, err := cli.ParseEmbedArgs(cfg.CommandArgs)
+		if err != nil {
+			return err
+		}
+		if cfg.Embedder == "" {
+			return fmt.Errorf("embed needs a model: set --embedder, e.g. --embedder ollama:nomic-embed-text")
+		}
+		embedder, err := llm.New(cfg.Embedder, cfg.EmbedderURL)
+		if err != nil {
+			return err
+		}
+		if _, err := idx.BuildIndex(context.Background()); err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return cli.Embed(ctx, os.Stdout, idx, embedder, opts)
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command %q", cfg.Command)
+	}
+
+	exp, err := export.New(cfg.ExportDir)
+	if err != nil {
+		return err
+	}
+	exp.ExportImages = cfg.ExportImages
+	exp.ToolLines = cfg.ExportToolLines
+	exp.FrontMatter = cfg.ExportFrontMatter
+	exp.Versions = cfg.ExportVersions
+	exp.Manifest = cfg.ExportManifest
+	exp.Sessions = idx
+	if exp.NameTemplate, err = export.ParseNameTemplate(cfg.ExportName); err != nil {
+		return err
+	}
+	exp.Pricing = cfg.Pricing
+	exp.Log = logger
+
+	if cfg.Here || cfg.Dir != "" {
+		if cfg.Scope, err = projectRoot(cfg.Dir); err != nil {
+			return err
+		}
+		idx.SetScope(cfg.Scope)
+	}
+
+	model := ui.NewModel(cfg, idx, exp)
+	model.UseLogger(logger)
+	if cfg.Embedder != "" {
+		embedder, err := llm.New(cfg.Embedder, cfg.EmbedderURL)
+		if err != nil {
+			return err
+		}
+		model.UseEmbedder(embedder)
+	}
+	switch {
+	case cfg.Session != "":
+		model.FocusSession(cfg.Session)
+	case cfg.Latest == config.LatestHere:
+		dir := cfg.Scope
+		if dir == "" {
+			if dir, err = projectRoot(""); err != nil {
+				return err
+			}
+		}
+		model.FocusLatest(dir)
+	case cfg.Latest != "":
+		model.FocusLatest("")
+	}
+	if pid, ok := cli.DaemonRunning(cli.DaemonPIDPath(cfg.DBPath)); ok {
+		model.UseDaemon(pid)
+	}
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
+
+// projectRoot resolves dir (the current directory when empty) to the
+// repository holding it, or to dir itself outside a repository.
+func projectRoot(dir string) (string, error) {
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("resolve current directory: %w", err)
+		}
+		dir = cwd
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", dir, err)
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("-C %s: not a directory", dir)
+	}
+	if root := export.FindRepoRoot(abs); root != "" {
+		return root, nil
+	}
+	return abs, nil
+}
+
+// buildNormalizer assembles the configured strip rules and external command
+// into one normalizer. It returns nil when normalization is off; the close
+// function stops the external command if one was started.
+func buildNormalizer(cfg config.NormalizeConfig) (index.Normalizer, func()) {
+	var chain index.ChainNormalizer
+	if len(cfg.Strip) > 0 {
+		rules := make(index.RuleNormalizer, 0, len(cfg.Strip))
+		for _, r := range cfg.Strip {
+			// Patterns were validated by config.Parse.
+			rules = append(rules, index.StripRule{Pattern: regexp.MustCompile(r.Pattern), Replace: r.Replace})
+		}
+		chain = append(chain, rules)
+	}
+	closeFn := func() {}
+	if len(cfg.Command) > 0 {
+		cmd := index.NewCommandNormalizer(cfg.Command)
+		chain = append(chain, cmd)
+		closeFn = func() { _ = cmd.Close() }
+	}
+	if len(chain) == 0 {
+		return nil, closeFn
+	}
+	return chain, closeFn
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+)
+
+func TestCommandsIndexWithTheNormalizer(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	id := "42424242-4242-4242-4242-424242424242"
+	line := `{"type":"user","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"deploy with token sk-live-123"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(proj, id+".jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "index.sqlite")
+	cfg := config.AppConfig{
+		Command:     "report",
+		CodexHomes:  []string{t.TempDir()},
+		ClaudeHomes: []string{claudeHome},
+		DBPath:      dbPath,
+		Normalize:   config.NormalizeConfig{Strip: []config.StripRuleConfig{{Pattern: `sk-live-\w+`, Replace: "[key]"}}},
+	}
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	os.Stdout = devNull
+	err = runCommand(cfg, logging.Discard())
+	os.Stdout = stdout
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+
+	idx, err := index.New(cfg.CodexHomes, cfg.ClaudeHomes, dbPath, false)
+	if err != nil {
+		t.Fatalf("reopen index: %v", err)
+	}
+	defer idx.Close()
+	msgs, err := idx.GetMessages(id)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("messages = %+v, %v", msgs, err)
+	}
+	if strings.Contains(msgs[0].Content, "sk-live") || !strings.Contains(msgs[0].Content, "[key]") {
+		t.Fatalf("report indexed un-normalized content: %q", msgs[0].Content)
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+// DaemonOptions are the flags of the daemon command.
+type DaemonOptions struct {
+	Interval time.Duration
+}
+
+// ParseDaemonArgs parses `daemon` flags from args.
+func ParseDaemonArgs(args []string) (DaemonOptions, error) {
+	fs := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	var opts DaemonOptions
+	fs.DurationVar(&opts.Interval, "interval", 5*time.Second, "how often to check agent homes for new or changed sessions")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Interval < time.Second {
+		return opts, fmt.Errorf("daemon --interval must be at least 1s, got %s", opts.Interval)
+	}
+	return opts, nil
+}
+
+// Daemon keeps the index fresh until ctx is cancelled, re-running the
+// incremental index pass every opts.Interval. It records its pid at pidPath
+// so status and the TUI can find it, and refuses to start while another
+// daemon for the same index is alive.
+func Daemon(ctx context.Context, w io.Writer, idx *index.Indexer, pidPath string, opts DaemonOptions) error {
+	if pid, ok := DaemonRunning(pidPath); ok {
+		return fmt.Errorf("daemon already running (pid %d)", pid)
+	}
+	pid := strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(pidPath, []byte(pid+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write daemon pid file: %w", err)
+	}
+	defer func() {
+		// Leave the file alone if a newer daemon has taken it over.
+		if data, err := os.ReadFile(pidPath); err == nil && strings.TrimSpace(string(data)) == pid {
+			_ = os.Remove(pidPath)
+		}
+	}()
+
+	fmt.Fprintf(w, "agent-trace daemon (pid %s): indexing every %s\n", pid, opts.Interval)
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		res, err := idx.BuildIndex(ctx)
+		switch {
+		case errors.Is(err, context.Canceled):
+		case err != nil:
+			fmt.Fprintf(w, "%s index failed: %v\n", time.Now().Format(time.DateTime), err)
+		case res.Skipped > 0:
+			fmt.Fprintf(w, "%s indexed (%d file(s) skipped)\n", time.Now().Format(time.DateTime), res.Skipped)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
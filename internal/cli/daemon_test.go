@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+func TestDaemonKeepsIndexFresh(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	if err := os.MkdirAll(proj, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(t.TempDir(), "index.sqlite")
+	idx, err := index.New([]string{t.TempDir()}, []string{claudeHome}, dbPath, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+	pidPath := DaemonPIDPath(dbPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	var out bytes.Buffer
+	go func() { done <- Daemon(ctx, &out, idx, pidPath, DaemonOptions{Interval: time.Second}) }()
+
+	waitFor(t, func() bool {
+		pid, ok := DaemonRunning(pidPath)
+		return ok && pid == os.Getpid()
+	})
+	if err := Daemon(context.Background(), &bytes.Buffer{}, idx, pidPath, DaemonOptions{Interval: time.Second}); err == nil || !strings.Contains(err.Error(), "already running") {
+		t.Fatalf("expected a second daemon to be refused, got %v", err)
+	}
+
+	// A session written while the daemon runs is picked up without a TUI.
+	id := "99999999-9999-9999-9999-999999999999"
+	line := `{"type":"user","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}` + "\n"
+	if err := os.WriteFile(filepath.Join(proj, id+".jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		s, err := idx.GetSession(id)
+		return err == nil && s.ID == id
+	})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("daemon: %v", err)
+	}
+	if _, err := os.Stat(pidPath); !os.IsNotExist(err) {
+		t.Fatalf("expected the pid file removed on exit, stat err %v", err)
+	}
+	if !strings.Contains(out.String(), "pid "+strconv.Itoa(os.Getpid())) {
+		t.Fatalf("unexpected daemon output: %q", out.String())
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
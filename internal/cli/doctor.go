@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"agent-trace/internal/index"
+)
+
+// Doctor indexes, then lists source files that were skipped or had lines
+// that did not parse, so users can fix or remove them.
+func Doctor(ctx context.Context, w io.Writer, idx *index.Indexer) error {
+	if _, err := idx.BuildIndex(ctx); err != nil {
+		return err
+	}
+	issues, err := idx.IngestIssues()
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		fmt.Fprintln(w, "No ingest problems found.")
+		return nil
+	}
+	fmt.Fprintf(w, "%d file(s) with problems:\n", len(issues))
+	for _, is := range issues {
+		fmt.Fprintf(w, "\n%s\n", is.Path)
+		for _, line := range is.Describe() {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+	return nil
+}
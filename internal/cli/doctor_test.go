@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestDoctor(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "13131313-1313-1313-1313-131313131313"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := index.New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	var out bytes.Buffer
+	if err := Doctor(context.Background(), &out, idx); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if !strings.Contains(out.String(), "No ingest problems found.") {
+		t.Fatalf("expected a clean bill of health, got:\n%s", out.String())
+	}
+
+	if err := os.WriteFile(path, []byte(line+"{truncated\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := Doctor(context.Background(), &out, idx); err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	for _, want := range []string{"1 file(s) with problems:", path, "1 unparseable line(s) left out; first at byte " + strconv.Itoa(len(line))} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in doctor output:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"agent-trace/internal/index"
+)
+
+// EmbedOptions are the flags of the embed command.
+type EmbedOptions struct {
+	// Limit caps how many messages one run embeds; 0 means all.
+	Limit int
+}
+
+// ParseEmbedArgs parses `embed` flags from args.
+func ParseEmbedArgs(args []string) (EmbedOptions, error) {
+	fs := flag.NewFlagSet("embed", flag.ContinueOnError)
+	var opts EmbedOptions
+	fs.IntVar(&opts.Limit, "limit", 0, "embed at most N messages, newest first (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Limit < 0 {
+		return opts, fmt.Errorf("limit must not be negative, got %d", opts.Limit)
+	}
+	return opts, nil
+}
+
+// Embed stores vectors for the prompts and replies the embedder has not
+// seen, so about: searches can find them, and reports how many it added.
+// Vectors stored before a failure are kept.
+func Embed(ctx context.Context, w io.Writer, idx *index.Indexer, e index.Embedder, opts EmbedOptions) error {
+	n, err := idx.EmbedMessages(ctx, e, opts.Limit, nil)
+	if err != nil {
+		return fmt.Errorf("embed messages (%d stored): %w", n, err)
+	}
+	total, err := idx.EmbeddingCount(e.Name())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "embedded %d message(s) with %s; %d in total\n", n, e.Name(), total)
+	return nil
+}
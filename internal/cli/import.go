@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"agent-trace/internal/index"
+)
+
+// Import merges the index at each path in args into idx, e.g. a copy of
+// another machine's index.sqlite, and reports what was added.
+func Import(ctx context.Context, w io.Writer, idx *index.Indexer, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: agent-trace import OTHER-INDEX.sqlite...")
+	}
+	for _, path := range args {
+		res, err := idx.Import(ctx, path)
+		if err != nil {
+			return err
+		}
+		if res.Messages == 0 {
+			fmt.Fprintf(w, "%s: nothing new\n", path)
+			continue
+		}
+		fmt.Fprintf(w, "%s: imported %d message(s) in %d session(s)\n", path, res.Messages, res.Sessions)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+// PruneOptions are the flags of the prune command.
+type PruneOptions struct {
+	OlderThan time.Duration
+	DryRun    bool
+}
+
+// ParsePruneArgs parses `prune` flags from args.
+func ParsePruneArgs(args []string) (PruneOptions, error) {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	var opts PruneOptions
+	olderThan := fs.String("older-than", "", "delete sessions last active longer ago than this, e.g. 90d, 12w or 720h (required)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "report what would be deleted without changing the index")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if *olderThan == "" {
+		return opts, errors.New("prune needs --older-than, e.g. --older-than 90d")
+	}
+	d, err := ParseAge(*olderThan)
+	if err != nil {
+		return opts, err
+	}
+	opts.OlderThan = d
+	return opts, nil
+}
+
+// ParseAge parses a positive age: whole days ("90d"), weeks ("12w") or any
+// time.ParseDuration value ("36h").
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	var d time.Duration
+	if unit > 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		d = time.Duration(n) * unit
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid age %q: use e.g. 90d, 12w or 720h", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("age %q must be positive", s)
+	}
+	return d, nil
+}
+
+// Prune deletes sessions older than the cutoff, vacuums the index and
+// reports how much space that freed.
+func Prune(ctx context.Context, w io.Writer, idx *index.Indexer, opts PruneOptions, now time.Time) error {
+	cutoff := now.Add(-opts.OlderThan)
+	before := idx.DBSize()
+	res, err := idx.Prune(ctx, cutoff, opts.DryRun)
+	if err != nil {
+		return err
+	}
+	what := fmt.Sprintf("%d session(s), %d message(s) last active before %s", res.Sessions, res.Messages, index.FormatUnix(cutoff.Unix()))
+	if opts.DryRun {
+		fmt.Fprintf(w, "would prune %s\nindex: %s\n", what, formatSize(before))
+		return nil
+	}
+	if err := idx.Vacuum(ctx); err != nil {
+		return err
+	}
+	after := idx.DBSize()
+	fmt.Fprintf(w, "pruned %s\nindex: %s -> %s (%s freed)\n", what, formatSize(before), formatSize(after), formatSize(max(before-after, 0)))
+	return nil
+}
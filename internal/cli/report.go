@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
+)
+
+// ReportOptions are the flags of the report command.
+type ReportOptions struct {
+	Period string // "week" or "month"
+	Format string // "markdown" or "csv"
+	Since  time.Time
+	Top    int // repos listed per period
+}
+
+// ParseReportArgs parses `report` flags from args.
+func ParseReportArgs(args []string) (ReportOptions, error) {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	opts := ReportOptions{}
+	var since string
+	fs.StringVar(&opts.Period, "period", "month", "group sessions by week or month")
+	fs.StringVar(&opts.Format, "format", "markdown", "output format: markdown or csv")
+	fs.StringVar(&since, "since", "", "only include sessions active on or after this date (YYYY-MM-DD)")
+	fs.IntVar(&opts.Top, "top", 5, "number of repos listed per period")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Period != "week" && opts.Period != "month" {
+		return opts, fmt.Errorf("unknown report period %q (want week or month)", opts.Period)
+	}
+	if opts.Format != "markdown" && opts.Format != "csv" {
+		return opts, fmt.Errorf("unknown report format %q (want markdown or csv)", opts.Format)
+	}
+	if since != "" {
+		t, err := time.ParseInLocation("2006-01-02", since, index.DisplayZone())
+		if err != nil {
+			return opts, fmt.Errorf("parse --since: %w", err)
+		}
+		opts.Since = t
+	}
+	return opts, nil
+}
+
+// reportBucket aggregates sessions for one period, or one repo within it.
+type reportBucket struct {
+	Name     string
+	Sessions int
+	BySource map[string]int
+	Usage    []index.Usage
+	repos    map[string]*reportBucket
+}
+
+func (b *reportBucket) add(s index.Session) {
+	b.Sessions++
+	if b.BySource == nil {
+		b.BySource = map[string]int{}
+	}
+	b.BySource[s.Source]++
+	b.Usage = append(b.Usage, s.Usage...)
+}
+
+func (b *reportBucket) tokens() int64 {
+	return index.SumUsage(b.Usage).Total()
+}
+
+// Report writes a per-period summary of sessions, tokens, estimated cost and
+// top repos.
+func Report(w io.Writer, sessions []index.Session, prices pricing.Table, opts ReportOptions) error {
+	periods := buildReport(sessions, opts, export.FindRepoRoot)
+	if opts.Format == "csv" {
+		return writeReportCSV(w, periods, prices, opts)
+	}
+	return writeReportMarkdown(w, periods, prices, opts)
+}
+
+// buildReport buckets sessions by the period of their last activity,
+// newest period first. repoOf maps a workdir to its repository root.
+func buildReport(sessions []index.Session, opts ReportOptions, repoOf func(string) string) []*reportBucket {
+	byName := map[string]*reportBucket{}
+	roots := map[string]string{}
+	for _, s := range sessions {
+		if s.LastActivityTS <= 0 {
+			continue
+		}
+		t := index.DisplayTime(s.LastActivityTS)
+		if !opts.Since.IsZero() && t.Before(opts.Since) {
+			continue
+		}
+		name := periodName(t, opts.Period)
+		p := byName[name]
+		if p == nil {
+			p = &reportBucket{Name: name, repos: map[string]*reportBucket{}}
+			byName[name] = p
+		}
+		p.add(s)
+
+		repo, ok := roots[s.Workdir]
+		if !ok {
+			repo = repoOf(s.Workdir)
+			if repo == "" {
+				repo = s.Workdir
+			}
+			if repo == "" {
+				repo = "(no workdir)"
+			}
+			roots[s.Workdir] = repo
+		}
+		r := p.repos[repo]
+		if r == nil {
+			r = &reportBucket{Name: repo}
+			p.repos[repo] = r
+		}
+		r.add(s)
+	}
+
+	out := make([]*reportBucket, 0, len(byName))
+	for _, p := range byName {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].Name > out[b].Name })
+	return out
+}
+
+func periodName(t time.Time, period string) string {
+	if period == "week" {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01")
+}
+
+// topRepos orders repos by tokens, then sessions, then name.
+func (b *reportBucket) topRepos(n int) []*reportBucket {
+	repos := make([]*reportBucket, 0, len(b.repos))
+	for _, r := range b.repos {
+		repos = append(repos, r)
+	}
+	sort.Slice(repos, func(x, y int) bool {
+		tx, ty := repos[x].tokens(), repos[y].tokens()
+		if tx != ty {
+			return tx > ty
+		}
+		if repos[x].Sessions != repos[y].Sessions {
+			return repos[x].Sessions > repos[y].Sessions
+		}
+		return repos[x].Name < repos[y].Name
+	})
+	if n > 0 && len(repos) > n {
+		repos = repos[:n]
+	}
+	return repos
+}
+
+func writeReportMarkdown(w io.Writer, periods []*reportBucket, prices pricing.Table, opts ReportOptions) error {
+	var b strings.Builder
+	b.WriteString("# agent-trace usage report (by " + opts.Period + ")\n\n")
+	if len(periods) == 0 {
+		b.WriteString("_No sessions in range._\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	b.WriteString("| Period | Sessions | Claude | Codex | Tokens | Est. cost |\n")
+	b.WriteString("|---|---:|---:|---:|---:|---:|\n")
+	for _, p := range periods {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %s | %s |\n", p.Name, p.Sessions, p.BySource["claude"], p.BySource["codex"],
+			pricing.FormatTokens(p.tokens()), pricing.FormatCost(prices.Estimate(p.Usage)))
+	}
+	for _, p := range periods {
+		b.WriteString("\n## " + p.Name + "\n\n")
+		b.WriteString("| Repo | Sessions | Tokens | Est. cost |\n")
+		b.WriteString("|---|---:|---:|---:|\n")
+		for _, r := range p.topRepos(opts.Top) {
+			fmt.Fprintf(&b, "| %s | %d | %s | %s |\n", markdownCell(displayRepo(r.Name)), r.Sessions,
+				pricing.FormatTokens(r.tokens()), pricing.FormatCost(prices.Estimate(r.Usage)))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeReportCSV writes one row per period and repo, plus a period total row
+// with an empty repo, using exact token counts and costs.
+func writeReportCSV(w io.Writer, periods []*reportBucket, prices pricing.Table, opts ReportOptions) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"period", "repo", "sessions", "claude_sessions", "codex_sessions", "input_tokens", "output_tokens", "cache_read_tokens", "cache_write_tokens", "estimated_cost_usd"})
+	row := func(period string, b *reportBucket, repo string) {
+		u := index.SumUsage(b.Usage)
+		usd, _ := prices.Estimate(b.Usage)
+		_ = cw.Write([]string{
+			period, repo,
+			strconv.Itoa(b.Sessions), strconv.Itoa(b.BySource["claude"]), strconv.Itoa(b.BySource["codex"]),
+			strconv.FormatInt(u.InputTokens, 10), strconv.FormatInt(u.OutputTokens, 10),
+			strconv.FormatInt(u.CacheReadTokens, 10), strconv.FormatInt(u.CacheWriteTokens, 10),
+			strconv.FormatFloat(usd, 'f', 4, 64),
+		})
+	}
+	for _, p := range periods {
+		row(p.Name, p, "")
+		for _, r := range p.topRepos(opts.Top) {
+			row(p.Name, r, r.Name)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func displayRepo(path string) string {
+	if !filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Base(path) + " (" + path + ")"
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
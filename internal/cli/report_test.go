@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
+)
+
+func TestParseReportArgs(t *testing.T) {
+	opts, err := ParseReportArgs([]string{"--period", "week", "--format", "csv", "--since", "2026-03-01", "--top", "2"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if opts.Period != "week" || opts.Format != "csv" || opts.Top != 2 {
+		t.Fatalf("opts = %+v", opts)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local); !opts.Since.Equal(want) {
+		t.Fatalf("since = %v, want %v", opts.Since, want)
+	}
+	if _, err := ParseReportArgs([]string{"--period", "day"}); err == nil {
+		t.Fatal("expected error for unknown period")
+	}
+}
+
+func TestReportMarkdownAndCSV(t *testing.T) {
+	march := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local).Unix()
+	april := time.Date(2026, 4, 2, 12, 0, 0, 0, time.Local).Unix()
+	sessions := []index.Session{
+		{ID: "a", Source: "claude", LastActivityTS: april, Workdir: "/src/app/web",
+			Usage: []index.Usage{{Model: "m", InputTokens: 1_000_000}}},
+		{ID: "b", Source: "codex", LastActivityTS: march, Workdir: "/src/app",
+			Usage: []index.Usage{{Model: "m", OutputTokens: 500_000}}},
+		{ID: "c", Source: "claude", LastActivityTS: march, Workdir: "/src/lib"},
+		{ID: "d", Source: "claude", LastActivityTS: 0, Workdir: "/src/lib"},
+	}
+	prices := pricing.Table{"m": {Input: 1, Output: 2}}
+	repoOf := func(dir string) string {
+		if strings.HasPrefix(dir, "/src/app") {
+			return "/src/app"
+		}
+		return ""
+	}
+
+	periods := buildReport(sessions, ReportOptions{Period: "month"}, repoOf)
+	if len(periods) != 2 || periods[0].Name != "2026-04" || periods[1].Name != "2026-03" {
+		t.Fatalf("periods = %+v", periods)
+	}
+	if periods[1].Sessions != 2 || periods[1].BySource["codex"] != 1 {
+		t.Fatalf("march = %+v", periods[1])
+	}
+	if top := periods[1].topRepos(1); len(top) != 1 || top[0].Name != "/src/app" {
+		t.Fatalf("top repos = %+v", top)
+	}
+
+	var md bytes.Buffer
+	if err := writeReportMarkdown(&md, periods, prices, ReportOptions{Period: "month", Top: 5}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| 2026-04 | 1 | 1 | 0 | 1.0M | ~$1.00 |",
+		"| 2026-03 | 2 | 1 | 1 | 500.0k | ~$1.00 |",
+		"| app (/src/app) | 1 | 500.0k | ~$1.00 |",
+		"| lib (/src/lib) | 1 | 0 |",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeReportCSV(&csvOut, periods, prices, ReportOptions{Period: "month", Top: 5}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 6 {
+		t.Fatalf("csv lines = %d:\n%s", len(lines), csvOut.String())
+	}
+	if lines[1] != "2026-04,,1,1,0,1000000,0,0,0,1.0000" {
+		t.Fatalf("csv period row = %q", lines[1])
+	}
+
+	weeks := buildReport(sessions, ReportOptions{Period: "week"}, repoOf)
+	if len(weeks) != 2 || weeks[0].Name != "2026-W14" || weeks[1].Name != "2026-W11" {
+		t.Fatalf("weeks = %+v", weeks)
+	}
+}
+
+func TestReportUsesTheDisplayZone(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	index.SetTimeDisplay(tokyo, "")
+	t.Cleanup(func() { index.SetTimeDisplay(nil, "") })
+
+	// 20:00 UTC on March 31 is already April 1 in Tokyo.
+	ts := time.Date(2026, 3, 31, 20, 0, 0, 0, time.UTC).Unix()
+	periods := buildReport([]index.Session{{ID: "a", LastActivityTS: ts}}, ReportOptions{Period: "month"}, func(string) string { return "" })
+	if len(periods) != 1 || periods[0].Name != "2026-04" {
+		t.Fatalf("periods = %+v, want April in the display zone", periods)
+	}
+	opts, err := ParseReportArgs([]string{"--since", "2026-04-01"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if want := time.Date(2026, 4, 1, 0, 0, 0, 0, tokyo); !opts.Since.Equal(want) {
+		t.Fatalf("since = %v, want %v", opts.Since, want)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// ParseSessionsArgs parses `sessions` flags from args and returns the
+// output format, "csv" or "tsv".
+func ParseSessionsArgs(args []string) (string, error) {
+	fs := flag.NewFlagSet("sessions", flag.ContinueOnError)
+	format := fs.String("format", "csv", "output format: csv or tsv")
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if *format != "csv" && *format != "tsv" {
+		return "", fmt.Errorf("unknown sessions format %q (want csv or tsv)", *format)
+	}
+	return *format, nil
+}
+
+// Sessions writes one row of metadata per session for spreadsheet analysis.
+// first maps session ids to their earliest message time; tags come from
+// annotations.
+func Sessions(w io.Writer, format string, sessions []index.Session, first map[string]int64, annotations map[string]index.Annotation) error {
+	cw := csv.NewWriter(w)
+	if format == "tsv" {
+		cw.Comma = '\t'
+	}
+	_ = cw.Write([]string{"id", "source", "workdir", "message_count", "first_activity", "last_activity", "tags"})
+	for _, s := range sessions {
+		_ = cw.Write([]string{
+			s.ID,
+			s.Source,
+			tsvSafe(s.Workdir),
+			strconv.Itoa(s.MessageCount),
+			formatSheetTime(first[s.ID]),
+			formatSheetTime(s.LastActivityTS),
+			tsvSafe(strings.Join(annotations[s.ID].Tags, ",")),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatSheetTime renders a unix time in a layout spreadsheets parse as a
+// date, or "" when unknown.
+func formatSheetTime(ts int64) string {
+	if ts <= 0 {
+		return ""
+	}
+	return index.DisplayTime(ts).Format("2006-01-02 15:04:05")
+}
+
+// tsvSafe flattens tabs and newlines so a field never splits a row, even
+// for readers that ignore quoting.
+func tsvSafe(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+func TestSessions(t *testing.T) {
+	first := time.Date(2026, 2, 1, 9, 0, 0, 0, time.Local).Unix()
+	last := time.Date(2026, 2, 1, 10, 30, 0, 0, time.Local).Unix()
+	sessions := []index.Session{
+		{ID: "a", Source: "claude", Workdir: "/src/my app", MessageCount: 4, LastActivityTS: last},
+		{ID: "b", Source: "codex", Workdir: "/src/tab\there", MessageCount: 2},
+	}
+	anns := map[string]index.Annotation{"a": {SessionID: "a", Tags: []string{"bug", "infra"}}}
+
+	var csvOut bytes.Buffer
+	if err := Sessions(&csvOut, "csv", sessions, map[string]int64{"a": first}, anns); err != nil {
+		t.Fatal(err)
+	}
+	want := "id,source,workdir,message_count,first_activity,last_activity,tags\n" +
+		"a,claude,/src/my app,4,2026-02-01 09:00:00,2026-02-01 10:30:00,\"bug,infra\"\n" +
+		"b,codex,/src/tab here,2,,,\n"
+	if csvOut.String() != want {
+		t.Fatalf("csv =\n%s\nwant\n%s", csvOut.String(), want)
+	}
+
+	var tsvOut bytes.Buffer
+	if err := Sessions(&tsvOut, "tsv", sessions, nil, anns); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(tsvOut.String(), "\n"), "\n")
+	if got := strings.Split(lines[1], "\t"); len(got) != 7 || got[6] != "bug,infra" {
+		t.Fatalf("tsv row = %q", lines[1])
+	}
+	if got := strings.Split(lines[2], "\t"); len(got) != 7 {
+		t.Fatalf("tsv row with tab in workdir split into %d fields", len(got))
+	}
+
+	if _, err := ParseSessionsArgs([]string{"--format", "xlsx"}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
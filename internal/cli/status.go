@@ -0,0 +1,122 @@
+// Package cli implements agent-trace's non-interactive subcommands.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"agent-trace/internal/index"
+)
+
+// statusNewestLimit caps the per-workdir listing so the glance stays short.
+const statusNewestLimit = 10
+
+// DaemonPIDPath is where a running watch daemon records its pid, next to
+// the index it maintains.
+func DaemonPIDPath(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "agent-trace.pid")
+}
+
+// DaemonRunning reports the pid of the daemon recorded in pidPath when that
+// process is still alive.
+func DaemonRunning(pidPath string) (int, bool) {
+	data, err := os.ReadFile(pidPath)
+	if err != nil {
+		return 0, false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return 0, false
+	}
+	if err := proc.Signal(syscall.Signal(0)); err != nil {
+		return 0, false
+	}
+	return pid, true
+}
+
+// Status prints a quick health summary of the index without ingesting
+// anything.
+func Status(ctx context.Context, w io.Writer, idx *index.Indexer, dbPath string) error {
+	st, err := idx.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "index:\t%s (%s, schema v%d)\n", dbPath, formatSize(st.DBBytes), st.SchemaVersion)
+
+	indexed := "never"
+	if st.LastIndexedTS > 0 {
+		indexed = index.FormatUnix(st.LastIndexedTS)
+	}
+	if st.PendingFiles == 0 {
+		indexed += fmt.Sprintf(" (up to date, %d files)", st.SourceFiles)
+	} else {
+		indexed += fmt.Sprintf(" (%d of %d files new or changed since)", st.PendingFiles, st.SourceFiles)
+	}
+	fmt.Fprintf(tw, "indexed:\t%s\n", indexed)
+
+	total := 0
+	sources := make([]string, 0, len(st.SessionsBySource))
+	for source, n := range st.SessionsBySource {
+		total += n
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	parts := make([]string, 0, len(sources))
+	for _, source := range sources {
+		parts = append(parts, fmt.Sprintf("%s %d", source, st.SessionsBySource[source]))
+	}
+	line := strconv.Itoa(total)
+	if len(parts) > 0 {
+		line += " (" + strings.Join(parts, ", ") + ")"
+	}
+	fmt.Fprintf(tw, "sessions:\t%s\n", line)
+
+	daemon := "not running"
+	if pid, ok := DaemonRunning(DaemonPIDPath(dbPath)); ok {
+		daemon = fmt.Sprintf("running (pid %d)", pid)
+	}
+	fmt.Fprintf(tw, "daemon:\t%s\n", daemon)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(st.Newest) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nnewest session per workdir:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for n, s := range st.Newest {
+		if n == statusNewestLimit {
+			fmt.Fprintf(tw, "  ... %d more\n", len(st.Newest)-n)
+			break
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", index.FormatUnix(s.LastActivityTS), s.Source, s.SessionID, s.Workdir)
+	}
+	return tw.Flush()
+}
+
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestStatus(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "99999999-9999-9999-9999-999999999999"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	line := `{"type":"user","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}` + "\n"
+	if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dbPath := filepath.Join(t.TempDir(), "index.sqlite")
+	idx, err := index.New([]string{t.TempDir()}, []string{claudeHome}, dbPath, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	// A second file arrives after indexing.
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa.jsonl"), []byte(line), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(DaemonPIDPath(dbPath), []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := Status(context.Background(), &out, idx, dbPath); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	for _, want := range []string{
+		"(1 of 2 files new or changed since)",
+		"sessions:  1 (claude 1)",
+		"daemon:    running (pid " + strconv.Itoa(os.Getpid()) + ")",
+		id + "  /tmp/proj",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("expected %q in status output:\n%s", want, out.String())
+		}
+	}
+}
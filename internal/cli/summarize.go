@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+)
+
+// summaryInputChars bounds how much of a transcript is sent to the model;
+// longer ones keep their start and end.
+const summaryInputChars = 24000
+
+const summarySystemPrompt = "You summarize coding-agent sessions for a searchable history. " +
+	"In 2-3 plain sentences, say what the user wanted, what the agent did and how it ended. " +
+	"No preamble, no markdown, no bullet points."
+
+// Completer is the model that writes summaries.
+type Completer interface {
+	Complete(ctx context.Context, system, prompt string) (string, error)
+	Name() string
+}
+
+// SummarizeOptions are the flags of the summarize command.
+type SummarizeOptions struct {
+	// Limit caps how many sessions one run summarizes; 0 means all.
+	Limit int
+}
+
+// ParseSummarizeArgs parses `summarize` flags from args.
+func ParseSummarizeArgs(args []string) (SummarizeOptions, error) {
+	fs := flag.NewFlagSet("summarize", flag.ContinueOnError)
+	var opts SummarizeOptions
+	fs.IntVar(&opts.Limit, "limit", 50, "summarize at most N sessions, newest first (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if opts.Limit < 0 {
+		return opts, fmt.Errorf("limit must not be negative, got %d", opts.Limit)
+	}
+	return opts, nil
+}
+
+// Summarize has model summarize the sessions without an up-to-date
+// summary, newest first, and stores each as it arrives. It stops at the
+// first failure, keeping what was stored.
+func Summarize(ctx context.Context, w io.Writer, idx *index.Indexer, model Completer, opts SummarizeOptions) error {
+	sessions, err := idx.SessionsToSummarize(opts.Limit)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintln(w, "every session is summarized")
+		return nil
+	}
+	for n, s := range sessions {
+		msgs, err := idx.GetMessages(s.ID)
+		if err != nil {
+			return err
+		}
+		md := export.BuildTranscriptMarkdown(msgs, index.TranscriptToggles{}, s.Source)
+		reply, err := model.Complete(ctx, summarySystemPrompt, summaryInput(md, summaryInputChars))
+		if err != nil {
+			return fmt.Errorf("summarize %s (%d of %d): %w", s.ID, n+1, len(sessions), err)
+		}
+		summary := strings.Join(strings.Fields(reply), " ")
+		if err := idx.SetSummary(ctx, s.ID, summary, model.Name(), s.MessageCount); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "[%d/%d] %s: %s\n", n+1, len(sessions), s.ID, summary)
+	}
+	return nil
+}
+
+// summaryInput cuts a transcript longer than limit to its first two
+// thirds and last third, where the task and its outcome are.
+func summaryInput(md string, limit int) string {
+	if len(md) <= limit {
+		return md
+	}
+	const gap = "\n\n[… middle of the session omitted …]\n\n"
+	head := limit * 2 / 3
+	tail := limit - head
+	return strings.ToValidUTF8(md[:head], "") + gap + strings.ToValidUTF8(md[len(md)-tail:], "")
+}
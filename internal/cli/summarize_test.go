@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+// newIndexedIndexer indexes two Claude sessions.
+func newIndexedIndexer(t *testing.T) *index.Indexer {
+	t.Helper()
+	claudeHome := t.TempDir()
+	for _, id := range []string{"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"} {
+		path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		line := `{"type":"user","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"fix the flaky test ` + id + `"}}` + "\n"
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := index.New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	return idx
+}
+
+type fakeModel struct {
+	prompts []string
+	err     error
+}
+
+func (f *fakeModel) Complete(_ context.Context, _, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	return "Fixed the\n flaky test. ", f.err
+}
+
+func (f *fakeModel) Name() string { return "fake:model" }
+
+func TestSummarizeStoresSummariesOnce(t *testing.T) {
+	idx := newIndexedIndexer(t)
+	model := &fakeModel{}
+	var out bytes.Buffer
+	if err := Summarize(context.Background(), &out, idx, model, SummarizeOptions{}); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if len(model.prompts) == 0 || !strings.Contains(out.String(), "Fixed the flaky test.") {
+		t.Fatalf("prompts %d, output %q", len(model.prompts), out.String())
+	}
+	sessions, err := idx.ListSessions("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sessions {
+		if s.Summary != "Fixed the flaky test." {
+			t.Errorf("%s: summary = %q", s.ID, s.Summary)
+		}
+	}
+
+	out.Reset()
+	if err := Summarize(context.Background(), &out, idx, model, SummarizeOptions{}); err != nil || out.String() != "every session is summarized\n" {
+		t.Fatalf("second run: %q, %v", out.String(), err)
+	}
+}
+
+func TestSummarizeStopsAtTheFirstFailure(t *testing.T) {
+	idx := newIndexedIndexer(t)
+	model := &fakeModel{err: errors.New("connection refused")}
+	if err := Summarize(context.Background(), &bytes.Buffer{}, idx, model, SummarizeOptions{}); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("expected the model error, got %v", err)
+	}
+	if len(model.prompts) != 1 {
+		t.Fatalf("expected one attempt, got %d", len(model.prompts))
+	}
+}
+
+func TestSummaryInputKeepsStartAndEnd(t *testing.T) {
+	md := strings.Repeat("a", 60) + strings.Repeat("b", 60)
+	got := summaryInput(md, 30)
+	if !strings.HasPrefix(got, strings.Repeat("a", 20)+"\n") || !strings.HasSuffix(got, "\n"+strings.Repeat("b", 10)) {
+		t.Fatalf("summaryInput = %q", got)
+	}
+	if summaryInput("short", 30) != "short" {
+		t.Fatal("short transcripts should be sent whole")
+	}
+}
@@ -0,0 +1,260 @@
+package compare
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// maxCells bounds the LCS tables so huge transcripts degrade to a positional
+// comparison instead of allocating gigabytes.
+const maxCells = 4_000_000
+
+type turn struct {
+	role    string
+	content string
+	key     string
+}
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	a, b int
+}
+
+// Markdown renders a turn-aligned diff of two session transcripts. Turns are
+// matched on role + normalized content; unmatched turns of the same role that
+// sit at the same position are shown as a line diff.
+func Markdown(a, b index.Session, aMsgs, bMsgs []index.Message, toggles index.TranscriptToggles) string {
+	at := conversationTurns(aMsgs, toggles)
+	bt := conversationTurns(bMsgs, toggles)
+	ops := alignTurns(at, bt)
+
+	var body strings.Builder
+	var same, changed, onlyA, onlyB int
+	n := 0
+	section := func(role, label string) {
+		n++
+		fmt.Fprintf(&body, "### %d. %s · %s\n\n", n, roleLabel(role), label)
+	}
+	for idx := 0; idx < len(ops); {
+		if ops[idx].kind == opEqual {
+			same++
+			t := at[ops[idx].a]
+			section(t.role, "identical")
+			body.WriteString("> " + preview(t.content) + "\n\n")
+			idx++
+			continue
+		}
+
+		// Collect the hunk of unmatched turns and pair them up by position:
+		// an A turn and a B turn of the same role are one changed turn.
+		var dels, ins []int
+		for ; idx < len(ops) && ops[idx].kind != opEqual; idx++ {
+			if ops[idx].kind == opDelete {
+				dels = append(dels, ops[idx].a)
+			} else {
+				ins = append(ins, ops[idx].b)
+			}
+		}
+		for k := 0; k < max(len(dels), len(ins)); k++ {
+			if k < len(dels) && k < len(ins) && at[dels[k]].role == bt[ins[k]].role {
+				changed++
+				section(at[dels[k]].role, "changed")
+				writeDiffBlock(&body, diffLines(at[dels[k]].content, bt[ins[k]].content))
+				continue
+			}
+			if k < len(dels) {
+				onlyA++
+				section(at[dels[k]].role, "only in A")
+				writeDiffBlock(&body, prefixLines("-", at[dels[k]].content))
+			}
+			if k < len(ins) {
+				onlyB++
+				section(bt[ins[k]].role, "only in B")
+				writeDiffBlock(&body, prefixLines("+", bt[ins[k]].content))
+			}
+		}
+	}
+
+	var b2 strings.Builder
+	b2.WriteString("# Session diff\n\n")
+	b2.WriteString(sessionLine("A", a, len(at)))
+	b2.WriteString(sessionLine("B", b, len(bt)))
+	fmt.Fprintf(&b2, "\n%d identical, %d changed, %d only in A, %d only in B.\n\n---\n\n", same, changed, onlyA, onlyB)
+	if n == 0 {
+		b2.WriteString("_Neither session has conversational turns with current filters._\n")
+	}
+	b2.WriteString(body.String())
+	return b2.String()
+}
+
+func sessionLine(label string, s index.Session, turns int) string {
+	wd := s.Workdir
+	if wd == "" {
+		wd = "n/a"
+	}
+	return fmt.Sprintf("- **%s**: `%s` · %s · %s · %d turns\n", label, s.ID, s.Source, wd, turns)
+}
+
+func conversationTurns(msgs []index.Message, toggles index.TranscriptToggles) []turn {
+	filtered := index.FilterMessages(msgs, toggles)
+	out := make([]turn, 0, len(filtered))
+	for _, m := range filtered {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		content := strings.TrimSpace(m.Content)
+		if content == "" {
+			continue
+		}
+		out = append(out, turn{
+			role:    m.Role,
+			content: content,
+			key:     m.Role + "\x00" + strings.Join(strings.Fields(strings.ToLower(content)), " "),
+		})
+	}
+	return out
+}
+
+func alignTurns(a, b []turn) []op {
+	keys := func(ts []turn) []string {
+		out := make([]string, len(ts))
+		for i, t := range ts {
+			out[i] = t.key
+		}
+		return out
+	}
+	return lcsOps(keys(a), keys(b))
+}
+
+// lcsOps returns an edit script turning a into b. Inputs whose LCS table
+// would exceed maxCells are compared position by position instead.
+func lcsOps(a, b []string) []op {
+	if len(a)*len(b) > maxCells {
+		var ops []op
+		for i := 0; i < max(len(a), len(b)); i++ {
+			switch {
+			case i < len(a) && i < len(b) && a[i] == b[i]:
+				ops = append(ops, op{kind: opEqual, a: i, b: i})
+			default:
+				if i < len(a) {
+					ops = append(ops, op{kind: opDelete, a: i})
+				}
+				if i < len(b) {
+					ops = append(ops, op{kind: opInsert, b: i})
+				}
+			}
+		}
+		return ops
+	}
+
+	// table[i][j] = LCS length of a[i:] and b[j:].
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, a: i, b: j})
+			i++
+			j++
+		case table[i+1][j] >= table[i][j+1]:
+			ops = append(ops, op{kind: opDelete, a: i})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, b: j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{kind: opDelete, a: i})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{kind: opInsert, b: j})
+	}
+	return ops
+}
+
+func diffLines(a, b string) string {
+	al := strings.Split(a, "\n")
+	bl := strings.Split(b, "\n")
+	var out strings.Builder
+	for _, o := range lcsOps(al, bl) {
+		switch o.kind {
+		case opEqual:
+			out.WriteString("  " + al[o.a] + "\n")
+		case opDelete:
+			out.WriteString("- " + al[o.a] + "\n")
+		case opInsert:
+			out.WriteString("+ " + bl[o.b] + "\n")
+		}
+	}
+	return out.String()
+}
+
+func prefixLines(prefix, content string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		out.WriteString(prefix + " " + line + "\n")
+	}
+	return out.String()
+}
+
+func writeDiffBlock(b *strings.Builder, diff string) {
+	fence := Fence(diff)
+	b.WriteString(fence + "diff\n")
+	b.WriteString(diff)
+	b.WriteString(fence + "\n\n")
+}
+
+// Fence returns a backtick fence longer than any backtick run in content, so
+// embedded code blocks cannot terminate it early.
+func Fence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+			continue
+		}
+		run = 0
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func roleLabel(role string) string {
+	if role == "user" {
+		return "You"
+	}
+	return "Assistant"
+}
+
+func preview(content string) string {
+	s := strings.Join(strings.Fields(content), " ")
+	if len(s) <= 160 {
+		return s
+	}
+	return s[:157] + "..."
+}
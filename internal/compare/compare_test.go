@@ -0,0 +1,57 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestMarkdown_AlignsTurns(t *testing.T) {
+	a := []index.Message{
+		{Role: "user", Type: "message", Content: "fix the flaky test"},
+		{Role: "assistant", Type: "message", Content: "Looking at it.\nDone."},
+		{Role: "user", Type: "message", Content: "thanks"},
+	}
+	b := []index.Message{
+		{Role: "user", Type: "message", Content: "fix the flaky test"},
+		{Role: "assistant", Type: "message", Content: "Looking at it.\nFixed the race."},
+		{Role: "user", Type: "message", Content: "now run lint"},
+	}
+	out := Markdown(index.Session{ID: "a"}, index.Session{ID: "b"}, a, b, index.TranscriptToggles{})
+
+	for _, want := range []string{
+		"1 identical, 2 changed, 0 only in A, 0 only in B.",
+		"### 1. You · identical",
+		"### 2. Assistant · changed",
+		"  Looking at it.\n- Done.\n+ Fixed the race.\n",
+		"- thanks\n+ now run lint\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected diff to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestMarkdown_OnlyInOneSide(t *testing.T) {
+	a := []index.Message{
+		{Role: "user", Type: "message", Content: "hello"},
+	}
+	b := []index.Message{
+		{Role: "user", Type: "message", Content: "hello"},
+		{Role: "assistant", Type: "message", Content: "hi there"},
+	}
+	out := Markdown(index.Session{ID: "a"}, index.Session{ID: "b"}, a, b, index.TranscriptToggles{})
+	if !strings.Contains(out, "### 2. Assistant · only in B") || !strings.Contains(out, "+ hi there") {
+		t.Fatalf("expected insertion for B-only turn, got:\n%s", out)
+	}
+}
+
+func TestFence(t *testing.T) {
+	if got := Fence("plain"); got != "```" {
+		t.Fatalf("unexpected fence %q", got)
+	}
+	if got := Fence("has ```go\ncode\n```"); got != "````" {
+		t.Fatalf("expected a longer fence, got %q", got)
+	}
+}
@@ -0,0 +1,148 @@
+package compare
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// contextLines is how many unchanged lines surround each change in a file diff.
+const contextLines = 3
+
+// SnapshotMarkdown renders what a session changed on disk: for each file its
+// file-history snapshots track, the earliest recorded state is diffed against
+// the latest. Files with a single recorded version are compared against the
+// working tree instead, since the snapshot only captured the "before" state;
+// relative paths are read from the session's workdir.
+func SnapshotMarkdown(s index.Session, files []index.FileSnapshot) string {
+	var b strings.Builder
+	b.WriteString("# Files changed\n\n")
+	fmt.Fprintf(&b, "- **Session**: `%s`\n- **Files tracked**: %d\n\n---\n\n", s.ID, len(files))
+	if len(files) == 0 {
+		b.WriteString("_This session has no file-history snapshots._\n")
+		return b.String()
+	}
+
+	unchanged := 0
+	for _, f := range files {
+		if len(f.Versions) == 0 {
+			continue
+		}
+		first := f.Versions[0]
+		before, _, err := index.ReadSnapshotVersion(first)
+		if err != nil {
+			fmt.Fprintf(&b, "## `%s`\n\n_Backup unavailable: %v_\n\n", f.Path, err)
+			continue
+		}
+
+		var after, label string
+		afterExists := true
+		if last := f.Versions[len(f.Versions)-1]; len(f.Versions) > 1 {
+			after, afterExists, err = index.ReadSnapshotVersion(last)
+			label = fmt.Sprintf("v%d → v%d", first.Version, last.Version)
+		} else {
+			path, ok := workingTreePath(s.Workdir, f.Path)
+			if !ok {
+				fmt.Fprintf(&b, "## `%s`\n\n_Working tree not compared: the path is outside the session workdir._\n\n", f.Path)
+				continue
+			}
+			var raw []byte
+			raw, err = os.ReadFile(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				afterExists, err = false, nil
+			}
+			after = string(raw)
+			label = fmt.Sprintf("v%d → working tree", first.Version)
+		}
+		if err != nil {
+			fmt.Fprintf(&b, "## `%s`\n\n_Backup unavailable: %v_\n\n", f.Path, err)
+			continue
+		}
+		if before == after {
+			unchanged++
+			continue
+		}
+
+		switch {
+		case first.BackupPath == "":
+			label += " (created)"
+		case !afterExists:
+			label += " (deleted)"
+		}
+		fmt.Fprintf(&b, "## `%s` · %s\n\n", f.Path, label)
+		writeDiffBlock(&b, unifiedLines(before, after, contextLines))
+	}
+	if unchanged > 0 {
+		fmt.Fprintf(&b, "_%d tracked file(s) ended up unchanged._\n", unchanged)
+	}
+	return b.String()
+}
+
+// workingTreePath resolves a recorded path against the session workdir.
+// Relative paths that leave the workdir, or that have no workdir to be
+// relative to, are refused.
+func workingTreePath(workdir, path string) (string, bool) {
+	if filepath.IsAbs(path) {
+		return path, true
+	}
+	if workdir == "" {
+		return "", false
+	}
+	rel := filepath.Clean(path)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(workdir, rel), true
+}
+
+// unifiedLines is diffLines trimmed to the changed regions plus ctx lines of
+// context, with an @@ marker between distant hunks.
+func unifiedLines(a, b string, ctx int) string {
+	al := splitLines(a)
+	bl := splitLines(b)
+	ops := lcsOps(al, bl)
+
+	keep := make([]bool, len(ops))
+	for idx, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		for k := max(0, idx-ctx); k <= min(len(ops)-1, idx+ctx); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out strings.Builder
+	skipped := false
+	for idx, o := range ops {
+		if !keep[idx] {
+			skipped = true
+			continue
+		}
+		if skipped && out.Len() > 0 {
+			out.WriteString("@@\n")
+		}
+		skipped = false
+		switch o.kind {
+		case opEqual:
+			out.WriteString("  " + al[o.a] + "\n")
+		case opDelete:
+			out.WriteString("- " + al[o.a] + "\n")
+		case opInsert:
+			out.WriteString("+ " + bl[o.b] + "\n")
+		}
+	}
+	return out.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
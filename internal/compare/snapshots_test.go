@@ -0,0 +1,58 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestSnapshotMarkdown_DiffsEarliestAgainstLatest(t *testing.T) {
+	dir := t.TempDir()
+	v1 := filepath.Join(dir, "v1")
+	v2 := filepath.Join(dir, "v2")
+	old := "package main\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\nfunc d() {}\nfunc e() {}\nfunc f() {}\nfunc g() {}\n"
+	if err := os.WriteFile(v1, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(v2, []byte(strings.Replace(old, "func g() {}", "func g() { return }", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := SnapshotMarkdown(index.Session{ID: "s1"}, []index.FileSnapshot{{
+		Path:     "/repo/main.go",
+		Versions: []index.SnapshotVersion{{Version: 1, BackupPath: v1}, {Version: 3, BackupPath: v2}},
+	}})
+
+	for _, want := range []string{"## `/repo/main.go` · v1 → v3", "- func g() {}\n+ func g() { return }\n", "  func f() {}\n"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "package main") {
+		t.Fatalf("expected distant unchanged lines to be trimmed, got:\n%s", out)
+	}
+}
+
+func TestSnapshotMarkdown_ReadsRelativePathsFromTheWorkdir(t *testing.T) {
+	dir, workdir := t.TempDir(), t.TempDir()
+	v1 := filepath.Join(dir, "v1")
+	if err := os.WriteFile(v1, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workdir, "main.go"), []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	version := []index.SnapshotVersion{{Version: 1, BackupPath: v1}}
+	out := SnapshotMarkdown(index.Session{ID: "s1", Workdir: workdir}, []index.FileSnapshot{
+		{Path: "main.go", Versions: version},
+		{Path: "../secret", Versions: version},
+	})
+	for _, want := range []string{"## `main.go` · v1 → working tree", "- old\n+ new\n", "## `../secret`\n\n_Working tree not compared"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in:\n%s", want, out)
+		}
+	}
+}
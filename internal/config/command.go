@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultResumeCommands are the resume command templates used for sources
+// the config file doesn't override.
+var DefaultResumeCommands = map[string]string{
+	"claude": "claude --resume {{.SessionID}}",
+	"codex":  "codex resume {{.SessionID}}",
+}
+
+// DefaultHandoffCommands start the agent a session is handed off to, keyed
+// by that agent's source.
+var DefaultHandoffCommands = map[string]string{
+	"claude": "claude {{.Prompt}}",
+	"codex":  "codex {{.Prompt}}",
+}
+
+// CommandTemplate is a command line whose arguments are Go templates.
+// The line is split into arguments before expansion, so expanded values
+// never need quoting.
+type CommandTemplate struct {
+	words []string
+	args  []*template.Template
+}
+
+// ParseCommandTemplate splits line into shell-style words (single and
+// double quotes group, backslash escapes) and parses each as a template.
+func ParseCommandTemplate(line string) (CommandTemplate, error) {
+	words, err := splitWords(line)
+	if err != nil {
+		return CommandTemplate{}, fmt.Errorf("command %q: %w", line, err)
+	}
+	if len(words) == 0 {
+		return CommandTemplate{}, fmt.Errorf("command %q is empty", line)
+	}
+	ct := CommandTemplate{words: words}
+	for _, w := range words {
+		t, err := template.New("arg").Option("missingkey=error").Parse(w)
+		if err != nil {
+			return CommandTemplate{}, fmt.Errorf("command %q: %w", line, err)
+		}
+		ct.args = append(ct.args, t)
+	}
+	return ct, nil
+}
+
+// Expand executes every argument against data.
+func (c CommandTemplate) Expand(data any) ([]string, error) {
+	argv := make([]string, 0, len(c.args))
+	for _, t := range c.args {
+		var b strings.Builder
+		if err := t.Execute(&b, data); err != nil {
+			return nil, fmt.Errorf("expand command: %w", err)
+		}
+		argv = append(argv, b.String())
+	}
+	if argv[0] == "" {
+		return nil, errors.New("expand command: program name is empty")
+	}
+	return argv, nil
+}
+
+// Literal reports whether no argument is a template, so the command
+// refers to none of the data it is expanded with.
+func (c CommandTemplate) Literal() bool {
+	for _, w := range c.words {
+		if strings.Contains(w, "{{") {
+			return false
+		}
+	}
+	return true
+}
+
+// Program is the command's literal program name, or "" when it is itself
+// a template.
+func (c CommandTemplate) Program() string {
+	if len(c.words) == 0 || strings.Contains(c.words[0], "{{") {
+		return ""
+	}
+	return c.words[0]
+}
+
+// ResumeCommands parses the default resume templates with overrides from
+// the config file applied.
+func ResumeCommands(overrides map[string]string) (map[string]CommandTemplate, error) {
+	return parseCommands("resume", DefaultResumeCommands, overrides)
+}
+
+// HandoffCommands parses the default handoff templates with overrides from
+// the config file applied.
+func HandoffCommands(overrides map[string]string) (map[string]CommandTemplate, error) {
+	return parseCommands("handoff", DefaultHandoffCommands, overrides)
+}
+
+func parseCommands(what string, defaults, overrides map[string]string) (map[string]CommandTemplate, error) {
+	out := make(map[string]CommandTemplate, len(defaults)+len(overrides))
+	for source, line := range defaults {
+		if _, ok := overrides[source]; ok {
+			continue
+		}
+		ct, err := ParseCommandTemplate(line)
+		if err != nil {
+			return nil, err
+		}
+		out[source] = ct
+	}
+	for source, line := range overrides {
+		ct, err := ParseCommandTemplate(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s command for %s: %w", what, source, err)
+		}
+		out[source] = ct
+	}
+	return out, nil
+}
+
+func splitWords(line string) ([]string, error) {
+	var (
+		words   []string
+		cur     strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				inWord = false
+			}
+		default:
+			cur.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandTemplate(t *testing.T) {
+	data := struct{ SessionID, Workdir string }{"abc", "/home/me/my repo"}
+	cases := []struct {
+		line string
+		want []string
+	}{
+		{"claude --resume {{.SessionID}}", []string{"claude", "--resume", "abc"}},
+		{"devcontainer exec --workspace-folder {{.Workdir}} claude -r {{.SessionID}}", []string{"devcontainer", "exec", "--workspace-folder", "/home/me/my repo", "claude", "-r", "abc"}},
+		{`sh -c 'cd "$0" && my-claude --resume {{.SessionID}}' {{.Workdir}}`, []string{"sh", "-c", `cd "$0" && my-claude --resume abc`, "/home/me/my repo"}},
+		{`wrap "two words" a\ b`, []string{"wrap", "two words", "a b"}},
+	}
+	for _, tc := range cases {
+		ct, err := ParseCommandTemplate(tc.line)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.line, err)
+		}
+		got, err := ct.Expand(data)
+		if err != nil {
+			t.Fatalf("expand %q: %v", tc.line, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %q, want %q", tc.line, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"", "   ", `claude "unterminated`, "claude {{.SessionID"} {
+		if _, err := ParseCommandTemplate(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+	ct, _ := ParseCommandTemplate("claude {{.Nope}}")
+	if _, err := ct.Expand(data); err == nil {
+		t.Error("expected unknown field to fail expansion")
+	}
+}
+
+func TestCommandTemplateLiteral(t *testing.T) {
+	for line, want := range map[string]bool{"code -n": true, "code -n {{.Workdir}}": false} {
+		ct, err := ParseCommandTemplate(line)
+		if err != nil || ct.Literal() != want {
+			t.Errorf("%q: literal %t, err %v; want %t", line, ct.Literal(), err, want)
+		}
+	}
+}
+
+func TestResumeCommandsOverride(t *testing.T) {
+	cmds, err := ResumeCommands(map[string]string{"claude": "cl -r {{.SessionID}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := struct{ SessionID string }{"x"}
+	claude, _ := cmds["claude"].Expand(data)
+	codex, _ := cmds["codex"].Expand(data)
+	if !reflect.DeepEqual(claude, []string{"cl", "-r", "x"}) || !reflect.DeepEqual(codex, []string{"codex", "resume", "x"}) {
+		t.Fatalf("claude %q, codex %q", claude, codex)
+	}
+}
@@ -6,16 +6,184 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"agent-trace/internal/llm"
+	"agent-trace/internal/logging"
+	"agent-trace/internal/pricing"
+	"agent-trace/internal/termimg"
 )
 
 const DefaultGlamourStyle = "dark"
 
 type AppConfig struct {
-	CodexHome   string
+	CodexHomes  []string
 	ClaudeHomes []string
-	DBPath      string
-	ExportDir   string
-	Reindex     bool
+	// ScanRoots are glob patterns for more agent homes, such as Claude homes
+	// in containers, matched on every index pass.
+	ScanRoots []string
+	// Exclude are path globs (** spans directories) for source files kept
+	// out of the index.
+	Exclude []string
+	// NoCodex and NoClaude leave that agent's sessions out of the index.
+	NoCodex  bool
+	NoClaude bool
+	// Remotes are ssh destinations whose agent homes are mirrored and
+	// indexed along with the local ones.
+	Remotes []string
+	DBPath  string
+	// AnnotationsFile is the JSONL file tags, notes, bookmarks and aliases
+	// are synced through.
+	AnnotationsFile string
+	ExportDir       string
+	ExportImages    bool
+	// ExportToolLines cuts tool message bodies in exports to this many
+	// lines; 0 keeps them whole.
+	ExportToolLines int
+	// ExportName is the export filename template (see export.NameData);
+	// empty names exports after the session ID.
+	ExportName string
+	// ExportManifest names a file listing every export in the export
+	// directory, kept current on each export; empty disables it.
+	ExportManifest string
+	// ExportVersions writes changed exports to -v2, -v3... files instead
+	// of over the previous export.
+	ExportVersions bool
+	// ExportFrontMatter writes session details as YAML front matter.
+	ExportFrontMatter bool
+	// SnippetUsage adds tokens, cost and tool calls to copied snippets.
+	SnippetUsage bool
+	// ImageProtocol selects how images are previewed: auto, kitty, iterm2
+	// or none (system viewer).
+	ImageProtocol string
+	// SafeRender shows markdown from agent replies literally where it could
+	// imitate the viewer's own headings and hints.
+	SafeRender bool
+	// CompressContent stores large message content zstd-compressed.
+	CompressContent bool
+	// Display bounds how much of a transcript the TUI renders.
+	Display DisplayLimits
+	// ShowEmpty lists sessions with no conversational messages too.
+	ShowEmpty bool
+	// RelativeTime shows last activity as "2h ago" in the list and status
+	// line instead of a date and time.
+	RelativeTime bool
+	// TimeZone is where times are shown, nil for local time; TimeFormat is
+	// their Go layout, empty for index.DefaultTimeFormat.
+	TimeZone   *time.Location
+	TimeFormat string
+	// QuickUnder folds sessions with fewer conversational messages into a
+	// collapsed group at the bottom of the list; 0 disables it.
+	QuickUnder int
+	// Similar is how many sessions with similar prompts the detail header
+	// suggests; 0 disables the suggestions.
+	Similar int
+	// RefreshInterval re-runs the incremental index this often while the
+	// TUI is open; 0 indexes only at startup.
+	RefreshInterval time.Duration
+	// Summarizer is the model the summarize command uses, as backend:model
+	// (ollama or openai); empty disables summaries. SummarizerURL overrides
+	// the backend's default address.
+	Summarizer    string
+	SummarizerURL string
+	// Embedder is the model the embed command and about: searches use, as
+	// backend:model; empty disables semantic search. EmbedderURL overrides
+	// the backend's default address.
+	Embedder    string
+	EmbedderURL string
+	// LogFile receives structured logs at LogLevel and above, formatted as
+	// LogFormat (logfmt or json); empty disables logging.
+	LogFile   string
+	LogLevel  string
+	LogFormat string
+	// OpenCommand opens a session's workdir; {{.Workdir}} stands for the
+	// directory, which is appended when the command does not refer to any
+	// field. nil falls back to $VISUAL, $EDITOR, code or the system opener.
+	OpenCommand *CommandTemplate
+	// ResumeCommands are the per-source commands `r` runs, the built-in
+	// ones with config overrides applied.
+	ResumeCommands map[string]CommandTemplate
+	// Session is the session the TUI starts on (--session or "open <id>"):
+	// an ID, unique ID prefix or alias.
+	Session string
+	// Here and Dir (-C) limit the TUI to the repository containing the
+	// current directory or Dir; main resolves that repository into Scope.
+	Here  bool
+	Dir   string
+	Scope string
+	// Latest starts the TUI on the most recent session: LatestAnywhere,
+	// LatestHere for the current project only, or "" when off.
+	Latest string
+	// HandoffCommands start the agent `H` hands a session off to, keyed by
+	// that agent's source.
+	HandoffCommands map[string]CommandTemplate
+	// SpawnCommand starts a resume command in a new terminal or tmux
+	// window; nil means tmux new-window.
+	SpawnCommand *CommandTemplate
+	Reindex      bool
+	ConfigPath   string
+	GlamourStyle string // built-in style name or absolute path to a style JSON file
+	// SourceToggles are transcript toggle defaults applied when a session
+	// of that source is opened.
+	SourceToggles map[string]ToggleProfile
+	Normalize     NormalizeConfig
+	// CollapseRules are the built-in and configured instruction blocks the
+	// transcript can hide.
+	CollapseRules []CollapseRule
+	// Pricing is the built-in price table with config overrides applied.
+	Pricing pricing.Table
+	// StatePath is where the TUI remembers its layout; State is what was
+	// saved there.
+	StatePath string
+	State     UIState
+	// Command is the subcommand named before any flags ("status"); empty
+	// runs the TUI. CommandArgs are the arguments left after flags.
+	Command     string
+	CommandArgs []string
+}
+
+// DisplayLimits bound the work the TUI does per transcript; 0 lifts a
+// limit. Exports are never limited.
+type DisplayLimits struct {
+	// LineChars clamps longer lines to their head and tail.
+	LineChars int
+	// RenderChars is the largest piece Glamour formats; bigger pieces are
+	// shown as plain text.
+	RenderChars int
+	// TotalChars truncates longer transcripts.
+	TotalChars int
+}
+
+// DefaultDisplayLimits suit a typical terminal.
+func DefaultDisplayLimits() DisplayLimits {
+	return DisplayLimits{LineChars: 8_000, RenderChars: 500_000, TotalChars: 1_000_000}
+}
+
+// Validate rejects negative limits.
+func (d DisplayLimits) Validate() error {
+	for _, l := range []struct {
+		name  string
+		value int
+	}{{"max-line-chars", d.LineChars}, {"max-render-chars", d.RenderChars}, {"max-display-chars", d.TotalChars}} {
+		if l.value < 0 {
+			return fmt.Errorf("%s must not be negative, got %d", l.name, l.value)
+		}
+	}
+	return nil
+}
+
+// validateTimeFormat rejects a layout without any of Go's reference time
+// elements, such as a strftime pattern, which would print the same text for
+// every time.
+func validateTimeFormat(layout string) error {
+	if layout == "" {
+		return nil
+	}
+	probe := time.Date(2001, 11, 12, 13, 14, 15, 0, time.UTC)
+	if probe.Format(layout) == layout {
+		return fmt.Errorf("invalid time-format %q: want a Go layout such as \"2006-01-02 15:04\"", layout)
+	}
+	return nil
 }
 
 // stringSliceFlag is a flag.Value that collects comma-separated or
@@ -36,23 +204,363 @@ func (f *stringSliceFlag) Set(v string) error {
 	return nil
 }
 
-func Parse() (AppConfig, error) {
-	var cfg AppConfig
+// Values of --latest.
+const (
+	LatestAnywhere = "anywhere"
+	LatestHere     = "here"
+)
 
-	defaultCodexHome, err := DetectCodexHome("")
-	if err != nil {
-		return cfg, err
+// latestFlag is --latest: bare it means LatestAnywhere, and --latest=here
+// limits the pick to the current project.
+type latestFlag string
+
+func (f *latestFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *latestFlag) Set(v string) error {
+	switch v {
+	case "true", LatestAnywhere:
+		*f = LatestAnywhere
+	case "false":
+		*f = ""
+	case LatestHere:
+		*f = LatestHere
+	default:
+		return fmt.Errorf("want --latest or --latest=here, got %q", v)
 	}
+	return nil
+}
+
+func (f *latestFlag) IsBoolFlag() bool { return true }
+
+func Parse() (AppConfig, error) {
+	var cfg AppConfig
+	var err error
 
+	var codexHomeFlag stringSliceFlag
 	var claudeHomeFlag stringSliceFlag
-	flag.StringVar(&cfg.CodexHome, "codex-home", defaultCodexHome, "path to CODEX_HOME")
+	var remoteFlag stringSliceFlag
+	var scanRootFlag stringSliceFlag
+	var excludeFlag stringSliceFlag
+	var spawnCommand, openCommand string
+	var ephemeral bool
+	var refreshMinutes int
+	var timeZone string
+	flag.Var(&codexHomeFlag, "codex-home", "path(s) to Codex home director(ies), e.g. one per sandbox; comma-separated or repeated (default: $CODEX_HOME or ~/.codex)")
 	flag.Var(&claudeHomeFlag, "claude-home", "path(s) to Claude home director(ies); comma-separated or repeated (default: all ~/.claude* dirs with a projects/ subdir)")
+	flag.Var(&excludeFlag, "exclude", "glob pattern(s) for session files to keep out of the index, e.g. \"**/scratch/**\" or \"**/tmp-*\"; ** spans directories; comma-separated or repeated")
+	flag.BoolVar(&cfg.NoCodex, "no-codex", false, "skip Codex sessions entirely: nothing is scanned and earlier indexed ones are dropped")
+	flag.BoolVar(&cfg.NoClaude, "no-claude", false, "skip Claude sessions entirely: nothing is scanned and earlier indexed ones are dropped")
+	flag.Var(&scanRootFlag, "scan-root", "glob pattern(s) for more agent homes to index, e.g. \"~/dev/*/.claude\"; matches with projects/ are read as Claude homes, with sessions/ as Codex homes; comma-separated or repeated")
+	flag.Var(&remoteFlag, "remote", "ssh destination(s) such as user@devbox whose ~/.codex and ~/.claude sessions are mirrored with rsync and indexed too; comma-separated or repeated")
 	flag.StringVar(&cfg.DBPath, "db-path", "", "path to SQLite index file")
+	flag.StringVar(&cfg.AnnotationsFile, "annotations-file", "", "path to the annotations sync file (default: annotations.jsonl next to the index)")
 	flag.StringVar(&cfg.ExportDir, "export-dir", "", "override export output directory")
+	flag.BoolVar(&cfg.ExportImages, "export-images", false, "write embedded images to files next to exports instead of inline base64")
+	flag.IntVar(&cfg.ExportToolLines, "export-tool-lines", 0, "cut tool calls and outputs in exports to N lines, noting how many were dropped (0 keeps them whole)")
+	flag.StringVar(&cfg.ExportName, "export-name", "", "export filename template over {{.ID}}, {{.ShortID}}, {{.Date}}, {{.Slug}} and {{.Source}}, e.g. \"{{.Date}}-{{.Slug}}-{{.ShortID}}.md\" (default: \"{{.ID}}.md\")")
+	flag.StringVar(&cfg.ExportManifest, "export-manifest", "", "keep a listing of all exports with dates and summaries in this file of the export directory, e.g. README.md or index.md (default: off)")
+	flag.BoolVar(&cfg.ExportVersions, "export-versions", false, "write a changed export to <name>-v2.md, -v3 and so on instead of over the previous one")
+	flag.BoolVar(&cfg.ExportFrontMatter, "export-front-matter", false, "start exports with YAML front matter (id, source, workdir, date, tags, tokens) for Hugo, Jekyll or Obsidian instead of the text block")
+	flag.BoolVar(&cfg.SnippetUsage, "snippet-usage", false, "add a usage line (tokens, estimated cost, tool calls) to the snippets c and C copy")
+	flag.StringVar(&cfg.Session, "session", "", "start with this session selected: an ID, unique ID prefix or alias")
+	flag.BoolVar(&cfg.Here, "here", false, "only list sessions whose workdir is in the current repo (or directory, outside a repo)")
+	flag.StringVar(&cfg.Dir, "C", "", "like --here, for the repo containing this directory")
+	flag.Var((*latestFlag)(&cfg.Latest), "latest", "start on the most recently active session; --latest=here only considers sessions in the current repo (or directory)")
 	flag.BoolVar(&cfg.Reindex, "reindex", false, "force full DB rebuild")
-	flag.Parse()
+	flag.BoolVar(&ephemeral, "ephemeral", false, "build the index in memory for this run and write nothing to disk (same as --db-path :memory:)")
+	flag.StringVar(&cfg.ConfigPath, "config", "", "path to JSON config file (default: ~/.config/agent-trace/config.json)")
+	flag.StringVar(&cfg.GlamourStyle, "glamour-style", "", "transcript style: a built-in glamour style name or a style JSON file (default: dark)")
+	flag.StringVar(&cfg.ImageProtocol, "image-protocol", "auto", "inline image preview: auto, kitty, iterm2 or none (open in the system viewer)")
+	flag.BoolVar(&cfg.CompressContent, "compress-content", false, "zstd-compress large message content in the index (applies to newly ingested messages)")
+	defaults := DefaultDisplayLimits()
+	flag.IntVar(&cfg.Display.LineChars, "max-line-chars", defaults.LineChars, "clamp transcript lines longer than N chars to their head and tail in the viewer (0 disables)")
+	flag.IntVar(&cfg.Display.RenderChars, "max-render-chars", defaults.RenderChars, "show transcript pieces longer than N chars as plain text instead of formatting them (0 disables)")
+	flag.IntVar(&cfg.Display.TotalChars, "max-display-chars", defaults.TotalChars, "truncate transcripts longer than N chars in the viewer; exports stay whole (0 disables)")
+	flag.BoolVar(&cfg.RelativeTime, "relative-time", false, "show last activity in the list and status line as \"2h ago\" instead of a date and time")
+	flag.StringVar(&timeZone, "timezone", "", "show times in this IANA time zone, e.g. UTC or Europe/Berlin, in the list, status line, exports and CLI output (default: local time)")
+	flag.StringVar(&cfg.TimeFormat, "time-format", "", "Go layout for those times, e.g. \"Jan 2 15:04\" or \"2006-01-02T15:04Z07:00\" (default: \"2006-01-02 15:04\")")
+	flag.BoolVar(&cfg.ShowEmpty, "show-empty", false, "also list sessions with no conversational messages (boilerplate only), which are hidden by default; Z toggles it in the UI")
+	flag.IntVar(&cfg.QuickUnder, "quick-under", 0, "fold sessions with fewer than N conversational messages into a collapsed group at the bottom of the list (0 disables)")
+	flag.IntVar(&cfg.Similar, "similar", 3, "suggest up to N sessions with similar prompts above the transcript, e.g. earlier attempts at the same task (0 disables)")
+	flag.IntVar(&refreshMinutes, "refresh-interval", 0, "re-index new and changed sessions every N minutes while the UI is open (0 indexes only at startup)")
+	flag.StringVar(&cfg.Summarizer, "summarizer", "", "model the summarize command writes session summaries with, as backend:model, e.g. ollama:llama3.2 or openai:gpt-4o-mini (key from $OPENAI_API_KEY)")
+	flag.StringVar(&cfg.SummarizerURL, "summarizer-url", "", "address of the --summarizer backend, e.g. an OpenAI-compatible server (default: http://localhost:11434 for ollama, https://api.openai.com/v1 for openai)")
+	flag.StringVar(&cfg.Embedder, "embedder", "", "embedding model for semantic (about:) search and the embed command, as backend:model, e.g. ollama:nomic-embed-text or openai:text-embedding-3-small")
+	flag.StringVar(&cfg.EmbedderURL, "embedder-url", "", "address of the --embedder backend (default: as for --summarizer-url)")
+	flag.StringVar(&cfg.LogFile, "log-file", "", "append structured logs from indexing, exports and the UI to this file")
+	flag.StringVar(&cfg.LogLevel, "log-level", "info", "minimum level written to --log-file: debug, info, warn or error")
+	flag.StringVar(&cfg.LogFormat, "log-format", "logfmt", "--log-file record format: logfmt or json")
+	flag.StringVar(&spawnCommand, "spawn-command", "", "command W resumes a session with, without suspending the TUI; {{.Command}} is the quoted resume command (default: \"tmux new-window -c {{.Workdir}} {{.Command}}\")")
+	flag.StringVar(&openCommand, "open-command", "", "command o opens a session workdir with, e.g. \"code -n {{.Workdir}}\" (default: $VISUAL, $EDITOR, code, then the system opener)")
+	flag.BoolVar(&cfg.SafeRender, "safe-render", false, "show headings, hint-like quotes, images and HTML in agent replies literally")
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cfg.Command, args = args[0], args[1:]
+	}
+	if err := flag.CommandLine.Parse(args); err != nil {
+		return cfg, err
+	}
+	cfg.CommandArgs = flag.Args()
+	if cfg.Command == "open" && len(cfg.CommandArgs) > 0 {
+		// "open <id> --flags": the session comes first, flags may follow.
+		cfg.Session = cfg.CommandArgs[0]
+		if err := flag.CommandLine.Parse(cfg.CommandArgs[1:]); err != nil {
+			return cfg, err
+		}
+		cfg.CommandArgs = flag.Args()
+	}
+	if cfg.Session != "" && cfg.Latest != "" {
+		return cfg, fmt.Errorf("--latest and --session both pick the starting session; use one")
+	}
+
+	setFlags := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
 
-	cfg.CodexHome, err = DetectCodexHome(cfg.CodexHome)
+	if cfg.ConfigPath == "" {
+		if cfg.ConfigPath, err = DefaultConfigPath(); err != nil {
+			return cfg, err
+		}
+	}
+	fc, err := LoadFile(cfg.ConfigPath)
+	if err != nil {
+		return cfg, err
+	}
+	if !setFlags["codex-home"] {
+		if fc.CodexHome != "" {
+			codexHomeFlag = append(codexHomeFlag, expandHome(fc.CodexHome))
+		}
+		for _, h := range fc.CodexHomes {
+			codexHomeFlag = append(codexHomeFlag, expandHome(h))
+		}
+	}
+	if !setFlags["claude-home"] {
+		for _, h := range fc.ClaudeHomes {
+			claudeHomeFlag = append(claudeHomeFlag, expandHome(h))
+		}
+	}
+	if !setFlags["exclude"] {
+		excludeFlag = append(excludeFlag, fc.Exclude...)
+	}
+	for _, p := range excludeFlag {
+		cfg.Exclude = append(cfg.Exclude, expandHome(p))
+	}
+	if !setFlags["no-codex"] {
+		cfg.NoCodex = fc.NoCodex
+	}
+	if !setFlags["no-claude"] {
+		cfg.NoClaude = fc.NoClaude
+	}
+	if cfg.NoCodex && cfg.NoClaude {
+		return cfg, fmt.Errorf("--no-codex and --no-claude together leave nothing to index")
+	}
+	if !setFlags["scan-root"] {
+		scanRootFlag = append(scanRootFlag, fc.ScanRoots...)
+	}
+	for _, p := range scanRootFlag {
+		cfg.ScanRoots = append(cfg.ScanRoots, expandHome(p))
+	}
+	if !setFlags["remote"] {
+		remoteFlag = append(remoteFlag, fc.Remotes...)
+	}
+	cfg.Remotes = remoteFlag
+	if !setFlags["db-path"] && fc.DBPath != "" {
+		cfg.DBPath = expandHome(fc.DBPath)
+	}
+	if ephemeral {
+		cfg.DBPath = memoryDBPath
+	}
+	if !setFlags["annotations-file"] && fc.AnnotationsFile != "" {
+		cfg.AnnotationsFile = expandHome(fc.AnnotationsFile)
+	}
+	if !setFlags["export-dir"] && fc.ExportDir != "" {
+		cfg.ExportDir = expandHome(fc.ExportDir)
+	}
+	if !setFlags["export-images"] {
+		cfg.ExportImages = fc.ExportImages
+	}
+	if !setFlags["export-tool-lines"] && fc.ExportToolLines != 0 {
+		cfg.ExportToolLines = fc.ExportToolLines
+	}
+	if cfg.ExportToolLines < 0 {
+		return cfg, fmt.Errorf("export-tool-lines must not be negative, got %d", cfg.ExportToolLines)
+	}
+	if !setFlags["export-name"] && fc.ExportName != "" {
+		cfg.ExportName = fc.ExportName
+	}
+	if !setFlags["export-manifest"] && fc.ExportManifest != "" {
+		cfg.ExportManifest = fc.ExportManifest
+	}
+	if cfg.ExportManifest != "" && (filepath.Base(cfg.ExportManifest) != cfg.ExportManifest || filepath.Ext(cfg.ExportManifest) != ".md") {
+		return cfg, fmt.Errorf("export-manifest must be a markdown file name such as index.md, got %q", cfg.ExportManifest)
+	}
+	if !setFlags["export-versions"] {
+		cfg.ExportVersions = fc.ExportVersions
+	}
+	if !setFlags["export-front-matter"] {
+		cfg.ExportFrontMatter = fc.ExportFrontMatter
+	}
+	if !setFlags["snippet-usage"] {
+		cfg.SnippetUsage = fc.SnippetUsage
+	}
+	if !setFlags["compress-content"] {
+		cfg.CompressContent = fc.CompressContent
+	}
+	if !setFlags["safe-render"] {
+		cfg.SafeRender = fc.SafeRender
+	}
+	if !setFlags["max-line-chars"] && fc.MaxLineChars != 0 {
+		cfg.Display.LineChars = fc.MaxLineChars
+	}
+	if !setFlags["max-render-chars"] && fc.MaxRenderChars != 0 {
+		cfg.Display.RenderChars = fc.MaxRenderChars
+	}
+	if !setFlags["max-display-chars"] && fc.MaxDisplayChars != 0 {
+		cfg.Display.TotalChars = fc.MaxDisplayChars
+	}
+	if err := cfg.Display.Validate(); err != nil {
+		return cfg, err
+	}
+	if !setFlags["show-empty"] {
+		cfg.ShowEmpty = fc.ShowEmpty
+	}
+	if !setFlags["relative-time"] {
+		cfg.RelativeTime = fc.RelativeTime
+	}
+	if !setFlags["timezone"] && fc.TimeZone != "" {
+		timeZone = fc.TimeZone
+	}
+	if timeZone != "" {
+		zone, err := time.LoadLocation(timeZone)
+		if err != nil {
+			return cfg, fmt.Errorf("invalid timezone %q: %w", timeZone, err)
+		}
+		cfg.TimeZone = zone
+	}
+	if !setFlags["time-format"] && fc.TimeFormat != "" {
+		cfg.TimeFormat = fc.TimeFormat
+	}
+	if err := validateTimeFormat(cfg.TimeFormat); err != nil {
+		return cfg, err
+	}
+	if !setFlags["quick-under"] && fc.QuickUnder != 0 {
+		cfg.QuickUnder = fc.QuickUnder
+	}
+	if cfg.QuickUnder < 0 {
+		return cfg, fmt.Errorf("quick-under must not be negative, got %d", cfg.QuickUnder)
+	}
+	if !setFlags["similar"] && fc.Similar != nil {
+		cfg.Similar = *fc.Similar
+	}
+	if cfg.Similar < 0 {
+		return cfg, fmt.Errorf("similar must not be negative, got %d", cfg.Similar)
+	}
+	if !setFlags["refresh-interval"] && fc.RefreshInterval != 0 {
+		refreshMinutes = fc.RefreshInterval
+	}
+	if refreshMinutes < 0 {
+		return cfg, fmt.Errorf("refresh-interval must not be negative, got %d", refreshMinutes)
+	}
+	cfg.RefreshInterval = time.Duration(refreshMinutes) * time.Minute
+	if !setFlags["summarizer"] && fc.Summarizer != "" {
+		cfg.Summarizer = fc.Summarizer
+	}
+	if !setFlags["summarizer-url"] && fc.SummarizerURL != "" {
+		cfg.SummarizerURL = fc.SummarizerURL
+	}
+	if cfg.Summarizer != "" {
+		if _, _, err := llm.ParseSpec(cfg.Summarizer); err != nil {
+			return cfg, fmt.Errorf("summarizer: %w", err)
+		}
+	}
+	if !setFlags["embedder"] && fc.Embedder != "" {
+		cfg.Embedder = fc.Embedder
+	}
+	if !setFlags["embedder-url"] && fc.EmbedderURL != "" {
+		cfg.EmbedderURL = fc.EmbedderURL
+	}
+	if cfg.Embedder != "" {
+		if _, _, err := llm.ParseSpec(cfg.Embedder); err != nil {
+			return cfg, fmt.Errorf("embedder: %w", err)
+		}
+	}
+	if !setFlags["log-file"] && fc.LogFile != "" {
+		cfg.LogFile = expandHome(fc.LogFile)
+	}
+	if !setFlags["log-level"] && fc.LogLevel != "" {
+		cfg.LogLevel = fc.LogLevel
+	}
+	if !setFlags["log-format"] && fc.LogFormat != "" {
+		cfg.LogFormat = fc.LogFormat
+	}
+	if _, err := logging.ParseLevel(cfg.LogLevel); err != nil {
+		return cfg, err
+	}
+	if err := logging.ValidateFormat(cfg.LogFormat); err != nil {
+		return cfg, err
+	}
+	if !setFlags["open-command"] {
+		openCommand = fc.OpenCommand
+	}
+	if openCommand != "" {
+		if strings.Contains(openCommand, "{dir}") {
+			return cfg, fmt.Errorf("open command %q: write {{.Workdir}} for the directory", openCommand)
+		}
+		ct, err := ParseCommandTemplate(openCommand)
+		if err != nil {
+			return cfg, fmt.Errorf("open command: %w", err)
+		}
+		cfg.OpenCommand = &ct
+	}
+	if !setFlags["glamour-style"] {
+		cfg.GlamourStyle = fc.GlamourStyle
+	}
+	if !setFlags["image-protocol"] && fc.ImageProtocol != "" {
+		cfg.ImageProtocol = fc.ImageProtocol
+	}
+	if _, _, err := termimg.ParseProtocol(cfg.ImageProtocol); err != nil {
+		return cfg, err
+	}
+	cfg.SourceToggles = fc.Toggles
+	cfg.Pricing = pricing.Default().Merge(fc.Pricing)
+	cfg.CollapseRules = append(DefaultCollapseRules(), fc.Collapse...)
+	for _, r := range fc.Collapse {
+		if err := r.Validate(); err != nil {
+			return cfg, err
+		}
+	}
+	cfg.ResumeCommands, err = ResumeCommands(fc.Resume)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.HandoffCommands, err = HandoffCommands(fc.Handoff)
+	if err != nil {
+		return cfg, err
+	}
+	if !setFlags["spawn-command"] {
+		spawnCommand = fc.SpawnCommand
+	}
+	if spawnCommand != "" {
+		ct, err := ParseCommandTemplate(spawnCommand)
+		if err != nil {
+			return cfg, fmt.Errorf("spawn command: %w", err)
+		}
+		cfg.SpawnCommand = &ct
+	}
+	cfg.Normalize = fc.Normalize
+	if err := cfg.Normalize.Validate(); err != nil {
+		return cfg, err
+	}
+	cfg.GlamourStyle, err = ResolveGlamourStyle(cfg.GlamourStyle)
+	if err != nil {
+		return cfg, err
+	}
+
+	cfg.CodexHomes, err = DetectCodexHomes([]string(codexHomeFlag))
 	if err != nil {
 		return cfg, err
 	}
@@ -70,25 +578,50 @@ func Parse() (AppConfig, error) {
 		cfg.DBPath = filepath.Join(home, ".local", "share", "agent-trace", "index.sqlite")
 	}
 
+	if cfg.InMemory() {
+		// Nothing lands on disk: annotations sync only to an explicitly
+		// given file and the layout is not remembered.
+		return cfg, nil
+	}
 	if err := os.MkdirAll(filepath.Dir(cfg.DBPath), 0o755); err != nil {
 		return cfg, fmt.Errorf("create db dir: %w", err)
 	}
+	if cfg.AnnotationsFile == "" {
+		cfg.AnnotationsFile = filepath.Join(filepath.Dir(cfg.DBPath), "annotations.jsonl")
+	}
+	cfg.StatePath = DefaultStatePath(cfg.DBPath)
+	cfg.State = LoadState(cfg.StatePath)
 
 	return cfg, nil
 }
 
-func DetectCodexHome(explicit string) (string, error) {
-	if explicit != "" {
-		return filepath.Clean(explicit), nil
+// memoryDBPath is SQLite's name for a database held in RAM.
+const memoryDBPath = ":memory:"
+
+// InMemory reports whether the index lives in RAM for this run only
+// (--ephemeral or --db-path :memory:).
+func (c AppConfig) InMemory() bool {
+	return c.DBPath == memoryDBPath
+}
+
+// DetectCodexHomes returns the Codex home directories to scan: explicit
+// ones when given, else $CODEX_HOME, else ~/.codex.
+func DetectCodexHomes(explicit []string) ([]string, error) {
+	if len(explicit) > 0 {
+		cleaned := make([]string, 0, len(explicit))
+		for _, p := range explicit {
+			cleaned = append(cleaned, filepath.Clean(p))
+		}
+		return cleaned, nil
 	}
 	if fromEnv := os.Getenv("CODEX_HOME"); fromEnv != "" {
-		return filepath.Clean(fromEnv), nil
+		return []string{filepath.Clean(fromEnv)}, nil
 	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return "", fmt.Errorf("resolve home directory: %w", err)
+		return nil, fmt.Errorf("resolve home directory: %w", err)
 	}
-	return filepath.Join(home, ".codex"), nil
+	return []string{filepath.Join(home, ".codex")}, nil
 }
 
 // DetectClaudeHomes returns the list of Claude home directories to scan.
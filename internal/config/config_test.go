@@ -0,0 +1,46 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLatestFlag(t *testing.T) {
+	for args, want := range map[string]string{
+		"":              "",
+		"--latest":      LatestAnywhere,
+		"--latest=here": LatestHere,
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		var latest string
+		fs.Var((*latestFlag)(&latest), "latest", "")
+		var argv []string
+		if args != "" {
+			argv = []string{args}
+		}
+		if err := fs.Parse(argv); err != nil || latest != want {
+			t.Errorf("%q: latest %q, err %v; want %q", args, latest, err, want)
+		}
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var latest string
+	fs.Var((*latestFlag)(&latest), "latest", "")
+	if err := fs.Parse([]string{"--latest=there"}); err == nil {
+		t.Error("expected --latest=there to be rejected")
+	}
+}
+
+func TestValidateTimeFormat(t *testing.T) {
+	for _, ok := range []string{"", "2006-01-02 15:04", "Jan 2 15:04", time.RFC3339} {
+		if err := validateTimeFormat(ok); err != nil {
+			t.Errorf("validateTimeFormat(%q) = %v", ok, err)
+		}
+	}
+	if err := validateTimeFormat("%Y-%m-%d"); err == nil {
+		t.Error("expected a strftime pattern to be rejected")
+	}
+}
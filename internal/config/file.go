@@ -0,0 +1,274 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"agent-trace/internal/pricing"
+
+	"github.com/charmbracelet/glamour/styles"
+)
+
+// FileConfig mirrors the optional JSON config file. Empty values mean "not
+// set"; command-line flags always win over values from the file.
+type FileConfig struct {
+	CodexHome string `json:"codex_home,omitempty"`
+	// CodexHomes are more Codex homes, added to CodexHome.
+	CodexHomes  []string `json:"codex_homes,omitempty"`
+	ClaudeHomes []string `json:"claude_homes,omitempty"`
+	// Exclude are path globs for session files kept out of the index.
+	Exclude []string `json:"exclude,omitempty"`
+	// NoCodex and NoClaude skip that agent's sessions.
+	NoCodex  bool `json:"no_codex,omitempty"`
+	NoClaude bool `json:"no_claude,omitempty"`
+	// ScanRoots are glob patterns for more agent homes, like --scan-root.
+	ScanRoots []string `json:"scan_roots,omitempty"`
+	// Remotes are ssh destinations indexed like --remote.
+	Remotes []string `json:"remotes,omitempty"`
+	DBPath  string   `json:"db_path,omitempty"`
+	// AnnotationsFile is the annotations sync file, e.g. in a dotfiles repo.
+	AnnotationsFile string `json:"annotations_file,omitempty"`
+	ExportDir       string `json:"export_dir,omitempty"`
+	GlamourStyle    string `json:"glamour_style,omitempty"`
+	ExportImages    bool   `json:"export_images,omitempty"`
+	// ExportToolLines cuts tool message bodies in exports to N lines.
+	ExportToolLines int `json:"export_tool_lines,omitempty"`
+	// ExportName is the export filename template, e.g.
+	// "{{.Date}}-{{.Slug}}-{{.ShortID}}.md".
+	ExportName string `json:"export_name,omitempty"`
+	// ExportManifest is the file listing all exports, e.g. "README.md".
+	ExportManifest string `json:"export_manifest,omitempty"`
+	// ExportVersions keeps earlier exports as -v2, -v3... files.
+	ExportVersions bool `json:"export_versions,omitempty"`
+	// ExportFrontMatter writes session details as YAML front matter.
+	ExportFrontMatter bool `json:"export_front_matter,omitempty"`
+	// SnippetUsage adds a usage line to copied snippets.
+	SnippetUsage bool `json:"snippet_usage,omitempty"`
+	// ImageProtocol is auto, kitty, iterm2 or none.
+	ImageProtocol string `json:"image_protocol,omitempty"`
+	SafeRender    bool   `json:"safe_render,omitempty"`
+	// CompressContent zstd-compresses large message content in the index.
+	CompressContent bool `json:"compress_content,omitempty"`
+	// MaxLineChars, MaxRenderChars and MaxDisplayChars override the
+	// viewer's display limits.
+	MaxLineChars    int `json:"max_line_chars,omitempty"`
+	MaxRenderChars  int `json:"max_render_chars,omitempty"`
+	MaxDisplayChars int `json:"max_display_chars,omitempty"`
+	// ShowEmpty lists sessions with no conversational messages too.
+	ShowEmpty bool `json:"show_empty,omitempty"`
+	// RelativeTime shows last activity as "2h ago".
+	RelativeTime bool `json:"relative_time,omitempty"`
+	// TimeZone and TimeFormat set how times are shown.
+	TimeZone   string `json:"timezone,omitempty"`
+	TimeFormat string `json:"time_format,omitempty"`
+	// QuickUnder folds sessions with fewer messages into a "quick sessions"
+	// group.
+	QuickUnder int `json:"quick_under,omitempty"`
+	// Similar is how many similar sessions the detail header suggests; 0
+	// turns the suggestions off.
+	Similar *int `json:"similar,omitempty"`
+	// RefreshInterval re-indexes every N minutes while the TUI is open.
+	RefreshInterval int `json:"refresh_interval,omitempty"`
+	// Summarizer is the summarize command's model as backend:model, and
+	// SummarizerURL its address.
+	Summarizer    string `json:"summarizer,omitempty"`
+	SummarizerURL string `json:"summarizer_url,omitempty"`
+	// Embedder is the semantic search model as backend:model, and
+	// EmbedderURL its address.
+	Embedder    string `json:"embedder,omitempty"`
+	EmbedderURL string `json:"embedder_url,omitempty"`
+	// LogFile, LogLevel and LogFormat configure structured logging.
+	LogFile   string `json:"log_file,omitempty"`
+	LogLevel  string `json:"log_level,omitempty"`
+	LogFormat string `json:"log_format,omitempty"`
+	// OpenCommand opens a session workdir, e.g. "code -n {{.Workdir}}".
+	OpenCommand string `json:"open_command,omitempty"`
+	// Resume overrides the command `r` runs, keyed by source. Arguments are
+	// Go templates over {{.SessionID}}, {{.Workdir}} and {{.Source}}.
+	Resume map[string]string `json:"resume,omitempty"`
+	// Handoff overrides the command `H` starts the other agent with, keyed by
+	// that agent's source; templates see {{.Prompt}}, {{.TranscriptPath}},
+	// {{.SessionID}}, {{.Workdir}} and {{.Source}}.
+	Handoff map[string]string `json:"handoff,omitempty"`
+	// SpawnCommand resumes a session in a new window, e.g.
+	// "kitty @ launch --type=tab --cwd {{.Workdir}} sh -c {{.Command}}".
+	SpawnCommand string `json:"spawn_command,omitempty"`
+	// Toggles holds per-source transcript toggle defaults, keyed by source
+	// ("claude", "codex").
+	Toggles map[string]ToggleProfile `json:"toggles,omitempty"`
+	// Normalize rewrites message content at ingest.
+	Normalize NormalizeConfig `json:"normalize,omitempty"`
+	// Pricing adds or overrides model prices (USD per 1M tokens), keyed by
+	// model name prefix.
+	Pricing pricing.Table `json:"pricing,omitempty"`
+	// Collapse adds rules for instruction blocks hidden in the transcript,
+	// on top of the built-in AGENTS.md rule.
+	Collapse []CollapseRule `json:"collapse,omitempty"`
+}
+
+// CollapseRule hides a block of injected instructions in the TUI transcript
+// behind a one-line hint.
+type CollapseRule struct {
+	// Name is shown in the hint, e.g. "AGENTS.md instructions".
+	Name string `json:"name"`
+	// Start is a Go regular expression matched at the start of a line; the
+	// block begins there. Its first capture group, if any, is a directory
+	// for RequireFile.
+	Start string `json:"start"`
+	// End is literal text that closes the block and is hidden with it.
+	End string `json:"end"`
+	// RequireFile, when set, collapses the block only if the captured
+	// directory still holds this file, so stale instructions stay visible.
+	RequireFile string `json:"require_file,omitempty"`
+}
+
+// DefaultCollapseRules hides the AGENTS.md preamble Codex injects.
+func DefaultCollapseRules() []CollapseRule {
+	return []CollapseRule{{
+		Name:        "AGENTS.md instructions",
+		Start:       `# AGENTS\.md instructions for (.+)`,
+		End:         "</INSTRUCTIONS>",
+		RequireFile: "AGENTS.md",
+	}}
+}
+
+// Validate checks that the rule has a name, an end marker and a start
+// pattern that compiles.
+func (r CollapseRule) Validate() error {
+	if r.Name == "" || r.Start == "" || r.End == "" {
+		return fmt.Errorf("collapse rule %q: name, start and end are required", r.Name)
+	}
+	if _, err := regexp.Compile(r.Start); err != nil {
+		return fmt.Errorf("collapse rule %q start pattern: %w", r.Name, err)
+	}
+	return nil
+}
+
+// NormalizeConfig describes ingest-time content normalization: regex strip
+// rules applied in order, then an optional external command.
+type NormalizeConfig struct {
+	Strip   []StripRuleConfig `json:"strip,omitempty"`
+	Command []string          `json:"command,omitempty"`
+}
+
+// StripRuleConfig replaces matches of a Go regular expression.
+type StripRuleConfig struct {
+	Pattern string `json:"pattern"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// Fingerprint identifies the normalization settings so the index can tell
+// when content must be re-ingested. It is empty when normalization is off.
+func (n NormalizeConfig) Fingerprint() string {
+	if len(n.Strip) == 0 && len(n.Command) == 0 {
+		return ""
+	}
+	b, _ := json.Marshal(n)
+	return string(b)
+}
+
+// Validate checks that every strip pattern compiles.
+func (n NormalizeConfig) Validate() error {
+	for _, rule := range n.Strip {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("normalize strip pattern %q: %w", rule.Pattern, err)
+		}
+	}
+	return nil
+}
+
+// ToggleProfile is a set of transcript toggle defaults. Nil fields leave the
+// current setting alone.
+type ToggleProfile struct {
+	Tools     *bool `json:"tools,omitempty"`
+	Aborted   *bool `json:"aborted,omitempty"`
+	Events    *bool `json:"events,omitempty"`
+	Thinking  *bool `json:"thinking,omitempty"`
+	Reasoning *bool `json:"reasoning,omitempty"`
+}
+
+// DefaultConfigPath returns $XDG_CONFIG_HOME/agent-trace/config.json, falling
+// back to ~/.config/agent-trace/config.json.
+func DefaultConfigPath() (string, error) {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "agent-trace", "config.json"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "agent-trace", "config.json"), nil
+}
+
+// LoadFile reads a JSON config file. A missing file is not an error and
+// yields an empty config.
+func LoadFile(path string) (FileConfig, error) {
+	var fc FileConfig
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fc, nil
+	}
+	if err != nil {
+		return fc, fmt.Errorf("read config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(b, &fc); err != nil {
+		return fc, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// BuiltinGlamourStyles lists the style names glamour ships with, sorted.
+func BuiltinGlamourStyles() []string {
+	names := make([]string, 0, len(styles.DefaultStyles)+1)
+	for name := range styles.DefaultStyles {
+		names = append(names, name)
+	}
+	names = append(names, styles.AutoStyle)
+	sort.Strings(names)
+	return names
+}
+
+// ResolveGlamourStyle validates a style setting: either a built-in style
+// name or a path to a glamour style JSON file. Paths are returned absolute.
+func ResolveGlamourStyle(style string) (string, error) {
+	style = strings.TrimSpace(style)
+	if style == "" {
+		return DefaultGlamourStyle, nil
+	}
+	if _, ok := styles.DefaultStyles[style]; ok || style == styles.AutoStyle {
+		return style, nil
+	}
+
+	path := expandHome(style)
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("glamour style %q is neither a built-in style (%s) nor a readable file: %w",
+			style, strings.Join(BuiltinGlamourStyles(), ", "), err)
+	}
+	var probe map[string]any
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return "", fmt.Errorf("parse glamour style %s: %w", path, err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolve glamour style path: %w", err)
+	}
+	return abs, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
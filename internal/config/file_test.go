@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadFileMissingIsEmpty(t *testing.T) {
+	fc, err := LoadFile(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fc.GlamourStyle != "" || fc.DBPath != "" {
+		t.Fatalf("expected empty config, got %+v", fc)
+	}
+}
+
+func TestResolveGlamourStyle(t *testing.T) {
+	if got, err := ResolveGlamourStyle(""); err != nil || got != DefaultGlamourStyle {
+		t.Fatalf("empty style = %q, %v", got, err)
+	}
+	if got, err := ResolveGlamourStyle("dracula"); err != nil || got != "dracula" {
+		t.Fatalf("built-in style = %q, %v", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "mine.json")
+	if err := os.WriteFile(path, []byte(`{"document":{"margin":0}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ResolveGlamourStyle(path); err != nil || got != path {
+		t.Fatalf("custom style = %q, %v", got, err)
+	}
+	if _, err := ResolveGlamourStyle("not-a-style"); err == nil {
+		t.Fatalf("expected unknown style to be rejected")
+	}
+}
+
+func TestUIStateRoundTrip(t *testing.T) {
+	path := DefaultStatePath(filepath.Join(t.TempDir(), "index.sqlite"))
+	if st := LoadState(path); st.SidePane != "" {
+		t.Fatalf("missing state should be empty, got %+v", st)
+	}
+	if err := SaveState(path, UIState{SidePane: "stats"}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if st := LoadState(path); st.SidePane != "stats" {
+		t.Fatalf("loaded %+v", st)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if st := LoadState(path); st.SidePane != "" {
+		t.Fatalf("malformed state should be ignored, got %+v", st)
+	}
+}
+
+func TestCollapseRuleValidate(t *testing.T) {
+	for _, r := range DefaultCollapseRules() {
+		if err := r.Validate(); err != nil {
+			t.Fatalf("default rule: %v", err)
+		}
+	}
+	if err := (CollapseRule{Name: "x", Start: "(", End: "y"}).Validate(); err == nil {
+		t.Fatal("expected bad pattern to fail")
+	}
+	if err := (CollapseRule{Name: "x", Start: "a"}).Validate(); err == nil {
+		t.Fatal("expected missing end to fail")
+	}
+}
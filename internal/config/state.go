@@ -0,0 +1,65 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// UIState is TUI layout remembered between runs. Unlike FileConfig it is
+// written by the app, so it lives next to the index rather than in the
+// user's config file.
+type UIState struct {
+	// SidePane is the third pane shown beside the transcript: "outline",
+	// "stats" or empty for none.
+	SidePane string `json:"side_pane,omitempty"`
+}
+
+// DefaultStatePath returns ui-state.json next to the index.
+func DefaultStatePath(dbPath string) string {
+	return filepath.Join(filepath.Dir(dbPath), "ui-state.json")
+}
+
+// LoadState reads saved UI state. A missing or unreadable file yields the
+// default layout: losing it is harmless and should never block startup.
+func LoadState(path string) UIState {
+	var st UIState
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return UIState{}
+	}
+	if err := json.Unmarshal(b, &st); err != nil {
+		return UIState{}
+	}
+	return st
+}
+
+// SaveState writes UI state atomically.
+func SaveState(path string, st UIState) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode ui state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create ui state dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ui-state-*.json")
+	if err != nil {
+		return fmt.Errorf("create ui state: %w", err)
+	}
+	if _, err := tmp.Write(append(b, '\n')); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write ui state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write ui state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("replace ui state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+// ErrNoCommands is returned when a session recorded no shell commands.
+var ErrNoCommands = errors.New("no shell commands recorded")
+
+// ExportCommands writes the session's shell commands as a reviewable script
+// next to the markdown export, named after it (`<name>-commands.sh`).
+func (e *Exporter) ExportCommands(session index.Session, messages []index.Message) (string, error) {
+	cmds := index.ExtractShellCommands(messages)
+	if len(cmds) == 0 {
+		return "", ErrNoCommands
+	}
+	mdPath, err := e.outputPath(session, messages)
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSuffix(mdPath, ".md") + "-commands.sh"
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("create export directory: %w", err)
+	}
+	script := BuildCommandScript(session, cmds, time.Now().UTC())
+	if err := os.WriteFile(path, []byte(script), 0o644); err != nil {
+		return "", fmt.Errorf("write commands file: %w", err)
+	}
+	e.logger().Info("exported shell commands", "session", session.ID, "path", path, "commands", len(cmds))
+	return path, nil
+}
+
+// BuildCommandScript renders commands as a shell script. Commands that exited
+// non-zero are kept but commented out so the script replays the happy path.
+func BuildCommandScript(session index.Session, cmds []index.ShellCommand, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("#!/usr/bin/env bash\n")
+	b.WriteString("# Shell commands from " + sourceHeading(session.Source) + " session " + session.ID + "\n")
+	b.WriteString("# Exported: " + now.Format(time.RFC3339) + "\n")
+	b.WriteString("# Workdir: " + safeValue(session.Workdir) + "\n")
+	b.WriteString("#\n")
+	b.WriteString("# Listed in the order the agent ran them. Review before running;\n")
+	b.WriteString("# commands that failed are commented out.\n")
+
+	cwd := ""
+	for n, c := range cmds {
+		b.WriteString("\n")
+		if c.Workdir != "" && c.Workdir != cwd {
+			b.WriteString("cd " + index.ShellQuote(c.Workdir) + " || exit 1\n")
+			cwd = c.Workdir
+		}
+
+		header := fmt.Sprintf("# [%d]", n+1)
+		if c.TS.Valid {
+			header += " " + index.FormatUnix(c.TS.Int64)
+		}
+		failed := c.Failed || (c.HasExit && c.ExitCode != 0)
+		switch {
+		case failed && !c.HasExit:
+			header += " exit=? (failed or not run)"
+		case failed:
+			header += fmt.Sprintf(" exit=%d (failed)", c.ExitCode)
+		case c.HasExit:
+			header += " exit=0"
+		default:
+			header += " exit=?"
+		}
+		b.WriteString(header + "\n")
+
+		for _, line := range strings.Split(strings.TrimRight(c.Command, "\n"), "\n") {
+			if failed {
+				b.WriteString("# ")
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+func sourceHeading(source string) string {
+	if source == "claude" {
+		return "Claude"
+	}
+	return "Codex"
+}
@@ -2,18 +2,56 @@ package export
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"text/template"
 	"time"
 
 	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+	"agent-trace/internal/pricing"
 )
 
 type Exporter struct {
 	overrideDir string
 	cwd         string
+
+	// ExportImages decodes embedded base64 images into files next to the
+	// markdown export and links them, instead of leaving the data inline.
+	ExportImages bool
+	// ToolLines cuts tool calls and outputs to this many lines, so exports
+	// meant for PRs don't carry whole test logs; 0 keeps them whole.
+	ToolLines int
+	// Pricing prices the usage summary in the export header; nil omits the
+	// cost estimate.
+	Pricing pricing.Table
+	// FrontMatter puts the session details in YAML front matter for static
+	// site generators and note apps, instead of a heading and text block.
+	FrontMatter bool
+	// Versions keeps earlier exports: a session whose export changed is
+	// written to <name>-v2.md, -v3 and so on instead of over the old one.
+	Versions bool
+	// Manifest, when set, is the file name of a listing of every export
+	// in the export directory, kept up to date on each export.
+	Manifest string
+	// Sessions supplies the manifest's dates and summaries; nil lists
+	// exports by file time only.
+	Sessions SessionLookup
+	// NameTemplate names export files (see NameData); nil uses
+	// DefaultNameTemplate.
+	NameTemplate *template.Template
+	// Log records written exports; nil discards.
+	Log *slog.Logger
+}
+
+func (e *Exporter) logger() *slog.Logger {
+	if e.Log == nil {
+		return logging.Discard()
+	}
+	return e.Log
 }
 
 func New(overrideDir string) (*Exporter, error) {
@@ -24,69 +62,346 @@ func New(overrideDir string) (*Exporter, error) {
 	return &Exporter{overrideDir: strings.TrimSpace(overrideDir), cwd: cwd}, nil
 }
 
-func (e *Exporter) Export(session index.Session, messages []index.Message, toggles index.TranscriptToggles) (string, error) {
-	path, err := e.outputPath(session)
+// Export writes the session's markdown export and returns its path. When
+// the newest existing export already has the same content (the export
+// timestamp aside), nothing is written and written is false, so repeated
+// exports don't churn git status.
+func (e *Exporter) Export(session index.Session, messages []index.Message, toggles index.TranscriptToggles) (path string, written bool, err error) {
+	path, err = e.outputPath(session, messages)
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return "", fmt.Errorf("create export directory: %w", err)
+		return "", false, fmt.Errorf("create export directory: %w", err)
 	}
 
-	body := BuildTranscriptMarkdown(messages, toggles, session.Source)
-	md := BuildSessionMarkdown(session, body, time.Now().UTC())
+	body := FilesTouchedMarkdown(index.ExtractFilesTouched(messages)) + BuildTranscriptMarkdown(TruncateToolOutput(messages, e.ToolLines), toggles, session.Source)
+	md := BuildSessionMarkdown(session, body, e.Pricing, time.Now().UTC())
+	if e.FrontMatter {
+		md = BuildFrontMatterMarkdown(session, sessionStart(session, messages), body, e.Pricing, time.Now().UTC())
+	}
+	latest, version := path, 1
+	if e.Versions {
+		latest, version = latestVersion(path)
+	}
+	existing, err := os.ReadFile(latest)
+	if err == nil {
+		same := md
+		if e.ExportImages {
+			same, _ = writeImages(md, latest, false)
+		}
+		if sameExport(string(existing), same) {
+			e.logger().Info("export unchanged", "session", session.ID, "path", latest)
+			e.updateManifest(latest)
+			return latest, false, nil
+		}
+		if e.Versions {
+			path = versionPath(path, version+1)
+		}
+	}
+	if e.ExportImages {
+		if md, err = writeImages(md, path, true); err != nil {
+			return "", false, err
+		}
+	}
 	if err := os.WriteFile(path, []byte(md), 0o644); err != nil {
-		return "", fmt.Errorf("write export file: %w", err)
+		return "", false, fmt.Errorf("write export file: %w", err)
+	}
+	e.logger().Info("exported session", "session", session.ID, "path", path, "bytes", len(md))
+	e.updateManifest(path)
+	return path, true, nil
+}
+
+// updateManifest refreshes the manifest next to exportPath. A failure is
+// logged rather than returned: the export itself was written.
+func (e *Exporter) updateManifest(exportPath string) {
+	if e.Manifest == "" {
+		return
+	}
+	if err := e.writeManifest(filepath.Dir(exportPath)); err != nil {
+		e.logger().Warn("export manifest not updated", "path", exportPath, "err", err)
+	}
+}
+
+// exportTimestamp matches the export time in the header or front matter,
+// the one line that differs between exports of unchanged content.
+var exportTimestamp = regexp.MustCompile(`(?m)^(Exported|exported): .*$`)
+
+func sameExport(a, b string) bool {
+	return exportTimestamp.ReplaceAllString(a, "") == exportTimestamp.ReplaceAllString(b, "")
+}
+
+// latestVersion returns the newest of path, path-v2, path-v3... that
+// exists and its version number, with path as version 1.
+func latestVersion(path string) (string, int) {
+	latest := path
+	for n := 2; ; n++ {
+		p := versionPath(path, n)
+		if _, err := os.Stat(p); err != nil {
+			return latest, n - 1
+		}
+		latest = p
+	}
+}
+
+// versionPath is path with -vN before its extension.
+func versionPath(path string, n int) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-v%d%s", strings.TrimSuffix(path, ext), n, ext)
+}
+
+// TruncateToolOutput returns messages with tool calls and outputs (anything
+// exported as a fenced Tool or Event block) longer than lines cut to their
+// first lines and a note of how many were dropped. lines <= 0 returns
+// messages unchanged; the input is never modified.
+func TruncateToolOutput(messages []index.Message, lines int) []index.Message {
+	if lines <= 0 {
+		return messages
 	}
-	return path, nil
+	out := messages
+	copied := false
+	for n, m := range messages {
+		if m.Role == "user" || m.Role == "assistant" {
+			continue
+		}
+		body := strings.Split(strings.TrimSpace(m.Content), "\n")
+		if len(body) <= lines {
+			continue
+		}
+		if !copied {
+			out = append([]index.Message(nil), messages...)
+			copied = true
+		}
+		out[n].Content = strings.Join(body[:lines], "\n") + fmt.Sprintf("\n… truncated (%d more lines)", len(body)-lines)
+	}
+	return out
 }
 
 func BuildTranscriptMarkdown(messages []index.Message, toggles index.TranscriptToggles, source string) string {
+	return buildTranscript(messages, toggles, source, "##", turnCounter(toggles))
+}
+
+// TurnLabel is what a prompt heading says after "You" when turns are
+// numbered, e.g. "(turn 37)".
+func TurnLabel(n int) string {
+	return fmt.Sprintf("(turn %d)", n)
+}
+
+// turnCounter returns the prompt count numbering starts after, or nil when
+// turns are not numbered.
+func turnCounter(toggles index.TranscriptToggles) *int {
+	if !toggles.NumberTurns {
+		return nil
+	}
+	n := toggles.TurnsBefore
+	return &n
+}
+
+// buildTranscript renders messages with turn headings at the given level, so
+// nested transcripts can sit below the turn that spawned them. When turns is
+// set, prompts are numbered on from it and it is advanced past them.
+func buildTranscript(messages []index.Message, toggles index.TranscriptToggles, source, heading string, turns *int) string {
 	filtered := index.FilterMessages(messages, toggles)
 	var b strings.Builder
 
-	assistantHeader := "## Codex"
+	assistantHeader := heading + " Codex"
 	if source == "claude" {
-		assistantHeader = "## Claude"
+		assistantHeader = heading + " Claude"
 	}
 
+	var prevTS int64
+	prevUser := false
 	for _, m := range filtered {
 		content := strings.TrimSpace(m.Content)
-		if m.Role == "user" {
+		if m.Role == "user" && m.Type != "image" {
 			content = sanitizeUserTranscriptContent(content)
 		}
 		if content == "" {
 			continue
 		}
+		stamp := ""
+		if toggles.Timestamps && m.TS.Valid {
+			stamp = turnStamp(m.TS.Int64, prevTS)
+			prevTS = m.TS.Int64
+		}
 
-		switch m.Role {
-		case "user":
-			header := "## You"
+		wasUser := prevUser
+		prevUser = m.Role == "user"
+		switch {
+		case m.Type == "image":
+			// Pasted images belong to the prompt they came with; only an
+			// image sent on its own gets a heading, and it is not a turn.
+			if !wasUser {
+				b.WriteString(heading + " You (image)" + stamp + "\n\n")
+			}
+			b.WriteString(content + "\n\n")
+		case m.Role == "user":
+			header := heading + " You"
 			if m.Type == "user_message" {
 				header += " (aborted)"
+			} else if turns != nil {
+				*turns++
+				header += " " + TurnLabel(*turns)
 			}
-			b.WriteString(header + "\n\n")
+			b.WriteString(header + stamp + "\n\n")
 			b.WriteString(content + "\n\n")
-		case "assistant":
-			b.WriteString(assistantHeader + "\n\n")
+		case m.Role == "assistant":
+			if m.Type == "thinking" || m.Type == "reasoning" {
+				b.WriteString(assistantHeader + " (" + m.Type + ")" + stamp + "\n\n")
+				b.WriteString(quoteLines(content) + "\n\n")
+				continue
+			}
+			b.WriteString(assistantHeader + stamp + "\n\n")
 			b.WriteString(content + "\n\n")
 		default:
-			title := "## Event"
+			title := heading + " Event"
 			if indexFilterIsTool(m) {
-				title = "## Tool"
+				title = heading + " Tool"
 			}
 			if m.Type != "" {
 				title += " (" + m.Type + ")"
 			}
-			b.WriteString(title + "\n\n")
-			b.WriteString("```text\n")
+			b.WriteString(title + stamp + "\n\n")
+			fence := codeFence(content)
+			b.WriteString(fence + "text\n")
 			b.WriteString(content + "\n")
-			b.WriteString("```\n\n")
+			b.WriteString(fence + "\n\n")
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+// turnStamp is the suffix a turn heading gets with timestamps on: the
+// time in the display zone, with the date when it differs from the previous turn's, and
+// the time since that turn. prev is 0 for the first turn.
+func turnStamp(ts, prev int64) string {
+	t := index.DisplayTime(ts)
+	if prev == 0 {
+		return " · " + t.Format(time.DateTime)
+	}
+	s := " · " + t.Format(time.TimeOnly)
+	if index.DisplayTime(prev).Format(time.DateOnly) != t.Format(time.DateOnly) {
+		s = " · " + t.Format(time.DateTime)
+	}
+	if ts >= prev {
+		s += " (+" + formatElapsed(time.Duration(ts-prev)*time.Second) + ")"
+	}
+	return s
+}
+
+// formatElapsed prints d like time.Duration does, without trailing zero
+// units: "45s", "2m5s", "1h3m".
+func formatElapsed(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// BuildTranscriptWithSubagents renders a transcript with each subagent's
+// transcript nested directly below the Task call that spawned it. Subagents
+// that cannot be matched to a call are appended at the end.
+func BuildTranscriptWithSubagents(messages []index.Message, subs []index.Subagent, toggles index.TranscriptToggles, source string) string {
+	if len(subs) == 0 {
+		return BuildTranscriptMarkdown(messages, toggles, source)
+	}
+	calls := index.FindSubagentCalls(messages)
+	matched := index.MatchSubagents(messages, calls, subs)
+
+	turns := turnCounter(toggles)
+	var b strings.Builder
+	writePart := func(md string) {
+		if md = strings.TrimSpace(md); md != "" {
+			b.WriteString(md + "\n\n")
+		}
+	}
+	used := make(map[int]bool, len(subs))
+	start := 0
+	for ci, c := range calls {
+		si, ok := matched[ci]
+		if !ok {
+			continue
+		}
+		writePart(buildTranscript(messages[start:c.Index+1], toggles, source, "##", turns))
+		writePart(subagentSection(c, subs[si], toggles))
+		used[si] = true
+		start = c.Index + 1
+	}
+	writePart(buildTranscript(messages[start:], toggles, source, "##", turns))
+
+	for si, s := range subs {
+		if !used[si] {
+			writePart(subagentSection(index.SubagentCall{}, s, toggles))
+		}
+	}
+	return strings.TrimSpace(b.String()) + "\n"
+}
+
+func subagentSection(call index.SubagentCall, sub index.Subagent, toggles index.TranscriptToggles) string {
+	title := "### ↳ Subagent"
+	if call.Description != "" {
+		title += ": " + call.Description
+	}
+	if call.AgentType != "" {
+		title += " (" + call.AgentType + ")"
+	}
+	body := strings.TrimSpace(buildTranscript(sub.Messages, toggles, "claude", "####", nil))
+	if body == "" {
+		body = "_No transcript content with current filters._"
+	}
+	return fmt.Sprintf("%s\n\n> `%s` · %d messages\n\n%s\n\n> _End of subagent transcript._", title, sub.Session.ID, sub.Session.MessageCount, body)
+}
+
+// BuildThreadMarkdown renders a chain of continued sessions as one transcript,
+// with a marker at each seam. Messages a continuation copied verbatim from
+// its predecessor (same role, timestamp and content) are only shown once.
+func BuildThreadMarkdown(sessions []index.Session, messages [][]index.Message, toggles index.TranscriptToggles) string {
+	var b strings.Builder
+	seen := make(map[string]struct{})
+	for n, s := range sessions {
+		if n >= len(messages) {
+			break
+		}
+		fresh := make([]index.Message, 0, len(messages[n]))
+		for _, m := range messages[n] {
+			key := fmt.Sprintf("%s|%d|%s", m.Role, m.TS.Int64, normalizeWhitespace(m.Content))
+			if _, dup := seen[key]; dup && m.TS.Valid {
+				continue
+			}
+			seen[key] = struct{}{}
+			fresh = append(fresh, m)
+		}
+
+		if n == 0 {
+			b.WriteString(fmt.Sprintf("> **Thread of %d sessions**, starting with `%s` (%s)\n\n", len(sessions), s.ID, index.FormatUnix(s.LastActivityTS)))
+		} else {
+			b.WriteString(fmt.Sprintf("---\n\n> **Continued in session `%s`** (%s)\n\n", s.ID, index.FormatUnix(s.LastActivityTS)))
+		}
+		body := strings.TrimSpace(BuildTranscriptMarkdown(fresh, toggles, s.Source))
+		if body == "" {
+			body = "_No new conversational turns in this session._"
 		}
+		b.WriteString(body + "\n\n")
 	}
 	return strings.TrimSpace(b.String()) + "\n"
 }
 
+func quoteLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for idx, line := range lines {
+		lines[idx] = strings.TrimRight("> "+line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
 func sanitizeUserTranscriptContent(content string) string {
 	content = strings.TrimSpace(content)
 	if content == "" {
@@ -175,7 +490,7 @@ func agentsFileExists(path string) bool {
 	return err == nil && !st.IsDir()
 }
 
-func BuildSessionMarkdown(session index.Session, transcript string, now time.Time) string {
+func BuildSessionMarkdown(session index.Session, transcript string, prices pricing.Table, now time.Time) string {
 	var b strings.Builder
 	heading := "Codex"
 	if session.Source == "claude" {
@@ -187,6 +502,27 @@ func BuildSessionMarkdown(session index.Session, transcript string, now time.Tim
 	b.WriteString("source: " + safeValue(session.Source) + "\n")
 	b.WriteString(fmt.Sprintf("message_count: %d\n", session.MessageCount))
 	b.WriteString("workdir: " + safeValue(session.Workdir) + "\n")
+	if session.Branch != "" {
+		b.WriteString("branch: " + safeValue(session.Branch) + "\n")
+	}
+	if len(session.SourcePaths) > 1 {
+		b.WriteString("source_paths:\n")
+		for _, p := range session.SourcePaths {
+			b.WriteString("  - " + p + "\n")
+		}
+	}
+	if len(session.Usage) > 0 {
+		total := index.SumUsage(session.Usage)
+		b.WriteString(fmt.Sprintf("tokens: input=%d output=%d cache_read=%d cache_write=%d\n",
+			total.InputTokens, total.OutputTokens, total.CacheReadTokens, total.CacheWriteTokens))
+		if total.Model != "" {
+			b.WriteString("models: " + total.Model + "\n")
+		}
+		if prices != nil {
+			usd, complete := prices.Estimate(session.Usage)
+			b.WriteString("estimated_cost: " + pricing.FormatCost(usd, complete) + "\n")
+		}
+	}
 	b.WriteString("```\n\n")
 	b.WriteString(transcript)
 	if !strings.HasSuffix(transcript, "\n") {
@@ -195,13 +531,39 @@ func BuildSessionMarkdown(session index.Session, transcript string, now time.Tim
 	return b.String()
 }
 
-func (e *Exporter) outputPath(session index.Session) (string, error) {
+// FilesTouchedMarkdown lists the files a session read or changed as a
+// section for review context; it is empty when there are none.
+func FilesTouchedMarkdown(files []index.FileTouch) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Files touched\n\n")
+	for _, f := range files {
+		b.WriteString("- `" + f.Path + "`")
+		if f.Edited {
+			b.WriteString(" (edited)")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func (e *Exporter) outputPath(session index.Session, messages []index.Message) (string, error) {
+	name := safeFileName(session.ID) + ".md"
+	if e.NameTemplate != nil {
+		var err error
+		if name, err = expandName(e.NameTemplate, SessionNameData(session, messages)); err != nil {
+			return "", fmt.Errorf("export name for %s: %w", session.ID, err)
+		}
+	}
 	if e.overrideDir != "" {
 		dir := e.overrideDir
 		if !filepath.IsAbs(dir) {
 			dir = filepath.Join(e.cwd, dir)
 		}
-		return filepath.Join(dir, safeFileName(session.ID)+".md"), nil
+		return filepath.Join(dir, name), nil
 	}
 
 	root := e.cwd
@@ -209,14 +571,16 @@ func (e *Exporter) outputPath(session index.Session) (string, error) {
 	if session.Source == "claude" {
 		subdir = "claude"
 	} else if session.Workdir != "" {
-		if repoRoot := findRepoRoot(session.Workdir); repoRoot != "" {
+		if repoRoot := FindRepoRoot(session.Workdir); repoRoot != "" {
 			root = repoRoot
 		}
 	}
-	return filepath.Join(root, "docs", subdir, safeFileName(session.ID)+".md"), nil
+	return filepath.Join(root, "docs", subdir, name), nil
 }
 
-func findRepoRoot(start string) string {
+// FindRepoRoot returns the nearest directory at or above start that holds a
+// .git entry, or "" when there is none.
+func FindRepoRoot(start string) string {
 	if start == "" {
 		return ""
 	}
@@ -250,6 +614,22 @@ func safeValue(s string) string {
 	return s
 }
 
+// codeFence returns a backtick fence longer than any backtick run in
+// content, so tool output containing ``` cannot close the block early and
+// have the rest rendered as markdown.
+func codeFence(content string) string {
+	longest, run := 0, 0
+	for _, r := range content {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
 func indexFilterIsTool(m index.Message) bool {
 	return strings.Contains(strings.ToLower(m.Role), "tool") || strings.Contains(strings.ToLower(m.Type), "tool")
 }
@@ -1,12 +1,16 @@
 package export
 
 import (
+	"database/sql"
+	"encoding/base64"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
 )
 
 func TestBuildTranscriptMarkdown_StripsUnstructuredAgentsHeading(t *testing.T) {
@@ -28,6 +32,22 @@ func TestBuildTranscriptMarkdown_StripsUnstructuredAgentsHeading(t *testing.T) {
 	}
 }
 
+func TestBuildTranscriptMarkdown_ImagesFollowTheirPrompt(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "what is this?"},
+		{Role: "user", Type: "image", Content: "data:image/png;base64,AAAA"},
+		{Role: "assistant", Type: "message", Content: "a cat"},
+		{Role: "user", Type: "image", Content: "data:image/png;base64,BBBB"},
+	}
+	out := BuildTranscriptMarkdown(msgs, index.TranscriptToggles{NumberTurns: true}, "claude")
+	want := "## You (turn 1)\n\nwhat is this?\n\ndata:image/png;base64,AAAA\n\n" +
+		"## Claude\n\na cat\n\n" +
+		"## You (image)\n\ndata:image/png;base64,BBBB\n"
+	if out != want {
+		t.Fatalf("transcript =\n%s\nwant\n%s", out, want)
+	}
+}
+
 func TestBuildTranscriptMarkdown_StripsUnstructuredAgentsHeadingWithoutHash(t *testing.T) {
 	msgs := []index.Message{
 		{
@@ -90,3 +110,300 @@ func TestBuildTranscriptMarkdown_StripsStaleStructuredAgentsBlock(t *testing.T)
 		t.Fatalf("expected conversational content to remain, got:\n%s", out)
 	}
 }
+
+func TestBuildTranscriptMarkdown_Timestamps(t *testing.T) {
+	start := time.Date(2026, 1, 15, 23, 58, 0, 0, time.Local).Unix()
+	at := func(offset int64) sql.NullInt64 { return sql.NullInt64{Int64: start + offset, Valid: true} }
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "fix the test", TS: at(0)},
+		{Role: "assistant", Type: "message", Content: "on it", TS: at(125)},
+		{Role: "user", Type: "message", Content: "thanks", TS: at(3725)},
+		{Role: "assistant", Type: "message", Content: "done", TS: at(7490)},
+	}
+
+	out := BuildTranscriptMarkdown(msgs, index.TranscriptToggles{Timestamps: true}, "claude")
+	for _, want := range []string{
+		"## You · 2026-01-15 23:58:00\n",
+		"## Claude · 2026-01-16 00:00:05 (+2m5s)\n",
+		"## You · 01:00:05 (+1h)\n",
+		"## Claude · 02:02:50 (+1h2m45s)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if out := BuildTranscriptMarkdown(msgs, index.TranscriptToggles{}, "claude"); strings.Contains(out, " · ") {
+		t.Errorf("timestamps off should leave headings plain, got:\n%s", out)
+	}
+}
+
+func TestBuildTranscriptMarkdown_NumbersTurns(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "fix the test"},
+		{Role: "assistant", Type: "message", Content: "on it"},
+		{Role: "user", Type: "user_message", Content: "stop"},
+		{Role: "user", Type: "message", Content: "add docs"},
+	}
+	toggles := index.TranscriptToggles{IncludeAborted: true, NumberTurns: true, TurnsBefore: 36}
+	out := BuildTranscriptMarkdown(msgs, toggles, "claude")
+	for _, want := range []string{"## You (turn 37)\n\nfix the test", "## You (aborted)\n\nstop", "## You (turn 38)\n\nadd docs"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+	if out := BuildTranscriptMarkdown(msgs, index.TranscriptToggles{}, "claude"); strings.Contains(out, "(turn ") {
+		t.Errorf("turns should only be numbered on request, got:\n%s", out)
+	}
+}
+
+func TestBuildCommandScript_CommentsOutFailures(t *testing.T) {
+	cmds := []index.ShellCommand{
+		{Command: "make build", Workdir: "/repo", HasExit: true},
+		{Command: "false", Workdir: "/repo", HasExit: true, ExitCode: 1},
+		{Command: "echo done"},
+		{Command: "rm -rf build", Failed: true},
+	}
+	out := BuildCommandScript(index.Session{ID: "s1", Source: "codex", Workdir: "/repo"}, cmds, time.Unix(0, 0).UTC())
+
+	for _, want := range []string{
+		"#!/usr/bin/env bash\n",
+		"cd /repo || exit 1\n",
+		"# [1] exit=0\nmake build\n",
+		"# [2] exit=1 (failed)\n# false\n",
+		"# [3] exit=?\necho done\n",
+		"# [4] exit=? (failed or not run)\n# rm -rf build\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected script to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "cd /repo") != 1 {
+		t.Fatalf("expected a single cd for an unchanged workdir, got:\n%s", out)
+	}
+}
+
+func TestBuildThreadMarkdown_SkipsCopiedHistory(t *testing.T) {
+	ts := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+	first := []index.Message{
+		{Role: "user", Type: "message", Content: "start the migration", TS: ts(10)},
+		{Role: "assistant", Type: "message", Content: "Migrating.", TS: ts(11)},
+	}
+	second := []index.Message{
+		{Role: "user", Type: "message", Content: "start the migration", TS: ts(10)},
+		{Role: "user", Type: "message", Content: "keep going", TS: ts(20)},
+	}
+	out := BuildThreadMarkdown(
+		[]index.Session{{ID: "s1", Source: "claude"}, {ID: "s2", Source: "claude"}},
+		[][]index.Message{first, second},
+		index.TranscriptToggles{},
+	)
+	if strings.Count(out, "start the migration") != 1 {
+		t.Fatalf("expected copied history to appear once, got:\n%s", out)
+	}
+	if !strings.Contains(out, "Continued in session `s2`") || !strings.Contains(out, "keep going") {
+		t.Fatalf("expected seam marker and new turn, got:\n%s", out)
+	}
+}
+
+func TestBuildTranscriptWithSubagents_NestsBelowTaskCall(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "audit the repo"},
+		{Role: "tool", Type: "tool_use", Content: `Task: {"description":"Find TODOs","prompt":"List every TODO"}`},
+		{Role: "assistant", Type: "message", Content: "The subagent found 3 TODOs."},
+	}
+	subs := []index.Subagent{{
+		Session: index.Session{ID: "p/agent-1", MessageCount: 2},
+		Messages: []index.Message{
+			{Role: "user", Type: "message", Content: "List every TODO"},
+			{Role: "assistant", Type: "message", Content: "Found 3 TODOs."},
+		},
+	}}
+	out := BuildTranscriptWithSubagents(msgs, subs, index.TranscriptToggles{}, "claude")
+
+	sub := strings.Index(out, "### ↳ Subagent: Find TODOs")
+	nested := strings.Index(out, "#### Claude\n\nFound 3 TODOs.")
+	after := strings.Index(out, "The subagent found 3 TODOs.")
+	if sub < 0 || nested < sub || after < nested {
+		t.Fatalf("expected subagent transcript nested before the follow-up turn, got:\n%s", out)
+	}
+}
+
+func TestExport_WritesEmbeddedImages(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(dir)
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	e.ExportImages = true
+
+	png := base64.StdEncoding.EncodeToString([]byte("\x89PNG fake"))
+	msgs := []index.Message{{Role: "user", Type: "message", Content: "see data:image/png;base64," + png + " here"}}
+	path, _, err := e.Export(index.Session{ID: "s1", Source: "codex"}, msgs, index.TranscriptToggles{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	md, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	if !strings.Contains(string(md), "see ![image 1](s1/img-1.png) here") || strings.Contains(string(md), png) {
+		t.Fatalf("expected data URI replaced by an image link, got:\n%s", md)
+	}
+	img, err := os.ReadFile(filepath.Join(dir, "s1", "img-1.png"))
+	if err != nil || string(img) != "\x89PNG fake" {
+		t.Fatalf("expected decoded image file, got %q, %v", img, err)
+	}
+}
+
+func TestExport_ImageNamesStayInTheExportDirectory(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(filepath.Join(dir, "docs"))
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	e.ExportImages = true
+
+	data := base64.StdEncoding.EncodeToString([]byte("payload"))
+	content := "a data:image/../../../x;base64," + data + " .\n" +
+		"b data:image/x-evil;base64," + data + " .\n" +
+		"c data:image/png and later ;base64," + data
+	msgs := []index.Message{{Role: "user", Type: "message", Content: content}}
+	path, _, err := e.Export(index.Session{ID: "s1", Source: "codex"}, msgs, index.TranscriptToggles{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	md, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	for _, want := range []string{"a data:image/../../../x;base64,", "b ![image 1](s1/img-1.bin)", "c data:image/png and later ;base64,"} {
+		if !strings.Contains(string(md), want) {
+			t.Fatalf("expected %q in export, got:\n%s", want, md)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.bin")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written outside the export directory, got %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(dir, "docs", "s1"))
+	if len(entries) != 1 || entries[0].Name() != "img-1.bin" {
+		t.Fatalf("expected one image file, got %v", entries)
+	}
+}
+
+func TestBuildSessionMarkdown_IncludesUsageAndCost(t *testing.T) {
+	session := index.Session{ID: "s1", Source: "claude", Branch: "feature-x", Usage: []index.Usage{
+		{Model: "claude-sonnet-4-5", InputTokens: 1_000_000, OutputTokens: 100_000},
+	}}
+	md := BuildSessionMarkdown(session, "body\n", pricing.Default(), time.Unix(0, 0).UTC())
+	for _, want := range []string{
+		"tokens: input=1000000 output=100000 cache_read=0 cache_write=0\n",
+		"models: claude-sonnet-4-5\n",
+		"estimated_cost: ~$4.50\n",
+		"branch: feature-x\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("expected %q in header:\n%s", want, md)
+		}
+	}
+	if md := BuildSessionMarkdown(index.Session{ID: "s2"}, "body\n", pricing.Default(), time.Unix(0, 0).UTC()); strings.Contains(md, "tokens:") || strings.Contains(md, "branch:") {
+		t.Fatalf("expected no usage or branch lines without the data:\n%s", md)
+	}
+}
+
+func TestBuildTranscriptMarkdown_ToolOutputCannotCloseFence(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "show the readme"},
+		{Role: "tool", Type: "tool_result", Content: "intro\n```\n## You\n\nfake turn"},
+	}
+	out := BuildTranscriptMarkdown(msgs, index.TranscriptToggles{IncludeTools: true}, "claude")
+	if !strings.Contains(out, "````text\nintro\n```\n## You\n\nfake turn\n````\n") {
+		t.Fatalf("expected a longer fence around tool output, got:\n%s", out)
+	}
+}
+
+func TestFilesTouchedMarkdown(t *testing.T) {
+	got := FilesTouchedMarkdown([]index.FileTouch{{Path: "main.go", Edited: true}, {Path: "go.mod"}})
+	want := "## Files touched\n\n- `main.go` (edited)\n- `go.mod`\n\n"
+	if got != want {
+		t.Fatalf("FilesTouchedMarkdown = %q, want %q", got, want)
+	}
+	if got := FilesTouchedMarkdown(nil); got != "" {
+		t.Fatalf("expected no section without files, got %q", got)
+	}
+}
+
+func TestTruncateToolOutput(t *testing.T) {
+	long := strings.Repeat("ok line\n", 10) + "FAIL"
+	msgs := []index.Message{
+		{Role: "assistant", Content: long},
+		{Type: "function_call_output", Content: long},
+		{Role: "tool", Type: "tool_result", Content: "short"},
+	}
+	out := TruncateToolOutput(msgs, 3)
+	if out[0].Content != long {
+		t.Fatalf("assistant text must stay whole")
+	}
+	if want := "ok line\nok line\nok line\n… truncated (8 more lines)"; out[1].Content != want {
+		t.Fatalf("truncated = %q, want %q", out[1].Content, want)
+	}
+	if out[2].Content != "short" || msgs[1].Content != long {
+		t.Fatalf("short output changed or input modified")
+	}
+	if got := TruncateToolOutput(msgs, 0); &got[0] != &msgs[0] {
+		t.Fatalf("expected messages untouched with no limit")
+	}
+}
+
+func TestBuildFrontMatterMarkdown(t *testing.T) {
+	session := index.Session{ID: "s1", Source: "claude", Workdir: `/tmp/a "repo"`, MessageCount: 4, Tags: []string{"auth", "bug fix"}, Usage: []index.Usage{
+		{Model: "claude-sonnet-4-5", InputTokens: 10, OutputTokens: 20},
+	}}
+	md := BuildFrontMatterMarkdown(session, 86400, "## You\n\nhi\n", nil, time.Unix(0, 0).UTC())
+	want := "---\n" +
+		"title: \"Claude session s1\"\n" +
+		"session_id: \"s1\"\n" +
+		"source: \"claude\"\n" +
+		"date: 1970-01-02T00:00:00Z\n" +
+		"exported: 1970-01-01T00:00:00Z\n" +
+		"workdir: \"/tmp/a \\\"repo\\\"\"\n" +
+		"message_count: 4\n" +
+		"tags:\n  - \"auth\"\n  - \"bug fix\"\n" +
+		"tokens:\n  input: 10\n  output: 20\n  cache_read: 0\n  cache_write: 0\n" +
+		"models: \"claude-sonnet-4-5\"\n" +
+		"---\n\n## You\n\nhi\n"
+	if md != want {
+		t.Fatalf("unexpected front matter:\n%s", md)
+	}
+}
+
+func TestExport_SkipsUnchangedAndVersions(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(dir)
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	session := index.Session{ID: "s1", Source: "codex"}
+	msgs := []index.Message{{Role: "user", Type: "message", Content: "hello"}}
+	first, written, err := e.Export(session, msgs, index.TranscriptToggles{})
+	if err != nil || !written {
+		t.Fatalf("first export: written=%v err=%v", written, err)
+	}
+	again, written, err := e.Export(session, msgs, index.TranscriptToggles{})
+	if err != nil || written || again != first {
+		t.Fatalf("expected an unchanged export skipped, got %s written=%v err=%v", again, written, err)
+	}
+
+	e.Versions = true
+	msgs = append(msgs, index.Message{Role: "assistant", Type: "message", Content: "hi"})
+	v2, written, err := e.Export(session, msgs, index.TranscriptToggles{})
+	if err != nil || !written || v2 != filepath.Join(dir, "s1-v2.md") {
+		t.Fatalf("expected a changed export in s1-v2.md, got %s written=%v err=%v", v2, written, err)
+	}
+	if again, written, _ := e.Export(session, msgs, index.TranscriptToggles{}); written || again != v2 {
+		t.Fatalf("expected the unchanged v2 skipped, got %s written=%v", again, written)
+	}
+	if md, _ := os.ReadFile(first); strings.Contains(string(md), "hi") {
+		t.Fatal("expected the first export kept as it was")
+	}
+}
@@ -0,0 +1,120 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"agent-trace/internal/index"
+)
+
+// DefaultNameTemplate names exports after the session ID.
+const DefaultNameTemplate = "{{.ID}}.md"
+
+// maxSlugWords and maxSlugLen bound the prompt slug in export names.
+const (
+	maxSlugWords = 8
+	maxSlugLen   = 60
+)
+
+// NameData is what an export filename template sees.
+type NameData struct {
+	// ID is the full session ID; ShortID its first 8 characters.
+	ID      string
+	ShortID string
+	// Date is the day the session started, as YYYY-MM-DD in local time.
+	Date string
+	// Slug is the first real user prompt, lowercased, in dash-joined words.
+	Slug   string
+	Source string
+}
+
+// ParseNameTemplate parses an export filename template, checking that it
+// expands to a usable name. An empty text uses DefaultNameTemplate.
+func ParseNameTemplate(text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultNameTemplate
+	}
+	t, err := template.New("name").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("export name %q: %w", text, err)
+	}
+	sample := NameData{ID: "0199a1b2-c3d4", ShortID: "0199a1b2", Date: "2025-01-02", Slug: "fix-the-parser", Source: "codex"}
+	if _, err := expandName(t, sample); err != nil {
+		return nil, fmt.Errorf("export name %q: %w", text, err)
+	}
+	return t, nil
+}
+
+// SessionNameData describes session for a filename template; messages give
+// the start date and the prompt slug.
+func SessionNameData(session index.Session, messages []index.Message) NameData {
+	d := NameData{ID: session.ID, ShortID: session.ID, Source: session.Source, Slug: slugify(index.FirstPrompt(messages))}
+	if len(d.ShortID) > 8 {
+		d.ShortID = d.ShortID[:8]
+	}
+	d.Date = "undated"
+	if start := sessionStart(session, messages); start > 0 {
+		d.Date = index.DisplayTime(start).Format("2006-01-02")
+	}
+	if d.Slug == "" {
+		d.Slug = "session"
+	}
+	return d
+}
+
+// sessionStart is the unix time of the first timestamped message, falling
+// back to the session's last activity; 0 when neither is known.
+func sessionStart(session index.Session, messages []index.Message) int64 {
+	for _, m := range messages {
+		if m.TS.Valid && m.TS.Int64 > 0 {
+			return m.TS.Int64
+		}
+	}
+	return session.LastActivityTS
+}
+
+func expandName(t *template.Template, data NameData) (string, error) {
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	if strings.Trim(strings.TrimSpace(b.String()), "./\\") == "" {
+		return "", fmt.Errorf("expands to the unusable name %q", b.String())
+	}
+	return safeFileName(b.String()), nil
+}
+
+// slugify keeps the first words of s, lowercased and dash-joined, with
+// anything but letters and digits dropped.
+func slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for n, w := range words {
+		if n == maxSlugWords {
+			break
+		}
+		if b.Len() == 0 {
+			b.WriteString(truncateRunes(w, maxSlugLen))
+			continue
+		}
+		if b.Len()+len(w)+1 > maxSlugLen {
+			break
+		}
+		b.WriteByte('-')
+		b.WriteString(w)
+	}
+	return b.String()
+}
+
+func truncateRunes(s string, n int) string {
+	for i := range s {
+		if i >= n {
+			return s[:i]
+		}
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+package export
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+func TestExport_NamesFilesFromTemplate(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(dir)
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	if e.NameTemplate, err = ParseNameTemplate("{{.Date}}-{{.Slug}}-{{.ShortID}}.md"); err != nil {
+		t.Fatalf("parse template: %v", err)
+	}
+	start := time.Date(2025, 3, 4, 12, 0, 0, 0, time.Local).Unix()
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "<environment_context><cwd>/tmp</cwd></environment_context>"},
+		{Role: "user", Type: "message", TS: sql.NullInt64{Int64: start, Valid: true}, Content: "Fix the flaky parser test, please!"},
+	}
+	path, _, err := e.Export(index.Session{ID: "0199a1b2-c3d4-e5f6", Source: "codex"}, msgs, index.TranscriptToggles{})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if want := filepath.Join(dir, "2025-03-04-fix-the-flaky-parser-test-please-0199a1b2.md"); path != want {
+		t.Fatalf("expected %s, got %s", want, path)
+	}
+}
+
+func TestParseNameTemplate(t *testing.T) {
+	if _, err := ParseNameTemplate(""); err != nil {
+		t.Fatalf("expected the default template to parse: %v", err)
+	}
+	for _, bad := range []string{"{{.Nope}}.md", "{{.ID", "{{/* empty */}}"} {
+		if _, err := ParseNameTemplate(bad); err == nil {
+			t.Fatalf("expected %q rejected", bad)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"Fix the `parser` in src/lexer.go":             "fix-the-parser-in-src-lexer-go",
+		"one two three four five six seven eight nine": "one-two-three-four-five-six-seven-eight",
+		"   ":       "",
+		"Über café": "über-café",
+	}
+	for in, want := range cases {
+		if got := slugify(in); got != want {
+			t.Fatalf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
+)
+
+// BuildFrontMatterMarkdown is BuildSessionMarkdown for static site
+// generators and note apps: the session details go in YAML front matter
+// instead of a heading and a text block. start is the session's first
+// activity (unix seconds; 0 when unknown).
+func BuildFrontMatterMarkdown(session index.Session, start int64, transcript string, prices pricing.Table, now time.Time) string {
+	var b strings.Builder
+	b.WriteString("---\n")
+	yamlField(&b, "title", sourceHeading(session.Source)+" session "+session.ID)
+	yamlField(&b, "session_id", session.ID)
+	yamlField(&b, "source", session.Source)
+	if start > 0 {
+		b.WriteString("date: " + time.Unix(start, 0).UTC().Format(time.RFC3339) + "\n")
+	}
+	b.WriteString("exported: " + now.Format(time.RFC3339) + "\n")
+	if session.Workdir != "" {
+		yamlField(&b, "workdir", session.Workdir)
+	}
+	if session.Branch != "" {
+		yamlField(&b, "branch", session.Branch)
+	}
+	b.WriteString(fmt.Sprintf("message_count: %d\n", session.MessageCount))
+	if len(session.Tags) > 0 {
+		b.WriteString("tags:\n")
+		for _, t := range session.Tags {
+			b.WriteString("  - " + strconv.Quote(t) + "\n")
+		}
+	}
+	if len(session.Usage) > 0 {
+		total := index.SumUsage(session.Usage)
+		b.WriteString("tokens:\n")
+		b.WriteString(fmt.Sprintf("  input: %d\n  output: %d\n  cache_read: %d\n  cache_write: %d\n",
+			total.InputTokens, total.OutputTokens, total.CacheReadTokens, total.CacheWriteTokens))
+		if total.Model != "" {
+			yamlField(&b, "models", total.Model)
+		}
+		if prices != nil {
+			usd, complete := prices.Estimate(session.Usage)
+			yamlField(&b, "estimated_cost", pricing.FormatCost(usd, complete))
+		}
+	}
+	b.WriteString("---\n\n")
+	b.WriteString(transcript)
+	if !strings.HasSuffix(transcript, "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// yamlField writes key with value as a double-quoted scalar; Go's escapes
+// are valid YAML ones, so any value round-trips.
+func yamlField(b *strings.Builder, key, value string) {
+	b.WriteString(key + ": " + strconv.Quote(value) + "\n")
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxDataURIHeader bounds the `data:image/...;base64` header, so the MIME
+// type passed to replace never spans ordinary text.
+const maxDataURIHeader = 128
+
+// ReplaceImageData calls replace for every `data:image/...;base64,` payload
+// in s and substitutes its result for the whole data URI. The header must be
+// a MIME type and parameters alone; anything else is left as text.
+func ReplaceImageData(s string, replace func(mime, payload string) string) string {
+	var b strings.Builder
+	pos := 0
+	for {
+		i := strings.Index(s[pos:], "data:image/")
+		if i < 0 {
+			b.WriteString(s[pos:])
+			break
+		}
+		start := pos + i
+		b.WriteString(s[pos:start])
+
+		header, ok := dataURIHeader(s[start:])
+		if !ok {
+			b.WriteString("data:image/")
+			pos = start + len("data:image/")
+			continue
+		}
+
+		payloadStart := start + len(header) + len(";base64,")
+		j := payloadStart
+		for j < len(s) && isBase64Byte(s[j]) {
+			j++
+		}
+		mime, _, _ := strings.Cut(header[len("data:"):], ";")
+		b.WriteString(replace(mime, s[payloadStart:j]))
+		pos = j
+	}
+	return b.String()
+}
+
+// dataURIHeader returns the part of s before `;base64,` when s starts with a
+// well-formed image data URI header.
+func dataURIHeader(s string) (string, bool) {
+	end := strings.IndexByte(s, ',')
+	if end < 0 || end > maxDataURIHeader || !strings.HasSuffix(s[:end], ";base64") {
+		return "", false
+	}
+	header := s[:end-len(";base64")]
+	for i := len("data:image/"); i < len(header); i++ {
+		if !isHeaderByte(header[i]) {
+			return "", false
+		}
+	}
+	return header, true
+}
+
+func isHeaderByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte(".+-;=", c) >= 0
+}
+
+func isBase64Byte(c byte) bool {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return true
+	case c >= 'a' && c <= 'z':
+		return true
+	case c >= '0' && c <= '9':
+		return true
+	case c == '+' || c == '/' || c == '=' || c == '\n' || c == '\r':
+		return true
+	default:
+		return false
+	}
+}
+
+// writeImages decodes embedded images in md into files in a directory named
+// after the export file (docs/<source>/<session>/img-N.<ext>) and replaces
+// each data URI with a relative markdown image link. With write false only
+// the links are made, so an export can be compared before it is written.
+func writeImages(md, exportPath string, write bool) (string, error) {
+	dir := strings.TrimSuffix(exportPath, filepath.Ext(exportPath))
+	rel := filepath.Base(dir)
+	n := 0
+	var firstErr error
+	out := ReplaceImageData(md, func(mime, payload string) string {
+		clean := strings.NewReplacer("\n", "", "\r", "").Replace(payload)
+		data, err := base64.StdEncoding.DecodeString(clean)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(clean, "="))
+		}
+		if err != nil {
+			return fmt.Sprintf("[embedded image could not be decoded: %d base64 chars]", len(payload))
+		}
+		n++
+		name := fmt.Sprintf("img-%d.%s", n, ImageExt(mime))
+		if write {
+			if n == 1 {
+				if err := os.MkdirAll(dir, 0o755); err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("create image directory: %w", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("write image %s: %w", name, err)
+			}
+		}
+		return fmt.Sprintf("![image %d](%s/%s)", n, rel, name)
+	})
+	return out, firstErr
+}
+
+// ImageExt returns the file extension for an image MIME type. The type comes
+// from transcript text, so only known image types get their own extension;
+// anything else is saved as "bin".
+func ImageExt(mime string) string {
+	switch strings.ToLower(strings.TrimPrefix(mime, "image/")) {
+	case "png", "":
+		return "png"
+	case "jpeg", "jpg":
+		return "jpg"
+	case "gif":
+		return "gif"
+	case "webp":
+		return "webp"
+	}
+	return "bin"
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// SessionLookup finds indexed sessions; *index.Indexer implements it.
+type SessionLookup interface {
+	GetSession(sessionID string) (index.Session, error)
+}
+
+// manifestEntry is one export listed in the manifest.
+type manifestEntry struct {
+	file    string
+	id      string
+	source  string
+	ts      int64
+	summary string
+}
+
+// exportHeading and frontMatterID find the session an export file holds,
+// in the plain header and in YAML front matter.
+var (
+	exportHeading     = regexp.MustCompile(`^# (Codex|Claude) session (\S+)$`)
+	frontMatterID     = regexp.MustCompile(`^session_id: (".*")$`)
+	frontMatterSource = regexp.MustCompile(`^source: (".*")$`)
+)
+
+// writeManifest regenerates the manifest in dir from the exports there,
+// with dates and summaries from the index. It rewrites the file only when
+// its content changes.
+func (e *Exporter) writeManifest(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("list exports: %w", err)
+	}
+	var entries []manifestEntry
+	for _, f := range files {
+		if filepath.Base(f) == e.Manifest {
+			continue
+		}
+		id, source := exportedSession(f)
+		if id == "" {
+			continue
+		}
+		entry := manifestEntry{file: filepath.Base(f), id: id, source: source}
+		if e.Sessions != nil {
+			if s, err := e.Sessions.GetSession(id); err == nil {
+				entry.ts, entry.summary, entry.source = s.LastActivityTS, s.Preview, s.Source
+			}
+		}
+		if entry.ts == 0 {
+			if info, err := os.Stat(f); err == nil {
+				entry.ts = info.ModTime().Unix()
+			}
+		}
+		entries = append(entries, entry)
+	}
+	md := buildManifest(entries)
+	path := filepath.Join(dir, e.Manifest)
+	if old, err := os.ReadFile(path); err == nil && string(old) == md {
+		return nil
+	}
+	if err := os.WriteFile(path, []byte(md), 0o644); err != nil {
+		return fmt.Errorf("write export manifest: %w", err)
+	}
+	return nil
+}
+
+// buildManifest lists exports newest first, one line each.
+func buildManifest(entries []manifestEntry) string {
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].ts != entries[b].ts {
+			return entries[a].ts > entries[b].ts
+		}
+		return entries[a].file < entries[b].file
+	})
+	var b strings.Builder
+	b.WriteString("# Exported sessions\n\n")
+	b.WriteString("Maintained by agent-trace; regenerated on every export.\n\n")
+	for _, en := range entries {
+		date := "undated"
+		if en.ts > 0 {
+			date = index.DisplayTime(en.ts).Format("2006-01-02")
+		}
+		short := en.id
+		if len(short) > 8 {
+			short = short[:8]
+		}
+		fmt.Fprintf(&b, "- %s [`%s`](%s) %s", date, short, en.file, sourceHeading(en.source))
+		if summary := strings.Join(strings.Fields(en.summary), " "); summary != "" {
+			b.WriteString(": " + summary)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// exportedSession reads the session ID and source from the head of an
+// export file; the ID is "" for files agent-trace did not write.
+func exportedSession(path string) (id, source string) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for n := 0; n < 10 && sc.Scan(); n++ {
+		line := sc.Text()
+		if m := exportHeading.FindStringSubmatch(line); m != nil {
+			return m[2], strings.ToLower(m[1])
+		}
+		if m := frontMatterID.FindStringSubmatch(line); m != nil {
+			id, _ = strconv.Unquote(m[1])
+		}
+		if m := frontMatterSource.FindStringSubmatch(line); m != nil {
+			source, _ = strconv.Unquote(m[1])
+		}
+		if line == "---" && n > 0 {
+			break
+		}
+	}
+	return id, source
+}
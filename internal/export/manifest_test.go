@@ -0,0 +1,55 @@
+package export
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+type fakeSessions map[string]index.Session
+
+func (f fakeSessions) GetSession(id string) (index.Session, error) {
+	s, ok := f[id]
+	if !ok {
+		return index.Session{}, errors.New("not found")
+	}
+	return s, nil
+}
+
+func TestExport_MaintainsManifest(t *testing.T) {
+	dir := t.TempDir()
+	e, err := New(dir)
+	if err != nil {
+		t.Fatalf("new exporter: %v", err)
+	}
+	day := func(d int) int64 { return time.Date(2025, 3, d, 12, 0, 0, 0, time.Local).Unix() }
+	e.Manifest = "index.md"
+	e.Sessions = fakeSessions{
+		"older-session": {ID: "older-session", Source: "codex", LastActivityTS: day(1), Preview: "set up   CI"},
+		"newer-session": {ID: "newer-session", Source: "claude", LastActivityTS: day(2), Preview: "fix the parser"},
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.md"), []byte("# My notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	msgs := []index.Message{{Role: "user", Type: "message", Content: "hello"}}
+	for _, s := range []index.Session{{ID: "older-session", Source: "codex"}, {ID: "newer-session", Source: "claude"}} {
+		if _, _, err := e.Export(s, msgs, index.TranscriptToggles{}); err != nil {
+			t.Fatalf("export %s: %v", s.ID, err)
+		}
+		e.FrontMatter = true
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	want := "# Exported sessions\n\nMaintained by agent-trace; regenerated on every export.\n\n" +
+		"- 2025-03-02 [`newer-se`](newer-session.md) Claude: fix the parser\n" +
+		"- 2025-03-01 [`older-se`](older-session.md) Codex: set up CI\n"
+	if string(got) != want {
+		t.Fatalf("unexpected manifest:\n%s", got)
+	}
+}
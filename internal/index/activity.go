@@ -0,0 +1,97 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// activityMaxBuckets caps the sparkline width; longer sessions fold
+// neighbouring hours or days together.
+const activityMaxBuckets = 16
+
+// Activity is a session's conversational message volume over its lifetime,
+// bucketed by hour, or by day for sessions spanning more than two days.
+type Activity struct {
+	Unit   string // "h" or "d"
+	Span   int    // hours or days from the first message to the last
+	Counts []int  // at most activityMaxBuckets buckets covering Span
+}
+
+// buildActivity buckets message timestamps (unix seconds, ascending).
+func buildActivity(ts []int64) Activity {
+	if len(ts) == 0 {
+		return Activity{}
+	}
+	first, last := ts[0], ts[len(ts)-1]
+	unit, size := "h", int64(3600)
+	if last-first > 48*3600 {
+		unit, size = "d", 86400
+	}
+	span := int((last-first)/size) + 1
+	n := span
+	if n > activityMaxBuckets {
+		n = activityMaxBuckets
+	}
+	counts := make([]int, n)
+	for _, t := range ts {
+		counts[int((t-first)/size)*n/span]++
+	}
+	return Activity{Unit: unit, Span: span, Counts: counts}
+}
+
+// encode stores the activity in sessions.activity, e.g. "d3:4,0,9".
+func (a Activity) encode() string {
+	if len(a.Counts) == 0 {
+		return ""
+	}
+	parts := make([]string, len(a.Counts))
+	for idx, c := range a.Counts {
+		parts[idx] = strconv.Itoa(c)
+	}
+	return fmt.Sprintf("%s%d:%s", a.Unit, a.Span, strings.Join(parts, ","))
+}
+
+// parseActivity reverses encode; malformed values read as no activity.
+func parseActivity(s string) Activity {
+	head, body, ok := strings.Cut(s, ":")
+	if !ok || len(head) < 2 {
+		return Activity{}
+	}
+	span, err := strconv.Atoi(head[1:])
+	if err != nil {
+		return Activity{}
+	}
+	a := Activity{Unit: head[:1], Span: span}
+	for _, p := range strings.Split(body, ",") {
+		c, err := strconv.Atoi(p)
+		if err != nil {
+			return Activity{}
+		}
+		a.Counts = append(a.Counts, c)
+	}
+	return a
+}
+
+func sessionActivity(ctx context.Context, tx *sql.Tx, sessionID string) (Activity, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT ts FROM messages
+		WHERE session_id = ? AND COALESCE(ts, 0) > 0 AND type = 'message' AND role IN ('user', 'assistant')
+		ORDER BY ts
+	`, sessionID)
+	if err != nil {
+		return Activity{}, err
+	}
+	defer rows.Close()
+	var ts []int64
+	for rows.Next() {
+		var t int64
+		if err := rows.Scan(&t); err != nil {
+			return Activity{}, err
+		}
+		ts = append(ts, t)
+	}
+	return buildActivity(ts), rows.Err()
+}
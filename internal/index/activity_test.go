@@ -0,0 +1,30 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildActivity(t *testing.T) {
+	const h, d = int64(3600), int64(86400)
+	cases := []struct {
+		name string
+		ts   []int64
+		want Activity
+	}{
+		{"empty", nil, Activity{}},
+		{"quick", []int64{100, 400, 900}, Activity{Unit: "h", Span: 1, Counts: []int{3}}},
+		{"hours", []int64{0, 10, 2*h + 5}, Activity{Unit: "h", Span: 3, Counts: []int{2, 0, 1}}},
+		{"days", []int64{0, 1, 3*d + 1}, Activity{Unit: "d", Span: 4, Counts: []int{2, 0, 0, 1}}},
+		{"folded", []int64{0, 31 * d}, Activity{Unit: "d", Span: 32, Counts: append(append([]int{1}, make([]int, 14)...), 1)}},
+	}
+	for _, c := range cases {
+		got := buildActivity(c.ts)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v, want %+v", c.name, got, c.want)
+		}
+		if back := parseActivity(got.encode()); !reflect.DeepEqual(back, got) {
+			t.Errorf("%s: round trip gave %+v", c.name, back)
+		}
+	}
+}
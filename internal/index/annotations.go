@@ -0,0 +1,274 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Annotation is user-authored metadata for a session. Annotations are the
+// only data in the index that cannot be rebuilt from session files, so they
+// are mirrored to a JSONL sync file that can live in a dotfiles repo or a
+// synced folder.
+type Annotation struct {
+	SessionID  string   `json:"session_id"`
+	Alias      string   `json:"alias,omitempty"`
+	Note       string   `json:"note,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Bookmarked bool     `json:"bookmarked,omitempty"`
+	// UpdatedAt (unix milliseconds) decides which side wins when the sync
+	// file and the local index disagree.
+	UpdatedAt int64 `json:"updated_at"`
+}
+
+// Empty reports whether the annotation carries no data. Empty annotations
+// are kept as tombstones so clearing a session syncs to other machines.
+func (a Annotation) Empty() bool {
+	return a.Alias == "" && a.Note == "" && len(a.Tags) == 0 && !a.Bookmarked
+}
+
+// ParseTags splits a comma- or space-separated tag list, dropping leading
+// '#', duplicates and empty entries.
+func ParseTags(s string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		t := strings.ToLower(strings.TrimLeft(f, "#"))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// SetSyncFile sets the JSONL file annotations are merged from and written
+// to. An empty path keeps annotations in the index only.
+func (i *Indexer) SetSyncFile(path string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.syncFile = path
+}
+
+// Annotations returns all non-empty annotations keyed by session ID.
+func (i *Indexer) Annotations() (map[string]Annotation, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	all, err := i.loadAnnotations()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]Annotation, len(all))
+	for id, a := range all {
+		if !a.Empty() {
+			out[id] = a
+		}
+	}
+	return out, nil
+}
+
+// SetAnnotation stores a for a.SessionID, stamping it with the current time,
+// and rewrites the sync file.
+func (i *Indexer) SetAnnotation(a Annotation) (Annotation, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	a.Alias = strings.TrimSpace(a.Alias)
+	a.Note = strings.TrimSpace(a.Note)
+	a.Tags = ParseTags(strings.Join(a.Tags, ","))
+	a.UpdatedAt = time.Now().UnixMilli()
+	if err := i.putAnnotation(i.db, a); err != nil {
+		return a, err
+	}
+	if i.syncFile == "" {
+		return a, nil
+	}
+	all, err := i.loadAnnotations()
+	if err != nil {
+		return a, err
+	}
+	return a, writeSyncFile(i.syncFile, all)
+}
+
+// syncAnnotations merges the sync file into the index, keeping the newer
+// side of each annotation, and writes the merged set back when the file is
+// missing anything the index knows.
+func (i *Indexer) syncAnnotations() error {
+	if i.syncFile == "" {
+		return nil
+	}
+	remote, err := readSyncFile(i.syncFile)
+	if err != nil {
+		return err
+	}
+	local, err := i.loadAnnotations()
+	if err != nil {
+		return err
+	}
+
+	tx, err := i.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin annotation sync: %w", err)
+	}
+	defer tx.Rollback()
+	for id, r := range remote {
+		if l, ok := local[id]; ok && l.UpdatedAt >= r.UpdatedAt {
+			continue
+		}
+		if err := i.putAnnotation(tx, r); err != nil {
+			return err
+		}
+		local[id] = r
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit annotation sync: %w", err)
+	}
+
+	for id, l := range local {
+		if r, ok := remote[id]; !ok || r.UpdatedAt != l.UpdatedAt {
+			return writeSyncFile(i.syncFile, local)
+		}
+	}
+	return nil
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func (i *Indexer) putAnnotation(db execer, a Annotation) error {
+	bookmarked := 0
+	if a.Bookmarked {
+		bookmarked = 1
+	}
+	_, err := db.Exec(`
+		INSERT INTO annotations(session_id, alias, note, tags, bookmarked, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+			alias = excluded.alias,
+			note = excluded.note,
+			tags = excluded.tags,
+			bookmarked = excluded.bookmarked,
+			updated_at = excluded.updated_at
+	`, a.SessionID, a.Alias, a.Note, strings.Join(a.Tags, ","), bookmarked, a.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("store annotation %s: %w", a.SessionID, err)
+	}
+	return nil
+}
+
+func (i *Indexer) loadAnnotations() (map[string]Annotation, error) {
+	rows, err := i.db.Query(`SELECT session_id, alias, note, tags, bookmarked, updated_at FROM annotations`)
+	if err != nil {
+		return nil, fmt.Errorf("query annotations: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string]Annotation{}
+	for rows.Next() {
+		var a Annotation
+		var tags string
+		if err := rows.Scan(&a.SessionID, &a.Alias, &a.Note, &tags, &a.Bookmarked, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan annotation: %w", err)
+		}
+		if tags != "" {
+			a.Tags = strings.Split(tags, ",")
+		}
+		out[a.SessionID] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate annotations: %w", err)
+	}
+	return out, nil
+}
+
+// readSyncFile parses the sync file. A missing file is empty; malformed
+// lines (for example a half-resolved merge conflict) are skipped. When a
+// session appears twice the newer record wins.
+func readSyncFile(path string) (map[string]Annotation, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]Annotation{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open sync file: %w", err)
+	}
+	defer f.Close()
+
+	out := map[string]Annotation{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var a Annotation
+		if err := json.Unmarshal(line, &a); err != nil || a.SessionID == "" {
+			continue
+		}
+		a.Tags = ParseTags(strings.Join(a.Tags, ","))
+		if prev, ok := out[a.SessionID]; ok && prev.UpdatedAt >= a.UpdatedAt {
+			continue
+		}
+		out[a.SessionID] = a
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read sync file: %w", err)
+	}
+	return out, nil
+}
+
+// writeSyncFile writes one annotation per line, sorted by session ID so the
+// file diffs and merges cleanly under version control. The file is replaced
+// atomically.
+func writeSyncFile(path string, all map[string]Annotation) error {
+	ids := make([]string, 0, len(all))
+	for id := range all {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var buf bytes.Buffer
+	for _, id := range ids {
+		b, err := json.Marshal(all[id])
+		if err != nil {
+			return fmt.Errorf("encode annotation %s: %w", id, err)
+		}
+		buf.Write(b)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create sync file dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".annotations-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("create sync file: %w", err)
+	}
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write sync file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("write sync file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("replace sync file: %w", err)
+	}
+	return nil
+}
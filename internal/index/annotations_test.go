@@ -0,0 +1,94 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags("#Bug, refactor  bug,,#")
+	if want := []string{"bug", "refactor"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseTags = %v, want %v", got, want)
+	}
+}
+
+func TestAnnotationsSyncAcrossIndexes(t *testing.T) {
+	syncPath := filepath.Join(t.TempDir(), "dotfiles", "annotations.jsonl")
+
+	// Machine A annotates a session and writes the sync file.
+	a := newTestIndexer(t, t.TempDir())
+	a.SetSyncFile(syncPath)
+	saved, err := a.SetAnnotation(Annotation{SessionID: "s1", Alias: " auth fix ", Tags: []string{"#Auth", "bug"}, Bookmarked: true})
+	if err != nil {
+		t.Fatalf("set annotation: %v", err)
+	}
+	if saved.Alias != "auth fix" || saved.UpdatedAt == 0 {
+		t.Fatalf("annotation not normalized: %+v", saved)
+	}
+	data, err := os.ReadFile(syncPath)
+	if err != nil {
+		t.Fatalf("read sync file: %v", err)
+	}
+	if !strings.Contains(string(data), `"alias":"auth fix"`) {
+		t.Fatalf("sync file missing annotation: %s", data)
+	}
+
+	// Machine B merges it at startup and adds its own.
+	b := newTestIndexer(t, t.TempDir())
+	if _, err := b.SetAnnotation(Annotation{SessionID: "s2", Note: "local only"}); err != nil {
+		t.Fatalf("set local annotation: %v", err)
+	}
+	b.SetSyncFile(syncPath)
+	if _, err := b.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	got, err := b.Annotations()
+	if err != nil {
+		t.Fatalf("annotations: %v", err)
+	}
+	if len(got) != 2 || got["s1"].Alias != "auth fix" || !reflect.DeepEqual(got["s1"].Tags, []string{"auth", "bug"}) {
+		t.Fatalf("unexpected merged annotations: %+v", got)
+	}
+	remote, err := readSyncFile(syncPath)
+	if err != nil {
+		t.Fatalf("read sync file: %v", err)
+	}
+	if remote["s2"].Note != "local only" {
+		t.Fatalf("local annotation not written back to sync file: %+v", remote)
+	}
+
+	// Clearing on B leaves a tombstone that wins on A.
+	if _, err := b.SetAnnotation(Annotation{SessionID: "s1"}); err != nil {
+		t.Fatalf("clear annotation: %v", err)
+	}
+	if _, err := a.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	got, err = a.Annotations()
+	if err != nil {
+		t.Fatalf("annotations: %v", err)
+	}
+	if _, ok := got["s1"]; ok || got["s2"].Note != "local only" {
+		t.Fatalf("expected s1 cleared and s2 synced on A, got %+v", got)
+	}
+}
+
+func TestReadSyncFileSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotations.jsonl")
+	writeJSONL(t, path,
+		`{"session_id":"s1","note":"old","updated_at":1}`,
+		`<<<<<<< HEAD`,
+		`{"session_id":"s1","note":"new","updated_at":2}`,
+	)
+	got, err := readSyncFile(path)
+	if err != nil {
+		t.Fatalf("read sync file: %v", err)
+	}
+	if len(got) != 1 || got["s1"].Note != "new" {
+		t.Fatalf("unexpected annotations: %+v", got)
+	}
+}
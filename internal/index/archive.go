@@ -0,0 +1,52 @@
+package index
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveExts are the compressions a rotated session log may carry, e.g.
+// rollout-….jsonl.gz. Such files are read whole: a change to one means it
+// was replaced, never appended to.
+var archiveExts = []string{".gz", ".zst"}
+
+// logName is name without an archive extension, so compressed logs match
+// the same patterns as plain ones.
+func logName(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// isArchive reports whether path is a compressed session log.
+func isArchive(path string) bool {
+	return logName(path) != path
+}
+
+// decompress reads the session log in r, compressed as path's extension
+// says.
+func decompress(path string, r io.Reader) (io.ReadCloser, error) {
+	switch {
+	case strings.HasSuffix(strings.ToLower(path), ".gz"):
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("open gzip %s: %w", path, err)
+		}
+		return zr, nil
+	case strings.HasSuffix(strings.ToLower(path), ".zst"):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("open zstd %s: %w", path, err)
+		}
+		return zr.IOReadCloser(), nil
+	}
+	return io.NopCloser(r), nil
+}
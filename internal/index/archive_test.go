@@ -0,0 +1,88 @@
+package index
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func writeArchive(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	var buf bytes.Buffer
+	if strings.HasSuffix(path, ".gz") {
+		zw := gzip.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+	} else {
+		zw, _ := zstd.NewWriter(&buf)
+		zw.Write(data)
+		zw.Close()
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestIngestReadsCompressedLogs(t *testing.T) {
+	codexHome, claudeHome := t.TempDir(), t.TempDir()
+	codexID := "019ac5e9-684f-7741-9974-4246554edb05"
+	writeArchive(t, filepath.Join(codexHome, "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+codexID+".jsonl.gz"),
+		`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+codexID+`","cwd":"/tmp/proj"}}`,
+		`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"archived codex prompt"}]}}`,
+	)
+	claudeID := "56565656-5656-5656-5656-565656565656"
+	claudePath := filepath.Join(claudeHome, "projects", "-tmp-proj", claudeID+".jsonl.zst")
+	line := func(uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + claudeID + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	writeArchive(t, claudePath, line("u1", "archived claude prompt"))
+	idx := newTestIndexer(t, codexHome, claudeHome)
+
+	for id, want := range map[string]string{codexID: "archived codex prompt", claudeID: "archived claude prompt"} {
+		msgs, _, err := idx.GetMessagesBefore(id, nil, 10)
+		if err != nil || len(msgs) != 1 || msgs[0].Content != want {
+			t.Fatalf("session %s messages = %+v, %v; want %q", id, msgs, err, want)
+		}
+	}
+	if sessions, err := idx.ListSessions("archived", 10); err != nil || len(sessions) != 2 {
+		t.Fatalf("expected archived sessions searchable, got %d, %v", len(sessions), err)
+	}
+
+	// A replaced archive is read again in full, without duplicates.
+	writeArchive(t, claudePath, line("u1", "archived claude prompt"), line("u2", "second prompt"))
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	msgs, _, err := idx.GetMessagesBefore(claudeID, nil, 10)
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("after replacing the archive: %+v, %v", msgs, err)
+	}
+
+	events, _, err := idx.RawEvents(claudeID, 1<<20)
+	if err != nil || len(events) != 2 || !strings.Contains(events[1].Data, "second prompt") {
+		t.Fatalf("raw events = %+v, %v", events, err)
+	}
+}
+
+func TestLogNameStripsArchiveExtensions(t *testing.T) {
+	for in, want := range map[string]string{
+		"a.jsonl":     "a.jsonl",
+		"a.jsonl.gz":  "a.jsonl",
+		"a.jsonl.ZST": "a.jsonl",
+		"a.gzip":      "a.gzip",
+	} {
+		if got := logName(in); got != want {
+			t.Errorf("logName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// parseCodexBranch returns the git branch a Codex session_meta record
+// names, with the record's session.
+func parseCodexBranch(line []byte, sourcePath string) (sessionID, branch string) {
+	if !bytes.Contains(line, []byte(`"session_meta"`)) {
+		return "", ""
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return "", ""
+	}
+	if asString(firstByPath(obj, []string{"type"})) != "session_meta" {
+		return "", ""
+	}
+	branch = asString(firstByPath(obj, []string{"payload", "git", "branch"}))
+	if branch == "" {
+		return "", ""
+	}
+	return extractSessionID(obj, sourcePath), branch
+}
+
+// prepareBranchStmt records a session's branch. Records are read in order,
+// so the branch seen last wins.
+func prepareBranchStmt(ctx context.Context, tx *sql.Tx) (*sql.Stmt, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO session_branches(session_id, branch, source_path)
+		VALUES(?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+			branch = excluded.branch,
+			source_path = excluded.source_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare branch insert: %w", err)
+	}
+	return stmt, nil
+}
@@ -0,0 +1,44 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSessionBranchesAndFilter(t *testing.T) {
+	codexHome, claudeHome := t.TempDir(), t.TempDir()
+	codexID := "019ac5e9-684f-7741-9974-4246554edb05"
+	writeJSONL(t, filepath.Join(codexHome, "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+codexID+".jsonl"),
+		`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+codexID+`","cwd":"/tmp/proj","git":{"commit_hash":"abc","branch":"feature-x"}}}`,
+		`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"fix the build"}]}}`,
+	)
+	claudeID := "41414141-4141-4141-4141-414141414141"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", claudeID+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+claudeID+`","gitBranch":"main","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"fix the build"}}`,
+		`{"type":"user","uuid":"u2","sessionId":"`+claudeID+`","gitBranch":"feature-y","timestamp":"2026-01-15T10:01:00Z","message":{"role":"user","content":"now on a branch"}}`,
+	)
+	idx := newTestIndexer(t, codexHome, claudeHome)
+
+	for id, want := range map[string]string{codexID: "feature-x", claudeID: "feature-y"} {
+		s, err := idx.GetSession(id)
+		if err != nil || s.Branch != want {
+			t.Errorf("session %s branch = %q, %v; want %q", id, s.Branch, err, want)
+		}
+	}
+
+	for query, want := range map[string]int{
+		"branch:feature-x":       1,
+		"branch:feature-*":       2,
+		"branch:main":            0,
+		"build branch:feature-y": 1,
+		"build branch:nope":      0,
+	} {
+		sessions, err := idx.ListSessions(query, 10)
+		if err != nil {
+			t.Fatalf("list %q: %v", query, err)
+		}
+		if len(sessions) != want {
+			t.Errorf("list %q: %d sessions, want %d", query, len(sessions), want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CodeBlock is a fenced code block from an assistant message.
+type CodeBlock struct {
+	SessionID string
+	MessageID int64
+	TS        int64
+	// Workdir is the session's workdir, the repo the code was written for.
+	Workdir string
+	// Lang is the fence's info string up to the first space, lowercased;
+	// empty when the fence names none.
+	Lang string
+	Code string
+}
+
+// fenceRe matches an opening code fence, capturing the fence and the first
+// word of its info string.
+var fenceRe = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})\\s*([^\\s`]*)")
+
+// ExtractCodeBlocks returns the closed, non-empty fenced code blocks in
+// markdown, in order. Only Lang and Code are set.
+func ExtractCodeBlocks(markdown string) []CodeBlock {
+	var out []CodeBlock
+	var fence, lang string
+	var body []string
+	for _, line := range strings.Split(markdown, "\n") {
+		if fence == "" {
+			if m := fenceRe.FindStringSubmatch(line); m != nil {
+				fence, lang, body = m[1], strings.ToLower(m[2]), nil
+			}
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if code := strings.Join(body, "\n"); strings.TrimSpace(code) != "" {
+				out = append(out, CodeBlock{Lang: lang, Code: code})
+			}
+			fence = ""
+			continue
+		}
+		body = append(body, line)
+	}
+	return out
+}
+
+// CodeBlocks returns the fenced code blocks of assistant messages in the
+// listed sessions, newest first, up to limit. A block repeated verbatim is
+// kept once, where it was last written. The scope applies as for
+// ListSessions.
+func (i *Indexer) CodeBlocks(limit int) ([]CodeBlock, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	listed, args := i.listedMessages("f.session_id", sessionFilters{})
+	rows, err := i.db.Query(`
+		SELECT f.rowid, f.session_id, COALESCE(m.ts, 0), COALESCE(s.workdir, ''), f.content
+		FROM messages_fts f
+		JOIN messages m ON m.id = f.rowid
+		JOIN sessions s ON s.id = f.session_id
+		WHERE m.type = 'message' AND m.role = 'assistant'
+			AND (f.content LIKE '%`+"```"+`%' OR f.content LIKE '%~~~%')`+listed+`
+		ORDER BY m.ts DESC, m.id DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query code blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []CodeBlock
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var b CodeBlock
+		var content string
+		if err := rows.Scan(&b.MessageID, &b.SessionID, &b.TS, &b.Workdir, &content); err != nil {
+			return nil, fmt.Errorf("scan code block message: %w", err)
+		}
+		for _, block := range ExtractCodeBlocks(content) {
+			if seen[block.Code] {
+				continue
+			}
+			seen[block.Code] = true
+			b.Lang, b.Code = block.Lang, block.Code
+			out = append(out, b)
+			if limit > 0 && len(out) == limit {
+				return out, nil
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate code block messages: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,50 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractCodeBlocks(t *testing.T) {
+	md := "Try this:\n\n```Go title=main.go\nfmt.Println(\"hi\")\n```\n\n~~~\nls -la\n~~~~\n\n```sh\n\n```\n\n````md\n```\nnested\n```\n````\n\n```py\nnever closed"
+	got := ExtractCodeBlocks(md)
+	want := []CodeBlock{
+		{Lang: "go", Code: `fmt.Println("hi")`},
+		{Code: "ls -la"},
+		{Lang: "md", Code: "```\nnested\n```"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for n := range want {
+		if got[n] != want[n] {
+			t.Errorf("block %d = %+v, want %+v", n, got[n], want[n])
+		}
+	}
+}
+
+func TestCodeBlocksAcrossSessions(t *testing.T) {
+	claudeHome := t.TempDir()
+	session := func(id, cwd, ts, reply string) {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-src", id+".jsonl"),
+			`{"type":"user","uuid":"`+id+`-u","sessionId":"`+id+`","cwd":"`+cwd+`","timestamp":"`+ts+`","message":{"role":"user","content":"show me"}}`,
+			`{"type":"assistant","uuid":"`+id+`-a","sessionId":"`+id+`","cwd":"`+cwd+`","timestamp":"`+ts+`","message":{"role":"assistant","content":[{"type":"text","text":`+reply+`}]}}`)
+	}
+	session("88888888-0000-0000-0000-000000000000", "/src/api", "2026-01-15T10:00:00Z", `"Run:\n\n`+"```"+`sh\nmake test\n`+"```"+`"`)
+	session("99999999-0000-0000-0000-000000000000", "/src/web", "2026-01-16T10:00:00Z", `"`+"```"+`ts\nexport {}\n`+"```"+`\n\n`+"```"+`sh\nmake test\n`+"```"+`"`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	blocks, err := idx.CodeBlocks(0)
+	if err != nil {
+		t.Fatalf("code blocks: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected the repeated block once, got %+v", blocks)
+	}
+	if blocks[0].Lang != "ts" || blocks[0].Workdir != "/src/web" {
+		t.Errorf("first block = %+v", blocks[0])
+	}
+	if blocks[1].Lang != "sh" || blocks[1].Code != "make test" || blocks[1].Workdir != "/src/web" {
+		t.Errorf("second block = %+v, want the newest copy of make test", blocks[1])
+	}
+}
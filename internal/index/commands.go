@@ -0,0 +1,236 @@
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ShellCommand is a shell command the agent issued during a session, paired
+// with its exit code when the matching tool output recorded one.
+type ShellCommand struct {
+	Command  string
+	Workdir  string
+	TS       sql.NullInt64
+	ExitCode int
+	HasExit  bool
+	// Failed is set when the command failed or never ran, including
+	// rejected, interrupted and timed-out tool uses that record no exit code.
+	Failed bool
+	Output string
+}
+
+var claudeExitCodeRe = regexp.MustCompile(`^Exit code (\d+)`)
+var codexExitCodeRe = regexp.MustCompile(`(?m)^(?:Exit code:|Process exited with code) (\d+)`)
+
+// claudeNotRunMarkers start the results of Claude tool uses that were
+// rejected, interrupted or cut off, which older indexes do not flag as
+// errors.
+var claudeNotRunMarkers = []string{
+	"The user doesn't want to proceed with this tool use",
+	"The user doesn't want to take this action",
+	"[Request interrupted by user",
+	"Command timed out",
+	"<tool_use_error>",
+}
+
+// ExtractShellCommands returns the Bash/exec commands recorded in messages, in
+// the order the agent issued them. Tool calls and their outputs are paired by
+// call id, so parallel calls that finish out of order keep their own output;
+// messages indexed without ids are paired in order instead.
+func ExtractShellCommands(messages []Message) []ShellCommand {
+	var out []ShellCommand
+	var pending []pendingCall
+
+	for _, m := range messages {
+		switch {
+		case isToolCall(m):
+			cmd, workdir, ok := shellCommandFromCall(m)
+			if !ok {
+				pending = append(pending, pendingCall{id: m.CallID, idx: -1})
+				continue
+			}
+			if workdir == "" {
+				workdir = m.Workdir
+			}
+			out = append(out, ShellCommand{Command: cmd, Workdir: workdir, TS: m.TS})
+			pending = append(pending, pendingCall{id: m.CallID, idx: len(out) - 1})
+		case isToolResult(m):
+			at := answeredCall(pending, m.CallID)
+			if at < 0 {
+				continue
+			}
+			idx := pending[at].idx
+			pending = append(pending[:at], pending[at+1:]...)
+			if idx < 0 {
+				continue
+			}
+			out[idx].Output = m.Content
+			out[idx].ExitCode, out[idx].HasExit, out[idx].Failed = exitCodeFromResult(m)
+		}
+	}
+	return out
+}
+
+// pendingCall is a tool call still waiting for its result. idx is the index
+// of its ShellCommand, or -1 for calls that are not shell commands.
+type pendingCall struct {
+	id  string
+	idx int
+}
+
+// answeredCall returns the position in pending of the call answered by a
+// result with callID, or -1. A result without an id answers the oldest call;
+// one whose id matches no call answers the oldest call that has no id.
+func answeredCall(pending []pendingCall, callID string) int {
+	if callID == "" {
+		if len(pending) == 0 {
+			return -1
+		}
+		return 0
+	}
+	fallback := -1
+	for i, c := range pending {
+		if c.id == callID {
+			return i
+		}
+		if c.id == "" && fallback < 0 {
+			fallback = i
+		}
+	}
+	return fallback
+}
+
+// CountToolCalls returns how many tool invocations msgs contain.
+func CountToolCalls(msgs []Message) int {
+	n := 0
+	for _, m := range msgs {
+		if isToolCall(m) {
+			n++
+		}
+	}
+	return n
+}
+
+func isToolCall(m Message) bool {
+	switch m.Type {
+	case "tool_use", "function_call", "custom_tool_call":
+		return true
+	}
+	return false
+}
+
+func isToolResult(m Message) bool {
+	switch m.Type {
+	case "tool_result", "tool_error", "function_call_output", "custom_tool_call_output":
+		return true
+	}
+	return false
+}
+
+func shellCommandFromCall(m Message) (string, string, bool) {
+	content := strings.TrimSpace(m.Content)
+	if m.Type == "tool_use" {
+		name, input, ok := strings.Cut(content, ": ")
+		if !ok || name != "Bash" {
+			return "", "", false
+		}
+		var args map[string]any
+		if err := json.Unmarshal([]byte(input), &args); err != nil {
+			return "", "", false
+		}
+		cmd := asString(args["command"])
+		return cmd, "", cmd != ""
+	}
+
+	var args map[string]any
+	if err := json.Unmarshal([]byte(content), &args); err != nil {
+		return "", "", false
+	}
+	workdir := asString(args["workdir"])
+	switch v := args["command"].(type) {
+	case string:
+		return strings.TrimSpace(v), workdir, strings.TrimSpace(v) != ""
+	case []any:
+		argv := make([]string, 0, len(v))
+		for _, a := range v {
+			argv = append(argv, asString(a))
+		}
+		cmd := unwrapShellArgv(argv)
+		return cmd, workdir, cmd != ""
+	}
+	if cmd := asString(args["cmd"]); cmd != "" {
+		return cmd, workdir, true
+	}
+	return "", "", false
+}
+
+// unwrapShellArgv turns ["bash", "-lc", "script"] into "script" and quotes
+// any other argv into a single shell line.
+func unwrapShellArgv(argv []string) string {
+	if len(argv) == 3 {
+		switch strings.TrimPrefix(argv[0], "/bin/") {
+		case "bash", "sh", "zsh":
+			if argv[1] == "-lc" || argv[1] == "-c" {
+				return strings.TrimSpace(argv[2])
+			}
+		}
+	}
+	quoted := make([]string, 0, len(argv))
+	for _, a := range argv {
+		quoted = append(quoted, ShellQuote(a))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// ShellQuote quotes s for a POSIX shell when it contains anything beyond
+// plain word characters.
+func ShellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// exitCodeFromResult reads a command's exit code from its tool output, and
+// whether it failed or never ran even when no code was recorded.
+func exitCodeFromResult(m Message) (code int, hasExit, failed bool) {
+	content := strings.TrimSpace(m.Content)
+	if m.Source == "claude" {
+		if match := claudeExitCodeRe.FindStringSubmatch(content); len(match) == 2 {
+			code, _ := strconv.Atoi(match[1])
+			return code, true, code != 0
+		}
+		if m.Type == "tool_error" {
+			return 0, false, true
+		}
+		for _, marker := range claudeNotRunMarkers {
+			if strings.HasPrefix(content, marker) {
+				return 0, false, true
+			}
+		}
+		// Claude only prefixes failing commands with an exit code.
+		return 0, true, false
+	}
+
+	var out struct {
+		Metadata struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(content), &out); err == nil && out.Metadata.ExitCode != nil {
+		return *out.Metadata.ExitCode, true, *out.Metadata.ExitCode != 0
+	}
+	if match := codexExitCodeRe.FindStringSubmatch(content); len(match) == 2 {
+		code, _ := strconv.Atoi(match[1])
+		return code, true, code != 0
+	}
+	return 0, false, false
+}
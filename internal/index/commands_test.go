@@ -0,0 +1,90 @@
+package index
+
+import "testing"
+
+func TestExtractShellCommands_Claude(t *testing.T) {
+	msgs := []Message{
+		{Role: "tool", Type: "tool_use", Source: "claude", Content: `Read: {"file_path":"/tmp/a.go"}`},
+		{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"go test ./...","description":"Run tests"}`},
+		{Role: "tool", Type: "tool_result", Source: "claude", Content: "package a"},
+		{Role: "tool", Type: "tool_result", Source: "claude", Content: "Exit code 1\nFAIL"},
+		{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"ls"}`},
+		{Role: "tool", Type: "tool_result", Source: "claude", Content: "a.go"},
+	}
+	got := ExtractShellCommands(msgs)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(got))
+	}
+	if got[0].Command != "go test ./..." || !got[0].HasExit || got[0].ExitCode != 1 {
+		t.Fatalf("unexpected first command: %#v", got[0])
+	}
+	if got[1].Command != "ls" || !got[1].HasExit || got[1].ExitCode != 0 {
+		t.Fatalf("unexpected second command: %#v", got[1])
+	}
+}
+
+func TestExtractShellCommands_ClaudeFailuresWithoutExitCode(t *testing.T) {
+	call := Message{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"rm -rf build"}`}
+	msgs := []Message{
+		call, {Role: "tool", Type: "tool_result", Source: "claude", Content: "The user doesn't want to proceed with this tool use. The tool use was rejected."},
+		call, {Role: "tool", Type: "tool_result", Source: "claude", Content: "[Request interrupted by user for tool use]"},
+		call, {Role: "tool", Type: "tool_result", Source: "claude", Content: "Command timed out after 2m 0.0s"},
+		call, {Role: "tool", Type: "tool_error", Source: "claude", Content: "permission denied"},
+		call, {Role: "tool", Type: "tool_result", Source: "claude", Content: ""},
+	}
+	got := ExtractShellCommands(msgs)
+	if len(got) != 5 {
+		t.Fatalf("expected 5 commands, got %d", len(got))
+	}
+	for n, c := range got[:4] {
+		if c.HasExit || !c.Failed {
+			t.Errorf("command %d should be failed without an exit code: %#v", n, c)
+		}
+	}
+	if !got[4].HasExit || got[4].ExitCode != 0 || got[4].Failed {
+		t.Errorf("a plain result should count as exit 0: %#v", got[4])
+	}
+}
+
+func TestExtractShellCommands_Codex(t *testing.T) {
+	msgs := []Message{
+		{Role: "event", Type: "function_call", Source: "codex", Content: `{"command":["bash","-lc","make build"],"workdir":"/repo"}`},
+		{Role: "event", Type: "function_call_output", Source: "codex", Content: `{"output":"ok","metadata":{"exit_code":0}}`},
+		{Role: "event", Type: "function_call", Source: "codex", Content: `{"command":["rg","-n","foo bar"]}`},
+	}
+	got := ExtractShellCommands(msgs)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(got))
+	}
+	if got[0].Command != "make build" || got[0].Workdir != "/repo" || !got[0].HasExit || got[0].ExitCode != 0 {
+		t.Fatalf("unexpected first command: %#v", got[0])
+	}
+	if got[1].Command != "rg -n 'foo bar'" || got[1].HasExit {
+		t.Fatalf("unexpected second command: %#v", got[1])
+	}
+}
+
+func TestExtractShellCommands_PairsResultsByCallID(t *testing.T) {
+	msgs := []Message{
+		{Role: "tool", Type: "tool_use", Source: "claude", CallID: "t1", Content: `Bash: {"command":"sleep 5 && make"}`},
+		{Role: "tool", Type: "tool_use", Source: "claude", CallID: "t2", Content: `Bash: {"command":"ls"}`},
+		{Role: "tool", Type: "tool_result", Source: "claude", CallID: "t2", Content: "a.go"},
+		{Role: "tool", Type: "tool_result", Source: "claude", CallID: "t1", Content: "Exit code 2\nmake: *** error"},
+		// Rows indexed before call ids were recorded still pair in order.
+		{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"pwd"}`},
+		{Role: "tool", Type: "tool_result", Source: "claude", Content: "/repo"},
+	}
+	got := ExtractShellCommands(msgs)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 commands, got %d", len(got))
+	}
+	if got[0].Command != "sleep 5 && make" || got[0].ExitCode != 2 || !got[0].Failed {
+		t.Fatalf("first command got the wrong result: %#v", got[0])
+	}
+	if got[1].Command != "ls" || got[1].Output != "a.go" || got[1].Failed {
+		t.Fatalf("second command got the wrong result: %#v", got[1])
+	}
+	if got[2].Output != "/repo" {
+		t.Fatalf("a command without a call id should pair in order: %#v", got[2])
+	}
+}
@@ -0,0 +1,59 @@
+package index
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Commit is a git commit the agent made during a session, as reported by
+// `git commit` in a tool result.
+type Commit struct {
+	SHA     string
+	Branch  string
+	Subject string
+	TS      sql.NullInt64
+}
+
+// gitCommitRe matches the summary line git commit prints, e.g.
+// "[main 1a2b3c4] Fix the build" or "[feature (root-commit) 1a2b3c4] Init".
+var gitCommitRe = regexp.MustCompile(`(?m)^\[(.+?)(?: \(root-commit\))? ([0-9a-f]{7,40})\] (.*)$`)
+
+// ExtractCommits returns the commits reported in the tool results of
+// messages, in order, once per SHA. A later amend shows up as its own
+// commit.
+func ExtractCommits(messages []Message) []Commit {
+	var out []Commit
+	seen := map[string]bool{}
+	for _, m := range messages {
+		if !isToolResult(m) || !strings.Contains(m.Content, "] ") {
+			continue
+		}
+		for _, match := range gitCommitRe.FindAllStringSubmatch(toolOutputText(m.Content), -1) {
+			sha := match[2]
+			if seen[sha] {
+				continue
+			}
+			seen[sha] = true
+			out = append(out, Commit{SHA: sha, Branch: match[1], Subject: strings.TrimSpace(match[3]), TS: m.TS})
+		}
+	}
+	return out
+}
+
+// toolOutputText unwraps Codex's JSON-encoded exec results, whose output
+// would otherwise sit on one line with escaped newlines.
+func toolOutputText(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "{") {
+		return content
+	}
+	var out struct {
+		Output string `json:"output"`
+	}
+	if err := json.Unmarshal([]byte(trimmed), &out); err != nil || out.Output == "" {
+		return content
+	}
+	return out.Output
+}
@@ -0,0 +1,29 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractCommits(t *testing.T) {
+	msgs := []Message{
+		{Type: "tool_use", Content: `Bash: {"command":"git commit -m 'Fix the build'"}`},
+		{Type: "tool_result", Source: "claude", Content: "[main 1a2b3c4] Fix the build\n 2 files changed, 10 insertions(+)"},
+		{Type: "function_call_output", Source: "codex", Content: `{"output":"[feature-x (root-commit) deadbeef0] Initial commit\n 1 file changed\n","metadata":{"exit_code":0}}`},
+		{Type: "tool_result", Source: "claude", Content: "[detached HEAD 9f8e7d6] Try something\n"},
+		{Type: "tool_result", Source: "claude", Content: "[main 1a2b3c4] Fix the build"},
+		{Type: "message", Role: "assistant", Content: "[main abcdef1] is what git printed"},
+	}
+	var got []string
+	for _, c := range ExtractCommits(msgs) {
+		got = append(got, c.Branch+" "+c.SHA+" "+c.Subject)
+	}
+	want := []string{
+		"main 1a2b3c4 Fix the build",
+		"feature-x deadbeef0 Initial commit",
+		"detached HEAD 9f8e7d6 Try something",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("commits = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,78 @@
+package index
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressMinBytes is the smallest content worth compressing; short
+// messages barely shrink and cost a decode on every read.
+const compressMinBytes = 512
+
+// zstdMagic starts every zstd frame. Compressed content is stored as a
+// BLOB beginning with it; plain content stays TEXT, so both kinds can live
+// in one table and compression can be switched on or off at any time.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// SetCompression turns zstd compression of newly ingested message content
+// on or off. Existing rows are read either way; the search index always
+// holds plain text.
+func (i *Indexer) SetCompression(enabled bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.compress = enabled
+}
+
+// encodeContent returns the value stored in messages.content: a zstd BLOB
+// when compression is on and pays off, the plain string otherwise. Encoding
+// is deterministic, so stored values can be compared for equality.
+func encodeContent(s string, compress bool) any {
+	if !compress || len(s) < compressMinBytes {
+		return s
+	}
+	out := zstdEncoder.EncodeAll([]byte(s), nil)
+	if len(out) >= len(s) {
+		return s
+	}
+	return out
+}
+
+// contentScanner reads messages.content into a string, decompressing
+// BLOBs written by encodeContent.
+type contentScanner struct {
+	dst *string
+}
+
+// scanContent wraps dst for rows.Scan.
+func scanContent(dst *string) contentScanner {
+	return contentScanner{dst: dst}
+}
+
+func (c contentScanner) Scan(v any) error {
+	switch v := v.(type) {
+	case nil:
+		*c.dst = ""
+	case string:
+		*c.dst = v
+	case []byte:
+		if !bytes.HasPrefix(v, zstdMagic) {
+			*c.dst = string(v)
+			return nil
+		}
+		out, err := zstdDecoder.DecodeAll(v, nil)
+		if err != nil {
+			return fmt.Errorf("decompress message content: %w", err)
+		}
+		*c.dst = string(out)
+	default:
+		return fmt.Errorf("unexpected message content type %T", v)
+	}
+	return nil
+}
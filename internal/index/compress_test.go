@@ -0,0 +1,77 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompressedContentReadsBackAndStaysSearchable(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "88888888-8888-8888-8888-888888888888"
+	long := "the flux capacitor needs recalibration. " + strings.Repeat("lorem ipsum dolor sit amet ", 40)
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"short question"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"`+long+`"}]}}`,
+	)
+
+	idx, err := New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	t.Cleanup(func() { _ = idx.Close() })
+	idx.SetCompression(true)
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+
+	var blobs, texts int
+	if err := idx.db.QueryRow(`SELECT
+		SUM(typeof(content) = 'blob'), SUM(typeof(content) = 'text')
+		FROM messages WHERE session_id = ?`, id).Scan(&blobs, &texts); err != nil {
+		t.Fatalf("count stored forms: %v", err)
+	}
+	if blobs != 1 || texts != 1 {
+		t.Fatalf("stored %d blobs and %d texts, want the long reply compressed and the short prompt plain", blobs, texts)
+	}
+
+	msgs, err := idx.GetMessages(id)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].Content != "short question" || msgs[1].Content != strings.TrimSpace(long) {
+		t.Fatalf("unexpected messages after decompression: %+v", msgs)
+	}
+
+	found, err := idx.ListSessions("capacitor", 10)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != id {
+		t.Fatalf("expected search to match compressed content, got %+v", found)
+	}
+}
+
+func TestEncodeContentSkipsShortAndScansBothForms(t *testing.T) {
+	if v, ok := encodeContent("short", true).(string); !ok || v != "short" {
+		t.Fatalf("short content should stay plain, got %#v", v)
+	}
+	long := strings.Repeat("abc ", 200)
+	if _, ok := encodeContent(long, false).(string); !ok {
+		t.Fatal("content should stay plain with compression off")
+	}
+	enc, ok := encodeContent(long, true).([]byte)
+	if !ok {
+		t.Fatal("long content should compress")
+	}
+	for _, v := range []any{enc, long, []byte(long), nil} {
+		var got string
+		if err := scanContent(&got).Scan(v); err != nil {
+			t.Fatalf("scan %T: %v", v, err)
+		}
+		if v != nil && got != long {
+			t.Fatalf("scan %T returned %q", v, got)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !purego
+
+package index
+
+import _ "github.com/mattn/go-sqlite3"
+
+// sqliteDriver is the database/sql driver the index opens. The default
+// build uses the cgo driver; build with -tags purego for a cgo-free binary.
+const sqliteDriver = "sqlite3"
+
+// sqliteDSN returns the data source name for path. go-sqlite3 already
+// waits busyTimeoutMillis on a locked database by default.
+func sqliteDSN(path string) string {
+	return path
+}
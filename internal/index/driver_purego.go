@@ -0,0 +1,19 @@
+//go:build purego
+
+package index
+
+import (
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDriver is the pure-Go driver, selected with -tags purego so static
+// binaries cross-compile with CGO_ENABLED=0. It includes FTS5.
+const sqliteDriver = "sqlite"
+
+// sqliteDSN returns the data source name for path. Pragmas in the DSN
+// apply to every pooled connection, unlike a one-off PRAGMA statement.
+func sqliteDSN(path string) string {
+	return fmt.Sprintf("%s?_pragma=busy_timeout(%d)", path, busyTimeoutMillis)
+}
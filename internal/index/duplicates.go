@@ -0,0 +1,210 @@
+package index
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// hiddenSessionIDsQuery selects sessions that are reached through another
+// session rather than listed on their own: subagent transcripts and copies of
+// a session recorded under a different id.
+const hiddenSessionIDsQuery = `SELECT session_id FROM session_links WHERE kind IN ('subagent', 'duplicate')`
+
+// duplicateSkewSeconds is how far apart two copies of a message may be
+// timestamped. Overlapping sources (a Codex rollout and history.jsonl, say)
+// record the same event a moment apart.
+const duplicateSkewSeconds = 2
+
+// messageHash keys a message for cross-file deduplication. Only the
+// normalized text is hashed: overlapping sources often disagree on the role
+// or event type they record for the same prompt.
+func messageHash(content string) string {
+	sum := sha256.Sum256([]byte(normalizeContent(content)))
+	return hex.EncodeToString(sum[:])
+}
+
+// sessionSourceTracker records which source files contribute to each
+// session and suppresses messages already stored from another file of the
+// same session: a synced backup of ~/.claude next to the original, or
+// overlapping Codex rollouts and history.
+type sessionSourceTracker struct {
+	path   string
+	insert *sql.Stmt
+	shared *sql.Stmt
+	exists *sql.Stmt
+	seen   map[string]bool // session id -> also recorded in another file
+}
+
+func prepareSessionSourceTracker(ctx context.Context, tx *sql.Tx, path string) (*sessionSourceTracker, error) {
+	t := &sessionSourceTracker{path: path, seen: make(map[string]bool)}
+	var err error
+	if t.insert, err = tx.PrepareContext(ctx, `INSERT OR IGNORE INTO session_sources(session_id, source_path) VALUES(?, ?)`); err != nil {
+		return nil, fmt.Errorf("prepare session source insert: %w", err)
+	}
+	if t.shared, err = tx.PrepareContext(ctx, `SELECT COUNT(*) FROM session_sources WHERE session_id = ? AND source_path != ?`); err != nil {
+		t.close()
+		return nil, fmt.Errorf("prepare session source lookup: %w", err)
+	}
+	if t.exists, err = tx.PrepareContext(ctx, `
+		SELECT COUNT(*) FROM messages
+		WHERE session_id = ? AND source_path != ?
+			AND (ts IS ? OR ABS(ts - ?) <= ?)
+			AND (content_hash = ? OR (content_hash IS NULL AND content IN (?, ?)))
+	`); err != nil {
+		t.close()
+		return nil, fmt.Errorf("prepare duplicate message lookup: %w", err)
+	}
+	return t, nil
+}
+
+func (t *sessionSourceTracker) close() {
+	for _, s := range []*sql.Stmt{t.insert, t.shared, t.exists} {
+		if s != nil {
+			_ = s.Close()
+		}
+	}
+}
+
+// duplicate records sessionID as present in this file and reports whether
+// the same message, hashed as hash, was already stored from another file.
+// Repeats within one file are kept: a prompt sent twice is two turns.
+func (t *sessionSourceTracker) duplicate(ctx context.Context, sessionID, hash string, evt parsedEvent) (bool, error) {
+	shared, ok := t.seen[sessionID]
+	if !ok {
+		if _, err := t.insert.ExecContext(ctx, sessionID, t.path); err != nil {
+			return false, fmt.Errorf("record session source: %w", err)
+		}
+		var n int
+		if err := t.shared.QueryRowContext(ctx, sessionID, t.path).Scan(&n); err != nil {
+			return false, fmt.Errorf("look up session sources: %w", err)
+		}
+		shared = n > 0
+		t.seen[sessionID] = shared
+	}
+	if !shared {
+		return false, nil
+	}
+	var n int
+	ts := nullableTS(evt.TS)
+	// Unhashed rows predate hashing; match both stored forms, since the copy
+	// may also predate a compression change.
+	if err := t.exists.QueryRowContext(ctx, sessionID, t.path, ts, ts, duplicateSkewSeconds,
+		hash, evt.Content, encodeContent(evt.Content, true)).Scan(&n); err != nil {
+		return false, fmt.Errorf("look up duplicate message: %w", err)
+	}
+	return n > 0, nil
+}
+
+// siblingSourcePaths returns the other files that recorded any session also
+// recorded in path.
+func siblingSourcePaths(ctx context.Context, tx *sql.Tx, path string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT source_path FROM session_sources
+		WHERE source_path != ?
+			AND session_id IN (SELECT session_id FROM session_sources WHERE source_path = ?)
+	`, path, path)
+	if err != nil {
+		return nil, fmt.Errorf("query sibling sources of %s: %w", path, err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("scan sibling source: %w", err)
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// refreshSessionHashes stores a hash of each listable session's
+// conversational turns so copies recorded under different ids can be
+// collapsed. The workdir and the first turn's time are hashed too: copies
+// share them, while separate sessions that only said "hi" do not.
+func refreshSessionHashes(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_hashes`); err != nil {
+		return fmt.Errorf("clear session hashes: %w", err)
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT m.session_id, m.role, m.content, m.ts, COALESCE(s.workdir, '')
+		FROM messages m JOIN sessions s ON s.id = m.session_id
+		WHERE m.type = 'message' AND m.role IN ('user', 'assistant')
+			AND COALESCE(s.message_count, 0) > 0
+		ORDER BY m.session_id, CASE WHEN m.ts IS NULL THEN 1 ELSE 0 END, m.ts, m.id
+	`)
+	if err != nil {
+		return fmt.Errorf("query session turns: %w", err)
+	}
+	hashes := make(map[string]string)
+	var cur string
+	h := sha256.New()
+	flush := func() {
+		if cur != "" {
+			hashes[cur] = hex.EncodeToString(h.Sum(nil))
+		}
+		h.Reset()
+	}
+	for rows.Next() {
+		var id, role, content, workdir string
+		var ts sql.NullInt64
+		if err := rows.Scan(&id, &role, scanContent(&content), &ts, &workdir); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan session turn: %w", err)
+		}
+		if id != cur {
+			flush()
+			cur = id
+			fmt.Fprintf(h, "%s\x00%d\x00", workdir, ts.Int64)
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", role, normalizeContent(content))
+	}
+	flush()
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate session turns: %w", err)
+	}
+
+	for id, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO session_hashes(session_id, hash) VALUES(?, ?)`, id, hash); err != nil {
+			return fmt.Errorf("store session hash: %w", err)
+		}
+	}
+	return nil
+}
+
+// SessionSources returns the source files a session was read from,
+// including those of copies collapsed into it.
+func (i *Indexer) SessionSources(sessionID string) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.sessionSources(sessionID)
+}
+
+func (i *Indexer) sessionSources(sessionID string) ([]string, error) {
+	rows, err := i.db.Query(`
+		SELECT DISTINCT source_path FROM session_sources
+		WHERE session_id = ?
+			OR session_id IN (SELECT session_id FROM session_links WHERE parent_id = ? AND kind = 'duplicate')
+		ORDER BY source_path
+	`, sessionID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query session sources: %w", err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("scan session source: %w", err)
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session sources: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,106 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDuplicateSessionsCollapse(t *testing.T) {
+	original, backup := t.TempDir(), t.TempDir()
+	id := "66666666-6666-6666-6666-666666666666"
+	renamed := "77777777-7777-7777-7777-777777777777"
+	lines := func(sid string) []string {
+		return []string{
+			`{"type":"user","uuid":"` + sid + `-1","sessionId":"` + sid + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"rename the package"}}`,
+			`{"type":"assistant","uuid":"` + sid + `-2","sessionId":"` + sid + `","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Renamed."}]}}`,
+		}
+	}
+	origPath := filepath.Join(original, "projects", "-tmp-proj", id+".jsonl")
+	writeJSONL(t, origPath, lines(id)...)
+	writeJSONL(t, filepath.Join(backup, "projects", "-tmp-proj", id+".jsonl"), lines(id)...)
+	writeJSONL(t, filepath.Join(backup, "projects", "-tmp-proj", renamed+".jsonl"), lines(renamed)...)
+
+	idx := newTestIndexer(t, t.TempDir(), original, backup)
+
+	sessions, err := idx.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != id {
+		t.Fatalf("expected one collapsed session, got %+v", sessions)
+	}
+	msgs, _ := idx.GetMessages(id)
+	if len(msgs) != 2 {
+		t.Fatalf("expected copied messages stored once, got %d", len(msgs))
+	}
+	s, err := idx.GetSession(id)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if len(s.SourcePaths) != 3 {
+		t.Fatalf("expected all three copies listed, got %v", s.SourcePaths)
+	}
+
+	// Removing the original falls back to the backup copy in full.
+	if err := os.Remove(origPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if msgs, _ := idx.GetMessages(id); len(msgs) != 2 {
+		t.Fatalf("expected backup messages after original removed, got %d", len(msgs))
+	}
+}
+
+func TestSessionsWithTheSameTrivialTurnsStayApart(t *testing.T) {
+	home := t.TempDir()
+	hi := func(sid, ts string) string {
+		return `{"type":"user","uuid":"` + sid + `-1","sessionId":"` + sid + `","timestamp":"` + ts + `","message":{"role":"user","content":"hi"}}`
+	}
+	a, b := "12121212-1212-1212-1212-121212121212", "34343434-3434-3434-3434-343434343434"
+	writeJSONL(t, filepath.Join(home, "projects", "-tmp-proj", a+".jsonl"), hi(a, "2026-01-15T10:00:00Z"))
+	writeJSONL(t, filepath.Join(home, "projects", "-tmp-proj", b+".jsonl"), hi(b, "2026-01-16T09:00:00Z"))
+
+	idx := newTestIndexer(t, t.TempDir(), home)
+	sessions, err := idx.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected both sessions listed, got %+v", sessions)
+	}
+}
+
+func TestOverlappingMessagesStoredOnce(t *testing.T) {
+	original, backup := t.TempDir(), t.TempDir()
+	id := "88888888-8888-8888-8888-888888888888"
+	user := func(uuid, ts, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","timestamp":"` + ts + `","message":{"role":"user","content":"` + text + `"}}`
+	}
+	writeJSONL(t, filepath.Join(original, "projects", "-tmp-proj", id+".jsonl"),
+		user("u1", "2026-01-15T10:00:00Z", "continue"),
+		user("u2", "2026-01-15T10:05:00Z", "continue"),
+	)
+	// The overlapping copy re-wraps the first prompt and stamps it a second
+	// later; its extra turn is new.
+	writeJSONL(t, filepath.Join(backup, "projects", "-tmp-proj", id+".jsonl"),
+		user("b1", "2026-01-15T10:00:01Z", "  Continue\\n"),
+		user("b2", "2026-01-15T10:10:00Z", "ship it"),
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), original, backup)
+	msgs, err := idx.GetMessages(id)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	var got []string
+	for _, m := range msgs {
+		got = append(got, m.Content)
+	}
+	if len(got) != 3 || got[0] != "continue" || got[1] != "continue" || got[2] != "ship it" {
+		t.Fatalf("expected the repeated prompt kept and the overlapping copy dropped, got %q", got)
+	}
+}
@@ -0,0 +1,284 @@
+package index
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Embedder turns texts into vectors for semantic search. Name identifies
+// the model; vectors from different models are never compared.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	Name() string
+}
+
+const (
+	// embedBatch is how many messages go to the embedder per call.
+	embedBatch = 32
+	// embedInputChars bounds the text embedded per message; embedding
+	// models only read the first few thousand tokens anyway.
+	embedInputChars = 8000
+)
+
+// pendingEmbedding is a message waiting for its vector.
+type pendingEmbedding struct {
+	id   int64
+	text string
+}
+
+// EmbedMessages embeds the prompts and replies that have no vector from e
+// yet, newest first, up to limit (0 for all), and returns how many it
+// stored. Vectors of other models and of messages no longer indexed are
+// dropped first. progress, when set, is called after each batch.
+func (i *Indexer) EmbedMessages(ctx context.Context, e Embedder, limit int, progress func(done, total int)) (int, error) {
+	pending, err := i.pendingEmbeddings(ctx, e.Name(), limit)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for start := 0; start < len(pending); start += embedBatch {
+		batch := pending[start:min(start+embedBatch, len(pending))]
+		texts := make([]string, len(batch))
+		for n, p := range batch {
+			texts[n] = p.text
+		}
+		// The embedder is called without the lock, so the index stays
+		// usable during slow calls.
+		vectors, err := e.Embed(ctx, texts)
+		if err != nil {
+			return done, err
+		}
+		if err := i.storeEmbeddings(ctx, e.Name(), batch, vectors); err != nil {
+			return done, err
+		}
+		done += len(batch)
+		if progress != nil {
+			progress(done, len(pending))
+		}
+	}
+	return done, nil
+}
+
+// pendingEmbeddings clears stale vectors and lists the messages still to
+// embed with model.
+func (i *Indexer) pendingEmbeddings(ctx context.Context, model string, limit int) ([]pendingEmbedding, error) {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, err := i.db.ExecContext(ctx, `DELETE FROM message_embeddings WHERE model != ? OR message_id NOT IN (SELECT id FROM messages)`, model); err != nil {
+		return nil, fmt.Errorf("drop stale embeddings: %w", err)
+	}
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT id, content FROM messages
+		WHERE type = 'message' AND role IN ('user', 'assistant')
+			AND id NOT IN (SELECT message_id FROM message_embeddings)
+		ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query messages to embed: %w", err)
+	}
+	defer rows.Close()
+	var out []pendingEmbedding
+	for rows.Next() {
+		var p pendingEmbedding
+		if err := rows.Scan(&p.id, scanContent(&p.text)); err != nil {
+			return nil, fmt.Errorf("scan message to embed: %w", err)
+		}
+		if isNonConversationalPreviewContent(p.text) {
+			continue
+		}
+		p.text = strings.TrimSpace(p.text)
+		if len(p.text) > embedInputChars {
+			p.text = strings.ToValidUTF8(p.text[:embedInputChars], "")
+		}
+		out = append(out, p)
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate messages to embed: %w", err)
+	}
+	return out, nil
+}
+
+func (i *Indexer) storeEmbeddings(ctx context.Context, model string, batch []pendingEmbedding, vectors [][]float32) error {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin storing embeddings: %w", err)
+	}
+	defer tx.Rollback()
+	for n, p := range batch {
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO message_embeddings(message_id, model, vector) VALUES(?, ?, ?)`, p.id, model, encodeVector(vectors[n])); err != nil {
+			return fmt.Errorf("store embedding of message %d: %w", p.id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit embeddings: %w", err)
+	}
+	return nil
+}
+
+// SemanticSearch embeds query with e and returns up to limit listed
+// sessions, most similar first, ranked by their closest message. Each
+// session's Snippet is the start of that message. Only messages embedded
+// with the same model are compared; branch: filters and the scope apply
+// as for ListSessions.
+func (i *Indexer) SemanticSearch(ctx context.Context, e Embedder, query string, limit int) ([]Session, error) {
+	text, filters := splitFilters(strings.TrimSpace(query))
+	if text == "" {
+		return nil, nil
+	}
+	vectors, err := e.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	want := normalizeVector(vectors[0])
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if limit <= 0 {
+		limit = 200
+	}
+	listed, args := i.listedMessages("m.session_id", filters)
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT e.message_id, m.session_id, e.vector
+		FROM message_embeddings e
+		JOIN messages m ON m.id = e.message_id
+		WHERE e.model = ?`+listed, append([]any{e.Name()}, args...)...)
+	if err != nil {
+		return nil, fmt.Errorf("query embeddings: %w", err)
+	}
+	type hit struct {
+		messageID int64
+		score     float32
+	}
+	best := make(map[string]hit)
+	for rows.Next() {
+		var id int64
+		var sessionID string
+		var blob []byte
+		if err := rows.Scan(&id, &sessionID, &blob); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan embedding: %w", err)
+		}
+		score := dot(want, decodeVector(blob))
+		if h, ok := best[sessionID]; !ok || score > h.score {
+			best[sessionID] = hit{messageID: id, score: score}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate embeddings: %w", err)
+	}
+
+	ids := make([]string, 0, len(best))
+	for id := range best {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		if best[ids[a]].score != best[ids[b]].score {
+			return best[ids[a]].score > best[ids[b]].score
+		}
+		return ids[a] < ids[b]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	out := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		s, err := i.getSession(id)
+		if err != nil {
+			return nil, fmt.Errorf("load session %s: %w", id, err)
+		}
+		var content string
+		if err := i.db.QueryRowContext(ctx, `SELECT content FROM messages WHERE id = ?`, best[id].messageID).Scan(scanContent(&content)); err != nil {
+			return nil, fmt.Errorf("load message %d: %w", best[id].messageID, err)
+		}
+		s.Snippet = strings.Join(strings.Fields(likeSnippet(content, nil, snippetTokens)), " ")
+		out = append(out, s)
+	}
+	if err := i.attachUsage(out); err != nil {
+		return nil, err
+	}
+	if err := i.attachModTimes(out); err != nil {
+		return nil, err
+	}
+	return out, i.attachSummaries(out)
+}
+
+// EmbeddingCount returns how many messages have a vector from model.
+func (i *Indexer) EmbeddingCount(model string) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var n int
+	if err := i.db.QueryRow(`SELECT COUNT(*) FROM message_embeddings WHERE model = ?`, model).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count embeddings: %w", err)
+	}
+	return n, nil
+}
+
+// encodeVector stores a vector scaled to unit length as little-endian
+// float32s, so similarity is a dot product.
+func encodeVector(v []float32) []byte {
+	v = normalizeVector(v)
+	out := make([]byte, 4*len(v))
+	for n, f := range v {
+		binary.LittleEndian.PutUint32(out[4*n:], math.Float32bits(f))
+	}
+	return out
+}
+
+func decodeVector(b []byte) []float32 {
+	out := make([]float32, len(b)/4)
+	for n := range out {
+		out[n] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*n:]))
+	}
+	return out
+}
+
+func normalizeVector(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for n, f := range v {
+		out[n] = f / norm
+	}
+	return out
+}
+
+// dot is the cosine similarity of two unit vectors; vectors of different
+// lengths, from a mismatched model, score zero.
+func dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var s float32
+	for n := range a {
+		s += a[n] * b[n]
+	}
+	return s
+}
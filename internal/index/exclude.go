@@ -0,0 +1,83 @@
+package index
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SetExclude keeps source files matching any of patterns out of the index.
+// Patterns match whole paths: * and ? stay within one directory, ** spans
+// any number of them, and a pattern not starting with / or ** matches at
+// any depth (scratch/** is **/scratch/**). Files excluded after they were
+// indexed are dropped on the next pass.
+func (i *Indexer) SetExclude(patterns []string) error {
+	for _, p := range patterns {
+		for _, seg := range strings.Split(filepath.ToSlash(p), "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("exclude pattern %q: %w", p, err)
+			}
+		}
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.exclude = patterns
+	return nil
+}
+
+// discover lists the source files of every home an index pass scans,
+// without excluded ones.
+func (i *Indexer) discover() ([]sourceFile, error) {
+	sources, err := discoverAllSources(i.homes())
+	if err != nil || len(i.exclude) == 0 {
+		return sources, err
+	}
+	kept := sources[:0]
+	for _, src := range sources {
+		if !i.excluded(src.Path) {
+			kept = append(kept, src)
+		}
+	}
+	return kept, nil
+}
+
+func (i *Indexer) excluded(p string) bool {
+	for _, pattern := range i.exclude {
+		if matchPathGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPathGlob reports whether name matches pattern as SetExclude
+// describes.
+func matchPathGlob(pattern, name string) bool {
+	pattern, name = filepath.ToSlash(pattern), filepath.ToSlash(name)
+	if !strings.HasPrefix(pattern, "/") && !strings.HasPrefix(pattern, "**") {
+		pattern = "**/" + pattern
+	}
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(name, "/"), "/"))
+}
+
+func matchSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for k := 0; k <= len(segs); k++ {
+				if matchSegments(pattern[1:], segs[k:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
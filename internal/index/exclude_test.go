@@ -0,0 +1,56 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/scratch/**", "/home/u/.claude/projects/-home-u-scratch/a.jsonl", false},
+		{"**/scratch/**", "/home/u/.claude/projects/scratch/a.jsonl", true},
+		{"scratch/**", "/home/u/.claude/projects/scratch/sub/a.jsonl", true},
+		{"**/tmp-*", "/home/u/.claude/projects/x/tmp-1.jsonl", true},
+		{"**/tmp-*", "/home/u/.claude/projects/tmp-x/a.jsonl", false},
+		{"/home/u/.codex/sessions/2024/**", "/home/u/.codex/sessions/2024/01/02/rollout-a.jsonl", true},
+		{"/home/u/.codex/sessions/2024/**", "/home/u/.codex/sessions/2025/01/02/rollout-a.jsonl", false},
+		{"/home/*/x.jsonl", "/home/u/sub/x.jsonl", false},
+	} {
+		if got := matchPathGlob(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("matchPathGlob(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestExcludedFilesLeaveTheIndex(t *testing.T) {
+	claudeHome := t.TempDir()
+	keep := "12341234-1234-1234-1234-123412341234"
+	drop := "56785678-5678-5678-5678-567856785678"
+	for id, project := range map[string]string{keep: "-work", drop: "scratch"} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", project, id+".jsonl"),
+			`{"type":"user","uuid":"u-`+id+`","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"prompt in `+project+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	if _, err := idx.GetSession(drop); err != nil {
+		t.Fatalf("expected the session indexed before excluding it: %v", err)
+	}
+	if err := idx.SetExclude([]string{"[bad/**"}); err == nil {
+		t.Fatal("expected a malformed pattern rejected")
+	}
+	if err := idx.SetExclude([]string{"**/scratch/**"}); err != nil {
+		t.Fatalf("set exclude: %v", err)
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if _, err := idx.GetSession(drop); err == nil {
+		t.Fatal("expected the excluded session dropped")
+	}
+	if _, err := idx.GetSession(keep); err != nil {
+		t.Fatalf("kept session lost: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package index
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileRef is a path:line reference printed in tool output, such as a
+// compiler error or a grep hit.
+type FileRef struct {
+	// Path is as printed: relative paths are relative to Workdir.
+	Path    string
+	Line    int
+	Col     int
+	Workdir string
+}
+
+// fileRefRe matches path:line and path:line:col where the path has a file
+// extension, so that host:port and timestamps mostly don't.
+var fileRefRe = regexp.MustCompile(`(?:^|[\s("'` + "`" + `])((?:~|\.{1,2})?/?(?:[\w.@+-]+/)*[\w@+-][\w.@+-]*\.[A-Za-z][A-Za-z0-9]*):(\d+)(?::(\d+))?`)
+
+// ExtractFileRefs returns the path:line references in the tool results of
+// messages, most recent first, once per path and line. Matches need not be
+// files; callers check that the path exists.
+func ExtractFileRefs(messages []Message) []FileRef {
+	var out []FileRef
+	seen := map[string]bool{}
+	for n := len(messages) - 1; n >= 0; n-- {
+		m := messages[n]
+		if !isToolResult(m) || !strings.Contains(m.Content, ":") {
+			continue
+		}
+		for _, match := range fileRefRe.FindAllStringSubmatch(toolOutputText(m.Content), -1) {
+			line, err := strconv.Atoi(match[2])
+			if err != nil || line == 0 {
+				continue
+			}
+			col, _ := strconv.Atoi(match[3])
+			key := filepath.Clean(match[1]) + ":" + match[2]
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, FileRef{Path: match[1], Line: line, Col: col, Workdir: m.Workdir})
+		}
+	}
+	return out
+}
+
+// AbsPath resolves the reference against its workdir and, for ~/, home.
+func (r FileRef) AbsPath(home string) string {
+	switch {
+	case strings.HasPrefix(r.Path, "~/") && home != "":
+		return filepath.Join(home, r.Path[2:])
+	case filepath.IsAbs(r.Path) || r.Workdir == "":
+		return r.Path
+	}
+	return filepath.Join(r.Workdir, r.Path)
+}
@@ -0,0 +1,29 @@
+package index
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExtractFileRefs(t *testing.T) {
+	msgs := []Message{
+		{Type: "tool_result", Workdir: "/src/app", Content: "# app\n./main.go:12:5: undefined: foo\ninternal/x.go:3: bad\n~/notes.txt:2"},
+		{Type: "message", Role: "assistant", Content: "see main.go:99"},
+		{Type: "function_call_output", Workdir: "/src/app", Content: `{"output":"listening on localhost:8080 and example.com:443\n/etc/app.conf:7:key = 1\nmain.go:12\n","metadata":{"exit_code":1}}`},
+	}
+	var got []string
+	for _, r := range ExtractFileRefs(msgs) {
+		got = append(got, fmt.Sprintf("%s %d %d", r.AbsPath("/home/u"), r.Line, r.Col))
+	}
+	want := []string{
+		"/src/app/example.com 443 0",
+		"/etc/app.conf 7 0",
+		"/src/app/main.go 12 0",
+		"/src/app/internal/x.go 3 0",
+		"/home/u/notes.txt 2 0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("refs = %q\nwant %q", got, want)
+	}
+}
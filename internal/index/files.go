@@ -0,0 +1,170 @@
+package index
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileTouch is a file the agent read or changed during a session.
+type FileTouch struct {
+	// Path is relative to the session workdir when the file lies under it.
+	Path   string
+	Edited bool
+}
+
+// claudeFileTools maps Claude's file tools to whether they change the file.
+var claudeFileTools = map[string]bool{
+	"Read":         false,
+	"Edit":         true,
+	"MultiEdit":    true,
+	"Write":        true,
+	"NotebookEdit": true,
+}
+
+var patchFileRe = regexp.MustCompile(`(?m)^\*\*\* (?:Add|Update|Delete) File: (.+)$`)
+
+// shellFileCommands maps commands whose plain arguments are files to
+// whether they change them.
+var shellFileCommands = map[string]bool{
+	"cat":   false,
+	"head":  false,
+	"tail":  false,
+	"nl":    false,
+	"less":  false,
+	"touch": true,
+	"rm":    true,
+}
+
+// ExtractFilesTouched returns the files named by file tool calls, patches
+// and simple shell commands in messages, in the order each was first
+// touched. Shell commands are read heuristically: output redirects and the
+// plain arguments of a few file commands count; globs and variables don't.
+func ExtractFilesTouched(messages []Message) []FileTouch {
+	var out []FileTouch
+	pos := map[string]int{}
+	touch := func(path, workdir string, edited bool) {
+		path = strings.Trim(strings.TrimSpace(path), `"'`)
+		if path == "" || path == "/dev/null" || strings.ContainsAny(path, "*?$`") {
+			return
+		}
+		if workdir != "" && filepath.IsAbs(path) {
+			if rel, err := filepath.Rel(workdir, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+		path = filepath.Clean(path)
+		n, ok := pos[path]
+		if !ok {
+			n = len(out)
+			pos[path] = n
+			out = append(out, FileTouch{Path: path})
+		}
+		out[n].Edited = out[n].Edited || edited
+	}
+
+	for _, m := range messages {
+		if !isToolCall(m) {
+			continue
+		}
+		content := strings.TrimSpace(m.Content)
+		if m.Type == "tool_use" {
+			name, input, _ := strings.Cut(content, ": ")
+			if edits, ok := claudeFileTools[name]; ok {
+				var args map[string]any
+				if json.Unmarshal([]byte(input), &args) == nil {
+					path := asString(args["file_path"])
+					if path == "" {
+						path = asString(args["notebook_path"])
+					}
+					touch(path, m.Workdir, edits)
+				}
+				continue
+			}
+		}
+		for _, match := range patchFileRe.FindAllStringSubmatch(content, -1) {
+			touch(match[1], m.Workdir, true)
+		}
+		if m.Type == "custom_tool_call" {
+			continue
+		}
+		cmd, workdir, ok := shellCommandFromCall(m)
+		if !ok {
+			continue
+		}
+		if workdir == "" {
+			workdir = m.Workdir
+		}
+		for _, f := range shellFiles(cmd) {
+			touch(f.path, workdir, f.edited)
+		}
+	}
+	return out
+}
+
+type shellFile struct {
+	path   string
+	edited bool
+}
+
+// shellFiles picks file paths out of a shell line: redirect targets and the
+// arguments of shellFileCommands, split on ;, && and pipes. Heredoc bodies
+// are skipped.
+func shellFiles(cmd string) []shellFile {
+	if i := strings.Index(cmd, "<<"); i >= 0 {
+		if nl := strings.IndexByte(cmd[i:], '\n'); nl >= 0 {
+			cmd = cmd[:i+nl]
+		}
+	}
+	var out []shellFile
+	for _, line := range strings.Split(cmd, "\n") {
+		for _, part := range splitShellLine(line) {
+			words := strings.Fields(part)
+			for n := 0; n < len(words); n++ {
+				w := words[n]
+				if w == ">" || w == ">>" {
+					if n+1 < len(words) {
+						out = append(out, shellFile{path: words[n+1], edited: true})
+						n++
+					}
+					continue
+				}
+				if t, ok := strings.CutPrefix(w, ">>"); ok && t != "" {
+					out = append(out, shellFile{path: t, edited: true})
+				} else if t, ok := strings.CutPrefix(w, ">"); ok && t != "" && !strings.HasPrefix(t, "&") {
+					out = append(out, shellFile{path: t, edited: true})
+				}
+			}
+			if len(words) == 0 {
+				continue
+			}
+			edits, ok := shellFileCommands[words[0]]
+			if !ok {
+				continue
+			}
+			for n := 1; n < len(words); n++ {
+				w := words[n]
+				if strings.HasPrefix(w, "-") || strings.ContainsAny(w, "<>|&") {
+					if w == ">" || w == ">>" || w == "<" {
+						n++
+					}
+					continue
+				}
+				if (words[0] == "head" || words[0] == "tail") && n > 1 && words[n-1] == "-n" {
+					continue
+				}
+				out = append(out, shellFile{path: w, edited: edits})
+			}
+		}
+	}
+	return out
+}
+
+// splitShellLine splits a line at ;, &&, || and |.
+func splitShellLine(line string) []string {
+	for _, sep := range []string{"&&", "||", ";"} {
+		line = strings.ReplaceAll(line, sep, "|")
+	}
+	return strings.Split(line, "|")
+}
@@ -0,0 +1,41 @@
+package index
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractFilesTouched(t *testing.T) {
+	msgs := []Message{
+		{Type: "tool_use", Workdir: "/src/app", Content: `Read: {"file_path":"/src/app/main.go"}`},
+		{Type: "tool_result", Content: "package main"},
+		{Type: "tool_use", Workdir: "/src/app", Content: `Edit: {"file_path":"/src/app/main.go","old_string":"a","new_string":"b"}`},
+		{Type: "tool_use", Workdir: "/src/app", Content: `Write: {"file_path":"/etc/app.conf","content":"x"}`},
+		{Type: "tool_use", Workdir: "/src/app", Content: `Grep: {"pattern":"TODO"}`},
+		{Type: "tool_use", Workdir: "/src/app", Content: `Bash: {"command":"cat go.mod | head -n 5 && echo hi > out.txt 2>/dev/null; ls *.go"}`},
+		{Type: "custom_tool_call", Workdir: "/src/app", Content: "*** Begin Patch\n*** Update File: internal/x.go\n@@\n-a\n+b\n*** Add File: internal/y.go\n+c\n*** End Patch"},
+		{Type: "function_call", Workdir: "/src/app", Content: `{"command":["bash","-lc","rm -f old.txt"],"workdir":"/src/app"}`},
+		{Type: "function_call", Workdir: "/src/app", Content: `{"command":["bash","-lc","cat <<'EOF' > notes.md\ncat secret.txt\nEOF"]}`},
+	}
+	var got []string
+	for _, f := range ExtractFilesTouched(msgs) {
+		mark := "read"
+		if f.Edited {
+			mark = "edited"
+		}
+		got = append(got, f.Path+" "+mark)
+	}
+	want := []string{
+		"main.go edited",
+		"/etc/app.conf edited",
+		"go.mod read",
+		"out.txt edited",
+		"internal/x.go edited",
+		"internal/y.go edited",
+		"old.txt edited",
+		"notes.md edited",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("files = %q\nwant %q", got, want)
+	}
+}
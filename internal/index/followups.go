@@ -0,0 +1,122 @@
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// FollowUp is work an assistant message left for later: an item under a
+// "Next steps" or "Follow-ups" heading, or a TODO line.
+type FollowUp struct {
+	SessionID string
+	MessageID int64
+	TS        int64
+	Workdir   string
+	Text      string
+}
+
+var (
+	// followUpHeadingRe matches a heading introducing follow-up work, as a
+	// markdown heading, a bold line or a line ending in a colon.
+	followUpHeadingRe = regexp.MustCompile(`(?i)^(?:#{1,6}\s+|\*\*|__)?\s*(?:suggested\s+|recommended\s+|possible\s+)?(?:next\s+steps?|follow[\s-]?ups?|todos?|to-dos?|remaining\s+work|open\s+items|still\s+to\s+do|left\s+to\s+do)\b[^a-z0-9]*$`)
+	// listItemRe matches a bullet or numbered list item, capturing its text.
+	listItemRe = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(?:\[[ xX]\]\s+)?(.+)$`)
+	// todoLineRe matches a TODO outside any list, capturing what follows.
+	todoLineRe = regexp.MustCompile(`^\s*(?:[-*+]\s+)?(?://\s*|#\s*)?(?:TODO|FIXME)\b[:\s]+(.+)$`)
+)
+
+// ExtractFollowUps returns the follow-up items in markdown, in order: the
+// list items under a follow-up heading, and TODO lines elsewhere. Code
+// blocks are skipped, so TODO comments in code the agent showed do not
+// count.
+func ExtractFollowUps(markdown string) []string {
+	var out []string
+	inList := false
+	fence := ""
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			fence, inList = m[1], false
+			continue
+		}
+		switch {
+		case followUpHeadingRe.MatchString(trimmed):
+			inList = true
+		case inList && trimmed == "":
+			// Blank lines may separate the heading from its items.
+		case inList && listItemRe.MatchString(line):
+			out = append(out, cleanFollowUp(listItemRe.FindStringSubmatch(line)[1]))
+		case inList && (line[0] == ' ' || line[0] == '\t'):
+			// A continuation of the previous item.
+		default:
+			inList = false
+			if m := todoLineRe.FindStringSubmatch(line); m != nil {
+				out = append(out, cleanFollowUp(m[1]))
+			}
+		}
+	}
+	return out
+}
+
+// cleanFollowUp drops the emphasis markers agents wrap items in.
+func cleanFollowUp(s string) string {
+	return strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(s, "**", ""), "__", ""))
+}
+
+// FollowUps returns the follow-ups in assistant messages of the listed
+// sessions, newest first, up to limit; each session lists an item once.
+// The scope applies as for ListSessions.
+func (i *Indexer) FollowUps(limit int) ([]FollowUp, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	listed, args := i.listedMessages("f.session_id", sessionFilters{})
+	rows, err := i.db.Query(`
+		SELECT f.rowid, f.session_id, COALESCE(m.ts, 0), COALESCE(s.workdir, ''), f.content
+		FROM messages_fts f
+		JOIN messages m ON m.id = f.rowid
+		JOIN sessions s ON s.id = f.session_id
+		WHERE m.type = 'message' AND m.role = 'assistant'
+			AND (f.content LIKE '%next step%' OR f.content LIKE '%follow%' OR f.content LIKE '%todo%'
+				OR f.content LIKE '%to-do%' OR f.content LIKE '%fixme%' OR f.content LIKE '%remaining work%'
+				OR f.content LIKE '%open items%' OR f.content LIKE '%to do%')`+listed+`
+		ORDER BY m.ts DESC, m.id DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query follow-ups: %w", err)
+	}
+	defer rows.Close()
+
+	var out []FollowUp
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var f FollowUp
+		var content string
+		if err := rows.Scan(&f.MessageID, &f.SessionID, &f.TS, &f.Workdir, &content); err != nil {
+			return nil, fmt.Errorf("scan follow-up message: %w", err)
+		}
+		for _, text := range ExtractFollowUps(content) {
+			key := f.SessionID + "\x00" + normalizeContent(text)
+			if text == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			f.Text = text
+			out = append(out, f)
+			if limit > 0 && len(out) == limit {
+				return out, nil
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate follow-up messages: %w", err)
+	}
+	return out, nil
+}
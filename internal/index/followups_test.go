@@ -0,0 +1,61 @@
+package index
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractFollowUps(t *testing.T) {
+	md := `Done: the parser handles empty files now.
+
+## Next steps
+
+1. Add a fuzz test for the parser
+2. **Benchmark** the large-file path
+   with the 2 GB fixture
+
+Unrelated paragraph.
+- not a follow-up
+
+**Follow-ups:**
+- [ ] Update the changelog
+
+TODO: drop the legacy flag once 2.0 ships
+
+` + "```go\n// TODO: this is code, not a promise\n```\n"
+	got := ExtractFollowUps(md)
+	want := []string{
+		"Add a fuzz test for the parser",
+		"Benchmark the large-file path",
+		"Update the changelog",
+		"drop the legacy flag once 2.0 ships",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestFollowUpsAcrossSessions(t *testing.T) {
+	claudeHome := t.TempDir()
+	const id = "12121212-0000-0000-0000-000000000000"
+	reply := func(uuid, ts, text string) string {
+		return `{"type":"assistant","uuid":"` + uuid + `","sessionId":"` + id + `","cwd":"/src/api","timestamp":"` + ts + `","message":{"role":"assistant","content":[{"type":"text","text":"` + text + `"}]}}`
+	}
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-src-api", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/src/api","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"fix the retry loop"}}`,
+		reply("a1", "2026-01-15T10:01:00Z", `Fixed.\n\nNext steps:\n- Add a regression test`),
+		reply("a2", "2026-01-15T10:05:00Z", `Still open.\n\nNext steps:\n- add a regression test\n- Tune the backoff`))
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	items, err := idx.FollowUps(0)
+	if err != nil {
+		t.Fatalf("follow-ups: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected the repeated item once, got %+v", items)
+	}
+	if items[0].Text != "add a regression test" || items[1].Text != "Tune the backoff" || items[0].Workdir != "/src/api" || items[0].SessionID != id {
+		t.Errorf("items = %+v", items)
+	}
+}
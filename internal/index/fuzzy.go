@@ -0,0 +1,93 @@
+package index
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxFuzzyWords caps how many similar words a ~term expands to.
+const maxFuzzyWords = 20
+
+// fuzzyDistance is how many edits a ~term tolerates: none for short words,
+// where one edit makes a different common word, then one, then two.
+func fuzzyDistance(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// editDistance is the optimal string alignment distance between a and b:
+// insertions, deletions, substitutions and swaps of adjacent runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// similarWords lists the indexed words within fuzzyDistance of term,
+// closest and then most common first.
+func (i *Indexer) similarWords(term string) ([]string, error) {
+	dist := fuzzyDistance(term)
+	if dist == 0 {
+		return nil, nil
+	}
+	n := utf8.RuneCountInString(term)
+	rows, err := i.db.Query(`SELECT term, doc FROM messages_vocab WHERE length(term) BETWEEN ? AND ?`, n-dist, n+dist)
+	if err != nil {
+		return nil, fmt.Errorf("read search vocabulary: %w", err)
+	}
+	defer rows.Close()
+	type candidate struct {
+		word string
+		dist int
+		docs int
+	}
+	var found []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.word, &c.docs); err != nil {
+			return nil, fmt.Errorf("scan search vocabulary: %w", err)
+		}
+		if c.dist = editDistance(term, c.word); c.dist <= dist {
+			found = append(found, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate search vocabulary: %w", err)
+	}
+	sort.Slice(found, func(a, b int) bool {
+		if found[a].dist != found[b].dist {
+			return found[a].dist < found[b].dist
+		}
+		return found[a].docs > found[b].docs
+	})
+	out := make([]string, 0, min(len(found), maxFuzzyWords))
+	for _, c := range found[:min(len(found), maxFuzzyWords)] {
+		out = append(out, c.word)
+	}
+	return out, nil
+}
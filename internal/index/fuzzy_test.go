@@ -0,0 +1,62 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"kubernetes", "kubernetes", 0},
+		{"kuberntes", "kubernetes", 1},
+		{"kubrenetes", "kubernetes", 1},
+		{"kubernets", "kubernetes", 1},
+		{"kbuernets", "kubernetes", 2},
+		{"", "abc", 3},
+		{"héllo", "hello", 1},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestExpandFuzzy(t *testing.T) {
+	parsed, _ := parseSearchQuery(`~kuberntes pods`)
+	got := buildFTSQuery(`~kuberntes pods`, func(term string) ([]string, error) {
+		return []string{"kubernetes", "kuberntes"}, nil
+	})
+	if want := `("kuberntes"* OR "kubernetes") AND "pods"*`; got != want {
+		t.Fatalf("expanded = %q, want %q", got, want)
+	}
+	if got := buildFTSQuery(`~kuberntes`, nil); got != `"kuberntes"*` {
+		t.Fatalf("without expansion = %q", got)
+	}
+	if terms := parsed.terms(); len(terms) != 2 || terms[0] != "kuberntes" {
+		t.Fatalf("terms = %q", terms)
+	}
+}
+
+func TestFuzzySearchFindsTypos(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "99999999-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"restart the kubernetes pods"}}`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	if !idx.ftsEnabled {
+		t.Skip("fuzzy search needs FTS5 (build with -tags sqlite_fts5)")
+	}
+	for query, want := range map[string]int{"~kuberntes": 1, "kuberntes": 0, "~pod": 1, "~pud": 0} {
+		sessions, err := idx.ListSessions(query, 10)
+		if err != nil {
+			t.Fatalf("%q: %v", query, err)
+		}
+		if len(sessions) != want {
+			t.Errorf("%q: got %d sessions, want %d", query, len(sessions), want)
+		}
+	}
+}
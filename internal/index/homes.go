@@ -0,0 +1,79 @@
+package index
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SetScanRoots adds the agent homes matching each glob pattern (see
+// filepath.Match, e.g. ~/dev/*/.claude with ~ already expanded) to every
+// index pass. A match with a projects/ directory is read as a Claude home,
+// one with sessions/ as a Codex home. Patterns are matched again on each
+// pass, so containers started later are picked up.
+func (i *Indexer) SetScanRoots(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("scan root %q: %w", p, err)
+		}
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.scanRoots = patterns
+	return nil
+}
+
+// SetSources turns indexing of each agent's sessions on or off. A source
+// that is off is not scanned at all, and what was indexed from it is
+// dropped on the next pass like files that disappeared.
+func (i *Indexer) SetSources(codex, claude bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.skipCodex, i.skipClaude = !codex, !claude
+}
+
+// homes are the codex and Claude homes an index pass scans: the configured
+// ones, those matching a scan root and the mirrored remote ones, each once.
+func (i *Indexer) homes() (codex []string, claude []string) {
+	seen := make(map[string]bool)
+	add := func(list *[]string, dir string) {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			*list = append(*list, dir)
+		}
+	}
+	for _, h := range i.codexHomes {
+		add(&codex, h)
+	}
+	for _, h := range i.claudeHomes {
+		add(&claude, h)
+	}
+	for _, pattern := range i.scanRoots {
+		matches, _ := filepath.Glob(pattern)
+		for _, m := range matches {
+			switch {
+			case isDir(filepath.Join(m, "projects")):
+				add(&claude, m)
+			case isDir(filepath.Join(m, "sessions")):
+				add(&codex, m)
+			}
+		}
+	}
+	for _, r := range i.remotes {
+		add(&codex, r.codexHome())
+		add(&claude, r.claudeHome())
+	}
+	if i.skipCodex {
+		codex = nil
+	}
+	if i.skipClaude {
+		claude = nil
+	}
+	return codex, claude
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,111 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanRootsAddMatchingHomesOnEachPass(t *testing.T) {
+	dev := t.TempDir()
+	idx := newTestIndexer(t, t.TempDir(), t.TempDir())
+	if err := idx.SetScanRoots([]string{"[bad"}); err == nil {
+		t.Fatal("expected a malformed pattern rejected")
+	}
+	if err := idx.SetScanRoots([]string{filepath.Join(dev, "*", ".claude"), filepath.Join(dev, "*", ".codex")}); err != nil {
+		t.Fatalf("set scan roots: %v", err)
+	}
+
+	claudeID := "23232323-2323-2323-2323-232323232323"
+	writeJSONL(t, filepath.Join(dev, "api", ".claude", "projects", "-workspace", claudeID+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+claudeID+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"from a container"}}`)
+	codexID := "019ac5e9-684f-7741-9974-4246554edb05"
+	writeJSONL(t, filepath.Join(dev, "web", ".codex", "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+codexID+".jsonl"),
+		`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+codexID+`","cwd":"/workspace"}}`,
+		`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"codex in a sandbox"}]}}`)
+	// Neither a Claude nor a Codex home.
+	writeJSONL(t, filepath.Join(dev, "docs", ".claude", "notes.jsonl"), `{}`)
+
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	for _, id := range []string{claudeID, codexID} {
+		if _, err := idx.GetSession(id); err != nil {
+			t.Errorf("session %s from a scan root not indexed: %v", id, err)
+		}
+	}
+	codex, claude := idx.homes()
+	if len(codex) != 2 || len(claude) != 2 {
+		t.Fatalf("homes = %v, %v", codex, claude)
+	}
+}
+
+func TestEveryCodexHomeIsIndexed(t *testing.T) {
+	homeA, homeB := t.TempDir(), t.TempDir()
+	ids := []string{"019ac5e9-684f-7741-9974-4246554edb05", "019ac5e9-684f-7741-9974-4246554edb06"}
+	for n, home := range []string{homeA, homeB} {
+		writeJSONL(t, filepath.Join(home, "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+ids[n]+".jsonl"),
+			`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+ids[n]+`","cwd":"/tmp/proj"}}`,
+			`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"sandboxed"}]}}`)
+	}
+	idx, err := New([]string{homeA, homeB}, nil, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	for _, id := range ids {
+		if _, err := idx.GetSession(id); err != nil {
+			t.Errorf("session %s not indexed: %v", id, err)
+		}
+	}
+}
+
+func TestEveryClaudeHomeIsIndexed(t *testing.T) {
+	root := t.TempDir()
+	ids := map[string]string{
+		filepath.Join(root, ".claude"):           "45454545-4545-4545-4545-454545454545",
+		filepath.Join(root, ".claude-container"): "46464646-4646-4646-4646-464646464646",
+	}
+	var homes []string
+	for home, id := range ids {
+		homes = append(homes, home)
+		writeJSONL(t, filepath.Join(home, "projects", "-tmp-proj", id+".jsonl"),
+			`{"type":"user","uuid":"u-`+id+`","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello from `+filepath.Base(home)+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), homes...)
+	for home, id := range ids {
+		s, err := idx.GetSession(id)
+		if err != nil || len(s.SourcePaths) != 1 || filepath.Dir(filepath.Dir(filepath.Dir(s.SourcePaths[0]))) != home {
+			t.Errorf("session %s from %s: %+v, %v", id, home, s.SourcePaths, err)
+		}
+	}
+}
+
+func TestDisabledSourceIsSkippedAndDropped(t *testing.T) {
+	codexHome, claudeHome := t.TempDir(), t.TempDir()
+	codexID := "019ac5e9-684f-7741-9974-4246554edb05"
+	writeJSONL(t, filepath.Join(codexHome, "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+codexID+".jsonl"),
+		`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+codexID+`","cwd":"/tmp/proj"}}`,
+		`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"codex prompt"}]}}`)
+	claudeID := "67676767-6767-6767-6767-676767676767"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", claudeID+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+claudeID+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"claude prompt"}}`)
+	idx := newTestIndexer(t, codexHome, claudeHome)
+	if _, err := idx.GetSession(codexID); err != nil {
+		t.Fatalf("codex session not indexed: %v", err)
+	}
+
+	idx.SetSources(false, true)
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if _, err := idx.GetSession(codexID); err == nil {
+		t.Fatal("expected the codex session dropped with codex off")
+	}
+	if _, err := idx.GetSession(claudeID); err != nil {
+		t.Fatalf("claude session lost: %v", err)
+	}
+}
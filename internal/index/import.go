@@ -0,0 +1,269 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ImportResult counts what Import added.
+type ImportResult struct {
+	Sessions int
+	Messages int
+}
+
+// importedTables are the source-scoped tables Import copies along with a
+// file's messages. Ingest problems stay with the machine that had them.
+var importedTables = []string{"claude_entries", "claude_refs", "claude_subagents", "claude_file_snapshots", "session_sources", "session_usage", "session_branches", "claude_titles"}
+
+// Import merges the sessions of another agent-trace index, say a
+// laptop's, into this one. Messages are copied per session and source
+// file; a pair this index holds as many messages of is skipped, and a
+// message the session already holds with the same content and time is
+// too, so importing twice adds nothing while a file that grew on the other
+// machine brings its new messages. Annotations come along for sessions
+// without local ones.
+//
+// Imported files are not tracked as ingested here, so index passes leave
+// them alone: their rows are stored under a source path namespaced by the
+// imported index (see importedSourcePath), which a local file at the same
+// path never matches. A --reindex or a normalization change drops them
+// with everything else.
+func (i *Indexer) Import(ctx context.Context, path string) (ImportResult, error) {
+	var res ImportResult
+	if _, err := os.Stat(path); err != nil {
+		return res, fmt.Errorf("open index to import: %w", err)
+	}
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// ATTACH is per connection, so the copy runs on one, released before
+	// sessions are summarized.
+	conn, err := i.db.Conn(ctx)
+	if err != nil {
+		return res, fmt.Errorf("import connection: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS other`, path); err != nil {
+		conn.Close()
+		return res, fmt.Errorf("attach %s: %w", path, err)
+	}
+	origin, err := filepath.Abs(path)
+	if err != nil {
+		origin = path
+	}
+	res, err = importFrom(ctx, conn, origin)
+	_, _ = conn.ExecContext(context.Background(), `DETACH DATABASE other`)
+	conn.Close()
+	if err != nil || res.Messages == 0 {
+		return res, err
+	}
+	i.log.Info("imported index", "path", path, "sessions", res.Sessions, "messages", res.Messages)
+	return res, i.refreshSessions(ctx)
+}
+
+// importedSourcePath is where rows that other recorded at path are stored
+// when imported from the index at origin. Shared paths such as
+// ~/.claude/history.jsonl exist on every machine; without the prefix,
+// re-ingesting the local file would delete the imported rows with it.
+func importedSourcePath(origin, path string) string {
+	return "import:" + origin + ":" + path
+}
+
+// importFrom copies what the index attached as other, read from path, has
+// and main lacks.
+func importFrom(ctx context.Context, conn *sql.Conn, path string) (ImportResult, error) {
+	var res ImportResult
+	var version int
+	if err := conn.QueryRowContext(ctx, `PRAGMA other.user_version`).Scan(&version); err != nil {
+		return res, fmt.Errorf("read schema version of %s: %w", path, err)
+	}
+	if version != len(migrations) {
+		return res, fmt.Errorf("%s has index schema version %d, this build %d; open it once with this agent-trace (agent-trace status --db-path %s) first", path, version, len(migrations), path)
+	}
+
+	pairs, err := importPairs(ctx, conn, importedSourcePath(path, ""))
+	if err != nil {
+		return res, err
+	}
+	if len(pairs) == 0 {
+		return res, nil
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("begin import: %w", err)
+	}
+	defer tx.Rollback()
+	sessions := make(map[string]bool)
+	for _, p := range pairs {
+		to := importedSourcePath(path, p.sourcePath)
+		n, err := importMessages(ctx, tx, p, to)
+		if err != nil {
+			return res, err
+		}
+		if n > 0 {
+			sessions[p.sessionID] = true
+			res.Messages += n
+		}
+		for _, table := range importedTables {
+			if err := importTableRows(ctx, tx, table, p.sourcePath, to); err != nil {
+				return res, fmt.Errorf("import %s rows of %s: %w", table, p.sourcePath, err)
+			}
+		}
+	}
+	for id := range sessions {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO main.annotations SELECT * FROM other.annotations WHERE session_id = ?`, id); err != nil {
+			return res, fmt.Errorf("import annotations of %s: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("commit import: %w", err)
+	}
+	res.Sessions = len(sessions)
+	return res, nil
+}
+
+// importPair is one session's messages from one source file.
+type importPair struct {
+	sessionID  string
+	sourcePath string
+}
+
+// importPairs lists the session and file pairs the other index has more
+// messages of than this one imported under prefix: new pairs, ones that
+// grew since the last import, and ones whose messages this index already
+// held from elsewhere.
+func importPairs(ctx context.Context, conn *sql.Conn, prefix string) ([]importPair, error) {
+	rows, err := conn.QueryContext(ctx, `
+		SELECT o.session_id, o.path FROM (
+			SELECT session_id, COALESCE(source_path, '') AS path, COUNT(*) AS n
+			FROM other.messages GROUP BY 1, 2
+		) o LEFT JOIN (
+			SELECT session_id, substr(source_path, length(?1) + 1) AS path, COUNT(*) AS n
+			FROM main.messages WHERE substr(source_path, 1, length(?1)) = ?1 GROUP BY 1, 2
+		) m ON m.session_id = o.session_id AND m.path = o.path
+		WHERE COALESCE(m.n, 0) < o.n
+		ORDER BY 1, 2
+	`, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("compare indexes: %w", err)
+	}
+	defer rows.Close()
+	var out []importPair
+	for rows.Next() {
+		var p importPair
+		if err := rows.Scan(&p.sessionID, &p.sourcePath); err != nil {
+			return nil, fmt.Errorf("scan session to import: %w", err)
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sessions to import: %w", err)
+	}
+	return out, nil
+}
+
+// importTableRows copies the rows of a source-scoped table recorded at
+// from, storing them at to.
+func importTableRows(ctx context.Context, tx *sql.Tx, table, from, to string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return fmt.Errorf("read columns: %w", err)
+	}
+	var cols, values []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan column: %w", err)
+		}
+		cols = append(cols, name)
+		if name == "source_path" {
+			name = "?"
+		}
+		values = append(values, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate columns: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT OR IGNORE INTO main.`+table+`(`+strings.Join(cols, ", ")+`)
+		SELECT `+strings.Join(values, ", ")+` FROM other.`+table+` WHERE source_path = ?`, to, from)
+	return err
+}
+
+// importMessages copies one pair's messages, content stored as it was but
+// recorded at to, and returns how many were new.
+func importMessages(ctx context.Context, tx *sql.Tx, p importPair, to string) (int, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT ts, role, content, type, source, workdir, content_hash, call_id FROM other.messages
+		WHERE session_id = ? AND COALESCE(source_path, '') = ?
+		ORDER BY id
+	`, p.sessionID, p.sourcePath)
+	if err != nil {
+		return 0, fmt.Errorf("read messages to import: %w", err)
+	}
+	type row struct {
+		ts                         sql.NullInt64
+		role, typ, source, workdir sql.NullString
+		hash, callID               sql.NullString
+		content                    any
+	}
+	var msgs []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.ts, &r.role, &r.content, &r.typ, &r.source, &r.workdir, &r.hash, &r.callID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan message to import: %w", err)
+		}
+		msgs = append(msgs, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate messages to import: %w", err)
+	}
+
+	stored := 0
+	for _, r := range msgs {
+		if r.hash.Valid {
+			var dup int
+			err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM main.messages WHERE session_id = ? AND content_hash = ? AND ts IS ?`, p.sessionID, r.hash.String, r.ts).Scan(&dup)
+			if err != nil {
+				return stored, fmt.Errorf("check imported message: %w", err)
+			}
+			if dup > 0 {
+				continue
+			}
+		}
+		var text string
+		if err := scanContent(&text).Scan(r.content); err != nil {
+			return stored, err
+		}
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO main.messages(session_id, ts, role, content, type, source, source_path, workdir, content_hash, call_id)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, p.sessionID, r.ts, r.role, r.content, r.typ, r.source, to, r.workdir, r.hash, r.callID)
+		if err != nil {
+			return stored, fmt.Errorf("import message: %w", err)
+		}
+		rowID, err := res.LastInsertId()
+		if err != nil {
+			return stored, fmt.Errorf("import message: %w", err)
+		}
+		if r.typ.String != "image" {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO main.messages_fts(rowid, session_id, role, content) VALUES(?, ?, ?, ?)`, rowID, p.sessionID, r.role, text); err != nil {
+				return stored, fmt.Errorf("index imported message: %w", err)
+			}
+		}
+		stored++
+	}
+	return stored, nil
+}
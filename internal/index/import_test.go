@@ -0,0 +1,151 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportMergesAnotherIndexOnce(t *testing.T) {
+	claudeLine := func(id, uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	shared := "90909090-9090-9090-9090-909090909090"
+	laptopOnly := "91919191-9191-9191-9191-919191919191"
+	long := "laptop-only " + strings.Repeat("words ", 200)
+
+	desktopHome := t.TempDir()
+	writeJSONL(t, filepath.Join(desktopHome, "projects", "-tmp-proj", shared+".jsonl"), claudeLine(shared, "u1", "same prompt"))
+	desktop := newTestIndexer(t, t.TempDir(), desktopHome)
+
+	laptopHome := t.TempDir()
+	writeJSONL(t, filepath.Join(laptopHome, "projects", "-tmp-proj", shared+".jsonl"), claudeLine(shared, "u1", "same prompt"))
+	writeJSONL(t, filepath.Join(laptopHome, "projects", "-tmp-proj", laptopOnly+".jsonl"), claudeLine(laptopOnly, "u2", long),
+		`{"type":"summary","summary":"Laptop title","leafUuid":"u2"}`)
+	laptopDB := filepath.Join(t.TempDir(), "laptop.sqlite")
+	laptop, err := New([]string{t.TempDir()}, []string{laptopHome}, laptopDB, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	laptop.SetCompression(true)
+	if _, err := laptop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build laptop index: %v", err)
+	}
+	if _, err := laptop.SetAnnotation(Annotation{SessionID: laptopOnly, Alias: "from-laptop"}); err != nil {
+		t.Fatalf("annotate: %v", err)
+	}
+	laptop.Close()
+
+	res, err := desktop.Import(context.Background(), laptopDB)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Messages != 1 || res.Sessions != 1 {
+		t.Fatalf("import = %+v, want the laptop-only message alone", res)
+	}
+	msgs, _, err := desktop.GetMessagesBefore(shared, nil, 10)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("shared session messages = %+v, %v; want no duplicate", msgs, err)
+	}
+	if sessions, err := desktop.ListSessions("laptop-only", 10); err != nil || len(sessions) != 1 || sessions[0].ID != laptopOnly || sessions[0].Title != "Laptop title" {
+		t.Fatalf("expected the compressed import searchable, got %+v, %v", sessions, err)
+	}
+	if anns, _ := desktop.Annotations(); anns[laptopOnly].Alias != "from-laptop" {
+		t.Fatalf("expected the annotation imported, got %+v", anns[laptopOnly])
+	}
+
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 0 {
+		t.Fatalf("second import = %+v, %v; want nothing new", res, err)
+	}
+	if _, err := desktop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if _, err := desktop.GetSession(laptopOnly); err != nil {
+		t.Fatalf("expected imported sessions to survive an index pass: %v", err)
+	}
+}
+
+func TestImportSurvivesReingestOfTheSamePath(t *testing.T) {
+	claudeLine := func(id, uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	laptopID := "92929292-9292-9292-9292-929292929292"
+	desktopID := "93939393-9393-9393-9393-939393939393"
+
+	// Both machines have a file at the same path with different sessions.
+	home := t.TempDir()
+	file := filepath.Join(home, "projects", "-tmp-proj", "shared.jsonl")
+	writeJSONL(t, file, claudeLine(laptopID, "u1", "laptop prompt"))
+	laptopDB := filepath.Join(t.TempDir(), "laptop.sqlite")
+	laptop, err := New([]string{t.TempDir()}, []string{home}, laptopDB, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	if _, err := laptop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build laptop index: %v", err)
+	}
+	laptop.Close()
+
+	writeJSONL(t, file, claudeLine(desktopID, "u2", "desktop prompt"))
+	desktop := newTestIndexer(t, t.TempDir(), home)
+	if _, err := desktop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build desktop index: %v", err)
+	}
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 1 {
+		t.Fatalf("import = %+v, %v; want the laptop message", res, err)
+	}
+
+	// A rewritten file is reset: its rows are deleted and ingested again.
+	writeJSONL(t, file, claudeLine(desktopID, "u3", "desktop prompt, edited at length"))
+	if _, err := desktop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("rebuild desktop index: %v", err)
+	}
+	if msgs, _, err := desktop.GetMessagesBefore(laptopID, nil, 10); err != nil || len(msgs) != 1 {
+		t.Fatalf("imported messages = %+v, %v; want them kept across a local re-ingest", msgs, err)
+	}
+	if msgs, _, err := desktop.GetMessagesBefore(desktopID, nil, 10); err != nil || len(msgs) != 1 || !strings.Contains(msgs[0].Content, "edited") {
+		t.Fatalf("local messages = %+v, %v; want the re-ingested file", msgs, err)
+	}
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 0 {
+		t.Fatalf("second import = %+v, %v; want nothing new", res, err)
+	}
+}
+
+func TestImportBringsInMessagesOfAGrownFile(t *testing.T) {
+	claudeLine := func(id, uuid, ts, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","timestamp":"` + ts + `","message":{"role":"user","content":"` + text + `"}}`
+	}
+	id := "94949494-9494-9494-9494-949494949494"
+	laptopHome := t.TempDir()
+	file := filepath.Join(laptopHome, "projects", "-tmp-proj", id+".jsonl")
+	writeJSONL(t, file, claudeLine(id, "u1", "2026-01-15T10:00:00Z", "first prompt"))
+	laptopDB := filepath.Join(t.TempDir(), "laptop.sqlite")
+	laptop, err := New([]string{t.TempDir()}, []string{laptopHome}, laptopDB, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer laptop.Close()
+	if _, err := laptop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build laptop index: %v", err)
+	}
+
+	desktop := newTestIndexer(t, t.TempDir(), t.TempDir())
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 1 {
+		t.Fatalf("import = %+v, %v; want the first message", res, err)
+	}
+
+	writeJSONL(t, file, claudeLine(id, "u1", "2026-01-15T10:00:00Z", "first prompt"), claudeLine(id, "u2", "2026-01-15T10:05:00Z", "second prompt"))
+	if _, err := laptop.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("rebuild laptop index: %v", err)
+	}
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 1 || res.Sessions != 1 {
+		t.Fatalf("second import = %+v, %v; want the new message alone", res, err)
+	}
+	if msgs, _, err := desktop.GetMessagesBefore(id, nil, 10); err != nil || len(msgs) != 2 {
+		t.Fatalf("messages = %+v, %v; want both prompts", msgs, err)
+	}
+	if res, err := desktop.Import(context.Background(), laptopDB); err != nil || res.Messages != 0 {
+		t.Fatalf("third import = %+v, %v; want nothing new", res, err)
+	}
+}
@@ -2,40 +2,62 @@ package index
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"agent-trace/internal/logging"
 )
 
 type Indexer struct {
-	codexHome   string
+	codexHomes  []string
 	claudeHomes []string
 	dbPath      string
 	db          *sql.DB
 	ftsEnabled  bool
+	normalizer  Normalizer
+	syncFile    string
+	compress    bool
+	scope       string
+	remotes     []remote
+	scanRoots   []string
+	skipCodex   bool
+	skipClaude  bool
+	exclude     []string
+	showEmpty   bool
+	log         *slog.Logger
 	mu          sync.Mutex
 }
 
-func New(codexHome string, claudeHomes []string, dbPath string, reindex bool) (*Indexer, error) {
-	if reindex {
+// MemoryPath as the database path keeps the whole index in RAM for one run.
+const MemoryPath = ":memory:"
+
+func New(codexHomes, claudeHomes []string, dbPath string, reindex bool) (*Indexer, error) {
+	if reindex && dbPath != MemoryPath {
 		_ = os.Remove(dbPath)
 		_ = os.Remove(dbPath + "-wal")
 		_ = os.Remove(dbPath + "-shm")
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open(sqliteDriver, sqliteDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite db: %w", err)
 	}
+	if dbPath == MemoryPath {
+		// Every connection to :memory: is a separate, empty database.
+		db.SetMaxOpenConns(1)
+	}
 
-	i := &Indexer{codexHome: codexHome, claudeHomes: claudeHomes, dbPath: dbPath, db: db}
+	i := &Indexer{codexHomes: codexHomes, claudeHomes: claudeHomes, dbPath: dbPath, db: db, log: logging.Discard()}
 	if err := i.initSchema(); err != nil {
 		_ = db.Close()
 		return nil, err
@@ -47,45 +69,26 @@ func (i *Indexer) Close() error {
 	return i.db.Close()
 }
 
+// SetLogger sends index runs, skipped files and other maintenance events to
+// l; nil discards them.
+func (i *Indexer) SetLogger(l *slog.Logger) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if l == nil {
+		l = logging.Discard()
+	}
+	i.log = l
+}
+
 func (i *Indexer) initSchema() error {
-	stmts := []string{
-		`PRAGMA journal_mode = WAL;`,
-		`PRAGMA foreign_keys = ON;`,
-		`CREATE TABLE IF NOT EXISTS sessions (
-			id TEXT PRIMARY KEY,
-			source TEXT,
-			last_activity_ts INTEGER,
-			message_count INTEGER,
-			workdir TEXT,
-			preview TEXT
-		);`,
-		`CREATE TABLE IF NOT EXISTS messages (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			session_id TEXT,
-			ts INTEGER,
-			role TEXT,
-			content TEXT,
-			type TEXT,
-			source TEXT,
-			source_path TEXT,
-			workdir TEXT
-		);`,
-		`CREATE INDEX IF NOT EXISTS idx_messages_session_id ON messages(session_id);`,
-		`CREATE INDEX IF NOT EXISTS idx_messages_session_ts ON messages(session_id, ts, id);`,
-		`CREATE TABLE IF NOT EXISTS ingested_files (
-			path TEXT PRIMARY KEY,
-			mtime INTEGER,
-			size INTEGER,
-			offset INTEGER,
-			source TEXT
-		);`,
-	}
-
-	for _, stmt := range stmts {
+	for _, stmt := range []string{`PRAGMA journal_mode = WAL;`, `PRAGMA foreign_keys = ON;`} {
 		if _, err := i.db.Exec(stmt); err != nil {
 			return fmt.Errorf("init schema: %w", err)
 		}
 	}
+	if err := migrate(i.db, migrations); err != nil {
+		return err
+	}
 	return i.ensureFTSTable()
 }
 
@@ -95,6 +98,9 @@ func (i *Indexer) ensureFTSTable() error {
 	if err == nil {
 		lower := strings.ToLower(sqlDef)
 		i.ftsEnabled = strings.Contains(lower, "virtual table") && strings.Contains(lower, "fts5")
+		if i.ftsEnabled {
+			return i.ensureVocabTable()
+		}
 		return nil
 	}
 	if !errors.Is(err, sql.ErrNoRows) {
@@ -108,7 +114,7 @@ func (i *Indexer) ensureFTSTable() error {
 	);`)
 	if err == nil {
 		i.ftsEnabled = true
-		return nil
+		return i.ensureVocabTable()
 	}
 
 	if !strings.Contains(strings.ToLower(err.Error()), "no such module: fts5") {
@@ -131,18 +137,68 @@ func (i *Indexer) ensureFTSTable() error {
 	return nil
 }
 
+// ensureVocabTable exposes the words in messages_fts, which ~fuzzy search
+// terms are matched against.
+func (i *Indexer) ensureVocabTable() error {
+	if _, err := i.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS messages_vocab USING fts5vocab(messages_fts, 'row')`); err != nil {
+		return fmt.Errorf("create messages_vocab: %w", err)
+	}
+	return nil
+}
+
 // IndexResult contains the outcome of a BuildIndex run.
 type IndexResult struct {
-	Skipped int // number of files that failed to ingest
+	Skipped int // number of files that failed to ingest; IngestIssues says why
+}
+
+// sessionFlushInterval is how often a long index run summarizes the
+// sessions it has read so far, so the list fills in before it finishes.
+var sessionFlushInterval = 2 * time.Second
+
+// interimSessionLimit caps the session list sent with a flush.
+const interimSessionLimit = 500
+
+// IndexProgress reports how far a BuildIndex run has got.
+type IndexProgress struct {
+	Files      int    // source files finished so far
+	TotalFiles int    // source files discovered
+	Path       string // file being read; empty once sessions are summarized
+	Messages   int    // messages stored so far in this run
+	// Sessions, when non-nil, is the session list as of a mid-run flush,
+	// newest first. Subagent and duplicate sessions may still show.
+	Sessions []Session
 }
 
 func (i *Indexer) BuildIndex(ctx context.Context) (IndexResult, error) {
+	return i.BuildIndexProgress(ctx, nil)
+}
+
+// BuildIndexProgress is BuildIndex calling report, when non-nil, before
+// each source file and once more before sessions are summarized. report
+// runs on the indexing goroutine and should not block.
+func (i *Indexer) BuildIndexProgress(ctx context.Context, report func(IndexProgress)) (IndexResult, error) {
+	if report == nil {
+		report = func(IndexProgress) {}
+	}
+	var result IndexResult
+	start := time.Now()
+	if err := i.syncRemotes(ctx); err != nil {
+		return result, err
+	}
+
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return result, err
+	}
+	defer unlock()
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	var result IndexResult
+	if err := i.syncAnnotations(); err != nil {
+		return result, err
+	}
 
-	sources, err := discoverAllSources(i.codexHome, i.claudeHomes)
+	sources, err := i.discover()
 	if err != nil {
 		return result, fmt.Errorf("discover sources: %w", err)
 	}
@@ -153,90 +209,141 @@ func (i *Indexer) BuildIndex(ctx context.Context) (IndexResult, error) {
 		if err := i.refreshSessions(ctx); err != nil {
 			return result, err
 		}
-		return result, nil
+		return result, i.markIndexed(ctx)
 	}
 
-	for _, src := range sources {
+	progress := IndexProgress{TotalFiles: len(sources)}
+	touched := make(map[string]bool)
+	lastFlush := time.Now()
+	for n, src := range sources {
 		select {
 		case <-ctx.Done():
 			return result, ctx.Err()
 		default:
 		}
-		if err := i.ingestFile(ctx, src); err != nil {
+		progress.Files, progress.Path, progress.Sessions = n, src.Path, nil
+		if len(touched) > 0 && time.Since(lastFlush) >= sessionFlushInterval {
+			// The interim list is best effort; the final refresh redoes it.
+			if err := i.flushSessions(ctx, touched); err == nil {
+				progress.Sessions, _ = i.listSessions("", interimSessionLimit)
+			}
+			clear(touched)
+			lastFlush = time.Now()
+		}
+		report(progress)
+		stored, err := i.ingestFile(ctx, src, touched)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
 			result.Skipped++
+			i.log.Warn("skipped source file", "path", src.Path, "err", err)
+			i.recordIngestFailure(ctx, src.Path, err)
 			continue
 		}
+		progress.Messages += stored
 	}
+	progress.Files, progress.Path, progress.Sessions = len(sources), "", nil
+	report(progress)
 
-	return result, i.refreshSessions(ctx)
+	if err := i.refreshSessions(ctx); err != nil {
+		return result, err
+	}
+	// Idle passes (the daemon runs one every few seconds) only log at debug.
+	level := slog.LevelDebug
+	if progress.Messages > 0 || result.Skipped > 0 {
+		level = slog.LevelInfo
+	}
+	i.log.Log(ctx, level, "index built", "files", len(sources), "messages", progress.Messages, "skipped", result.Skipped, "duration", time.Since(start).Round(time.Millisecond))
+	return result, i.markIndexed(ctx)
 }
 
 type fileMeta struct {
 	Mtime  int64
 	Size   int64
 	Offset int64
+	// Hash is the prefixHash of the first Offset bytes; empty for files
+	// ingested before hashes were kept.
+	Hash string
 }
 
-func (i *Indexer) ingestFile(ctx context.Context, src sourceFile) error {
+// ingestFile reads src from its last offset and returns how many messages
+// it stored. Sessions that gained messages are added to touched when it is
+// non-nil.
+func (i *Indexer) ingestFile(ctx context.Context, src sourceFile, touched map[string]bool) (int, error) {
 	stat, err := os.Stat(src.Path)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
-			return nil
+			return 0, nil
 		}
-		return fmt.Errorf("stat %s: %w", src.Path, err)
+		return 0, fmt.Errorf("stat %s: %w", src.Path, err)
 	}
 
 	meta, found, err := i.getIngestedMeta(src.Path)
 	if err != nil {
-		return err
+		return 0, err
 	}
 
+	file, err := os.Open(src.Path)
+	if err != nil {
+		return 0, fmt.Errorf("open %s: %w", src.Path, err)
+	}
+	defer file.Close()
+
 	var offset int64
 	needsReset := false
 	if found {
 		offset = meta.Offset
-		if stat.Size() < meta.Offset ||
-			stat.ModTime().Unix() < meta.Mtime ||
-			(stat.ModTime().Unix() != meta.Mtime && stat.Size() == meta.Size) {
-			needsReset = true
+		if needsReset, err = prefixChanged(file, stat, meta); err != nil {
+			return 0, err
+		}
+		if needsReset {
 			offset = 0
 		}
 	}
-	if !found {
-		offset = 0
-	}
-
-	file, err := os.Open(src.Path)
-	if err != nil {
-		return fmt.Errorf("open %s: %w", src.Path, err)
-	}
-	defer file.Close()
 
 	if _, err := file.Seek(offset, 0); err != nil {
-		return fmt.Errorf("seek %s: %w", src.Path, err)
+		return 0, fmt.Errorf("seek %s: %w", src.Path, err)
+	}
+	var lines io.Reader = file
+	if isArchive(src.Path) && offset < stat.Size() {
+		// An archive that changed was replaced; read it again in full.
+		needsReset, offset = found, 0
+		if _, err := file.Seek(0, 0); err != nil {
+			return 0, fmt.Errorf("seek %s: %w", src.Path, err)
+		}
+		zr, err := decompress(src.Path, file)
+		if err != nil {
+			return 0, err
+		}
+		defer zr.Close()
+		lines = zr
 	}
 
 	tx, err := i.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin ingest tx: %w", err)
+		return 0, fmt.Errorf("begin ingest tx: %w", err)
 	}
 	defer tx.Rollback()
 
 	if needsReset {
 		if _, err := tx.ExecContext(ctx, `DELETE FROM messages_fts WHERE rowid IN (SELECT id FROM messages WHERE source_path = ?);`, src.Path); err != nil {
-			return fmt.Errorf("clear stale fts rows for %s: %w", src.Path, err)
+			return 0, fmt.Errorf("clear stale fts rows for %s: %w", src.Path, err)
 		}
 		if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE source_path = ?;`, src.Path); err != nil {
-			return fmt.Errorf("clear stale rows for %s: %w", src.Path, err)
+			return 0, fmt.Errorf("clear stale rows for %s: %w", src.Path, err)
+		}
+		if err := deleteSourceScopedRows(ctx, tx, src.Path); err != nil {
+			return 0, err
 		}
 	}
 
 	insertMsgStmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO messages(session_id, ts, role, content, type, source, source_path, workdir)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO messages(session_id, ts, role, content, type, source, source_path, workdir, content_hash, call_id)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
 	`)
 	if err != nil {
-		return fmt.Errorf("prepare message insert: %w", err)
+		return 0, fmt.Errorf("prepare message insert: %w", err)
 	}
 	defer insertMsgStmt.Close()
 
@@ -245,31 +352,103 @@ func (i *Indexer) ingestFile(ctx context.Context, src sourceFile) error {
 		VALUES(?, ?, ?, ?)
 	`)
 	if err != nil {
-		return fmt.Errorf("prepare fts insert: %w", err)
+		return 0, fmt.Errorf("prepare fts insert: %w", err)
 	}
 	defer insertFTSStmt.Close()
 
-	scanner := bufio.NewScanner(file)
+	links, err := prepareClaudeLinkStmts(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer links.close()
+	seenUUIDs := make(map[string]struct{})
+	subagentStmt, err := prepareSubagentStmt(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer subagentStmt.Close()
+	snapshotStmt, err := prepareSnapshotStmt(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer snapshotStmt.Close()
+	usageStmt, err := prepareUsageStmt(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer usageStmt.Close()
+	branchStmt, err := prepareBranchStmt(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+	defer branchStmt.Close()
+	var codexModel string
+	sources, err := prepareSessionSourceTracker(ctx, tx, src.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer sources.close()
+
+	stored := 0
+	var bad badLines
+	pos := offset
+	scanner := bufio.NewScanner(lines)
 	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
 
 	for scanner.Scan() {
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return 0, ctx.Err()
 		default:
 		}
 
 		line := scanner.Bytes()
+		linePos := pos
+		pos += int64(len(line)) + 1
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
 		var events []parsedEvent
 		if src.Source == "claude" {
-			events, err = parseClaudeJSONLLine(line, src.Path)
+			var entry claudeEntry
+			entry, err = parseClaudeEntry(line, src.Path)
+			if err == nil {
+				links.record(ctx, entry, src.Path, seenUUIDs)
+				if entry.parentSession != "" {
+					_, _ = subagentStmt.ExecContext(ctx, entry.sessionID, entry.parentSession, src.Path)
+				}
+				for _, b := range entry.backups {
+					_, _ = snapshotStmt.ExecContext(ctx, entry.sessionID, b.path, b.version, b.backupFile, nullableTS(b.ts), src.Path)
+				}
+				if entry.usage != nil {
+					execUsage(ctx, usageStmt, entry.usage, "", src.Path)
+				}
+				if entry.branch != "" {
+					_, _ = branchStmt.ExecContext(ctx, entry.sessionID, entry.branch, src.Path)
+				}
+			}
+			events = entry.events
 		} else {
+			if rec, model := parseCodexUsage(line, src.Path); rec != nil {
+				execUsage(ctx, usageStmt, rec, codexModel, src.Path)
+			} else if model != "" {
+				codexModel = model
+			}
+			if sessionID, branch := parseCodexBranch(line, src.Path); branch != "" {
+				_, _ = branchStmt.ExecContext(ctx, sessionID, branch, src.Path)
+			}
 			events, err = parseJSONLLine(line, src.Path)
 		}
 		if err != nil {
+			bad.add(linePos, err)
 			continue
 		}
 		for _, evt := range events {
+			if i.normalizer != nil {
+				if evt.Content, err = i.normalizer.Normalize(evt.Role, evt.Type, evt.Content); err != nil {
+					return 0, fmt.Errorf("normalize %s: %w", src.Path, err)
+				}
+			}
 			if strings.TrimSpace(evt.Content) == "" {
 				continue
 			}
@@ -277,16 +456,26 @@ func (i *Indexer) ingestFile(ctx context.Context, src sourceFile) error {
 			if sessionID == "" {
 				sessionID = inferSessionIDFromPath(src.Path)
 			}
+			hash := messageHash(evt.Content)
+			dup, err := sources.duplicate(ctx, sessionID, hash, evt)
+			if err != nil {
+				return 0, err
+			}
+			if dup {
+				continue
+			}
 
 			res, err := insertMsgStmt.ExecContext(ctx,
 				sessionID,
 				nullableTS(evt.TS),
 				evt.Role,
-				evt.Content,
+				encodeContent(evt.Content, i.compress),
 				evt.Type,
 				src.Source,
 				src.Path,
 				evt.Workdir,
+				hash,
+				evt.CallID,
 			)
 			if err != nil {
 				continue
@@ -295,36 +484,55 @@ func (i *Indexer) ingestFile(ctx context.Context, src sourceFile) error {
 			if err != nil {
 				continue
 			}
-			_, _ = insertFTSStmt.ExecContext(ctx, rowID, sessionID, evt.Role, evt.Content)
+			if evt.Type != "image" {
+				_, _ = insertFTSStmt.ExecContext(ctx, rowID, sessionID, evt.Role, evt.Content)
+			}
+			stored++
+			if touched != nil {
+				touched[sessionID] = true
+			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("scan %s: %w", src.Path, err)
+		return 0, fmt.Errorf("scan %s: %w", src.Path, err)
 	}
 
+	hash := meta.Hash
+	if !found || needsReset || hash == "" || stat.Size() != meta.Offset {
+		if hash, err = prefixHash(file, stat.Size()); err != nil {
+			return 0, err
+		}
+	}
 	if _, err := tx.ExecContext(ctx, `
-		INSERT INTO ingested_files(path, mtime, size, offset, source)
-		VALUES(?, ?, ?, ?, ?)
+		INSERT INTO ingested_files(path, mtime, size, offset, source, prefix_hash)
+		VALUES(?, ?, ?, ?, ?, ?)
 		ON CONFLICT(path) DO UPDATE SET
 			mtime=excluded.mtime,
 			size=excluded.size,
 			offset=excluded.offset,
-			source=excluded.source
-	`, src.Path, stat.ModTime().Unix(), stat.Size(), stat.Size(), src.Source); err != nil {
-		return fmt.Errorf("update ingested file metadata: %w", err)
+			source=excluded.source,
+			prefix_hash=excluded.prefix_hash
+	`, src.Path, stat.ModTime().Unix(), stat.Size(), stat.Size(), src.Source, hash); err != nil {
+		return 0, fmt.Errorf("update ingested file metadata: %w", err)
+	}
+	if err := bad.record(ctx, tx, src.Path, needsReset); err != nil {
+		return 0, err
+	}
+	if bad.count > 0 {
+		i.log.Warn("unparseable lines left out", "path", src.Path, "lines", bad.count, "first_offset", bad.firstOffset, "first_err", bad.firstErr)
 	}
 
 	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit ingest %s: %w", src.Path, err)
+		return 0, fmt.Errorf("commit ingest %s: %w", src.Path, err)
 	}
-	return nil
+	return stored, nil
 }
 
 func (i *Indexer) getIngestedMeta(path string) (fileMeta, bool, error) {
-	row := i.db.QueryRow(`SELECT mtime, size, offset FROM ingested_files WHERE path = ?`, path)
+	row := i.db.QueryRow(`SELECT mtime, size, offset, COALESCE(prefix_hash, '') FROM ingested_files WHERE path = ?`, path)
 	var meta fileMeta
-	if err := row.Scan(&meta.Mtime, &meta.Size, &meta.Offset); err != nil {
+	if err := row.Scan(&meta.Mtime, &meta.Size, &meta.Offset, &meta.Hash); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fileMeta{}, false, nil
 		}
@@ -368,7 +576,35 @@ func (i *Indexer) pruneMissingSources(ctx context.Context, sources []sourceFile)
 	}
 	defer tx.Rollback()
 
+	// Copies of a session only store messages the first-read copy lacked, so
+	// when a file disappears, files sharing its sessions are re-read in full.
+	resetPaths := append([]string(nil), stale...)
 	for _, path := range stale {
+		siblings, err := siblingSourcePaths(ctx, tx, path)
+		if err != nil {
+			return err
+		}
+		for _, sib := range siblings {
+			if _, ok := keep[sib]; ok {
+				resetPaths = append(resetPaths, sib)
+			}
+		}
+	}
+
+	if err := forgetSources(ctx, tx, resetPaths); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit stale-source cleanup: %w", err)
+	}
+	i.log.Info("forgot missing source files", "missing", len(stale), "reread", len(resetPaths)-len(stale))
+	return nil
+}
+
+// forgetSources deletes everything ingested from paths, so the next ingest
+// reads them from the start.
+func forgetSources(ctx context.Context, tx *sql.Tx, paths []string) error {
+	for _, path := range paths {
 		if _, err := tx.ExecContext(ctx, `DELETE FROM messages_fts WHERE rowid IN (SELECT id FROM messages WHERE source_path = ?)`, path); err != nil {
 			return fmt.Errorf("delete stale fts for %s: %w", path, err)
 		}
@@ -378,10 +614,9 @@ func (i *Indexer) pruneMissingSources(ctx context.Context, sources []sourceFile)
 		if _, err := tx.ExecContext(ctx, `DELETE FROM ingested_files WHERE path = ?`, path); err != nil {
 			return fmt.Errorf("delete stale ingested metadata for %s: %w", path, err)
 		}
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit stale-source cleanup: %w", err)
+		if err := deleteSourceScopedRows(ctx, tx, path); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -430,30 +665,69 @@ func (i *Indexer) refreshSessions(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-
-		if _, err := tx.ExecContext(ctx, `
-			INSERT INTO sessions(id, source, last_activity_ts, message_count, workdir, preview)
-			VALUES(?, ?, ?, ?, ?, ?)
-			ON CONFLICT(id) DO UPDATE SET
-				source=excluded.source,
-				last_activity_ts=excluded.last_activity_ts,
-				message_count=excluded.message_count,
-				workdir=excluded.workdir,
-				preview=excluded.preview
-		`, session.ID, session.Source, session.LastActivityTS, session.MessageCount, session.Workdir, session.Preview); err != nil {
-			return fmt.Errorf("upsert session %s: %w", session.ID, err)
+		if err := upsertSession(ctx, tx, session); err != nil {
+			return err
 		}
 	}
 	if err := rows.Err(); err != nil {
 		return fmt.Errorf("iterate session ids: %w", err)
 	}
 
+	if err := refreshSessionHashes(ctx, tx); err != nil {
+		return err
+	}
+	if err := refreshSessionLinks(ctx, tx); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("commit refresh sessions: %w", err)
 	}
 	return nil
 }
 
+func upsertSession(ctx context.Context, tx *sql.Tx, session Session) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO sessions(id, source, last_activity_ts, message_count, workdir, preview, activity, branch)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			source=excluded.source,
+			last_activity_ts=excluded.last_activity_ts,
+			message_count=excluded.message_count,
+			workdir=excluded.workdir,
+			preview=excluded.preview,
+			activity=excluded.activity,
+			branch=excluded.branch
+	`, session.ID, session.Source, session.LastActivityTS, session.MessageCount, session.Workdir, session.Preview, session.Activity.encode(), session.Branch); err != nil {
+		return fmt.Errorf("upsert session %s: %w", session.ID, err)
+	}
+	return nil
+}
+
+// flushSessions summarizes just the given sessions mid-run, so a long first
+// index can list them early. Duplicate and subagent links, which need every
+// session, wait for the full refresh at the end of the run.
+func (i *Indexer) flushSessions(ctx context.Context, ids map[string]bool) error {
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin flush sessions tx: %w", err)
+	}
+	defer tx.Rollback()
+	for id := range ids {
+		session, err := i.computeSessionSummary(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := upsertSession(ctx, tx, session); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit flush sessions: %w", err)
+	}
+	return nil
+}
+
 func (i *Indexer) computeSessionSummary(ctx context.Context, tx *sql.Tx, sessionID string) (Session, error) {
 	session := Session{ID: sessionID}
 
@@ -500,7 +774,11 @@ func (i *Indexer) computeSessionSummary(ctx context.Context, tx *sql.Tx, session
 			session.Workdir = workdirFromClaudePath(sourcePath)
 		}
 	}
+	_ = tx.QueryRowContext(ctx, `SELECT branch FROM session_branches WHERE session_id = ?`, sessionID).Scan(&session.Branch)
 	session.Preview = trimPreview(pickSessionPreview(ctx, tx, sessionID))
+	if session.Activity, err = sessionActivity(ctx, tx, sessionID); err != nil {
+		return session, fmt.Errorf("activity for session %s: %w", sessionID, err)
+	}
 	return session, nil
 }
 
@@ -529,7 +807,7 @@ func pickSessionPreview(ctx context.Context, tx *sql.Tx, sessionID string) strin
 		{
 			sql: `
 				SELECT content FROM messages
-				WHERE session_id = ? AND role = 'user'
+				WHERE session_id = ? AND role = 'user' AND type != 'image'
 				ORDER BY id DESC
 				LIMIT 40
 			`,
@@ -544,7 +822,7 @@ func pickSessionPreview(ctx context.Context, tx *sql.Tx, sessionID string) strin
 		}
 		var candidate string
 		for rows.Next() {
-			if err := rows.Scan(&candidate); err != nil {
+			if err := rows.Scan(scanContent(&candidate)); err != nil {
 				continue
 			}
 			if isNonConversationalPreviewContent(candidate) {
@@ -572,7 +850,7 @@ func countConversationalMessages(ctx context.Context, tx *sql.Tx, sessionID stri
 	count := 0
 	for rows.Next() {
 		var role, content string
-		if err := rows.Scan(&role, &content); err != nil {
+		if err := rows.Scan(&role, scanContent(&content)); err != nil {
 			continue
 		}
 		content = strings.TrimSpace(content)
@@ -603,7 +881,7 @@ func hasRealUserMessage(ctx context.Context, tx *sql.Tx, sessionID string) (bool
 
 	for rows.Next() {
 		var content string
-		if err := rows.Scan(&content); err != nil {
+		if err := rows.Scan(scanContent(&content)); err != nil {
 			continue
 		}
 		if strings.TrimSpace(content) == "" {
@@ -630,7 +908,7 @@ func inferWorkdirFromSessionContent(ctx context.Context, tx *sql.Tx, sessionID s
 
 	for rows.Next() {
 		var content string
-		if err := rows.Scan(&content); err != nil {
+		if err := rows.Scan(scanContent(&content)); err != nil {
 			continue
 		}
 		if wd := extractWorkdirFromContent(content); wd != "" {
@@ -682,118 +960,264 @@ func looksLikePath(s string) bool {
 func (i *Indexer) ListSessions(query string, limit int) ([]Session, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
+	return i.listSessions(query, limit)
+}
 
+func (i *Indexer) listSessions(query string, limit int) ([]Session, error) {
 	if limit <= 0 {
 		limit = 200
 	}
 	query = strings.TrimSpace(query)
 
-	var rows *sql.Rows
-	var err error
 	if query == "" {
-		rows, err = i.db.Query(`
-			SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, '')
-			FROM sessions
-			WHERE COALESCE(message_count, 0) > 0
-			ORDER BY last_activity_ts DESC, id
-			LIMIT ?
-		`, limit)
-	} else {
-		rows, err = i.searchRows(query, limit)
-		if err != nil {
-			return nil, err
-		}
+		return i.listSessionsPage(nil, limit, sessionFilters{})
+	}
+	text, filters := splitFilters(query)
+	if text == "" {
+		return i.listSessionsPage(nil, limit, filters)
+	}
+	return i.searchSessions(text, limit, filters)
+}
+
+// SessionCursor marks the end of a page of the session list: the activity
+// time and id of its last session.
+type SessionCursor struct {
+	LastActivityTS int64
+	ID             string
+}
+
+// CursorAfter returns the cursor for the page following s.
+func CursorAfter(s Session) SessionCursor {
+	return SessionCursor{LastActivityTS: s.LastActivityTS, ID: s.ID}
+}
+
+// ListSessionsPage returns up to limit listed sessions, newest first,
+// starting after cursor (nil for the first page). Unlike offsets, cursors
+// stay put when sessions are added to the top of the list between pages.
+func (i *Indexer) ListSessionsPage(after *SessionCursor, limit int) ([]Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.listSessionsPage(after, limit, sessionFilters{})
+}
+
+func (i *Indexer) listSessionsPage(after *SessionCursor, limit int, filters sessionFilters) ([]Session, error) {
+	if limit <= 0 {
+		limit = 200
 	}
+	// Sessions without timestamps sort last, as 0.
+	where := i.listedCondition("")
+	args := []any{}
+	if after != nil {
+		where += ` AND (COALESCE(last_activity_ts, 0) < ? OR (COALESCE(last_activity_ts, 0) = ? AND id > ?))`
+		args = append(args, after.LastActivityTS, after.LastActivityTS, after.ID)
+	}
+	scope, scopeArgs := i.sessionConditions(filters)
+	where += scope
+	args = append(args, scopeArgs...)
+	rows, err := i.db.Query(`
+		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, ''), COALESCE(activity, ''), COALESCE(branch, '')
+		FROM sessions
+		WHERE `+where+`
+		ORDER BY COALESCE(last_activity_ts, 0) DESC, id
+		LIMIT ?
+	`, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("list sessions: %w", err)
 	}
+	return i.scanSessions(rows, nil)
+}
+
+// AllSessions returns every listed session, newest first, without the
+// limit ListSessions applies. Subagent and duplicate sessions are excluded.
+func (i *Indexer) AllSessions() ([]Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`
+		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, ''), COALESCE(activity, ''), COALESCE(branch, '')
+		FROM sessions
+		WHERE ` + i.listedCondition("") + `
+		ORDER BY last_activity_ts DESC, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list all sessions: %w", err)
+	}
+	return i.scanSessions(rows, nil)
+}
+
+// FirstActivity returns the earliest message timestamp of every session
+// that has one, keyed by session id.
+func (i *Indexer) FirstActivity() (map[string]int64, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`SELECT session_id, MIN(ts) FROM messages WHERE COALESCE(ts, 0) > 0 GROUP BY session_id`)
+	if err != nil {
+		return nil, fmt.Errorf("query first activity: %w", err)
+	}
+	defer rows.Close()
+	out := map[string]int64{}
+	for rows.Next() {
+		var id string
+		var ts int64
+		if err := rows.Scan(&id, &ts); err != nil {
+			return nil, fmt.Errorf("scan first activity: %w", err)
+		}
+		out[id] = ts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate first activity: %w", err)
+	}
+	return out, nil
+}
+
+// scanSessions reads session rows and attaches their usage. Search rows
+// end with the match count and a hit column that snippet turns into
+// Session.Snippet; snippet is nil for plain listings.
+func (i *Indexer) scanSessions(rows *sql.Rows, snippet func(string) string) ([]Session, error) {
 	defer rows.Close()
 
 	out := make([]Session, 0, 128)
 	for rows.Next() {
 		var s Session
-		if err := rows.Scan(&s.ID, &s.Source, &s.LastActivityTS, &s.MessageCount, &s.Workdir, &s.Preview); err != nil {
+		var activity, hit string
+		dest := []any{&s.ID, &s.Source, &s.LastActivityTS, &s.MessageCount, &s.Workdir, &s.Preview, &activity, &s.Branch}
+		if snippet != nil {
+			dest = append(dest, &s.Matches, &hit)
+		}
+		if err := rows.Scan(dest...); err != nil {
 			return nil, fmt.Errorf("scan session row: %w", err)
 		}
+		s.Activity = parseActivity(activity)
+		if snippet != nil {
+			s.Snippet = strings.Join(strings.Fields(snippet(hit)), " ")
+		}
 		out = append(out, s)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate session rows: %w", err)
 	}
+	if err := i.attachUsage(out); err != nil {
+		return nil, err
+	}
+	if err := i.attachModTimes(out); err != nil {
+		return nil, err
+	}
+	if err := i.attachSummaries(out); err != nil {
+		return nil, err
+	}
 	return out, nil
 }
 
-func (i *Indexer) searchRows(query string, limit int) (*sql.Rows, error) {
+// attachModTimes fills ModTime from the recorded mtimes of each session's
+// source files.
+func (i *Indexer) attachModTimes(sessions []Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	rows, err := i.db.Query(`
+		SELECT ss.session_id, MAX(f.mtime)
+		FROM session_sources ss JOIN ingested_files f ON f.path = ss.source_path
+		GROUP BY ss.session_id
+	`)
+	if err != nil {
+		return fmt.Errorf("query session file times: %w", err)
+	}
+	defer rows.Close()
+	mtimes := make(map[string]int64)
+	for rows.Next() {
+		var id string
+		var mtime sql.NullInt64
+		if err := rows.Scan(&id, &mtime); err != nil {
+			return fmt.Errorf("scan session file time: %w", err)
+		}
+		mtimes[id] = mtime.Int64
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate session file times: %w", err)
+	}
+	for n := range sessions {
+		sessions[n].ModTime = mtimes[sessions[n].ID]
+	}
+	return nil
+}
+
+// searchSessions lists the sessions matching query, best first, each with
+// a snippet of its first hit.
+func (i *Indexer) searchSessions(query string, limit int, filters sessionFilters) ([]Session, error) {
+	likeSnip := func(content string) string {
+		return likeSnippet(content, tokenizeSearchTerms(query), snippetTokens)
+	}
 	if i.ftsEnabled {
-		rows, err := i.searchRowsFTS(query, limit)
+		rows, err := i.searchRowsFTS(query, limit, filters)
 		if err == nil {
-			return rows, nil
+			return i.scanSessions(rows, func(s string) string { return s })
 		}
-		fallback, fbErr := i.searchRowsLike(query, limit)
+		fallback, fbErr := i.searchRowsLike(query, limit, filters)
 		if fbErr != nil {
 			return nil, fmt.Errorf("list sessions search (fts and fallback failed): fts=%w, fallback=%v", err, fbErr)
 		}
-		return fallback, nil
+		return i.scanSessions(fallback, likeSnip)
+	}
+	rows, err := i.searchRowsLike(query, limit, filters)
+	if err != nil {
+		return nil, err
 	}
-	return i.searchRowsLike(query, limit)
+	return i.scanSessions(rows, likeSnip)
 }
 
-func (i *Indexer) searchRowsFTS(query string, limit int) (*sql.Rows, error) {
-	ftsQuery := buildFTSQuery(query)
+func (i *Indexer) searchRowsFTS(query string, limit int, filters sessionFilters) (*sql.Rows, error) {
+	ftsQuery := buildFTSQuery(query, i.similarWords)
 	if ftsQuery == "" {
 		return nil, fmt.Errorf("empty fts query")
 	}
+	scope, args := i.scopedMessages(filters)
 	rows, err := i.db.Query(`
-		SELECT s.id, s.source, COALESCE(s.last_activity_ts, 0), COALESCE(s.message_count, 0), COALESCE(s.workdir, ''), COALESCE(s.preview, '')
+		SELECT s.id, s.source, COALESCE(s.last_activity_ts, 0), COALESCE(s.message_count, 0), COALESCE(s.workdir, ''), COALESCE(s.preview, ''), COALESCE(s.activity, ''), COALESCE(s.branch, ''), ranked.score,
+			COALESCE((SELECT snippet(messages_fts, 2, char(2), char(3), '…', ?) FROM messages_fts WHERE messages_fts MATCH ? AND rowid = ranked.first), '')
 		FROM sessions s
 		JOIN (
-			SELECT session_id, COUNT(*) AS score
+			SELECT session_id, COUNT(*) AS score, MIN(rowid) AS first
 			FROM messages_fts
-			WHERE messages_fts MATCH ?
+			WHERE messages_fts MATCH ?`+scope+`
 			GROUP BY session_id
 			ORDER BY score DESC
 			LIMIT ?
 		) ranked ON ranked.session_id = s.id
-		WHERE COALESCE(s.message_count, 0) > 0
+		WHERE `+i.listedCondition("s.")+`
 		ORDER BY ranked.score DESC, s.last_activity_ts DESC
-	`, ftsQuery, limit)
+	`, append(append([]any{snippetTokens, ftsQuery, ftsQuery}, args...), limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("fts query failed: %w", err)
 	}
 	return rows, nil
 }
 
-func (i *Indexer) searchRowsLike(query string, limit int) (*sql.Rows, error) {
-	terms := tokenizeSearchTerms(query)
-	if len(terms) == 0 {
-		terms = []string{strings.ToLower(strings.TrimSpace(query))}
-	}
-	if len(terms) == 0 || terms[0] == "" {
-		terms = []string{""}
+func (i *Indexer) searchRowsLike(query string, limit int, filters sessionFilters) (*sql.Rows, error) {
+	parsed, ok := parseSearchQuery(query)
+	if !ok {
+		parsed = queryNode{op: opTerm, term: strings.ToLower(strings.TrimSpace(query))}
 	}
+	cond, args := parsed.like("content")
 
 	var b strings.Builder
 	b.WriteString(`
-		SELECT s.id, s.source, COALESCE(s.last_activity_ts, 0), COALESCE(s.message_count, 0), COALESCE(s.workdir, ''), COALESCE(s.preview, '')
+		SELECT s.id, s.source, COALESCE(s.last_activity_ts, 0), COALESCE(s.message_count, 0), COALESCE(s.workdir, ''), COALESCE(s.preview, ''), COALESCE(s.activity, ''), COALESCE(s.branch, ''), ranked.score, COALESCE(ranked.content, '')
 		FROM sessions s
 		JOIN (
-			SELECT session_id, COUNT(*) AS score
-			FROM messages
+			SELECT session_id, COUNT(*) AS score, MIN(rowid), content
+			FROM messages_fts
 			WHERE `)
-	args := make([]any, 0, len(terms)+1)
-	for idx, term := range terms {
-		if idx > 0 {
-			b.WriteString(" OR ")
-		}
-		b.WriteString("LOWER(content) LIKE ?")
-		args = append(args, "%"+term+"%")
-	}
+	b.WriteString(cond)
+	scope, scopeArgs := i.scopedMessages(filters)
+	b.WriteString(scope)
+	args = append(args, scopeArgs...)
 	b.WriteString(`
 			GROUP BY session_id
 			ORDER BY score DESC
 			LIMIT ?
 		) ranked ON ranked.session_id = s.id
-		WHERE COALESCE(s.message_count, 0) > 0
+		WHERE ` + i.listedCondition("s.") + `
 		ORDER BY ranked.score DESC, s.last_activity_ts DESC
 	`)
 	args = append(args, limit)
@@ -804,58 +1228,114 @@ func (i *Indexer) searchRowsLike(query string, limit int) (*sql.Rows, error) {
 	return rows, nil
 }
 
-func buildFTSQuery(raw string) string {
-	parts := tokenizeSearchTerms(raw)
-	if len(parts) == 0 {
+// buildFTSQuery translates a search query into an FTS5 expression, with
+// ~terms widened to the similar words expand finds (plain terms when expand
+// is nil). It is empty when the query has no terms or FTS5 cannot express
+// it, in which case callers fall back to LIKE.
+func buildFTSQuery(raw string, expand func(string) ([]string, error)) string {
+	parsed, ok := parseSearchQuery(raw)
+	if !ok {
 		return ""
 	}
-	quoted := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p == "" {
-			continue
+	if expand != nil {
+		var err error
+		if parsed, err = parsed.expandFuzzy(expand); err != nil {
+			return ""
 		}
-		p = strings.ReplaceAll(p, `"`, "")
-		quoted = append(quoted, fmt.Sprintf(`"%s"*`, p))
 	}
-	return strings.Join(quoted, " AND ")
+	expr, err := parsed.fts()
+	if err != nil {
+		return ""
+	}
+	return expr
 }
 
+// tokenizeSearchTerms lists the words a search query looks for, without
+// operators and negated words.
 func tokenizeSearchTerms(raw string) []string {
-	parts := strings.Fields(strings.ToLower(strings.TrimSpace(raw)))
-	out := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		p = strings.Trim(p, "`\"'.,:;!?()[]{}<>|")
-		if p == "" {
-			continue
+	parsed, ok := parseSearchQuery(raw)
+	if !ok {
+		return nil
+	}
+	return parsed.terms()
+}
+
+// ListWorkdirs returns the distinct workdirs of listable sessions, most
+// recently active first.
+func (i *Indexer) ListWorkdirs() ([]WorkdirSummary, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	scope, args := workdirUnder("workdir", i.scope)
+	rows, err := i.db.Query(`
+		SELECT workdir, COUNT(*), COALESCE(MAX(last_activity_ts), 0)
+		FROM sessions
+		WHERE COALESCE(message_count, 0) > 0 AND COALESCE(workdir, '') != ''
+			AND id NOT IN (`+hiddenSessionIDsQuery+`)`+scope+`
+		GROUP BY workdir
+		ORDER BY MAX(last_activity_ts) DESC, workdir
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list workdirs: %w", err)
+	}
+	defer rows.Close()
+
+	var out []WorkdirSummary
+	for rows.Next() {
+		var w WorkdirSummary
+		if err := rows.Scan(&w.Path, &w.SessionCount, &w.LastActivityTS); err != nil {
+			return nil, fmt.Errorf("scan workdir row: %w", err)
 		}
-		out = append(out, p)
+		out = append(out, w)
 	}
-	return out
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate workdir rows: %w", err)
+	}
+	return out, nil
 }
 
 func (i *Indexer) GetSession(sessionID string) (Session, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
+	s, err := i.getSession(sessionID)
+	if err != nil {
+		return s, err
+	}
+	if s.SourcePaths, err = i.sessionSources(sessionID); err != nil {
+		return s, err
+	}
+	sessions := []Session{s}
+	if err := i.attachSummaries(sessions); err != nil {
+		return s, err
+	}
+	s = sessions[0]
+	s.Usage, err = i.sessionUsage(sessionID)
+	return s, err
+}
 
+func (i *Indexer) getSession(sessionID string) (Session, error) {
 	var s Session
+	var activity string
 	err := i.db.QueryRow(`
-		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, '')
+		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, ''), COALESCE(activity, ''), COALESCE(branch, '')
 		FROM sessions WHERE id = ?
-	`, sessionID).Scan(&s.ID, &s.Source, &s.LastActivityTS, &s.MessageCount, &s.Workdir, &s.Preview)
+	`, sessionID).Scan(&s.ID, &s.Source, &s.LastActivityTS, &s.MessageCount, &s.Workdir, &s.Preview, &activity, &s.Branch)
 	if err != nil {
 		return Session{}, err
 	}
+	s.Activity = parseActivity(activity)
 	return s, nil
 }
 
 func (i *Indexer) GetMessages(sessionID string) ([]Message, error) {
 	i.mu.Lock()
 	defer i.mu.Unlock()
+	return i.getMessages(sessionID)
+}
 
+func (i *Indexer) getMessages(sessionID string) ([]Message, error) {
 	rows, err := i.db.Query(`
-		SELECT id, session_id, ts, role, content, type, source, source_path, COALESCE(workdir, '')
+		SELECT id, session_id, ts, role, content, type, source, source_path, COALESCE(workdir, ''), COALESCE(call_id, '')
 		FROM messages
 		WHERE session_id = ?
 		ORDER BY CASE WHEN ts IS NULL THEN 1 ELSE 0 END, ts, id
@@ -863,12 +1343,94 @@ func (i *Indexer) GetMessages(sessionID string) ([]Message, error) {
 	if err != nil {
 		return nil, fmt.Errorf("query session messages: %w", err)
 	}
+	return scanMessages(rows)
+}
+
+// GetMessagesBefore returns up to limit messages of the session that come
+// before `before` in transcript order, or the newest ones when before is
+// nil, oldest first. older reports whether earlier messages remain, so
+// long transcripts can be loaded a window at a time from the end.
+func (i *Indexer) GetMessagesBefore(sessionID string, before *Message, limit int) (msgs []Message, older bool, err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	where, args := messagesBefore(sessionID, before)
+	rows, err := i.db.Query(`
+		SELECT id, session_id, ts, role, content, type, source, source_path, COALESCE(workdir, ''), COALESCE(call_id, '')
+		FROM messages
+		WHERE `+where+`
+		ORDER BY CASE WHEN ts IS NULL THEN 1 ELSE 0 END DESC, ts DESC, id DESC
+		LIMIT ?
+	`, append(args, limit+1)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("query session messages: %w", err)
+	}
+	msgs, err = scanMessages(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(msgs) > limit {
+		msgs, older = msgs[:limit], true
+	}
+	slices.Reverse(msgs)
+	return msgs, older, nil
+}
+
+// messagesBefore returns the condition keeping the session's messages that
+// come before `before` in transcript order (all of them when it is nil),
+// with its arguments. Transcript order puts untimestamped messages last,
+// then orders by ts and id.
+func messagesBefore(sessionID string, before *Message) (string, []any) {
+	where := `session_id = ?`
+	args := []any{sessionID}
+	switch {
+	case before == nil:
+	case before.TS.Valid:
+		where += ` AND ts IS NOT NULL AND (ts < ? OR (ts = ? AND id < ?))`
+		args = append(args, before.TS.Int64, before.TS.Int64, before.ID)
+	default:
+		where += ` AND (ts IS NOT NULL OR id < ?)`
+		args = append(args, before.ID)
+	}
+	return where, args
+}
+
+// CountPromptsBefore returns how many of the user's own prompts come before
+// `before` in the session's transcript, so a transcript loaded a window at
+// a time can number its turns from the start of the session.
+func (i *Indexer) CountPromptsBefore(sessionID string, before Message) (int, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	where, args := messagesBefore(sessionID, &before)
+	rows, err := i.db.Query(`SELECT content FROM messages WHERE `+where+` AND role = 'user' AND type = 'message'`, args...)
+	if err != nil {
+		return 0, fmt.Errorf("query earlier prompts: %w", err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		var content string
+		if err := rows.Scan(scanContent(&content)); err != nil {
+			return 0, fmt.Errorf("scan earlier prompt: %w", err)
+		}
+		if strings.TrimSpace(content) != "" && !isBoilerplateUserContent(content) {
+			n++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate earlier prompts: %w", err)
+	}
+	return n, nil
+}
+
+func scanMessages(rows *sql.Rows) ([]Message, error) {
 	defer rows.Close()
 
 	out := make([]Message, 0, 256)
 	for rows.Next() {
 		var m Message
-		if err := rows.Scan(&m.ID, &m.SessionID, &m.TS, &m.Role, &m.Content, &m.Type, &m.Source, &m.SourcePath, &m.Workdir); err != nil {
+		if err := rows.Scan(&m.ID, &m.SessionID, &m.TS, &m.Role, scanContent(&m.Content), &m.Type, &m.Source, &m.SourcePath, &m.Workdir, &m.CallID); err != nil {
 			return nil, fmt.Errorf("scan message row: %w", err)
 		}
 		out = append(out, m)
@@ -879,9 +1441,68 @@ func (i *Indexer) GetMessages(sessionID string) ([]Message, error) {
 	return out, nil
 }
 
+// DefaultTimeFormat is the layout FormatUnix uses unless SetTimeDisplay
+// changes it.
+const DefaultTimeFormat = "2006-01-02 15:04"
+
+var (
+	displayZone   = time.Local
+	displayFormat = DefaultTimeFormat
+)
+
+// SetTimeDisplay sets the time zone and layout FormatUnix uses everywhere:
+// the list, the status line, exports and CLI output. A nil zone is local
+// time and an empty layout is DefaultTimeFormat. Call it once at startup.
+func SetTimeDisplay(zone *time.Location, layout string) {
+	if zone == nil {
+		zone = time.Local
+	}
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+	displayZone, displayFormat = zone, layout
+}
+
+// DisplayZone is the time zone set by SetTimeDisplay.
+func DisplayZone() *time.Location {
+	return displayZone
+}
+
+// DisplayTime is ts in the zone set by SetTimeDisplay, for callers that
+// need their own layout.
+func DisplayTime(ts int64) time.Time {
+	return time.Unix(ts, 0).In(displayZone)
+}
+
 func FormatUnix(ts int64) string {
 	if ts <= 0 {
 		return "n/a"
 	}
-	return time.Unix(ts, 0).Local().Format("2006-01-02 15:04")
+	return DisplayTime(ts).Format(displayFormat)
+}
+
+// FormatAgo formats ts relative to now in its largest whole unit, such as
+// "5m ago", "2h ago" or "3d ago"; anything under a minute, or in the
+// future, is "just now".
+func FormatAgo(ts int64, now time.Time) string {
+	if ts <= 0 {
+		return "n/a"
+	}
+	d := now.Sub(time.Unix(ts, 0))
+	for _, u := range []struct {
+		size time.Duration
+		name string
+	}{
+		{365 * 24 * time.Hour, "y"},
+		{30 * 24 * time.Hour, "mo"},
+		{7 * 24 * time.Hour, "w"},
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+	} {
+		if d >= u.size {
+			return fmt.Sprintf("%d%s ago", int(d/u.size), u.name)
+		}
+	}
+	return "just now"
 }
@@ -0,0 +1,155 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListSessionsPageWalksTheWholeList(t *testing.T) {
+	claudeHome := t.TempDir()
+	// Two sessions share a timestamp so the id tiebreak is exercised.
+	stamps := []string{"10:00:00", "11:00:00", "11:00:00", "12:00:00", "13:00:00"}
+	for n, stamp := range stamps {
+		id := fmt.Sprintf("%08d-0000-0000-0000-000000000000", n)
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+			`{"type":"user","uuid":"`+id+`-u","sessionId":"`+id+`","timestamp":"2026-01-15T`+stamp+`Z","message":{"role":"user","content":"hello `+id+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	all, err := idx.ListSessions("", 10)
+	if err != nil || len(all) != len(stamps) {
+		t.Fatalf("list sessions: %d sessions, err %v", len(all), err)
+	}
+	var paged []string
+	var after *SessionCursor
+	for pages := 0; ; pages++ {
+		if pages > len(stamps) {
+			t.Fatal("paging did not terminate")
+		}
+		page, err := idx.ListSessionsPage(after, 2)
+		if err != nil {
+			t.Fatalf("list page: %v", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, s := range page {
+			paged = append(paged, s.ID)
+		}
+		c := CursorAfter(page[len(page)-1])
+		after = &c
+	}
+	var want []string
+	for _, s := range all {
+		want = append(want, s.ID)
+	}
+	if !reflect.DeepEqual(paged, want) {
+		t.Fatalf("pages = %v, want %v", paged, want)
+	}
+
+	// A session added at the top does not shift later pages.
+	first, _ := idx.ListSessionsPage(nil, 2)
+	c := CursorAfter(first[1])
+	newID := "99999999-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", newID+".jsonl"),
+		`{"type":"user","uuid":"new-u","sessionId":"`+newID+`","timestamp":"2026-01-16T10:00:00Z","message":{"role":"user","content":"hello again"}}`)
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	next, err := idx.ListSessionsPage(&c, 2)
+	if err != nil || len(next) != 2 || next[0].ID != want[2] {
+		t.Fatalf("expected the page after the cursor to start at %s, got %+v (err %v)", want[2], next, err)
+	}
+}
+
+func TestGetMessagesBeforeWalksBackwardsInWindows(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "31313131-3131-3131-3131-313131313131"
+	var lines []string
+	for n := 0; n < 7; n++ {
+		ts := fmt.Sprintf(`"timestamp":"2026-01-15T10:00:%02dZ",`, n)
+		if n >= 5 {
+			ts = "" // untimestamped messages sort last
+		}
+		lines = append(lines, fmt.Sprintf(`{"type":"user","uuid":"u%d","sessionId":"%s",%s"message":{"role":"user","content":"message %d"}}`, n, id, ts, n))
+	}
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"), lines...)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	all, err := idx.GetMessages(id)
+	if err != nil || len(all) != 7 {
+		t.Fatalf("get messages: %d, err %v", len(all), err)
+	}
+	var got []Message
+	var before *Message
+	for {
+		window, older, err := idx.GetMessagesBefore(id, before, 2)
+		if err != nil {
+			t.Fatalf("get messages before: %v", err)
+		}
+		got = append(append([]Message(nil), window...), got...)
+		if !older {
+			break
+		}
+		before = &window[0]
+	}
+	if len(got) != len(all) {
+		t.Fatalf("windows returned %d messages, want %d", len(got), len(all))
+	}
+	for n := range all {
+		if got[n].ID != all[n].ID {
+			t.Fatalf("message %d: got %q, want %q", n, got[n].Content, all[n].Content)
+		}
+	}
+	for n, want := range []int{0, 1, 2, 3, 4, 5, 6} {
+		if count, err := idx.CountPromptsBefore(id, all[n]); err != nil || count != want {
+			t.Fatalf("prompts before message %d = %d, %v; want %d", n, count, err, want)
+		}
+	}
+}
+
+func TestFormatAgo(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for _, c := range []struct {
+		ago  time.Duration
+		want string
+	}{
+		{-time.Minute, "just now"},
+		{30 * time.Second, "just now"},
+		{5 * time.Minute, "5m ago"},
+		{2*time.Hour + 59*time.Minute, "2h ago"},
+		{3 * 24 * time.Hour, "3d ago"},
+		{15 * 24 * time.Hour, "2w ago"},
+		{61 * 24 * time.Hour, "2mo ago"},
+		{400 * 24 * time.Hour, "1y ago"},
+	} {
+		if got := FormatAgo(now.Add(-c.ago).Unix(), now); got != c.want {
+			t.Errorf("FormatAgo(%v ago) = %q, want %q", c.ago, got, c.want)
+		}
+	}
+	if got := FormatAgo(0, now); got != "n/a" {
+		t.Errorf("FormatAgo(0) = %q", got)
+	}
+}
+
+func TestSetTimeDisplay(t *testing.T) {
+	t.Cleanup(func() { SetTimeDisplay(nil, "") })
+	ts := time.Date(2026, 3, 10, 23, 30, 0, 0, time.UTC).Unix()
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("no zoneinfo: %v", err)
+	}
+	SetTimeDisplay(tokyo, "Jan 2 15:04 MST")
+	if got := FormatUnix(ts); got != "Mar 11 08:30 JST" {
+		t.Errorf("FormatUnix = %q, want the Tokyo time in the custom layout", got)
+	}
+	SetTimeDisplay(time.UTC, "")
+	if got := FormatUnix(ts); got != "2026-03-10 23:30" {
+		t.Errorf("FormatUnix = %q, want UTC in the default layout", got)
+	}
+}
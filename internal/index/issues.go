@@ -0,0 +1,137 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// IngestIssue describes a source file that could not be read, or was read
+// with lines that did not parse.
+type IngestIssue struct {
+	Path string
+	// Error is why the file was skipped on its last read; empty when it
+	// was read.
+	Error string
+	// BadLines counts lines that failed to parse and were left out.
+	BadLines int
+	// FirstBadOffset is the byte offset of the first such line, and
+	// FirstBadError why it failed.
+	FirstBadOffset int64
+	FirstBadError  string
+	UpdatedTS      int64
+}
+
+// Describe renders the issue as short lines of plain text.
+func (is IngestIssue) Describe() []string {
+	var out []string
+	if is.Error != "" {
+		out = append(out, "skipped: "+is.Error)
+	}
+	if is.BadLines > 0 {
+		out = append(out, fmt.Sprintf("%d unparseable line(s) left out; first at byte %d: %s", is.BadLines, is.FirstBadOffset, is.FirstBadError))
+	}
+	return out
+}
+
+// badLines tallies unparseable lines during one ingest.
+type badLines struct {
+	count       int
+	firstOffset int64
+	firstErr    string
+}
+
+func (b *badLines) add(offset int64, err error) {
+	if b.count == 0 {
+		b.firstOffset, b.firstErr = offset, err.Error()
+	}
+	b.count++
+}
+
+// record stores the tally for path and clears any earlier read failure.
+// Counts add up across incremental reads unless the file was read from the
+// start, and the first bad line already on record is kept.
+func (b badLines) record(ctx context.Context, tx *sql.Tx, path string, reset bool) error {
+	if reset {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM ingest_issues WHERE source_path = ?`, path); err != nil {
+			return fmt.Errorf("clear ingest issues for %s: %w", path, err)
+		}
+	}
+	if b.count > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO ingest_issues(source_path, error, bad_lines, first_bad_offset, first_bad_error, updated_at)
+			VALUES(?, '', ?, ?, ?, ?)
+			ON CONFLICT(source_path) DO UPDATE SET
+				error = '',
+				bad_lines = ingest_issues.bad_lines + excluded.bad_lines,
+				first_bad_offset = CASE WHEN ingest_issues.bad_lines > 0 THEN ingest_issues.first_bad_offset ELSE excluded.first_bad_offset END,
+				first_bad_error = CASE WHEN ingest_issues.bad_lines > 0 THEN ingest_issues.first_bad_error ELSE excluded.first_bad_error END,
+				updated_at = excluded.updated_at
+		`, path, b.count, b.firstOffset, b.firstErr, time.Now().Unix()); err != nil {
+			return fmt.Errorf("record bad lines for %s: %w", path, err)
+		}
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ingest_issues SET error = '' WHERE source_path = ?`, path); err != nil {
+		return fmt.Errorf("clear ingest error for %s: %w", path, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ingest_issues WHERE source_path = ? AND bad_lines = 0`, path); err != nil {
+		return fmt.Errorf("clear ingest issues for %s: %w", path, err)
+	}
+	return nil
+}
+
+// recordIngestFailure notes why path was skipped. It is best effort: a
+// failure to record must not stop the index run.
+func (i *Indexer) recordIngestFailure(ctx context.Context, path string, err error) {
+	_, _ = i.db.ExecContext(ctx, `
+		INSERT INTO ingest_issues(source_path, error, updated_at) VALUES(?, ?, ?)
+		ON CONFLICT(source_path) DO UPDATE SET error = excluded.error, updated_at = excluded.updated_at
+	`, path, describeIngestError(err), time.Now().Unix())
+}
+
+// describeIngestError turns the common failures into something a user can
+// act on.
+func describeIngestError(err error) string {
+	switch {
+	case errors.Is(err, fs.ErrPermission):
+		return "permission denied"
+	case errors.Is(err, bufio.ErrTooLong):
+		return "a line is longer than 64 MB"
+	}
+	return err.Error()
+}
+
+// IngestIssues lists source files that were skipped or had unparseable
+// lines, ordered by path.
+func (i *Indexer) IngestIssues() ([]IngestIssue, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`
+		SELECT source_path, error, bad_lines, first_bad_offset, first_bad_error, updated_at
+		FROM ingest_issues
+		WHERE error != '' OR bad_lines > 0
+		ORDER BY source_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query ingest issues: %w", err)
+	}
+	defer rows.Close()
+	var out []IngestIssue
+	for rows.Next() {
+		var is IngestIssue
+		if err := rows.Scan(&is.Path, &is.Error, &is.BadLines, &is.FirstBadOffset, &is.FirstBadError, &is.UpdatedTS); err != nil {
+			return nil, fmt.Errorf("scan ingest issue: %w", err)
+		}
+		out = append(out, is)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate ingest issues: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIngestIssuesRecordBadLinesAndFailures(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "12121212-1212-1212-1212-121212121212"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	good := `{"type":"user","uuid":"u1","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}`
+	writeJSONL(t, path, good, `{"type":"user",`, "", good)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	issues, err := idx.IngestIssues()
+	if err != nil {
+		t.Fatalf("ingest issues: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("expected one issue, got %+v", issues)
+	}
+	is := issues[0]
+	if is.Path != path || is.BadLines != 1 || is.FirstBadOffset != int64(len(good)+1) || is.FirstBadError == "" || is.Error != "" {
+		t.Fatalf("unexpected issue %+v", is)
+	}
+
+	// A failed read is reported until the file is read again.
+	idx.recordIngestFailure(context.Background(), path, fmt.Errorf("open %s: %w", path, fs.ErrPermission))
+	issues, _ = idx.IngestIssues()
+	if len(issues) != 1 || issues[0].Error != "permission denied" || issues[0].BadLines != 1 {
+		t.Fatalf("expected the failure alongside the bad line, got %+v", issues)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(f, "not json")
+	f.Close()
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	issues, _ = idx.IngestIssues()
+	if len(issues) != 1 || issues[0].Error != "" || issues[0].BadLines != 2 || issues[0].FirstBadOffset != is.FirstBadOffset {
+		t.Fatalf("expected the error cleared and bad lines accumulated, got %+v", issues)
+	}
+
+	// Rewriting the file clean clears the entry.
+	writeJSONL(t, path, good)
+	if _, err := idx.RefreshSession(context.Background(), id); err != nil {
+		t.Fatalf("refresh session: %v", err)
+	}
+	if issues, _ = idx.IngestIssues(); len(issues) != 0 {
+		t.Fatalf("expected no issues after a clean reread, got %+v", issues)
+	}
+}
+
+func TestDescribeIngestError(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("open x: %w", fs.ErrPermission), "permission denied"},
+		{fmt.Errorf("scan x: %w", bufio.ErrTooLong), "a line is longer than 64 MB"},
+		{errors.New("disk on fire"), "disk on fire"},
+	} {
+		if got := describeIngestError(tc.err); got != tc.want {
+			t.Errorf("describeIngestError(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package index
+
+import (
+	"sort"
+	"time"
+)
+
+// Latency summarizes how long an agent took to start answering prompts.
+type Latency struct {
+	// Turns is how many prompts got a reply with both timestamps known.
+	Turns            int
+	Min, Median, Max time.Duration
+}
+
+// ResponseLatencies returns how long each prompt in msgs waited for the
+// agent: from the prompt to the next assistant message of any kind, a tool
+// call or thinking included. When prompts follow each other before a
+// reply, the wait counts from the last one. Messages without a timestamp
+// are skipped.
+func ResponseLatencies(msgs []Message) []time.Duration {
+	var out []time.Duration
+	var asked int64
+	for _, m := range msgs {
+		if !m.TS.Valid || m.TS.Int64 <= 0 {
+			continue
+		}
+		switch {
+		case m.Role == "user" && m.Type == "message" && !isBoilerplateUserMessage(m):
+			asked = m.TS.Int64
+		case m.Role == "assistant" && asked > 0:
+			if m.TS.Int64 >= asked {
+				out = append(out, time.Duration(m.TS.Int64-asked)*time.Second)
+			}
+			asked = 0
+		}
+	}
+	return out
+}
+
+// SummarizeLatencies returns the fewest, median and most of waits.
+func SummarizeLatencies(waits []time.Duration) Latency {
+	if len(waits) == 0 {
+		return Latency{}
+	}
+	sorted := append([]time.Duration(nil), waits...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+	n := len(sorted)
+	median := sorted[n/2]
+	if n%2 == 0 {
+		median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return Latency{Turns: n, Min: sorted[0], Median: median, Max: sorted[n-1]}
+}
@@ -0,0 +1,36 @@
+package index
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResponseLatencies(t *testing.T) {
+	at := func(ts int64) sql.NullInt64 { return sql.NullInt64{Int64: ts, Valid: true} }
+	msgs := []Message{
+		{Role: "user", Type: "message", Content: "<environment_context>zsh</environment_context>", TS: at(90)},
+		{Role: "user", Type: "message", Content: "fix the test", TS: at(100)},
+		{Role: "assistant", Type: "tool_use", Content: "go test", TS: at(104)},
+		{Role: "assistant", Type: "message", Content: "fixed", TS: at(130)},
+		{Role: "user", Type: "message", Content: "also the docs", TS: at(200)},
+		{Role: "user", Type: "message", Content: "and the changelog", TS: at(210)},
+		{Role: "assistant", Type: "message", Content: "done", TS: at(250)},
+		{Role: "user", Type: "message", Content: "thanks", TS: at(300)},
+		{Role: "assistant", Type: "message", Content: "untimed"},
+		{Role: "assistant", Type: "message", Content: "welcome", TS: at(302)},
+	}
+	want := []time.Duration{4 * time.Second, 40 * time.Second, 2 * time.Second}
+	if got := ResponseLatencies(msgs); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ResponseLatencies = %v, want %v", got, want)
+	}
+
+	got := SummarizeLatencies(append(want, 10*time.Second))
+	if got != (Latency{Turns: 4, Min: 2 * time.Second, Median: 7 * time.Second, Max: 40 * time.Second}) {
+		t.Errorf("SummarizeLatencies = %+v", got)
+	}
+	if got := SummarizeLatencies(nil); got != (Latency{}) {
+		t.Errorf("SummarizeLatencies(nil) = %+v", got)
+	}
+}
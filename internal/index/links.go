@@ -0,0 +1,175 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// sourceScopedTables hold rows derived from a single source file; they are
+// cleared alongside messages when that file is reset or disappears.
+var sourceScopedTables = []string{"claude_entries", "claude_refs", "claude_subagents", "claude_file_snapshots", "session_sources", "session_usage", "ingest_issues", "session_branches", "claude_titles"}
+
+func deleteSourceScopedRows(ctx context.Context, tx *sql.Tx, path string) error {
+	for _, table := range sourceScopedTables {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE source_path = ?`, path); err != nil {
+			return fmt.Errorf("clear %s rows for %s: %w", table, path, err)
+		}
+	}
+	return nil
+}
+
+type claudeLinkStmts struct {
+	entry *sql.Stmt
+	ref   *sql.Stmt
+	title *sql.Stmt
+}
+
+func prepareClaudeLinkStmts(ctx context.Context, tx *sql.Tx) (claudeLinkStmts, error) {
+	entry, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO claude_entries(uuid, session_id, source_path) VALUES(?, ?, ?)`)
+	if err != nil {
+		return claudeLinkStmts{}, fmt.Errorf("prepare claude entry insert: %w", err)
+	}
+	ref, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO claude_refs(session_id, uuid, kind, source_path) VALUES(?, ?, ?, ?)`)
+	if err != nil {
+		_ = entry.Close()
+		return claudeLinkStmts{}, fmt.Errorf("prepare claude ref insert: %w", err)
+	}
+	title, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO claude_titles(leaf_uuid, title, source_path) VALUES(?, ?, ?)`)
+	if err != nil {
+		_ = entry.Close()
+		_ = ref.Close()
+		return claudeLinkStmts{}, fmt.Errorf("prepare claude title insert: %w", err)
+	}
+	return claudeLinkStmts{entry: entry, ref: ref, title: title}, nil
+}
+
+func (s claudeLinkStmts) close() {
+	_ = s.entry.Close()
+	_ = s.ref.Close()
+	_ = s.title.Close()
+}
+
+// record stores the entry's uuid and any reference that may point into
+// another session: summary leaf uuids, and parent uuids not defined earlier
+// in the same file. A summary's text is kept as the title of the session
+// its leaf belongs to.
+func (s claudeLinkStmts) record(ctx context.Context, e claudeEntry, path string, seen map[string]struct{}) {
+	if e.uuid != "" {
+		seen[e.uuid] = struct{}{}
+		_, _ = s.entry.ExecContext(ctx, e.uuid, e.sessionID, path)
+	}
+	if e.leafUUID != "" {
+		_, _ = s.ref.ExecContext(ctx, e.sessionID, e.leafUUID, "summary", path)
+		if e.title != "" {
+			_, _ = s.title.ExecContext(ctx, e.leafUUID, e.title, path)
+		}
+	}
+	if e.parentUUID != "" {
+		if _, ok := seen[e.parentUUID]; !ok {
+			_, _ = s.ref.ExecContext(ctx, e.sessionID, e.parentUUID, "parent", path)
+		}
+	}
+}
+
+func refreshSessionLinks(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM session_links`); err != nil {
+		return fmt.Errorf("clear session links: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO session_links(session_id, parent_id, kind)
+		SELECT r.session_id, e.session_id, r.kind
+		FROM claude_refs r
+		JOIN claude_entries e ON e.uuid = r.uuid
+		WHERE e.session_id != r.session_id
+	`); err != nil {
+		return fmt.Errorf("rebuild session links: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO session_links(session_id, parent_id, kind)
+		SELECT session_id, parent_id, 'subagent' FROM claude_subagents
+	`); err != nil {
+		return fmt.Errorf("rebuild subagent links: %w", err)
+	}
+	// Identical transcripts under different ids collapse into the lowest id.
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO session_links(session_id, parent_id, kind)
+		SELECT h.session_id, c.canonical, 'duplicate'
+		FROM session_hashes h
+		JOIN (SELECT hash, MIN(session_id) AS canonical FROM session_hashes GROUP BY hash HAVING COUNT(*) > 1) c
+			ON c.hash = h.hash
+		WHERE h.session_id != c.canonical
+	`); err != nil {
+		return fmt.Errorf("rebuild duplicate links: %w", err)
+	}
+	return nil
+}
+
+// SessionChain returns the continuation thread containing sessionID, oldest
+// first. Sessions that were never continued yield a single-element chain.
+func (i *Indexer) SessionChain(sessionID string) ([]Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	visited := map[string]struct{}{sessionID: {}}
+	var ancestors []string
+	for cur := sessionID; ; {
+		var parent string
+		err := i.db.QueryRow(`
+			SELECT parent_id FROM session_links
+			WHERE session_id = ? AND kind IN ('parent', 'summary')
+			ORDER BY CASE kind WHEN 'parent' THEN 0 ELSE 1 END, parent_id
+			LIMIT 1
+		`, cur).Scan(&parent)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("walk session parents: %w", err)
+		}
+		if _, ok := visited[parent]; ok {
+			break
+		}
+		visited[parent] = struct{}{}
+		ancestors = append([]string{parent}, ancestors...)
+		cur = parent
+	}
+
+	ids := append(ancestors, sessionID)
+	for cur := sessionID; ; {
+		var child string
+		err := i.db.QueryRow(`
+			SELECT l.session_id FROM session_links l
+			LEFT JOIN sessions s ON s.id = l.session_id
+			WHERE l.parent_id = ? AND l.kind IN ('parent', 'summary')
+			ORDER BY COALESCE(s.last_activity_ts, 0), l.session_id
+			LIMIT 1
+		`, cur).Scan(&child)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("walk session children: %w", err)
+		}
+		if _, ok := visited[child]; ok {
+			break
+		}
+		visited[child] = struct{}{}
+		ids = append(ids, child)
+		cur = child
+	}
+
+	out := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		s, err := i.getSession(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load chained session %s: %w", id, err)
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
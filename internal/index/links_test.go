@@ -0,0 +1,91 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeJSONL(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func newTestIndexer(t *testing.T, codexHome string, claudeHomes ...string) *Indexer {
+	t.Helper()
+	idx, err := New([]string{codexHome}, claudeHomes, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	t.Cleanup(func() { _ = idx.Close() })
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	return idx
+}
+
+func TestSessionChainFollowsContinuations(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	first := "11111111-1111-1111-1111-111111111111"
+	second := "22222222-2222-2222-2222-222222222222"
+	writeJSONL(t, filepath.Join(proj, first+".jsonl"),
+		`{"type":"user","uuid":"u1","parentUuid":null,"sessionId":"`+first+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"start the task"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"`+first+`","timestamp":"2026-01-15T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"working"}]}}`,
+	)
+	writeJSONL(t, filepath.Join(proj, second+".jsonl"),
+		`{"type":"summary","summary":"Task in progress","leafUuid":"a1"}`,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","sessionId":"`+second+`","timestamp":"2026-01-15T11:00:00Z","message":{"role":"user","content":"keep going"}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"u2","sessionId":"`+second+`","timestamp":"2026-01-15T11:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"done"}]}}`,
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	for _, id := range []string{first, second} {
+		chain, err := idx.SessionChain(id)
+		if err != nil {
+			t.Fatalf("session chain: %v", err)
+		}
+		if len(chain) != 2 || chain[0].ID != first || chain[1].ID != second {
+			t.Fatalf("unexpected chain from %s: %v", id, chain)
+		}
+	}
+}
+
+func TestClaudeSummaryTitlesTheSessionOfItsLeaf(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	first := "11111111-1111-1111-1111-111111111111"
+	second := "22222222-2222-2222-2222-222222222222"
+	writeJSONL(t, filepath.Join(proj, first+".jsonl"),
+		`{"type":"summary","summary":"Early title","leafUuid":"u1"}`,
+		`{"type":"summary","summary":"Flaky websocket test fix","leafUuid":"a1"}`,
+		`{"type":"user","uuid":"u1","parentUuid":null,"sessionId":"`+first+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"fix the flaky test"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"`+first+`","timestamp":"2026-01-15T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"fixed"}]}}`,
+	)
+	writeJSONL(t, filepath.Join(proj, second+".jsonl"),
+		`{"type":"user","uuid":"u2","parentUuid":null,"sessionId":"`+second+`","timestamp":"2026-01-16T11:00:00Z","message":{"role":"user","content":"update the docs"}}`,
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	sessions, err := idx.ListSessions("", 10)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	titles := map[string]string{}
+	for _, s := range sessions {
+		titles[s.ID] = s.Title
+	}
+	if titles[first] != "Flaky websocket test fix" || titles[second] != "" {
+		t.Fatalf("titles = %v", titles)
+	}
+	if s, err := idx.GetSession(first); err != nil || s.Title != "Flaky websocket test fix" {
+		t.Fatalf("GetSession title = %q, %v", s.Title, err)
+	}
+}
@@ -0,0 +1,46 @@
+package index
+
+import (
+	"context"
+	"time"
+)
+
+// lockPollInterval is how often a waiting instance retries the write lock.
+const lockPollInterval = 100 * time.Millisecond
+
+// busyTimeoutMillis is how long a statement waits on another connection's
+// write before failing with SQLITE_BUSY.
+const busyTimeoutMillis = 5000
+
+// lockPath is the advisory lock file next to the index.
+func (i *Indexer) lockPath() string {
+	return i.dbPath + ".lock"
+}
+
+// writeLock elects this process as the index's single writer, waiting while
+// another agent-trace instance ingests. Without it two instances could read
+// the same ingest offset and store a file's new lines twice. Take it before
+// i.mu, never while holding i.mu: waiting for another instance's ingest
+// must not block this one's reads. The returned func releases the lock.
+func (i *Indexer) writeLock(ctx context.Context) (func(), error) {
+	if i.dbPath == MemoryPath {
+		return func() {}, nil
+	}
+	for waited := false; ; waited = true {
+		l, ok, err := tryLockFile(i.lockPath())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return l.unlock, nil
+		}
+		if !waited {
+			i.log.Debug("waiting for the index write lock", "lock", i.lockPath())
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package index
+
+// fileLock is a no-op where flock is unavailable; concurrent writers then
+// rely on SQLite's busy timeout alone.
+type fileLock struct{}
+
+func tryLockFile(path string) (*fileLock, bool, error) {
+	return &fileLock{}, true, nil
+}
+
+func (l *fileLock) unlock() {}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package index
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// fileLock is an exclusive advisory lock on a file. The kernel drops it
+// when the process exits, so a crashed instance never leaves it stuck.
+type fileLock struct {
+	f *os.File
+}
+
+// tryLockFile takes the lock on path without waiting; ok is false when
+// another process holds it.
+func tryLockFile(path string) (l *fileLock, ok bool, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, false, fmt.Errorf("open index lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("lock index: %w", err)
+	}
+	return &fileLock{f: f}, true, nil
+}
+
+func (l *fileLock) unlock() {
+	_ = syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	_ = l.f.Close()
+}
@@ -0,0 +1,141 @@
+//go:build unix
+
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBuildIndexWaitsForOtherWriter(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "index.sqlite")
+	idx, err := New([]string{t.TempDir()}, nil, dbPath, false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	// Another instance holds the write lock.
+	other, ok, err := tryLockFile(idx.lockPath())
+	if err != nil || !ok {
+		t.Fatalf("take lock: ok=%v err=%v", ok, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if _, err := idx.BuildIndex(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected BuildIndex to wait for the lock, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := idx.BuildIndex(context.Background())
+		done <- err
+	}()
+	time.Sleep(2 * lockPollInterval)
+	other.unlock()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("build after release: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BuildIndex did not proceed after the lock was released")
+	}
+}
+
+func TestRemoteSyncHoldsWriteLock(t *testing.T) {
+	idx, err := New([]string{t.TempDir()}, nil, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+	if err := idx.SetRemotes([]string{"me@devbox"}); err != nil {
+		t.Fatalf("set remotes: %v", err)
+	}
+	orig := runRsync
+	t.Cleanup(func() { runRsync = orig })
+	synced, locked := false, true
+	runRsync = func(context.Context, []string) error {
+		synced = true
+		if l, ok, err := tryLockFile(idx.lockPath()); err == nil && ok {
+			locked = false
+			l.unlock()
+		}
+		return nil
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if !synced || !locked {
+		t.Fatalf("synced %t; expected another instance kept out of the mirror", synced)
+	}
+}
+
+func TestVacuumWaitsForOtherWriter(t *testing.T) {
+	idx, err := New([]string{t.TempDir()}, nil, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	other, ok, err := tryLockFile(idx.lockPath())
+	if err != nil || !ok {
+		t.Fatalf("take lock: ok=%v err=%v", ok, err)
+	}
+	defer other.unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*lockPollInterval)
+	defer cancel()
+	if err := idx.Vacuum(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Vacuum to wait for the lock, got %v", err)
+	}
+}
+
+func TestReadsDoNotWaitForOtherWriter(t *testing.T) {
+	idx, err := New([]string{t.TempDir()}, nil, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	other, ok, err := tryLockFile(idx.lockPath())
+	if err != nil || !ok {
+		t.Fatalf("take lock: ok=%v err=%v", ok, err)
+	}
+	defer other.unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	writers := []func() error{
+		func() error { _, err := idx.BuildIndex(ctx); return err },
+		func() error { _, err := idx.Prune(ctx, time.Now(), true); return err },
+		func() error { _, err := idx.RefreshSession(ctx, "s1"); return err },
+		func() error { return idx.SetSummary(ctx, "s1", "summary", "model", 1) },
+	}
+	done := make(chan error, len(writers))
+	for _, w := range writers {
+		go func() { done <- w() }()
+	}
+	time.Sleep(2 * lockPollInterval)
+
+	read := make(chan error, 1)
+	go func() {
+		_, err := idx.ListSessions("", 10)
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatalf("list sessions: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ListSessions waited for another instance's write lock")
+	}
+	cancel()
+	for range writers {
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected the waiting writer canceled, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package index
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Snippets mark each hit with these bytes; callers style or strip them.
+const (
+	MatchStart = "\x02"
+	MatchEnd   = "\x03"
+)
+
+// snippetTokens is roughly how many words a snippet spans.
+const snippetTokens = 16
+
+// MessageMatch is a single message that matches a search query.
+type MessageMatch struct {
+	MessageID int64
+	SessionID string
+	TS        sql.NullInt64
+	Role      string
+	Type      string
+	// Snippet is an excerpt around the hit, on one line, with hits wrapped
+	// in MatchStart and MatchEnd.
+	Snippet string
+}
+
+// SearchMessages returns up to limit messages matching query across the
+// listed sessions, newest first. With full-text search the limit keeps the
+// best ranked matches; branch: filters and the scope apply as for
+// ListSessions.
+func (i *Indexer) SearchMessages(query string, limit int) ([]MessageMatch, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if limit <= 0 {
+		limit = 200
+	}
+	text, filters := splitFilters(strings.TrimSpace(query))
+	if text == "" {
+		return nil, nil
+	}
+	if i.ftsEnabled {
+		out, err := i.searchMessagesFTS(text, limit, filters)
+		if err == nil {
+			return out, nil
+		}
+		i.log.Warn("full-text message search failed; falling back to LIKE", "err", err)
+	}
+	return i.searchMessagesLike(text, limit, filters)
+}
+
+// listedMessages returns a condition keeping rows whose col names a session
+// that is listed and passes filters, with its arguments.
+func (i *Indexer) listedMessages(col string, filters sessionFilters) (string, []any) {
+	cond, args := i.sessionConditions(filters)
+	return ` AND ` + col + ` IN (SELECT id FROM sessions WHERE ` + i.listedCondition("") + cond + `)`, args
+}
+
+func (i *Indexer) searchMessagesFTS(text string, limit int, filters sessionFilters) ([]MessageMatch, error) {
+	ftsQuery := buildFTSQuery(text, i.similarWords)
+	if ftsQuery == "" {
+		return nil, fmt.Errorf("empty fts query")
+	}
+	listed, args := i.listedMessages("session_id", filters)
+	rows, err := i.db.Query(`
+		SELECT h.rowid, h.session_id, m.ts, COALESCE(m.role, ''), COALESCE(m.type, ''), h.snip
+		FROM (
+			SELECT rowid, session_id, snippet(messages_fts, 2, char(2), char(3), '…', ?) AS snip
+			FROM messages_fts
+			WHERE messages_fts MATCH ?`+listed+`
+			ORDER BY rank
+			LIMIT ?
+		) h
+		JOIN messages m ON m.id = h.rowid
+		ORDER BY m.ts DESC, m.id DESC
+	`, append(append([]any{snippetTokens, ftsQuery}, args...), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+	return scanMessageMatches(rows, func(s string) string { return s })
+}
+
+func (i *Indexer) searchMessagesLike(text string, limit int, filters sessionFilters) ([]MessageMatch, error) {
+	parsed, ok := parseSearchQuery(text)
+	if !ok {
+		return nil, nil
+	}
+	terms := parsed.terms()
+	listed, args := i.listedMessages("f.session_id", filters)
+	cond, likeArgs := parsed.like("f.content")
+	rows, err := i.db.Query(`
+		SELECT f.rowid, f.session_id, m.ts, COALESCE(m.role, ''), COALESCE(m.type, ''), f.content
+		FROM messages_fts f
+		JOIN messages m ON m.id = f.rowid
+		WHERE `+cond+listed+`
+		ORDER BY m.ts DESC, m.id DESC
+		LIMIT ?
+	`, append(append(likeArgs, args...), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+	return scanMessageMatches(rows, func(content string) string {
+		return likeSnippet(content, terms, snippetTokens)
+	})
+}
+
+// scanMessageMatches reads match rows, turning the last column into the
+// snippet with snippet.
+func scanMessageMatches(rows *sql.Rows, snippet func(string) string) ([]MessageMatch, error) {
+	defer rows.Close()
+	var out []MessageMatch
+	for rows.Next() {
+		var mm MessageMatch
+		var text string
+		if err := rows.Scan(&mm.MessageID, &mm.SessionID, &mm.TS, &mm.Role, &mm.Type, &text); err != nil {
+			return nil, fmt.Errorf("scan message match: %w", err)
+		}
+		mm.Snippet = strings.Join(strings.Fields(snippet(text)), " ")
+		out = append(out, mm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message matches: %w", err)
+	}
+	return out, nil
+}
+
+// likeSnippet is snippet() for the LIKE fallback: about words words of
+// content around the first term found, with every term marked.
+func likeSnippet(content string, terms []string, words int) string {
+	fields := strings.Fields(content)
+	first := -1
+	for n, f := range fields {
+		lower := strings.ToLower(f)
+		for _, t := range terms {
+			if strings.Contains(lower, t) {
+				first = n
+				break
+			}
+		}
+		if first >= 0 {
+			break
+		}
+	}
+	if first < 0 {
+		first = 0
+	}
+	start := max(first-words/4, 0)
+	end := min(start+words, len(fields))
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	for n := start; n < end; n++ {
+		if n > start {
+			b.WriteByte(' ')
+		}
+		b.WriteString(markTerms(fields[n], terms))
+	}
+	if end < len(fields) {
+		b.WriteString("…")
+	}
+	return b.String()
+}
+
+// markTerms wraps the first case-insensitive occurrence of any term in word.
+func markTerms(word string, terms []string) string {
+	lower := strings.ToLower(word)
+	for _, t := range terms {
+		if at := strings.Index(lower, t); at >= 0 && len(lower) == len(word) {
+			return word[:at] + MatchStart + word[at:at+len(t)] + MatchEnd + word[at+len(t):]
+		}
+	}
+	return word
+}
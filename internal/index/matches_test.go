@@ -0,0 +1,70 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchMessages(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "44444444-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"please deploy the widget to staging"}}`,
+		`{"type":"assistant","uuid":"a1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"The widget deploy finished."}]}}`,
+		`{"type":"user","uuid":"u2","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:02:00Z","message":{"role":"user","content":"thanks"}}`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	for _, fts := range []bool{true, false} {
+		idx.ftsEnabled = fts && idx.ftsEnabled
+		matches, err := idx.SearchMessages("widget", 10)
+		if err != nil {
+			t.Fatalf("fts=%v: %v", fts, err)
+		}
+		if len(matches) != 2 {
+			t.Fatalf("fts=%v: expected 2 matches, got %+v", fts, matches)
+		}
+		if matches[0].Role != "assistant" || matches[0].SessionID != id {
+			t.Errorf("fts=%v: expected the newest match first, got %+v", fts, matches[0])
+		}
+		if !strings.Contains(matches[1].Snippet, MatchStart+"widget"+MatchEnd) {
+			t.Errorf("fts=%v: hit not marked in %q", fts, matches[1].Snippet)
+		}
+	}
+}
+
+func TestLikeSnippet(t *testing.T) {
+	content := "one two three four five six seven eight nine Widget ten eleven twelve"
+	got := likeSnippet(content, []string{"widget"}, 4)
+	if want := "…nine " + MatchStart + "Widget" + MatchEnd + " ten eleven…"; got != want {
+		t.Fatalf("likeSnippet = %q, want %q", got, want)
+	}
+}
+
+func TestListSessionsSearchSnippet(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "55555555-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello there"}}`,
+		`{"type":"assistant","uuid":"a1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:01:00Z","message":{"role":"assistant","content":[{"type":"text","text":"The gizmo\nis ready."}]}}`,
+		`{"type":"user","uuid":"u2","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:02:00Z","message":{"role":"user","content":"ship the gizmo"}}`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	for _, fts := range []bool{true, false} {
+		idx.ftsEnabled = fts && idx.ftsEnabled
+		sessions, err := idx.ListSessions("gizmo", 10)
+		if err != nil || len(sessions) != 1 {
+			t.Fatalf("fts=%v: sessions = %+v, err %v", fts, sessions, err)
+		}
+		if sessions[0].Matches != 2 {
+			t.Errorf("fts=%v: matches = %d, want 2", fts, sessions[0].Matches)
+		}
+		if want := "The " + MatchStart + "gizmo" + MatchEnd + " is ready."; sessions[0].Snippet != want {
+			t.Errorf("fts=%v: snippet = %q, want %q", fts, sessions[0].Snippet, want)
+		}
+	}
+	sessions, _ := idx.ListSessions("", 10)
+	if len(sessions) != 1 || sessions[0].Snippet != "" || sessions[0].Matches != 0 {
+		t.Fatalf("expected no snippet without a query, got %+v", sessions)
+	}
+}
@@ -0,0 +1,34 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestInMemoryIndex(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "99999999-9999-9999-9999-999999999999"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello from ram"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`,
+	)
+	idx, err := New([]string{t.TempDir()}, []string{claudeHome}, MemoryPath, true)
+	if err != nil {
+		t.Fatalf("new in-memory indexer: %v", err)
+	}
+	defer idx.Close()
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	sessions, err := idx.ListSessions("ram", 10)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != id {
+		t.Fatalf("expected the session found in memory, got %+v (err %v)", sessions, err)
+	}
+	if msgs, err := idx.GetMessages(id); err != nil || len(msgs) != 2 {
+		t.Fatalf("expected 2 messages, got %d (err %v)", len(msgs), err)
+	}
+	if st, err := idx.Stats(context.Background()); err != nil || st.DBBytes != 0 {
+		t.Fatalf("expected no on-disk size, got %+v (err %v)", st, err)
+	}
+}
@@ -0,0 +1,276 @@
+package index
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migration is one schema change. Statements run in a single transaction
+// together with the version bump, so a failed upgrade leaves the previous
+// version intact.
+type migration struct {
+	name  string
+	stmts []string
+}
+
+// migrations upgrade the schema in order: migrations[n] takes the database
+// from version n to n+1, tracked in SQLite's user_version. Append new
+// migrations; never edit or reorder released ones. Columns added here keep
+// existing data, so users don't have to --reindex.
+//
+// The full-text table is managed by ensureFTSTable, since its shape depends
+// on whether the sqlite build has FTS5.
+var migrations = []migration{
+	{
+		// Databases created before versioning already have these tables;
+		// IF NOT EXISTS lets them adopt version 1 as they are.
+		name: "base schema",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS sessions (
+				id TEXT PRIMARY KEY,
+				source TEXT,
+				last_activity_ts INTEGER,
+				message_count INTEGER,
+				workdir TEXT,
+				preview TEXT
+			);`,
+			`CREATE TABLE IF NOT EXISTS messages (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				session_id TEXT,
+				ts INTEGER,
+				role TEXT,
+				content TEXT,
+				type TEXT,
+				source TEXT,
+				source_path TEXT,
+				workdir TEXT
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_messages_session_id ON messages(session_id);`,
+			`CREATE INDEX IF NOT EXISTS idx_messages_session_ts ON messages(session_id, ts, id);`,
+			`CREATE TABLE IF NOT EXISTS ingested_files (
+				path TEXT PRIMARY KEY,
+				mtime INTEGER,
+				size INTEGER,
+				offset INTEGER,
+				source TEXT
+			);`,
+			`CREATE TABLE IF NOT EXISTS meta (
+				key TEXT PRIMARY KEY,
+				value TEXT
+			);`,
+			`CREATE TABLE IF NOT EXISTS claude_entries (
+				uuid TEXT PRIMARY KEY,
+				session_id TEXT,
+				source_path TEXT
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_claude_entries_source_path ON claude_entries(source_path);`,
+			`CREATE TABLE IF NOT EXISTS claude_refs (
+				session_id TEXT,
+				uuid TEXT,
+				kind TEXT,
+				source_path TEXT,
+				PRIMARY KEY(session_id, uuid)
+			);`,
+			`CREATE TABLE IF NOT EXISTS claude_subagents (
+				session_id TEXT PRIMARY KEY,
+				parent_id TEXT,
+				source_path TEXT
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_claude_subagents_source_path ON claude_subagents(source_path);`,
+			`CREATE TABLE IF NOT EXISTS claude_file_snapshots (
+				session_id TEXT,
+				file_path TEXT,
+				version INTEGER,
+				backup_file TEXT,
+				ts INTEGER,
+				source_path TEXT,
+				PRIMARY KEY(session_id, file_path, version)
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_claude_file_snapshots_source_path ON claude_file_snapshots(source_path);`,
+			`CREATE TABLE IF NOT EXISTS session_sources (
+				session_id TEXT,
+				source_path TEXT,
+				PRIMARY KEY(session_id, source_path)
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_session_sources_source_path ON session_sources(source_path);`,
+			`CREATE TABLE IF NOT EXISTS session_hashes (
+				session_id TEXT PRIMARY KEY,
+				hash TEXT
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_session_hashes_hash ON session_hashes(hash);`,
+			`CREATE TABLE IF NOT EXISTS session_links (
+				session_id TEXT,
+				parent_id TEXT,
+				kind TEXT,
+				PRIMARY KEY(session_id, parent_id)
+			);`,
+			`CREATE TABLE IF NOT EXISTS session_usage (
+				session_id TEXT,
+				usage_key TEXT,
+				model TEXT NOT NULL DEFAULT '',
+				input_tokens INTEGER NOT NULL DEFAULT 0,
+				output_tokens INTEGER NOT NULL DEFAULT 0,
+				cache_read_tokens INTEGER NOT NULL DEFAULT 0,
+				cache_write_tokens INTEGER NOT NULL DEFAULT 0,
+				source_path TEXT,
+				PRIMARY KEY(session_id, usage_key)
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_session_usage_source_path ON session_usage(source_path);`,
+			`CREATE TABLE IF NOT EXISTS annotations (
+				session_id TEXT PRIMARY KEY,
+				alias TEXT NOT NULL DEFAULT '',
+				note TEXT NOT NULL DEFAULT '',
+				tags TEXT NOT NULL DEFAULT '',
+				bookmarked INTEGER NOT NULL DEFAULT 0,
+				updated_at INTEGER NOT NULL DEFAULT 0
+			);`,
+		},
+	},
+	{
+		// Rows from before this step keep a NULL hash; duplicate lookups
+		// fall back to comparing their content.
+		name: "message content hashes",
+		stmts: []string{
+			`ALTER TABLE messages ADD COLUMN content_hash TEXT;`,
+			`CREATE INDEX IF NOT EXISTS idx_messages_session_hash ON messages(session_id, content_hash);`,
+		},
+	},
+	{
+		// Filled by the next refresh, which every index pass runs.
+		name:  "session activity",
+		stmts: []string{`ALTER TABLE sessions ADD COLUMN activity TEXT;`},
+	},
+	{
+		name: "ingest issues",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS ingest_issues (
+				source_path TEXT PRIMARY KEY,
+				error TEXT NOT NULL DEFAULT '',
+				bad_lines INTEGER NOT NULL DEFAULT 0,
+				first_bad_offset INTEGER NOT NULL DEFAULT 0,
+				first_bad_error TEXT NOT NULL DEFAULT '',
+				updated_at INTEGER NOT NULL DEFAULT 0
+			);`,
+		},
+	},
+	{
+		// Branches appear as files are read; sessions indexed earlier get
+		// one when their file grows, on refresh, or with --reindex.
+		name: "session git branches",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS session_branches (
+				session_id TEXT PRIMARY KEY,
+				branch TEXT NOT NULL,
+				source_path TEXT
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_session_branches_source_path ON session_branches(source_path);`,
+			`ALTER TABLE sessions ADD COLUMN branch TEXT;`,
+		},
+	},
+	{
+		// Files keep the size and mtime checks until their next ingest
+		// records a hash.
+		name:  "ingested file prefix hashes",
+		stmts: []string{`ALTER TABLE ingested_files ADD COLUMN prefix_hash TEXT;`},
+	},
+	{
+		// Summaries cost a model call each, so clearing ingested data
+		// keeps them; message_count tells when one is stale.
+		name: "session summaries",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS session_summaries (
+				session_id TEXT PRIMARY KEY,
+				summary TEXT NOT NULL,
+				model TEXT NOT NULL,
+				message_count INTEGER NOT NULL,
+				created_at INTEGER NOT NULL
+			);`,
+		},
+	},
+	{
+		// Vectors are unit length, little-endian float32s.
+		name: "message embeddings",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS message_embeddings (
+				message_id INTEGER PRIMARY KEY,
+				model TEXT NOT NULL,
+				vector BLOB NOT NULL
+			);`,
+		},
+	},
+	{
+		// Sessions indexed by older builds pick up their title when their
+		// file is read again from the start, or with --reindex.
+		name: "claude session titles",
+		stmts: []string{
+			`CREATE TABLE IF NOT EXISTS claude_titles (
+				leaf_uuid TEXT,
+				title TEXT NOT NULL,
+				source_path TEXT,
+				PRIMARY KEY(leaf_uuid, source_path)
+			);`,
+			`CREATE INDEX IF NOT EXISTS idx_claude_titles_source_path ON claude_titles(source_path);`,
+		},
+	},
+	{
+		// Titles are looked up for the listed sessions only.
+		name:  "claude entry sessions",
+		stmts: []string{`CREATE INDEX IF NOT EXISTS idx_claude_entries_session_id ON claude_entries(session_id);`},
+	},
+	{
+		// Pasted images used to be stored as prompts. Claude files that
+		// may hold one are read again from the start on the next index
+		// pass, which stores them as images and drops them from search.
+		// Compressed content can't be told apart here, so files with any
+		// compressed prompt are read again too.
+		name: "claude image messages",
+		stmts: []string{
+			`UPDATE ingested_files SET mtime = -1, size = -1, prefix_hash = 'reread'
+			WHERE source = 'claude' AND path IN (
+				SELECT source_path FROM messages
+				WHERE role = 'user' AND type = 'message'
+					AND (content LIKE 'data:image/%' OR typeof(content) = 'blob')
+			);`,
+		},
+	},
+	{
+		// Tool calls indexed before this pair with their results in order
+		// until their file is read again from the start, or with --reindex.
+		name:  "tool call ids",
+		stmts: []string{`ALTER TABLE messages ADD COLUMN call_id TEXT;`},
+	},
+}
+
+// migrate applies the migrations the database has not seen yet. A database
+// from a newer build is rejected rather than guessed at.
+func migrate(db *sql.DB, migrations []migration) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("index schema version %d is newer than this build supports (%d); upgrade agent-trace or run with --reindex", version, len(migrations))
+	}
+	for n := version; n < len(migrations); n++ {
+		m := migrations[n]
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin migration %d (%s): %w", n+1, m.name, err)
+		}
+		for _, stmt := range m.stmts {
+			if _, err := tx.Exec(stmt); err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("migration %d (%s): %w", n+1, m.name, err)
+			}
+		}
+		// PRAGMA does not take bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, n+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("record schema version %d: %w", n+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit migration %d (%s): %w", n+1, m.name, err)
+		}
+	}
+	return nil
+}
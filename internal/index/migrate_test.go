@@ -0,0 +1,88 @@
+package index
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateAppliesPendingStepsInOrder(t *testing.T) {
+	db, err := sql.Open(sqliteDriver, filepath.Join(t.TempDir(), "m.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	version := func() int {
+		var v int
+		if err := db.QueryRow(`PRAGMA user_version`).Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	steps := []migration{
+		{name: "create", stmts: []string{`CREATE TABLE t (id INTEGER PRIMARY KEY)`, `INSERT INTO t(id) VALUES (1)`}},
+	}
+	if err := migrate(db, steps); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if version() != 1 {
+		t.Fatalf("version = %d, want 1", version())
+	}
+
+	// A later build adds a column; existing rows survive.
+	steps = append(steps, migration{name: "add column", stmts: []string{`ALTER TABLE t ADD COLUMN label TEXT NOT NULL DEFAULT 'x'`}})
+	if err := migrate(db, steps); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	var label string
+	if err := db.QueryRow(`SELECT label FROM t WHERE id = 1`).Scan(&label); err != nil || label != "x" {
+		t.Fatalf("label = %q (err %v)", label, err)
+	}
+
+	// A failing step rolls back with the version unchanged.
+	bad := append(steps, migration{name: "broken", stmts: []string{`CREATE TABLE u (id INTEGER)`, `NOT SQL`}})
+	if err := migrate(db, bad); err == nil || !strings.Contains(err.Error(), "migration 3 (broken)") {
+		t.Fatalf("expected migration 3 error, got %v", err)
+	}
+	if version() != 2 {
+		t.Fatalf("version = %d after failed migration, want 2", version())
+	}
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'u'`).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("failed migration left table u behind (n=%d, err %v)", n, err)
+	}
+
+	// An index written by a newer build is refused.
+	if err := migrate(db, steps[:1]); err == nil {
+		t.Fatal("expected an error for a newer schema")
+	}
+}
+
+func TestNewIndexerAdoptsUnversionedDatabase(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "index.sqlite")
+	db, err := sql.Open(sqliteDriver, dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate an index created before versioning: the base tables, no
+	// version recorded.
+	if err := migrate(db, migrations[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`PRAGMA user_version = 0`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	idx, err := New([]string{t.TempDir()}, nil, dbPath, false)
+	if err != nil {
+		t.Fatalf("reopen unversioned index: %v", err)
+	}
+	defer idx.Close()
+	var v int
+	if err := idx.db.QueryRow(`PRAGMA user_version`).Scan(&v); err != nil || v != len(migrations) {
+		t.Fatalf("version = %d (err %v), want %d", v, err, len(migrations))
+	}
+}
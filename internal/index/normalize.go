@@ -0,0 +1,206 @@
+package index
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Normalizer rewrites message content at ingest, before it is stored and
+// indexed for search. Returning "" drops the message.
+type Normalizer interface {
+	Normalize(role, typ, content string) (string, error)
+}
+
+// StripRule rewrites every match of Pattern with Replace (regexp.Expand
+// syntax, so $1 refers to capture groups). An empty Replace strips matches.
+type StripRule struct {
+	Pattern *regexp.Regexp
+	Replace string
+}
+
+// RuleNormalizer applies strip rules in order.
+type RuleNormalizer []StripRule
+
+func (r RuleNormalizer) Normalize(_, _ string, content string) (string, error) {
+	for _, rule := range r {
+		content = rule.Pattern.ReplaceAllString(content, rule.Replace)
+	}
+	return strings.TrimSpace(content), nil
+}
+
+// CommandNormalizer streams messages through an external process started on
+// first use. Each message is written to its stdin as one JSON line
+// ({"role","type","content"}); the process must answer each with one JSON
+// line ({"content"}) on stdout.
+type CommandNormalizer struct {
+	argv []string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	err    error
+}
+
+func NewCommandNormalizer(argv []string) *CommandNormalizer {
+	return &CommandNormalizer{argv: argv}
+}
+
+type normalizeRequest struct {
+	Role    string `json:"role"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+type normalizeResponse struct {
+	Content string `json:"content"`
+}
+
+func (c *CommandNormalizer) Normalize(role, typ, content string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return "", c.err
+	}
+	if c.cmd == nil {
+		if err := c.start(); err != nil {
+			c.err = err
+			return "", err
+		}
+	}
+
+	req, err := json.Marshal(normalizeRequest{Role: role, Type: typ, Content: content})
+	if err != nil {
+		return "", fmt.Errorf("encode normalize request: %w", err)
+	}
+	if _, err := c.stdin.Write(append(req, '\n')); err != nil {
+		c.err = fmt.Errorf("write to normalizer %s: %w", c.argv[0], err)
+		return "", c.err
+	}
+	line, err := c.stdout.ReadBytes('\n')
+	if err != nil {
+		c.err = fmt.Errorf("read from normalizer %s: %w", c.argv[0], err)
+		return "", c.err
+	}
+	var resp normalizeResponse
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.err = fmt.Errorf("decode normalizer %s output: %w", c.argv[0], err)
+		return "", c.err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func (c *CommandNormalizer) start() error {
+	if len(c.argv) == 0 {
+		return errors.New("normalizer command is empty")
+	}
+	cmd := exec.Command(c.argv[0], c.argv[1:]...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("normalizer stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("normalizer stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start normalizer %s: %w", c.argv[0], err)
+	}
+	c.cmd, c.stdin, c.stdout = cmd, stdin, bufio.NewReaderSize(stdout, 64*1024)
+	return nil
+}
+
+// Close stops the process, if it was started.
+func (c *CommandNormalizer) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cmd == nil {
+		return nil
+	}
+	_ = c.stdin.Close()
+	err := c.cmd.Wait()
+	c.cmd = nil
+	return err
+}
+
+// ChainNormalizer runs normalizers in order, stopping once content is empty.
+type ChainNormalizer []Normalizer
+
+func (c ChainNormalizer) Normalize(role, typ, content string) (string, error) {
+	for _, n := range c {
+		var err error
+		if content, err = n.Normalize(role, typ, content); err != nil {
+			return "", err
+		}
+		if content == "" {
+			break
+		}
+	}
+	return content, nil
+}
+
+// SetNormalizer installs n for subsequent ingests. fingerprint identifies the
+// normalization settings; when it differs from the one the index was built
+// with, ingested data is cleared so every source is re-read with the new rules.
+func (i *Indexer) SetNormalizer(n Normalizer, fingerprint string) error {
+	i.mu.Lock()
+	i.normalizer = n
+	stored, err := i.normalizeFingerprint()
+	i.mu.Unlock()
+	if err != nil || stored == fingerprint {
+		return err
+	}
+
+	unlock, err := i.writeLock(context.Background())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	// Another instance may have cleared the index while this one waited.
+	if stored, err := i.normalizeFingerprint(); err != nil || stored == fingerprint {
+		return err
+	}
+	if err := i.clearIngestedData(); err != nil {
+		return err
+	}
+	i.log.Info("normalization settings changed; cleared ingested data for a full re-read")
+	if _, err := i.db.Exec(`INSERT OR REPLACE INTO meta(key, value) VALUES('normalize_fingerprint', ?)`, fingerprint); err != nil {
+		return fmt.Errorf("store normalize fingerprint: %w", err)
+	}
+	return nil
+}
+
+// normalizeFingerprint returns the fingerprint of the normalization settings
+// the index was built with, or "" for none.
+func (i *Indexer) normalizeFingerprint() (string, error) {
+	var stored string
+	err := i.db.QueryRow(`SELECT value FROM meta WHERE key = 'normalize_fingerprint'`).Scan(&stored)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("read normalize fingerprint: %w", err)
+	}
+	return stored, nil
+}
+
+// clearIngestedData drops everything derived from source files so the next
+// BuildIndex re-reads them from the start.
+func (i *Indexer) clearIngestedData() error {
+	tables := append([]string{"messages_fts", "message_embeddings", "messages", "ingested_files", "sessions", "session_links"}, sourceScopedTables...)
+	for _, table := range tables {
+		if _, err := i.db.Exec(`DELETE FROM ` + table); err != nil {
+			return fmt.Errorf("clear %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNormalizerAppliedAtIngest(t *testing.T) {
+	claudeHome := t.TempDir()
+	session := "55555555-5555-5555-5555-555555555555"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", session+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+session+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"<harness run=\"7\">ignore</harness>fix the build"}}`,
+		`{"type":"user","uuid":"u2","sessionId":"`+session+`","timestamp":"2026-01-15T10:00:01Z","message":{"role":"user","content":"<harness run=\"8\">only envelope</harness>"}}`,
+	)
+
+	idx, err := New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+	rules := RuleNormalizer{{Pattern: regexp.MustCompile(`(?s)<harness[^>]*>.*?</harness>`)}}
+	if err := idx.SetNormalizer(rules, "v1"); err != nil {
+		t.Fatalf("set normalizer: %v", err)
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+
+	msgs, err := idx.GetMessages(session)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Content != "fix the build" {
+		t.Fatalf("expected envelope stripped and empty message dropped, got %+v", msgs)
+	}
+
+	// A new fingerprint clears the index so sources are re-read.
+	if err := idx.SetNormalizer(nil, ""); err != nil {
+		t.Fatalf("reset normalizer: %v", err)
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("rebuild index: %v", err)
+	}
+	msgs, _ = idx.GetMessages(session)
+	if len(msgs) != 2 || !strings.Contains(msgs[0].Content, "<harness") {
+		t.Fatalf("expected raw content after normalizer removed, got %+v", msgs)
+	}
+}
+
+func TestCommandNormalizer(t *testing.T) {
+	n := NewCommandNormalizer([]string{"sh", "-c", `while read -r line; do echo '{"content":"  clean  "}'; done`})
+	defer n.Close()
+	for range 2 {
+		got, err := n.Normalize("user", "message", "dirty")
+		if err != nil {
+			t.Fatalf("normalize: %v", err)
+		}
+		if got != "clean" {
+			t.Fatalf("got %q, want clean", got)
+		}
+	}
+}
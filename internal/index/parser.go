@@ -20,6 +20,8 @@ type parsedEvent struct {
 	Content   string
 	Type      string
 	Workdir   string
+	// CallID ties a tool call to its result, when the source records one.
+	CallID string
 }
 
 func parseJSONLLine(line []byte, sourcePath string) ([]parsedEvent, error) {
@@ -75,6 +77,23 @@ func parseJSONLLine(line []byte, sourcePath string) ([]parsedEvent, error) {
 		}}, nil
 	}
 
+	if isCodexReasoningType(typ) {
+		if typ == "reasoning" {
+			content = extractReasoningContent(obj)
+		}
+		if content == "" {
+			return nil, nil
+		}
+		return []parsedEvent{{
+			SessionID: sessionID,
+			TS:        timestamp,
+			Role:      "assistant",
+			Content:   content,
+			Type:      "reasoning",
+			Workdir:   workdir,
+		}}, nil
+	}
+
 	if typ == "user_message" {
 		if content == "" {
 			return nil, nil
@@ -106,9 +125,40 @@ func parseJSONLLine(line []byte, sourcePath string) ([]parsedEvent, error) {
 		Content:   content,
 		Type:      typ,
 		Workdir:   workdir,
+		CallID:    asString(firstByPath(obj, []string{"payload", "call_id"}, []string{"call_id"})),
 	}}, nil
 }
 
+// isCodexReasoningType reports whether a rollout record carries model
+// reasoning: the reasoning response item or its agent_reasoning event echoes.
+func isCodexReasoningType(typ string) bool {
+	switch typ {
+	case "reasoning", "agent_reasoning", "agent_reasoning_raw_content":
+		return true
+	}
+	return false
+}
+
+// extractReasoningContent joins the readable parts of a reasoning response
+// item: its summary, plus raw reasoning text when present. Encrypted content
+// is ignored.
+func extractReasoningContent(obj map[string]any) string {
+	var parts []string
+	for _, key := range []string{"summary", "content"} {
+		items, _ := firstByPath(obj, []string{"payload", key}).([]any)
+		for _, item := range items {
+			block, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if text := asString(block["text"]); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n"))
+}
+
 func extractSessionID(obj map[string]any, sourcePath string) string {
 	for _, path := range [][]string{
 		{"session_id"},
@@ -207,7 +257,7 @@ func extractContent(obj map[string]any) string {
 }
 
 func sessionIDFromPath(sourcePath string) string {
-	norm := filepath.ToSlash(sourcePath)
+	norm := filepath.ToSlash(logName(sourcePath))
 	if matches := rolloutPathRe.FindStringSubmatch(norm); len(matches) == 2 {
 		return matches[1]
 	}
@@ -9,39 +9,87 @@ import (
 
 var claudeSessionFileRe = regexp.MustCompile(`([0-9a-fA-F-]{36})\.jsonl$`)
 
+// claudeEntry is one parsed Claude JSONL record: its transcript events plus
+// the uuid links used to stitch continued sessions together.
+type claudeEntry struct {
+	events     []parsedEvent
+	sessionID  string
+	uuid       string
+	parentUUID string
+	leafUUID   string // set on summary records, pointing into the summarized session
+	title      string // the summary record's text, Claude Code's title for that session
+	// parentSession is set on subagent (sidechain) records, whose sessionID
+	// is rewritten so they are not merged into the parent transcript.
+	parentSession string
+	backups       []fileBackup // set on file-history-snapshot records
+	usage         *usageRecord // set on assistant records that report usage
+	branch        string       // git branch the record was written on
+}
+
 func parseClaudeJSONLLine(line []byte, sourcePath string) ([]parsedEvent, error) {
+	entry, err := parseClaudeEntry(line, sourcePath)
+	return entry.events, err
+}
+
+func parseClaudeEntry(line []byte, sourcePath string) (claudeEntry, error) {
 	var obj map[string]any
 	if err := json.Unmarshal(line, &obj); err != nil {
-		return nil, err
+		return claudeEntry{}, err
 	}
 
 	typ := asString(firstByPath(obj, []string{"type"}))
 
-	// Skip non-conversational types.
-	switch typ {
-	case "progress", "file-history-snapshot":
-		return nil, nil
-	}
-
 	sessionID := asString(firstByPath(obj, []string{"sessionId"}))
 	if sessionID == "" {
 		sessionID = claudeSessionIDFromPath(sourcePath)
 	}
+	var parentSession string
+	if sidechain, _ := obj["isSidechain"].(bool); sidechain {
+		agentID := asString(firstByPath(obj, []string{"agentId"}))
+		if agentID == "" {
+			agentID = claudeAgentIDFromPath(sourcePath)
+		}
+		if agentID != "" {
+			parentSession = sessionID
+			sessionID = claudeSubagentSessionID(sessionID, agentID)
+		}
+	}
+	entry := claudeEntry{
+		parentSession: parentSession,
+		sessionID:     sessionID,
+		uuid:          asString(firstByPath(obj, []string{"uuid"})),
+		parentUUID:    asString(firstByPath(obj, []string{"parentUuid"})),
+		branch:        asString(firstByPath(obj, []string{"gitBranch"})),
+	}
+
+	// Skip non-conversational types.
+	switch typ {
+	case "progress":
+		return entry, nil
+	case "file-history-snapshot":
+		entry.backups = parseClaudeFileBackups(obj)
+		return entry, nil
+	case "summary":
+		entry.leafUUID = asString(firstByPath(obj, []string{"leafUuid"}))
+		entry.title = strings.TrimSpace(asString(firstByPath(obj, []string{"summary"})))
+		return entry, nil
+	}
 
 	timestamp := parseClaudeTimestamp(obj)
 	workdir := asString(firstByPath(obj, []string{"cwd"}))
 
+	var err error
 	switch typ {
 	case "user":
-		return parseClaudeUserMessage(obj, sessionID, timestamp, workdir)
+		entry.events, err = parseClaudeUserMessage(obj, sessionID, timestamp, workdir)
 	case "assistant":
-		return parseClaudeAssistantMessage(obj, sessionID, timestamp, workdir)
+		entry.events, err = parseClaudeAssistantMessage(obj, sessionID, timestamp, workdir)
+		entry.usage = parseClaudeUsage(obj, sessionID, entry.uuid)
 	case "system":
-		return parseClaudeSystemMessage(obj, sessionID, timestamp, workdir)
+		entry.events, err = parseClaudeSystemMessage(obj, sessionID, timestamp, workdir)
 	}
-
-	// Unknown type — skip.
-	return nil, nil
+	// Unknown types carry no events.
+	return entry, err
 }
 
 func parseClaudeUserMessage(obj map[string]any, sessionID string, ts *int64, workdir string) ([]parsedEvent, error) {
@@ -74,7 +122,7 @@ func parseClaudeUserMessage(obj map[string]any, sessionID string, ts *int64, wor
 		return nil, nil
 	}
 
-	var events []parsedEvent
+	var events, images []parsedEvent
 	for _, item := range arr {
 		block, ok := item.(map[string]any)
 		if !ok {
@@ -87,13 +135,20 @@ func parseClaudeUserMessage(obj map[string]any, sessionID string, ts *int64, wor
 			if text == "" {
 				continue
 			}
+			// Failed, rejected and interrupted tool uses are kept apart so
+			// their commands are not mistaken for ones that succeeded.
+			typ := "tool_result"
+			if isError, _ := block["is_error"].(bool); isError {
+				typ = "tool_error"
+			}
 			events = append(events, parsedEvent{
 				SessionID: sessionID,
 				TS:        ts,
 				Role:      "tool",
 				Content:   text,
-				Type:      "tool_result",
+				Type:      typ,
 				Workdir:   workdir,
+				CallID:    asString(block["tool_use_id"]),
 			})
 		case "text":
 			text := strings.TrimSpace(asString(firstByPath(block, []string{"text"})))
@@ -108,9 +163,45 @@ func parseClaudeUserMessage(obj map[string]any, sessionID string, ts *int64, wor
 				Type:      "message",
 				Workdir:   workdir,
 			})
+		case "image":
+			// Pasted screenshots are kept as data URIs so they can be
+			// previewed and exported like inline images elsewhere. They
+			// are typed apart from prompts, so search, embeddings and
+			// turn numbering never see the base64, and follow the text
+			// they were pasted with.
+			uri := claudeImageDataURI(block)
+			if uri == "" {
+				continue
+			}
+			images = append(images, parsedEvent{
+				SessionID: sessionID,
+				TS:        ts,
+				Role:      "user",
+				Content:   uri,
+				Type:      "image",
+				Workdir:   workdir,
+			})
 		}
 	}
-	return events, nil
+	return append(events, images...), nil
+}
+
+// claudeImageDataURI renders a base64 image block as a data URI; images
+// referenced by URL or file are skipped.
+func claudeImageDataURI(block map[string]any) string {
+	source, _ := block["source"].(map[string]any)
+	if source == nil || asString(source["type"]) != "base64" {
+		return ""
+	}
+	data := asString(source["data"])
+	if data == "" {
+		return ""
+	}
+	mime := asString(source["media_type"])
+	if !strings.HasPrefix(mime, "image/") {
+		mime = "image/png"
+	}
+	return "data:" + mime + ";base64," + data
 }
 
 func parseClaudeAssistantMessage(obj map[string]any, sessionID string, ts *int64, workdir string) ([]parsedEvent, error) {
@@ -126,6 +217,7 @@ func parseClaudeAssistantMessage(obj map[string]any, sessionID string, ts *int64
 
 	var events []parsedEvent
 	var textParts []string
+	var thinkingParts []string
 
 	for _, item := range arr {
 		block, ok := item.(map[string]any)
@@ -139,6 +231,11 @@ func parseClaudeAssistantMessage(obj map[string]any, sessionID string, ts *int64
 			if text != "" {
 				textParts = append(textParts, text)
 			}
+		case "thinking":
+			text := strings.TrimSpace(asString(firstByPath(block, []string{"thinking"})))
+			if text != "" {
+				thinkingParts = append(thinkingParts, text)
+			}
 		case "tool_use":
 			name := asString(firstByPath(block, []string{"name"}))
 			input := firstByPath(block, []string{"input"})
@@ -151,6 +248,7 @@ func parseClaudeAssistantMessage(obj map[string]any, sessionID string, ts *int64
 					Content:   content,
 					Type:      "tool_use",
 					Workdir:   workdir,
+					CallID:    asString(block["id"]),
 				})
 			}
 		}
@@ -168,6 +266,18 @@ func parseClaudeAssistantMessage(obj map[string]any, sessionID string, ts *int64
 		}}, events...)
 	}
 
+	// Reasoning precedes the reply it led to.
+	if thinking := strings.TrimSpace(strings.Join(thinkingParts, "\n\n")); thinking != "" {
+		events = append([]parsedEvent{{
+			SessionID: sessionID,
+			TS:        ts,
+			Role:      "assistant",
+			Content:   thinking,
+			Type:      "thinking",
+			Workdir:   workdir,
+		}}, events...)
+	}
+
 	return events, nil
 }
 
@@ -223,7 +333,8 @@ func formatToolUse(name string, input any) string {
 		return name + "()"
 	}
 	s := string(b)
-	if len(s) > 500 {
+	// Shell commands are kept whole so they can be extracted verbatim.
+	if len(s) > 500 && name != "Bash" {
 		s = s[:497] + "..."
 	}
 	return name + ": " + s
@@ -238,7 +349,7 @@ func parseClaudeTimestamp(obj map[string]any) *int64 {
 }
 
 func claudeSessionIDFromPath(path string) string {
-	base := filepath.Base(path)
+	base := logName(filepath.Base(path))
 	if m := claudeSessionFileRe.FindStringSubmatch(base); len(m) == 2 {
 		return m[1]
 	}
@@ -58,6 +58,9 @@ func TestParseClaudeAssistantWithToolUse(t *testing.T) {
 	if !strings.HasPrefix(events[1].Content, "Read:") {
 		t.Errorf("event[1] content=%q, should start with 'Read:'", events[1].Content)
 	}
+	if events[1].CallID != "t1" {
+		t.Errorf("event[1] call id=%q, want t1", events[1].CallID)
+	}
 }
 
 func TestParseClaudeToolResult(t *testing.T) {
@@ -75,6 +78,17 @@ func TestParseClaudeToolResult(t *testing.T) {
 	if events[0].Content != "file contents here" {
 		t.Errorf("content=%q", events[0].Content)
 	}
+	if events[0].CallID != "t1" {
+		t.Errorf("call id=%q, want t1", events[0].CallID)
+	}
+}
+
+func TestParseClaudeToolErrorIsKeptApart(t *testing.T) {
+	line := `{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","is_error":true,"content":"The user doesn't want to proceed with this tool use."}]}}`
+	events, err := parseClaudeJSONLLine([]byte(line), "/fake.jsonl")
+	if err != nil || len(events) != 1 || events[0].Type != "tool_error" || events[0].Role != "tool" {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
 }
 
 func TestParseClaudeSkipsProgress(t *testing.T) {
@@ -165,3 +179,45 @@ func TestParseClaudeAssistantTextOnly(t *testing.T) {
 		t.Errorf("content=%q, expected both text blocks combined", events[0].Content)
 	}
 }
+
+func TestParseClaudeAssistantThinking(t *testing.T) {
+	line := `{"type":"assistant","sessionId":"s1","timestamp":"2026-01-15T10:31:00Z","message":{"role":"assistant","content":[{"type":"thinking","thinking":"The test is flaky because of a race.","signature":"x"},{"type":"text","text":"Fixed the race."}]}}`
+	events, err := parseClaudeJSONLLine([]byte(line), "/fake.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Type != "thinking" || events[0].Role != "assistant" || events[0].Content != "The test is flaky because of a race." {
+		t.Errorf("event[0] = %+v, want assistant thinking", events[0])
+	}
+	if events[1].Type != "message" || events[1].Content != "Fixed the race." {
+		t.Errorf("event[1] = %+v, want assistant message", events[1])
+	}
+
+	msgs := []Message{{Role: "assistant", Type: "thinking", Content: "hmm"}, {Role: "assistant", Type: "message", Content: "done"}}
+	if got := FilterMessages(msgs, TranscriptToggles{}); len(got) != 1 {
+		t.Errorf("expected thinking hidden by default, got %d messages", len(got))
+	}
+	if got := FilterMessages(msgs, TranscriptToggles{IncludeThinking: true}); len(got) != 2 {
+		t.Errorf("expected thinking shown when toggled, got %d messages", len(got))
+	}
+}
+
+func TestParseClaudeUserImageBlock(t *testing.T) {
+	line := `{"type":"user","sessionId":"s1","message":{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"AAAA"}},{"type":"text","text":"what is this?"}]}}`
+	events, err := parseClaudeJSONLLine([]byte(line), "/fake.jsonl")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected text and image events, got %d", len(events))
+	}
+	if events[0].Content != "what is this?" || events[0].Type != "message" {
+		t.Fatalf("unexpected text event: %+v", events[0])
+	}
+	if events[1].Content != "data:image/jpeg;base64,AAAA" || events[1].Role != "user" || events[1].Type != "image" {
+		t.Fatalf("unexpected image event: %+v", events[1])
+	}
+}
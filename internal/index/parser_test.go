@@ -53,3 +53,47 @@ func TestParseJSONLLine_EventMsgUserMessage(t *testing.T) {
 		t.Fatalf("expected content begin phase 4, got %q", e.Content)
 	}
 }
+
+func TestParseJSONLLine_ReasoningIsDistinctType(t *testing.T) {
+	path := "/home/u/.codex/sessions/2025/11/27/rollout-2025-11-27T09-23-19-019ac5e9-684f-7741-9974-4246554edb05.jsonl"
+	item := []byte(`{"timestamp":"2025-11-27T15:23:34Z","type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"**Planning**"},{"type":"summary_text","text":"Check the tests first."}],"content":null,"encrypted_content":"gAAA"}}`)
+	echo := []byte(`{"timestamp":"2025-11-27T15:23:34Z","type":"event_msg","payload":{"type":"agent_reasoning","text":"Check the tests first."}}`)
+
+	var msgs []Message
+	for _, line := range [][]byte{item, echo} {
+		events, err := parseJSONLLine(line, path)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if len(events) != 1 || events[0].Type != "reasoning" || events[0].Role != "assistant" {
+			t.Fatalf("expected one assistant reasoning event, got %+v", events)
+		}
+		msgs = append(msgs, Message{Role: events[0].Role, Type: events[0].Type, Content: events[0].Content})
+	}
+	if msgs[0].Content != "**Planning**\n\nCheck the tests first." {
+		t.Fatalf("unexpected reasoning content %q", msgs[0].Content)
+	}
+
+	if got := FilterMessages(msgs, TranscriptToggles{IncludeEvents: true}); len(got) != 0 {
+		t.Fatalf("expected reasoning hidden without its toggle, got %+v", got)
+	}
+	got := FilterMessages(msgs, TranscriptToggles{IncludeReasoning: true})
+	if len(got) != 1 || got[0].Content != msgs[0].Content {
+		t.Fatalf("expected the echoed summary part to be collapsed, got %+v", got)
+	}
+}
+
+func TestParseJSONLLine_ToolCallsKeepTheirCallID(t *testing.T) {
+	path := "/home/u/.codex/sessions/2025/11/27/rollout-2025-11-27T09-23-19-019ac5e9-684f-7741-9974-4246554edb05.jsonl"
+	call := []byte(`{"timestamp":"2025-11-27T15:23:34Z","type":"response_item","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\"]}","call_id":"call_a"}}`)
+	output := []byte(`{"timestamp":"2025-11-27T15:23:35Z","type":"response_item","payload":{"type":"function_call_output","call_id":"call_a","output":"{\"output\":\"a.go\"}"}}`)
+	for _, line := range [][]byte{call, output} {
+		events, err := parseJSONLLine(line, path)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		if len(events) != 1 || events[0].CallID != "call_a" {
+			t.Fatalf("expected one event with call id call_a, got %+v", events)
+		}
+	}
+}
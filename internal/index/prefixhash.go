@@ -0,0 +1,54 @@
+package index
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// prefixHashWindow is how much of each end of the ingested prefix
+// prefixHash reads, so checking a large file that grew stays cheap.
+const prefixHashWindow = 64 * 1024
+
+// prefixHash fingerprints the first n bytes of f: its first and last
+// prefixHashWindow bytes and n itself. Any rewrite of the part already
+// ingested that touches the start or the end of it changes the result,
+// which covers the truncate-and-rewrite and compaction cases sessions go
+// through, whatever the new size or mtime.
+func prefixHash(f *os.File, n int64) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", n)
+	head := min(n, prefixHashWindow)
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, head)); err != nil {
+		return "", fmt.Errorf("hash %s: %w", f.Name(), err)
+	}
+	if tail := max(head, n-prefixHashWindow); tail < n {
+		if _, err := io.Copy(h, io.NewSectionReader(f, tail, n-tail)); err != nil {
+			return "", fmt.Errorf("hash %s: %w", f.Name(), err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// prefixChanged reports whether the first meta.Offset bytes of f differ
+// from what was ingested. Rows recorded before hashes were kept fall back
+// to the size and mtime heuristics.
+func prefixChanged(f *os.File, stat os.FileInfo, meta fileMeta) (bool, error) {
+	if stat.Size() < meta.Offset {
+		return true, nil
+	}
+	if stat.Size() == meta.Size && stat.ModTime().Unix() == meta.Mtime {
+		return false, nil
+	}
+	if meta.Hash == "" {
+		return stat.ModTime().Unix() < meta.Mtime ||
+			(stat.ModTime().Unix() != meta.Mtime && stat.Size() == meta.Size), nil
+	}
+	hash, err := prefixHash(f, meta.Offset)
+	if err != nil {
+		return false, err
+	}
+	return hash != meta.Hash, nil
+}
@@ -0,0 +1,59 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIngestDetectsRewritesByPrefixHash(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "34343434-3434-3434-3434-343434343434"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	line := func(uuid, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	writeJSONL(t, path, line("u1", "first"), line("u2", "second"))
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	stat, _ := os.Stat(path)
+	build := func(mtime time.Time) []string {
+		t.Helper()
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := idx.BuildIndex(context.Background()); err != nil {
+			t.Fatalf("build index: %v", err)
+		}
+		msgs, _, err := idx.GetMessagesBefore(id, nil, 10)
+		if err != nil {
+			t.Fatalf("messages: %v", err)
+		}
+		var out []string
+		for _, m := range msgs {
+			out = append(out, m.Content)
+		}
+		return out
+	}
+
+	// An append whose mtime went backwards (clock skew) is read from where
+	// ingest stopped rather than from scratch.
+	writeJSONL(t, path, line("u1", "first"), line("u2", "second"), line("u3", "third"))
+	if got := build(stat.ModTime().Add(-time.Hour)); fmt.Sprint(got) != "[first second third]" {
+		t.Fatalf("after append = %v", got)
+	}
+
+	// A rewrite that grew the file, with a newer mtime, replaces what was
+	// read instead of being taken for an append.
+	writeJSONL(t, path, line("v1", "rewritten"), line("v2", "from"), line("v3", "scratch"), line("v4", "longer"))
+	if got := build(stat.ModTime().Add(time.Hour)); fmt.Sprint(got) != "[rewritten from scratch longer]" {
+		t.Fatalf("after rewrite = %v", got)
+	}
+
+	// Touching the file changes nothing.
+	if got := build(stat.ModTime().Add(2 * time.Hour)); fmt.Sprint(got) != "[rewritten from scratch longer]" {
+		t.Fatalf("after touch = %v", got)
+	}
+}
@@ -0,0 +1,76 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Prompt is a distinct prompt the user typed, folded across every session
+// it was sent in.
+type Prompt struct {
+	// Text is the most recent wording; copies differing only in case and
+	// whitespace fold into one.
+	Text string
+	// Uses counts how many times it was sent.
+	Uses int
+	// Sessions counts the sessions it was sent in.
+	Sessions int
+	// LastTS is when it was last sent (unix seconds), and SessionID where.
+	LastTS    int64
+	SessionID string
+}
+
+// Prompts returns the distinct real user prompts of the listed sessions,
+// most recently sent first, up to limit. Boilerplate and injected
+// instructions are skipped; the scope applies as for ListSessions.
+func (i *Indexer) Prompts(limit int) ([]Prompt, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	listed, args := i.listedMessages("session_id", sessionFilters{})
+	rows, err := i.db.Query(`
+		SELECT session_id, COALESCE(ts, 0), content
+		FROM messages
+		WHERE type = 'message' AND role = 'user'`+listed+`
+		ORDER BY ts DESC, id DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query prompts: %w", err)
+	}
+	defer rows.Close()
+
+	var out []Prompt
+	seen := make(map[string]int)
+	sessions := make(map[string]map[string]bool)
+	for rows.Next() {
+		var sessionID, content string
+		var ts int64
+		if err := rows.Scan(&sessionID, &ts, scanContent(&content)); err != nil {
+			return nil, fmt.Errorf("scan prompt: %w", err)
+		}
+		if isNonConversationalPreviewContent(content) {
+			continue
+		}
+		key := normalizeContent(content)
+		n, ok := seen[key]
+		if !ok {
+			// Rows come newest first, so the first copy is the latest.
+			n = len(out)
+			seen[key] = n
+			sessions[key] = make(map[string]bool)
+			out = append(out, Prompt{Text: strings.TrimSpace(content), LastTS: ts, SessionID: sessionID})
+		}
+		out[n].Uses++
+		if !sessions[key][sessionID] {
+			sessions[key][sessionID] = true
+			out[n].Sessions++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate prompts: %w", err)
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package index
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptsFoldsRepeatsAcrossSessions(t *testing.T) {
+	claudeHome := t.TempDir()
+	line := func(id, uuid, ts, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","cwd":"/src/app","timestamp":"` + ts + `","message":{"role":"user","content":"` + text + `"}}`
+	}
+	const a, b = "66666666-0000-0000-0000-000000000000", "77777777-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-src-app", a+".jsonl"),
+		line(a, "a1", "2026-01-15T10:00:00Z", "<environment_context>zsh</environment_context>"),
+		line(a, "a2", "2026-01-15T10:01:00Z", "review this diff for races"),
+		line(a, "a3", "2026-01-15T10:05:00Z", "run the tests"))
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-src-app", b+".jsonl"),
+		line(b, "b1", "2026-01-16T09:00:00Z", "Review this diff  for races"),
+		line(b, "b2", "2026-01-16T09:01:00Z", "review this diff for races"))
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	prompts, err := idx.Prompts(0)
+	if err != nil {
+		t.Fatalf("prompts: %v", err)
+	}
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 distinct prompts, got %+v", prompts)
+	}
+	p := prompts[0]
+	if p.Text != "review this diff for races" || p.Uses != 3 || p.Sessions != 2 || p.SessionID != b {
+		t.Errorf("first prompt = %+v", p)
+	}
+	if prompts[1].Text != "run the tests" || prompts[1].Uses != 1 {
+		t.Errorf("second prompt = %+v", prompts[1])
+	}
+	if limited, _ := idx.Prompts(1); len(limited) != 1 {
+		t.Errorf("limit 1: got %d prompts", len(limited))
+	}
+}
+
+func TestPastedImagesAreNotPrompts(t *testing.T) {
+	claudeHome := t.TempDir()
+	const id = "88888888-0000-0000-0000-000000000000"
+	image := `{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgoAAAANSUhEUg"}}`
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-src-app", id+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":[`+image+`]}}`,
+		`{"type":"user","uuid":"u2","sessionId":"`+id+`","cwd":"/src/app","timestamp":"2026-01-15T10:01:00Z","message":{"role":"user","content":[`+image+`,{"type":"text","text":"what is on this screen"}]}}`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	prompts, err := idx.Prompts(0)
+	if err != nil || len(prompts) != 1 || prompts[0].Text != "what is on this screen" {
+		t.Fatalf("prompts = %+v, %v; want the text prompt alone", prompts, err)
+	}
+	session, err := idx.GetSession(id)
+	if err != nil || session.Preview != "what is on this screen" {
+		t.Fatalf("session = %+v, %v; want the text prompt as preview", session, err)
+	}
+	if sessions, err := idx.ListSessions("iVBORw0KGgoAAAANSUhEUg", 10); err != nil || len(sessions) != 0 {
+		t.Fatalf("search for image data = %+v, %v; want no match", sessions, err)
+	}
+	msgs, _, err := idx.GetMessagesBefore(id, nil, 10)
+	if err != nil {
+		t.Fatalf("messages: %v", err)
+	}
+	var types []string
+	for _, m := range msgs {
+		types = append(types, m.Type)
+	}
+	if got := strings.Join(types, ","); got != "image,message,image" {
+		t.Fatalf("message types = %s, want the images kept after their text", got)
+	}
+	if n, err := idx.CountPromptsBefore(id, msgs[len(msgs)-1]); err != nil || n != 1 {
+		t.Fatalf("prompts before the last image = %d, %v; want 1", n, err)
+	}
+}
@@ -0,0 +1,129 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// PruneResult counts what Prune removed, or would remove in a dry run.
+type PruneResult struct {
+	Sessions int
+	Messages int
+}
+
+// sessionScopedTables hold rows keyed by the session they belong to; Prune
+// clears them along with the session's messages. Sessions, links and
+// hashes are rebuilt from what is left.
+var sessionScopedTables = []string{"session_usage", "claude_entries", "claude_refs", "claude_subagents", "claude_file_snapshots", "session_sources", "session_branches", "session_summaries"}
+
+// Prune deletes sessions whose last activity is before cutoff. Bookmarked
+// sessions and sessions without timestamps are kept, as are annotations.
+// Source files stay marked as ingested, so unchanged files are not read
+// back in; a file that is rewritten later is.
+func (i *Indexer) Prune(ctx context.Context, cutoff time.Time, dryRun bool) (PruneResult, error) {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return PruneResult{}, err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	var res PruneResult
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT s.id, (SELECT COUNT(*) FROM messages m WHERE m.session_id = s.id)
+		FROM sessions s
+		WHERE COALESCE(s.last_activity_ts, 0) > 0 AND s.last_activity_ts < ?
+			AND s.id NOT IN (SELECT session_id FROM annotations WHERE bookmarked = 1)
+		ORDER BY s.id
+	`, cutoff.Unix())
+	if err != nil {
+		return res, fmt.Errorf("query sessions to prune: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			rows.Close()
+			return res, fmt.Errorf("scan session to prune: %w", err)
+		}
+		ids = append(ids, id)
+		res.Messages += n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return res, fmt.Errorf("iterate sessions to prune: %w", err)
+	}
+	res.Sessions = len(ids)
+	if dryRun || len(ids) == 0 {
+		return res, nil
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("begin prune: %w", err)
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM messages_fts WHERE rowid IN (SELECT id FROM messages WHERE session_id = ?)`, id); err != nil {
+			return res, fmt.Errorf("prune fts rows of %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM message_embeddings WHERE message_id IN (SELECT id FROM messages WHERE session_id = ?)`, id); err != nil {
+			return res, fmt.Errorf("prune embeddings of %s: %w", id, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE session_id = ?`, id); err != nil {
+			return res, fmt.Errorf("prune messages of %s: %w", id, err)
+		}
+		// Titles are keyed by the entry they summarize, so they go before
+		// the session's entries do.
+		if _, err := tx.ExecContext(ctx, `DELETE FROM claude_titles WHERE leaf_uuid IN (SELECT uuid FROM claude_entries WHERE session_id = ?)`, id); err != nil {
+			return res, fmt.Errorf("prune claude_titles rows of %s: %w", id, err)
+		}
+		for _, table := range sessionScopedTables {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE session_id = ?`, id); err != nil {
+				return res, fmt.Errorf("prune %s rows of %s: %w", table, id, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return res, fmt.Errorf("commit prune: %w", err)
+	}
+	i.log.Info("pruned sessions", "sessions", res.Sessions, "messages", res.Messages, "cutoff", cutoff.Format(time.RFC3339))
+	return res, i.refreshSessions(ctx)
+}
+
+// Vacuum compacts the database and truncates the write-ahead log so freed
+// pages are returned to the filesystem. It waits for an indexing daemon
+// to finish its pass, as VACUUM needs the database to itself.
+func (i *Indexer) Vacuum(ctx context.Context) error {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if _, err := i.db.ExecContext(ctx, `VACUUM`); err != nil {
+		return fmt.Errorf("vacuum index: %w", err)
+	}
+	if _, err := i.db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return fmt.Errorf("checkpoint index: %w", err)
+	}
+	return nil
+}
+
+// DBSize returns the size of the database plus its WAL and shared-memory
+// files.
+func (i *Indexer) DBSize() int64 {
+	var n int64
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if info, err := os.Stat(i.dbPath + suffix); err == nil {
+			n += info.Size()
+		}
+	}
+	return n
+}
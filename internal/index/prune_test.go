@@ -0,0 +1,71 @@
+package index
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPruneDropsOldSessionsButKeepsBookmarks(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	session := func(id, ts string) {
+		writeJSONL(t, filepath.Join(proj, id+".jsonl"),
+			`{"type":"summary","summary":"title `+id+`","leafUuid":"u-`+id+`"}`,
+			`{"type":"user","uuid":"u-`+id+`","sessionId":"`+id+`","cwd":"/tmp/proj","timestamp":"`+ts+`","message":{"role":"user","content":"question `+id+`"}}`,
+			`{"type":"assistant","uuid":"a-`+id+`","sessionId":"`+id+`","timestamp":"`+ts+`","message":{"id":"msg-`+id+`","model":"m","role":"assistant","content":[{"type":"text","text":"answer"}],"usage":{"input_tokens":1,"output_tokens":1}}}`,
+		)
+	}
+	old := "11111111-0000-0000-0000-000000000001"
+	kept := "11111111-0000-0000-0000-000000000002"
+	recent := "11111111-0000-0000-0000-000000000003"
+	session(old, "2025-01-01T10:00:00Z")
+	session(kept, "2025-01-02T10:00:00Z")
+	session(recent, "2026-06-01T10:00:00Z")
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	if _, err := idx.SetAnnotation(Annotation{SessionID: kept, Bookmarked: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cutoff := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	res, err := idx.Prune(context.Background(), cutoff, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if res.Sessions != 1 || res.Messages != 2 {
+		t.Fatalf("dry run = %+v, want 1 session, 2 messages", res)
+	}
+	if all, _ := idx.AllSessions(); len(all) != 3 {
+		t.Fatalf("dry run changed the index: %d sessions", len(all))
+	}
+
+	if _, err := idx.Prune(context.Background(), cutoff, false); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if err := idx.Vacuum(context.Background()); err != nil {
+		t.Fatalf("vacuum: %v", err)
+	}
+	all, err := idx.AllSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ID != recent || all[1].ID != kept {
+		t.Fatalf("sessions after prune = %+v", all)
+	}
+	if usage, _ := idx.SessionUsage(old); len(usage) != 0 {
+		t.Fatalf("usage of pruned session left behind: %+v", usage)
+	}
+	var titles int
+	if err := idx.db.QueryRow(`SELECT COUNT(*) FROM claude_titles`).Scan(&titles); err != nil || titles != 2 {
+		t.Fatalf("titles after prune = %d, %v; want the pruned session's dropped", titles, err)
+	}
+
+	// Unchanged files are not read back in.
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := idx.AllSessions(); len(all) != 2 {
+		t.Fatalf("pruned session came back after BuildIndex: %d sessions", len(all))
+	}
+}
@@ -0,0 +1,280 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+)
+
+type queryOp int
+
+const (
+	opTerm queryOp = iota
+	opAnd
+	opOr
+	opNot
+)
+
+// queryNode is a parsed search query: a term, or an AND, OR or NOT of
+// sub-queries. Terms match as word prefixes; phrases match exactly.
+type queryNode struct {
+	op     queryOp
+	term   string
+	phrase bool
+	fuzzy  bool
+	kids   []queryNode
+}
+
+// parseSearchQuery parses words joined by implicit AND, "quoted phrases",
+// ~fuzzy words, the upper-case operators AND, OR and NOT, -term as NOT
+// term, and parenthesized groups. OR binds looser than AND. Stray operators and
+// parentheses are ignored, and an unclosed quote runs to the end. It
+// reports false when the query has no terms.
+func parseSearchQuery(raw string) (queryNode, bool) {
+	p := queryParser{tokens: lexQuery(raw)}
+	var groups []queryNode
+	for p.pos < len(p.tokens) {
+		if n, ok := p.or(); ok {
+			groups = append(groups, n)
+		} else {
+			p.pos++ // a stray ")" or operator
+		}
+	}
+	return joinNodes(opAnd, groups)
+}
+
+// lexQuery splits raw into words, "(" and ")", with -word split into "-"
+// and the word. A quoted phrase is one token that keeps its opening quote.
+func lexQuery(raw string) []string {
+	var out []string
+	for {
+		raw = strings.TrimLeft(raw, " \t\r\n")
+		if raw == "" {
+			return out
+		}
+		switch {
+		case raw[0] == '(' || raw[0] == ')':
+			out = append(out, raw[:1])
+			raw = raw[1:]
+		case raw[0] == '-' && len(raw) > 1 && raw[1] != ' ':
+			out = append(out, "-")
+			raw = raw[1:]
+		case raw[0] == '"':
+			end := strings.IndexByte(raw[1:], '"')
+			if end < 0 {
+				out = append(out, raw)
+				return out
+			}
+			out = append(out, raw[:end+1])
+			raw = raw[end+2:]
+		default:
+			end := strings.IndexAny(raw, " \t\r\n()\"")
+			if end < 0 {
+				end = len(raw)
+			}
+			out = append(out, raw[:end])
+			raw = raw[end:]
+		}
+	}
+}
+
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *queryParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *queryParser) or() (queryNode, bool) {
+	var kids []queryNode
+	for {
+		if n, ok := p.and(); ok {
+			kids = append(kids, n)
+		}
+		if p.peek() != "OR" {
+			return joinNodes(opOr, kids)
+		}
+		p.pos++
+	}
+}
+
+func (p *queryParser) and() (queryNode, bool) {
+	var kids []queryNode
+	for {
+		switch p.peek() {
+		case "", ")", "OR":
+			return joinNodes(opAnd, kids)
+		case "AND":
+			p.pos++
+			continue
+		}
+		if n, ok := p.unary(); ok {
+			kids = append(kids, n)
+		}
+	}
+}
+
+func (p *queryParser) unary() (queryNode, bool) {
+	switch tok := p.peek(); tok {
+	case "NOT", "-":
+		p.pos++
+		n, ok := p.unary()
+		if !ok {
+			return queryNode{}, false
+		}
+		return queryNode{op: opNot, kids: []queryNode{n}}, true
+	case "(":
+		p.pos++
+		n, ok := p.or()
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return n, ok
+	default:
+		p.pos++
+		if phrase, ok := strings.CutPrefix(tok, `"`); ok {
+			phrase = strings.ToLower(strings.Join(strings.Fields(phrase), " "))
+			return queryNode{op: opTerm, term: phrase, phrase: true}, phrase != ""
+		}
+		fuzzy := len(tok) > 1 && tok[0] == '~'
+		if fuzzy {
+			tok = tok[1:]
+		}
+		term := strings.ToLower(strings.Trim(tok, "`'.,:;!?[]{}<>|"))
+		return queryNode{op: opTerm, term: term, fuzzy: fuzzy}, term != ""
+	}
+}
+
+func joinNodes(op queryOp, kids []queryNode) (queryNode, bool) {
+	switch len(kids) {
+	case 0:
+		return queryNode{}, false
+	case 1:
+		return kids[0], true
+	}
+	return queryNode{op: op, kids: kids}, true
+}
+
+// terms lists the words the query looks for, leaving out negated ones.
+// Phrases contribute their words.
+func (n queryNode) terms() []string {
+	switch n.op {
+	case opTerm:
+		return strings.Fields(n.term)
+	case opNot:
+		return nil
+	}
+	var out []string
+	for _, k := range n.kids {
+		out = append(out, k.terms()...)
+	}
+	return out
+}
+
+// expandFuzzy replaces each ~term with an OR of the term and the similar
+// words expand finds for it.
+func (n queryNode) expandFuzzy(expand func(string) ([]string, error)) (queryNode, error) {
+	if n.op == opTerm {
+		if !n.fuzzy {
+			return n, nil
+		}
+		words, err := expand(n.term)
+		if err != nil {
+			return n, err
+		}
+		kids := []queryNode{{op: opTerm, term: n.term}}
+		for _, w := range words {
+			if w != n.term {
+				kids = append(kids, queryNode{op: opTerm, term: w, phrase: true})
+			}
+		}
+		out, _ := joinNodes(opOr, kids)
+		return out, nil
+	}
+	kids := make([]queryNode, len(n.kids))
+	for idx, k := range n.kids {
+		var err error
+		if kids[idx], err = k.expandFuzzy(expand); err != nil {
+			return n, err
+		}
+	}
+	n.kids = kids
+	return n, nil
+}
+
+// fts renders the query as an FTS5 expression with prefix terms. FTS5's
+// NOT is binary, so it fails for negations with nothing positive beside
+// them, such as a lone -term or NOT inside OR.
+func (n queryNode) fts() (string, error) {
+	switch n.op {
+	case opTerm:
+		if n.phrase {
+			return `"` + n.term + `"`, nil
+		}
+		return `"` + n.term + `"*`, nil
+	case opNot:
+		return "", fmt.Errorf("negation without a positive term")
+	case opOr:
+		parts := make([]string, len(n.kids))
+		for idx, k := range n.kids {
+			s, err := k.fts()
+			if err != nil {
+				return "", err
+			}
+			parts[idx] = s
+		}
+		return "(" + strings.Join(parts, " OR ") + ")", nil
+	}
+	var pos, neg []string
+	for _, k := range n.kids {
+		if k.op == opNot {
+			s, err := k.kids[0].fts()
+			if err != nil {
+				return "", err
+			}
+			neg = append(neg, s)
+			continue
+		}
+		s, err := k.fts()
+		if err != nil {
+			return "", err
+		}
+		pos = append(pos, s)
+	}
+	if len(pos) == 0 {
+		return "", fmt.Errorf("negation without a positive term")
+	}
+	out := strings.Join(pos, " AND ")
+	if len(neg) > 0 {
+		out = "(" + out + ") NOT (" + strings.Join(neg, " OR ") + ")"
+	}
+	return out, nil
+}
+
+// like renders the query as a condition on the lower-cased col, with its
+// arguments.
+func (n queryNode) like(col string) (string, []any) {
+	switch n.op {
+	case opTerm:
+		return "LOWER(" + col + ") LIKE ?", []any{"%" + n.term + "%"}
+	case opNot:
+		s, args := n.kids[0].like(col)
+		return "NOT (" + s + ")", args
+	}
+	sep := " AND "
+	if n.op == opOr {
+		sep = " OR "
+	}
+	parts := make([]string, len(n.kids))
+	var args []any
+	for idx, k := range n.kids {
+		s, a := k.like(col)
+		parts[idx] = s
+		args = append(args, a...)
+	}
+	return "(" + strings.Join(parts, sep) + ")", args
+}
@@ -0,0 +1,121 @@
+package index
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RawEvent is one source line behind a session's transcript.
+type RawEvent struct {
+	Path string
+	Line int // 1-based
+	Data string
+	// Err is why the line does not parse; such lines are always included,
+	// since they are what a raw view is for.
+	Err string
+}
+
+// RawEvents reads the session's source files and returns the lines that
+// belong to it, as the indexer attributes them. It stops once maxBytes of
+// lines are collected; truncated reports whether lines were left out.
+func (i *Indexer) RawEvents(sessionID string, maxBytes int) (events []RawEvent, truncated bool, err error) {
+	sources, err := i.sessionSourceFiles(sessionID)
+	if err != nil {
+		return nil, false, err
+	}
+	size := 0
+	for _, src := range sources {
+		file, err := os.Open(src.Path)
+		if err != nil {
+			return nil, false, fmt.Errorf("open %s: %w", src.Path, err)
+		}
+		lines, err := decompress(src.Path, file)
+		if err != nil {
+			file.Close()
+			return nil, false, err
+		}
+		scanner := bufio.NewScanner(lines)
+		scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			data := scanner.Bytes()
+			if len(bytes.TrimSpace(data)) == 0 {
+				continue
+			}
+			owner, perr := rawLineSession(src, data)
+			if perr == nil && owner != sessionID {
+				continue
+			}
+			if size+len(data) > maxBytes {
+				lines.Close()
+				file.Close()
+				return events, true, nil
+			}
+			size += len(data)
+			evt := RawEvent{Path: src.Path, Line: line, Data: string(data)}
+			if perr != nil {
+				evt.Err = perr.Error()
+			}
+			events = append(events, evt)
+		}
+		err = scanner.Err()
+		lines.Close()
+		file.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("scan %s: %w", src.Path, err)
+		}
+	}
+	return events, false, nil
+}
+
+// rawLineSession is the session a source line is ingested into.
+func rawLineSession(src sourceFile, line []byte) (string, error) {
+	if src.Source == "claude" {
+		entry, err := parseClaudeEntry(line, src.Path)
+		return entry.sessionID, err
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return "", err
+	}
+	if id := extractSessionID(obj, src.Path); id != "" {
+		return id, nil
+	}
+	return inferSessionIDFromPath(src.Path), nil
+}
+
+// sessionSourceFiles lists the files a session's own messages came from.
+func (i *Indexer) sessionSourceFiles(sessionID string) ([]sourceFile, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`
+		SELECT f.path, COALESCE(f.source, '') FROM ingested_files f
+		WHERE f.path IN (
+			SELECT source_path FROM messages WHERE session_id = ?1
+			UNION
+			SELECT source_path FROM session_sources WHERE session_id = ?1
+		)
+		ORDER BY f.path
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query session files: %w", err)
+	}
+	defer rows.Close()
+	var out []sourceFile
+	for rows.Next() {
+		var src sourceFile
+		if err := rows.Scan(&src.Path, &src.Source); err != nil {
+			return nil, fmt.Errorf("scan session file: %w", err)
+		}
+		out = append(out, src)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session files: %w", err)
+	}
+	return out, nil
+}
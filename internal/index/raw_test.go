@@ -0,0 +1,36 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRawEventsReturnsTheSessionsSourceLines(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "41414141-4141-4141-4141-414141414141"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	first := `{"type":"user","uuid":"u1","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}`
+	other := `{"type":"user","uuid":"u2","sessionId":"someone-else","timestamp":"2026-01-15T10:00:01Z","message":{"role":"user","content":"not mine"}}`
+	broken := `{"type":"assistant",`
+	writeJSONL(t, path, first, "", other, broken)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	events, truncated, err := idx.RawEvents(id, 1<<20)
+	if err != nil {
+		t.Fatalf("raw events: %v", err)
+	}
+	if truncated || len(events) != 2 {
+		t.Fatalf("expected the session's line and the broken one, got %+v (truncated %v)", events, truncated)
+	}
+	if events[0].Line != 1 || events[0].Data != first || events[0].Err != "" || events[0].Path != path {
+		t.Fatalf("unexpected first event %+v", events[0])
+	}
+	if events[1].Line != 4 || events[1].Err == "" {
+		t.Fatalf("expected line 4 flagged as unparseable, got %+v", events[1])
+	}
+
+	events, truncated, err = idx.RawEvents(id, len(first)+1)
+	if err != nil || !truncated || len(events) != 1 {
+		t.Fatalf("expected the listing cut after one line, got %d events (truncated %v, err %v)", len(events), truncated, err)
+	}
+}
@@ -0,0 +1,120 @@
+package index
+
+import (
+	"context"
+	"fmt"
+)
+
+// RefreshSession re-reads every source file of a session from the start,
+// bypassing the size and mtime checks BuildIndex relies on, then rebuilds
+// the session summaries. Files of duplicate copies and subagents are
+// included. It returns how many files were read.
+func (i *Indexer) RefreshSession(ctx context.Context, sessionID string) (int, error) {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	sources, err := i.refreshSources(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	if len(sources) == 0 {
+		return 0, fmt.Errorf("no source files recorded for session %s", sessionID)
+	}
+
+	tx, err := i.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin session refresh: %w", err)
+	}
+	defer tx.Rollback()
+	paths := make([]string, 0, len(sources))
+	for _, src := range sources {
+		paths = append(paths, src.Path)
+	}
+	if err := forgetSources(ctx, tx, paths); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit session refresh: %w", err)
+	}
+
+	for _, src := range sources {
+		if _, err := i.ingestFile(ctx, src, nil); err != nil {
+			return 0, err
+		}
+	}
+	if err := i.refreshSessions(ctx); err != nil {
+		return 0, err
+	}
+	i.log.Info("refreshed session", "session", sessionID, "files", len(sources))
+	return len(sources), nil
+}
+
+// refreshSources lists the files a session was read from, with the source
+// kind each was ingested as.
+func (i *Indexer) refreshSources(ctx context.Context, sessionID string) ([]sourceFile, error) {
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT f.path, COALESCE(f.source, '') FROM ingested_files f
+		WHERE f.path IN (
+			SELECT source_path FROM messages WHERE session_id = ?1
+			UNION
+			SELECT source_path FROM session_sources
+			WHERE session_id = ?1
+				OR session_id IN (SELECT session_id FROM session_links WHERE parent_id = ?1 AND kind IN ('duplicate', 'subagent'))
+		)
+		ORDER BY f.path
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query session files: %w", err)
+	}
+	defer rows.Close()
+	var out []sourceFile
+	for rows.Next() {
+		var src sourceFile
+		if err := rows.Scan(&src.Path, &src.Source); err != nil {
+			return nil, fmt.Errorf("scan session file: %w", err)
+		}
+		out = append(out, src)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session files: %w", err)
+	}
+	return out, nil
+}
+
+// FollowSession reads whatever was appended to a session's source files
+// since they were last ingested, for a session that is still being
+// written. Unlike RefreshSession nothing is re-read, and only sessions
+// that gained messages are summarized again. It returns how many messages
+// were stored.
+func (i *Indexer) FollowSession(ctx context.Context, sessionID string) (int, error) {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	sources, err := i.refreshSources(ctx, sessionID)
+	if err != nil {
+		return 0, err
+	}
+	touched := make(map[string]bool)
+	stored := 0
+	for _, src := range sources {
+		n, err := i.ingestFile(ctx, src, touched)
+		if err != nil {
+			return stored, err
+		}
+		stored += n
+	}
+	if len(touched) == 0 {
+		return stored, nil
+	}
+	return stored, i.flushSessions(ctx, touched)
+}
@@ -0,0 +1,146 @@
+package index
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRefreshSessionRereadsEditsHeuristicsMiss(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "77777777-7777-7777-7777-777777777777"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	line := func(text string) string {
+		return `{"type":"user","uuid":"u1","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"` + text + `"}}`
+	}
+	writeJSONL(t, path, line("first draft"))
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	// Same size and mtime: an in-place edit BuildIndex cannot detect.
+	writeJSONL(t, path, line("final draft"))
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	msgs, err := idx.GetMessages(id)
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "first draft" {
+		t.Fatalf("expected the stale message before refresh, got %+v (err %v)", msgs, err)
+	}
+
+	n, err := idx.RefreshSession(context.Background(), id)
+	if err != nil {
+		t.Fatalf("refresh session: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("refreshed %d files, want 1", n)
+	}
+	msgs, err = idx.GetMessages(id)
+	if err != nil || len(msgs) != 1 || msgs[0].Content != "final draft" {
+		t.Fatalf("expected the edited message after refresh, got %+v (err %v)", msgs, err)
+	}
+
+	if _, err := idx.RefreshSession(context.Background(), "no-such-session"); err == nil {
+		t.Fatal("expected an error for an unknown session")
+	}
+}
+
+func TestFollowSessionReadsAppendedLines(t *testing.T) {
+	claudeHome := t.TempDir()
+	id := "88888888-8888-8888-8888-888888888888"
+	path := filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl")
+	user := `{"type":"user","uuid":"u1","sessionId":"` + id + `","cwd":"/tmp/proj","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}`
+	reply := `{"type":"assistant","uuid":"a1","sessionId":"` + id + `","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`
+	writeJSONL(t, path, user)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+
+	if n, err := idx.FollowSession(context.Background(), id); err != nil || n != 0 {
+		t.Fatalf("expected nothing new, got %d (err %v)", n, err)
+	}
+	writeJSONL(t, path, user, reply)
+	n, err := idx.FollowSession(context.Background(), id)
+	if err != nil || n != 1 {
+		t.Fatalf("expected the appended reply, got %d (err %v)", n, err)
+	}
+	session, err := idx.GetSession(id)
+	if err != nil || session.MessageCount != 2 {
+		t.Fatalf("expected the summary updated to 2 messages, got %+v (err %v)", session, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listed, err := idx.ListSessions("", 10)
+	if err != nil || len(listed) != 1 || listed[0].ModTime != info.ModTime().Unix() {
+		t.Fatalf("expected the listing to carry the file's mtime %d, got %+v (err %v)", info.ModTime().Unix(), listed, err)
+	}
+}
+
+func TestBuildIndexProgressReportsFilesAndMessages(t *testing.T) {
+	claudeHome := t.TempDir()
+	for _, id := range []string{"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+			`{"type":"user","uuid":"`+id+`-u","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}`,
+			`{"type":"assistant","uuid":"`+id+`-a","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`,
+		)
+	}
+	idx, err := New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	var got []IndexProgress
+	if _, err := idx.BuildIndexProgress(context.Background(), func(p IndexProgress) { got = append(got, p) }); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected a report per file plus one before summarizing, got %+v", got)
+	}
+	if got[0].Files != 0 || got[0].TotalFiles != 2 || filepath.Ext(got[0].Path) != ".jsonl" {
+		t.Fatalf("unexpected first report: %+v", got[0])
+	}
+	if last := got[2]; last.Files != 2 || last.Path != "" || last.Messages != 4 {
+		t.Fatalf("unexpected final report: %+v", last)
+	}
+}
+
+func TestBuildIndexProgressFlushesSessionsMidRun(t *testing.T) {
+	defer func(d time.Duration) { sessionFlushInterval = d }(sessionFlushInterval)
+	sessionFlushInterval = 0
+
+	claudeHome := t.TempDir()
+	for _, id := range []string{"11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+			`{"type":"user","uuid":"`+id+`-u","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello"}}`,
+		)
+	}
+	idx, err := New([]string{t.TempDir()}, []string{claudeHome}, filepath.Join(t.TempDir(), "index.sqlite"), false)
+	if err != nil {
+		t.Fatalf("new indexer: %v", err)
+	}
+	defer idx.Close()
+
+	var flushed [][]Session
+	if _, err := idx.BuildIndexProgress(context.Background(), func(p IndexProgress) {
+		if p.Sessions != nil {
+			flushed = append(flushed, p.Sessions)
+		}
+	}); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	// Before the second file, the first file's session is already listed.
+	if len(flushed) != 1 || len(flushed[0]) != 1 || flushed[0][0].ID != "11111111-1111-1111-1111-111111111111" {
+		t.Fatalf("expected one interim list with the first session, got %+v", flushed)
+	}
+}
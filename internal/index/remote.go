@@ -0,0 +1,129 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// remote is a machine whose agent homes are mirrored next to the index
+// with rsync before each index pass.
+type remote struct {
+	target string // ssh destination, e.g. user@devbox
+	dir    string // local mirror holding codex/ and claude/
+}
+
+func (r remote) codexHome() string  { return filepath.Join(r.dir, "codex") }
+func (r remote) claudeHome() string { return filepath.Join(r.dir, "claude") }
+
+// remoteConnectTimeout bounds, in seconds, how long ssh waits for an
+// unreachable machine, so it cannot stall an index pass until TCP gives up.
+const remoteConnectTimeout = 10
+
+// remoteLogFilter keeps session logs, plain or compressed, and the
+// directories leading to them.
+var remoteLogFilter = []string{
+	"--include=*/", "--include=*.jsonl", "--include=*.jsonl.gz", "--include=*.jsonl.zst", "--exclude=*",
+}
+
+// runRsync runs rsync with args; tests replace it.
+var runRsync = func(ctx context.Context, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "rsync", args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// sync brings the mirror up to date: ~/.codex/sessions and history.jsonl
+// into codex/, ~/.claude/projects into claude/. Files gone from the remote
+// are removed, like local ones would be. rsync keeps mtimes and replaces
+// grown files with the same content plus the appended lines, so the next
+// index pass reads only what is new. A source that is off is not synced.
+func (r remote) sync(ctx context.Context, codex, claude bool) error {
+	for _, c := range []struct {
+		on      bool
+		dest    string
+		sources []string
+	}{
+		{codex, r.codexHome(), []string{".codex/sessions", ".codex/history.jsonl"}},
+		{claude, r.claudeHome(), []string{".claude/projects"}},
+	} {
+		if !c.on {
+			continue
+		}
+		if err := os.MkdirAll(c.dest, 0o755); err != nil {
+			return fmt.Errorf("create mirror for %s: %w", r.target, err)
+		}
+		args := []string{"-a", "--delete", "--prune-empty-dirs", "--ignore-missing-args", "-e", fmt.Sprintf("ssh -o BatchMode=yes -o ConnectTimeout=%d", remoteConnectTimeout)}
+		args = append(args, remoteLogFilter...)
+		for _, src := range c.sources {
+			args = append(args, r.target+":"+src)
+		}
+		args = append(args, c.dest+string(filepath.Separator))
+		if err := runRsync(ctx, args); err != nil {
+			return fmt.Errorf("sync %s from %s: %w", strings.Join(c.sources, ", "), r.target, err)
+		}
+	}
+	return nil
+}
+
+// validateRemote checks an ssh destination given to --remote.
+func validateRemote(target string) error {
+	if target == "" || strings.HasPrefix(target, "-") || strings.ContainsAny(target, " \t\n:/") {
+		return fmt.Errorf("remote must be an ssh destination such as user@devbox, got %q", target)
+	}
+	return nil
+}
+
+// SetRemotes mirrors the agent homes of each ssh destination (user@host,
+// or a Host alias from ~/.ssh/config) into remotes/ next to the index and
+// indexes them along with the local ones. Each index pass syncs first; an
+// unreachable machine is logged and its last mirror indexed as it is.
+func (i *Indexer) SetRemotes(targets []string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if len(targets) > 0 && i.dbPath == MemoryPath {
+		return fmt.Errorf("remote sessions are mirrored next to the index; drop --ephemeral to use --remote")
+	}
+	i.remotes = nil
+	for _, t := range targets {
+		if err := validateRemote(t); err != nil {
+			return err
+		}
+		i.remotes = append(i.remotes, remote{target: t, dir: filepath.Join(filepath.Dir(i.dbPath), "remotes", t)})
+	}
+	return nil
+}
+
+// syncRemotes updates every mirror, logging the ones that fail. It runs
+// without i.mu, so a slow machine does not hold up queries, but under the
+// write lock, so two instances never rsync into one mirror at once.
+func (i *Indexer) syncRemotes(ctx context.Context) error {
+	i.mu.Lock()
+	remotes, codex, claude := i.remotes, !i.skipCodex, !i.skipClaude
+	i.mu.Unlock()
+	if len(remotes) == 0 {
+		return nil
+	}
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, r := range remotes {
+		if err := r.sync(ctx, codex, claude); err != nil {
+			i.log.Warn("remote sync failed; indexing the last mirror", "remote", r.target, "err", err)
+		}
+	}
+	return nil
+}
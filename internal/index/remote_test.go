@@ -0,0 +1,76 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemotesAreMirroredAndIndexed(t *testing.T) {
+	idx := newTestIndexer(t, t.TempDir(), t.TempDir())
+	if err := idx.SetRemotes([]string{"-oProxyCommand=x"}); err == nil {
+		t.Fatal("expected an option-like remote rejected")
+	}
+	if err := idx.SetRemotes([]string{"me@devbox"}); err != nil {
+		t.Fatalf("set remotes: %v", err)
+	}
+
+	id := "78787878-7878-7878-7878-787878787878"
+	var calls [][]string
+	fail := false
+	queried := false
+	orig := runRsync
+	t.Cleanup(func() { runRsync = orig })
+	runRsync = func(_ context.Context, args []string) error {
+		calls = append(calls, args)
+		// Queries stay answerable while a slow machine syncs.
+		done := make(chan struct{})
+		go func() { _, _ = idx.ListSessions("", 1); close(done) }()
+		select {
+		case <-done:
+			queried = true
+		case <-time.After(2 * time.Second):
+		}
+		if fail {
+			return errors.New("ssh: connect to host devbox: Connection refused")
+		}
+		if slices.Contains(args, "me@devbox:.claude/projects") {
+			dest := args[len(args)-1]
+			writeJSONL(t, filepath.Join(dest, "projects", "-home-me-proj", id+".jsonl"),
+				`{"type":"user","uuid":"u1","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"remote prompt"}}`)
+		}
+		return nil
+	}
+
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index: %v", err)
+	}
+	if len(calls) != 2 || !slices.Contains(calls[0], "me@devbox:.codex/sessions") {
+		t.Fatalf("unexpected rsync calls %v", calls)
+	}
+	if !slices.Contains(calls[0], "ssh -o BatchMode=yes -o ConnectTimeout=10") {
+		t.Fatalf("expected ssh to time out on unreachable machines: %v", calls[0])
+	}
+	if !queried {
+		t.Fatal("queries blocked while the remote synced")
+	}
+	if dest := calls[1][len(calls[1])-1]; !strings.HasSuffix(dest, filepath.Join("remotes", "me@devbox", "claude")+string(filepath.Separator)) {
+		t.Fatalf("mirror = %q, want it next to the index", dest)
+	}
+	if s, err := idx.GetSession(id); err != nil || s.MessageCount != 1 {
+		t.Fatalf("remote session = %+v, %v", s, err)
+	}
+
+	// An unreachable machine keeps its last mirror indexed.
+	fail = true
+	if _, err := idx.BuildIndex(context.Background()); err != nil {
+		t.Fatalf("build index with the remote down: %v", err)
+	}
+	if _, err := idx.GetSession(id); err != nil {
+		t.Fatalf("expected the mirrored session kept: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSessionNotFound means no session matched a ResolveSession reference.
+var ErrSessionNotFound = errors.New("session not found")
+
+// ResolveSession finds the session a user-supplied reference names: an
+// exact ID, an alias, or a unique ID prefix, in that order of preference.
+func (i *Indexer) ResolveSession(ref string) (Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Session{}, ErrSessionNotFound
+	}
+	id, err := i.resolveSessionID(ref)
+	if err != nil {
+		return Session{}, err
+	}
+	s, err := i.getSession(id)
+	if err != nil {
+		return Session{}, fmt.Errorf("load session %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func (i *Indexer) resolveSessionID(ref string) (string, error) {
+	queries := []struct {
+		what string
+		sql  string
+		arg  string
+	}{
+		{"id", `SELECT id FROM sessions WHERE id = ?`, ref},
+		{"alias", `SELECT a.session_id FROM annotations a JOIN sessions s ON s.id = a.session_id WHERE a.alias = ?`, ref},
+		{"id prefix", `SELECT id FROM sessions WHERE id LIKE ? ESCAPE '\' ORDER BY id LIMIT 2`, likePrefix(ref)},
+	}
+	for _, q := range queries {
+		ids, err := i.querySessionIDs(q.sql, q.arg)
+		if err != nil {
+			return "", fmt.Errorf("resolve session by %s: %w", q.what, err)
+		}
+		switch len(ids) {
+		case 0:
+			continue
+		case 1:
+			return ids[0], nil
+		default:
+			return "", fmt.Errorf("%q matches more than one session by %s", ref, q.what)
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrSessionNotFound, ref)
+}
+
+func (i *Indexer) querySessionIDs(query, arg string) ([]string, error) {
+	rows, err := i.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// likePrefix turns s into a LIKE pattern matching strings that start with
+// it, escaping wildcards with a backslash.
+func likePrefix(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(s) + "%"
+}
+
+// LatestSession returns the most recently active listed session. A
+// non-empty dir limits it to sessions whose workdir is dir or lies below it;
+// otherwise the SetScope limit applies.
+func (i *Indexer) LatestSession(dir string) (Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	where := `COALESCE(message_count, 0) > 0 AND id NOT IN (` + hiddenSessionIDsQuery + `)`
+	if dir = strings.TrimRight(dir, "/"); dir == "" {
+		dir = i.scope
+	}
+	scope, args := workdirUnder("workdir", dir)
+	where += scope
+	rows, err := i.db.Query(`
+		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, ''), COALESCE(activity, ''), COALESCE(branch, '')
+		FROM sessions
+		WHERE `+where+`
+		ORDER BY COALESCE(last_activity_ts, 0) DESC, id
+		LIMIT 1
+	`, args...)
+	if err != nil {
+		return Session{}, fmt.Errorf("query latest session: %w", err)
+	}
+	sessions, err := i.scanSessions(rows, nil)
+	if err != nil {
+		return Session{}, err
+	}
+	if len(sessions) == 0 {
+		if dir != "" {
+			return Session{}, fmt.Errorf("%w under %s", ErrSessionNotFound, dir)
+		}
+		return Session{}, ErrSessionNotFound
+	}
+	return sessions[0], nil
+}
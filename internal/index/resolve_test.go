@@ -0,0 +1,66 @@
+package index
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSession(t *testing.T) {
+	claudeHome := t.TempDir()
+	for _, id := range []string{"abc11111-0000-0000-0000-000000000000", "abc22222-0000-0000-0000-000000000000"} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", id+".jsonl"),
+			`{"type":"user","uuid":"`+id+`-u","sessionId":"`+id+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"hello `+id+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	if _, err := idx.SetAnnotation(Annotation{SessionID: "abc22222-0000-0000-0000-000000000000", Alias: "login-fix"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for ref, want := range map[string]string{
+		"abc11111-0000-0000-0000-000000000000": "abc11111-0000-0000-0000-000000000000",
+		"abc1":                                 "abc11111-0000-0000-0000-000000000000",
+		"login-fix":                            "abc22222-0000-0000-0000-000000000000",
+	} {
+		s, err := idx.ResolveSession(ref)
+		if err != nil || s.ID != want {
+			t.Errorf("resolve %q = %q, %v; want %q", ref, s.ID, err, want)
+		}
+	}
+	if _, err := idx.ResolveSession("abc"); err == nil || errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("ambiguous prefix: err %v", err)
+	}
+	for _, ref := range []string{"zzz", "%", ""} {
+		if _, err := idx.ResolveSession(ref); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("resolve %q: want ErrSessionNotFound, got %v", ref, err)
+		}
+	}
+}
+
+func TestLatestSession(t *testing.T) {
+	claudeHome := t.TempDir()
+	for _, s := range []struct{ id, cwd, ts string }{
+		{"11111111-0000-0000-0000-000000000000", "/src/app", "10:00:00"},
+		{"22222222-0000-0000-0000-000000000000", "/src/app/web", "11:00:00"},
+		{"33333333-0000-0000-0000-000000000000", "/src/app2", "12:00:00"},
+	} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", s.id+".jsonl"),
+			`{"type":"user","uuid":"`+s.id+`-u","sessionId":"`+s.id+`","cwd":"`+s.cwd+`","timestamp":"2026-01-15T`+s.ts+`Z","message":{"role":"user","content":"hello `+s.id+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	for dir, want := range map[string]string{
+		"":          "33333333-0000-0000-0000-000000000000",
+		"/src/app":  "22222222-0000-0000-0000-000000000000",
+		"/src/app/": "22222222-0000-0000-0000-000000000000",
+		"/src/app2": "33333333-0000-0000-0000-000000000000",
+	} {
+		s, err := idx.LatestSession(dir)
+		if err != nil || s.ID != want {
+			t.Errorf("latest under %q = %q, %v; want %q", dir, s.ID, err, want)
+		}
+	}
+	if _, err := idx.LatestSession("/elsewhere"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound outside any workdir, got %v", err)
+	}
+}
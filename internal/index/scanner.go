@@ -13,10 +13,14 @@ type sourceFile struct {
 	Source string
 }
 
-func discoverAllSources(codexHome string, claudeHomes []string) ([]sourceFile, error) {
-	codex, err := discoverCodexSources(codexHome)
-	if err != nil {
-		return nil, err
+func discoverAllSources(codexHomes, claudeHomes []string) ([]sourceFile, error) {
+	var codex []sourceFile
+	for _, home := range codexHomes {
+		sources, err := discoverCodexSources(home)
+		if err != nil {
+			return nil, err
+		}
+		codex = append(codex, sources...)
 	}
 	var allClaude []sourceFile
 	for _, home := range claudeHomes {
@@ -40,7 +44,7 @@ func discoverCodexSources(codexHome string) ([]sourceFile, error) {
 		if d.IsDir() {
 			return nil
 		}
-		name := strings.ToLower(d.Name())
+		name := logName(strings.ToLower(d.Name()))
 		if strings.HasPrefix(name, "rollout-") && strings.HasSuffix(name, ".jsonl") {
 			rollouts = append(rollouts, sourceFile{Path: path, Source: "codex"})
 		}
@@ -72,12 +76,12 @@ func discoverClaudeSources(claudeHome string) ([]sourceFile, error) {
 		}
 		if d.IsDir() {
 			name := d.Name()
-			if name == "subagents" || name == "memory" {
+			if name == "memory" {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if strings.HasSuffix(strings.ToLower(d.Name()), ".jsonl") {
+		if strings.HasSuffix(logName(strings.ToLower(d.Name())), ".jsonl") {
 			sources = append(sources, sourceFile{Path: path, Source: "claude"})
 		}
 		return nil
@@ -0,0 +1,95 @@
+package index
+
+import "strings"
+
+// SetScope limits the session list, search, LatestSession and ListWorkdirs
+// to sessions whose workdir is dir or lies below it, e.g. the current
+// repository. An empty dir lifts the limit.
+func (i *Indexer) SetScope(dir string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.scope = strings.TrimRight(dir, "/")
+}
+
+// SetShowEmpty lists sessions whose message count is zero too: those
+// without a prompt of the user's own, or with nothing but boilerplate, which
+// the list hides so they can be audited or pruned.
+func (i *Indexer) SetShowEmpty(show bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.showEmpty = show
+}
+
+// listedCondition is the condition on sessions columns, qualified with
+// prefix, that the list, search and matches apply: subagents and folded
+// duplicates are hidden, and so are empty sessions unless SetShowEmpty.
+func (i *Indexer) listedCondition(prefix string) string {
+	hidden := prefix + `id NOT IN (` + hiddenSessionIDsQuery + `)`
+	if i.showEmpty {
+		return hidden
+	}
+	return `COALESCE(` + prefix + `message_count, 0) > 0 AND ` + hidden
+}
+
+// Scope returns the directory set by SetScope.
+func (i *Indexer) Scope() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.scope
+}
+
+// workdirUnder returns a condition matching col equal to dir or a path
+// below it, with its arguments. It is empty when dir is.
+func workdirUnder(col, dir string) (string, []any) {
+	if dir == "" {
+		return "", nil
+	}
+	return ` AND (` + col + ` = ? OR ` + col + ` LIKE ? ESCAPE '\')`, []any{dir, likePrefix(dir + "/")}
+}
+
+// sessionConditions returns conditions on sessions columns keeping the
+// sessions in scope that pass filters, with their arguments.
+func (i *Indexer) sessionConditions(filters sessionFilters) (string, []any) {
+	cond, args := workdirUnder("workdir", i.scope)
+	if filters.branch != "" {
+		if prefix, ok := strings.CutSuffix(filters.branch, "*"); ok {
+			cond += ` AND branch LIKE ? ESCAPE '\'`
+			args = append(args, likePrefix(prefix))
+		} else {
+			cond += ` AND branch = ?`
+			args = append(args, filters.branch)
+		}
+	}
+	return cond, args
+}
+
+// scopedMessages returns a condition on a messages_fts session_id column
+// keeping rows of the sessions sessionConditions keeps, with its arguments.
+func (i *Indexer) scopedMessages(filters sessionFilters) (string, []any) {
+	cond, args := i.sessionConditions(filters)
+	if cond == "" {
+		return "", nil
+	}
+	return ` AND session_id IN (SELECT id FROM sessions WHERE 1 = 1` + cond + `)`, args
+}
+
+// sessionFilters are field:value terms of a search query that narrow the
+// sessions it matches instead of being searched for.
+type sessionFilters struct {
+	// branch is an exact git branch, or a prefix when it ends in "*".
+	branch string
+}
+
+// splitFilters separates field:value terms from the text of query.
+func splitFilters(query string) (string, sessionFilters) {
+	var f sessionFilters
+	var text []string
+	for _, term := range strings.Fields(query) {
+		if v, ok := strings.CutPrefix(term, "branch:"); ok && v != "" {
+			f.branch = v
+			continue
+		}
+		text = append(text, term)
+	}
+	return strings.Join(text, " "), f
+}
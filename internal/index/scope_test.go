@@ -0,0 +1,78 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScopeLimitsListSearchAndWorkdirs(t *testing.T) {
+	claudeHome := t.TempDir()
+	for _, s := range []struct{ id, cwd string }{
+		{"11111111-0000-0000-0000-000000000000", "/src/app"},
+		{"22222222-0000-0000-0000-000000000000", "/src/app/web"},
+		{"33333333-0000-0000-0000-000000000000", "/src/app_2"},
+	} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", s.id+".jsonl"),
+			`{"type":"user","uuid":"`+s.id+`-u","sessionId":"`+s.id+`","cwd":"`+s.cwd+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"deploy the widget `+s.id+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	idx.SetScope("/src/app/")
+	if got := idx.Scope(); got != "/src/app" {
+		t.Fatalf("scope = %q", got)
+	}
+
+	for _, query := range []string{"", "widget"} {
+		sessions, err := idx.ListSessions(query, 10)
+		if err != nil {
+			t.Fatalf("list %q: %v", query, err)
+		}
+		if len(sessions) != 2 {
+			t.Errorf("list %q: expected the two sessions under /src/app, got %d", query, len(sessions))
+		}
+		for _, s := range sessions {
+			if s.Workdir == "/src/app_2" {
+				t.Errorf("list %q: %s is out of scope", query, s.ID)
+			}
+		}
+	}
+	workdirs, err := idx.ListWorkdirs()
+	if err != nil || len(workdirs) != 2 {
+		t.Fatalf("workdirs = %+v, err %v", workdirs, err)
+	}
+
+	idx.SetScope("")
+	if sessions, _ := idx.ListSessions("", 10); len(sessions) != 3 {
+		t.Fatalf("expected every session without a scope, got %d", len(sessions))
+	}
+}
+
+func TestShowEmptyListsSessionsWithoutARealPrompt(t *testing.T) {
+	claudeHome := t.TempDir()
+	const real, empty = "44444444-0000-0000-0000-000000000000", "55555555-0000-0000-0000-000000000000"
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", real+".jsonl"),
+		`{"type":"user","uuid":"r-u","sessionId":"`+real+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"tidy the widget"}}`)
+	writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", empty+".jsonl"),
+		`{"type":"assistant","uuid":"e-a","sessionId":"`+empty+`","cwd":"/src/app","timestamp":"2026-01-15T11:00:00Z","message":{"role":"assistant","content":[{"type":"text","text":"Ready when you are, widget fans."}]}}`)
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	for _, query := range []string{"", "widget"} {
+		sessions, err := idx.ListSessions(query, 10)
+		if err != nil {
+			t.Fatalf("list %q: %v", query, err)
+		}
+		if len(sessions) != 1 || sessions[0].ID != real {
+			t.Fatalf("list %q: expected only the session with a prompt, got %+v", query, sessions)
+		}
+	}
+
+	idx.SetShowEmpty(true)
+	for _, query := range []string{"", "widget"} {
+		sessions, err := idx.ListSessions(query, 10)
+		if err != nil {
+			t.Fatalf("list %q: %v", query, err)
+		}
+		if len(sessions) != 2 {
+			t.Fatalf("list %q: expected the empty session too, got %+v", query, sessions)
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package index
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+)
 
 func TestBuildFTSQuery(t *testing.T) {
-	got := buildFTSQuery(`hello "world" /path:test`)
-	want := `"hello"* AND "world"* AND "/path:test"*`
+	got := buildFTSQuery(`hello "world" /path:test`, nil)
+	want := `"hello"* AND "world" AND "/path:test"*`
 	if got != want {
 		t.Fatalf("unexpected fts query\nwant: %s\ngot:  %s", want, got)
 	}
@@ -16,3 +19,74 @@ func TestTokenizeSearchTerms(t *testing.T) {
 		t.Fatalf("unexpected tokens: %#v", got)
 	}
 }
+
+func TestBuildFTSQueryOperators(t *testing.T) {
+	cases := map[string]string{
+		`deploy OR release`:            `("deploy"* OR "release"*)`,
+		`docker -compose`:              `("docker"*) NOT ("compose"*)`,
+		`docker NOT compose NOT swarm`: `("docker"*) NOT ("compose"* OR "swarm"*)`,
+		`(deploy OR release) staging`:  `("deploy"* OR "release"*) AND "staging"*`,
+		`deploy or release`:            `"deploy"* AND "or"* AND "release"*`,
+		`a AND (b`:                     `"a"* AND "b"*`,
+		`-compose`:                     ``,
+		`"deploy the  Widget" staging`: `"deploy the widget" AND "staging"*`,
+		`docker -"compose up"`:         `("docker"*) NOT ("compose up")`,
+		`"unclosed (phrase`:            `"unclosed (phrase"`,
+		`deploy OR -compose`:           ``,
+	}
+	for query, want := range cases {
+		if got := buildFTSQuery(query, nil); got != want {
+			t.Errorf("buildFTSQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestSearchQueryLike(t *testing.T) {
+	parsed, ok := parseSearchQuery(`(deploy OR release) -staging`)
+	if !ok {
+		t.Fatal("expected a query")
+	}
+	cond, args := parsed.like("content")
+	want := `((LOWER(content) LIKE ? OR LOWER(content) LIKE ?) AND NOT (LOWER(content) LIKE ?))`
+	if cond != want || len(args) != 3 || args[2] != "%staging%" {
+		t.Fatalf("like = %s %v", cond, args)
+	}
+	if terms := parsed.terms(); len(terms) != 2 || terms[0] != "deploy" || terms[1] != "release" {
+		t.Fatalf("terms = %q", terms)
+	}
+}
+
+func TestListSessionsBooleanQuery(t *testing.T) {
+	claudeHome := t.TempDir()
+	for _, s := range []struct{ id, text string }{
+		{"66666666-0000-0000-0000-000000000000", "deploy the widget to staging"},
+		{"77777777-0000-0000-0000-000000000000", "deploy the widget to production"},
+		{"88888888-0000-0000-0000-000000000000", "release notes"},
+	} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-tmp-proj", s.id+".jsonl"),
+			`{"type":"user","uuid":"`+s.id+`-u","sessionId":"`+s.id+`","cwd":"/src/app","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"`+s.text+`"}}`)
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	for _, fts := range []bool{true, false} {
+		idx.ftsEnabled = fts && idx.ftsEnabled
+		for query, want := range map[string]int{
+			"deploy -staging":                1,
+			"widget OR release":              3,
+			"(staging OR production) widget": 2,
+			"-staging":                       2,
+			"deploy release":                 0,
+			`"deploy the widget"`:            2,
+			`"the deploy"`:                   0,
+			`"widget to staging" OR notes`:   2,
+		} {
+			sessions, err := idx.ListSessions(query, 10)
+			if err != nil {
+				t.Fatalf("fts=%v %q: %v", fts, query, err)
+			}
+			if len(sessions) != want {
+				t.Errorf("fts=%v %q: got %d sessions, want %d", fts, query, len(sessions), want)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package index
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// similarPromptChars bounds how much of a session's prompts is read to
+	// compare it; the opening prompts say what the session was about.
+	similarPromptChars = 4000
+	// minSimilarity drops sessions sharing only a stray word or two.
+	minSimilarity = 0.15
+)
+
+// similarWordRe matches the words sessions are compared by.
+var similarWordRe = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+
+// similarStopWords are too common in prompts to tell sessions apart.
+var similarStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "that": true, "this": true, "with": true,
+	"you": true, "your": true, "are": true, "was": true, "were": true, "have": true,
+	"has": true, "from": true, "not": true, "but": true, "can": true, "will": true,
+	"would": true, "should": true, "could": true, "into": true, "about": true,
+	"what": true, "when": true, "where": true, "which": true, "there": true,
+	"their": true, "them": true, "then": true, "than": true, "they": true,
+	"our": true, "all": true, "any": true, "use": true, "using": true,
+	"please": true, "make": true, "just": true, "also": true, "like": true,
+	"need": true, "want": true, "get": true, "one": true, "now": true, "how": true,
+	"why": true, "its": true, "it's": true, "let": true, "lets": true, "does": true,
+	"don": true, "some": true, "more": true, "only": true, "out": true, "here": true,
+	"these": true, "those": true, "after": true, "before": true, "still": true,
+	"sure": true, "okay": true, "yes": true, "thanks": true, "again": true,
+}
+
+// similarTerms adds the words of text worth comparing to into: three
+// letters or more, not a stop word and not a bare number.
+func similarTerms(text string, into map[string]bool) {
+	for _, w := range similarWordRe.FindAllString(strings.ToLower(text), -1) {
+		if len(w) < 3 || similarStopWords[w] || strings.Trim(w, "0123456789") == "" {
+			continue
+		}
+		into[w] = true
+	}
+}
+
+// SimilarSessions returns up to n listed sessions whose prompts share the
+// most distinctive words with sessionID's, most similar first: earlier
+// attempts at the same task. Words are weighted by how few sessions use
+// them. The scope applies as for ListSessions.
+func (i *Indexer) SimilarSessions(sessionID string, n int) ([]Session, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	listed, args := i.listedMessages("f.session_id", sessionFilters{})
+	terms, err := i.promptTerms(` AND f.session_id = ?`, []any{sessionID})
+	if err != nil {
+		return nil, err
+	}
+	want := terms[sessionID]
+	if len(want) == 0 {
+		return nil, nil
+	}
+	if terms, err = i.promptTerms(listed, args); err != nil {
+		return nil, err
+	}
+	delete(terms, sessionID)
+
+	df := make(map[string]int)
+	for _, words := range terms {
+		for w := range words {
+			df[w]++
+		}
+	}
+	total := float64(len(terms) + 1)
+	weight := func(w string) float64 {
+		idf := math.Log(total / float64(df[w]+1))
+		return idf * idf
+	}
+	var wantNorm float64
+	for w := range want {
+		wantNorm += weight(w)
+	}
+
+	type scored struct {
+		id    string
+		score float64
+	}
+	var ranked []scored
+	for id, words := range terms {
+		var shared, norm float64
+		for w := range words {
+			norm += weight(w)
+			if want[w] {
+				shared += weight(w)
+			}
+		}
+		if shared == 0 || norm == 0 || wantNorm == 0 {
+			continue
+		}
+		if score := shared / math.Sqrt(norm*wantNorm); score >= minSimilarity {
+			ranked = append(ranked, scored{id, score})
+		}
+	}
+	sort.Slice(ranked, func(a, b int) bool {
+		if ranked[a].score != ranked[b].score {
+			return ranked[a].score > ranked[b].score
+		}
+		return ranked[a].id < ranked[b].id
+	})
+	if len(ranked) > n {
+		ranked = ranked[:n]
+	}
+	out := make([]Session, 0, len(ranked))
+	for _, r := range ranked {
+		s, err := i.getSession(r.id)
+		if err != nil {
+			return nil, fmt.Errorf("load session %s: %w", r.id, err)
+		}
+		out = append(out, s)
+	}
+	return out, i.attachSummaries(out)
+}
+
+// promptTerms reads the user prompts of the sessions cond keeps, up to
+// similarPromptChars each, and returns each session's words.
+func (i *Indexer) promptTerms(cond string, args []any) (map[string]map[string]bool, error) {
+	rows, err := i.db.Query(`
+		SELECT f.session_id, f.content
+		FROM messages_fts f
+		JOIN messages m ON m.id = f.rowid
+		WHERE m.type = 'message' AND m.role = 'user'`+cond+`
+		ORDER BY m.id
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query prompts: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string]map[string]bool)
+	read := make(map[string]int)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			return nil, fmt.Errorf("scan prompt: %w", err)
+		}
+		if read[id] >= similarPromptChars || isNonConversationalPreviewContent(content) {
+			continue
+		}
+		if rest := similarPromptChars - read[id]; len(content) > rest {
+			content = content[:rest]
+		}
+		read[id] += len(content)
+		if out[id] == nil {
+			out[id] = make(map[string]bool)
+		}
+		similarTerms(content, out[id])
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate prompts: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,34 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSimilarSessionsRanksByDistinctiveSharedWords(t *testing.T) {
+	claudeHome := t.TempDir()
+	line := func(id, uuid, ts, text string) string {
+		return `{"type":"user","uuid":"` + uuid + `","sessionId":"` + id + `","cwd":"/src/app","timestamp":"` + ts + `","message":{"role":"user","content":"` + text + `"}}`
+	}
+	const a, b, c, d = "a1111111-0000-0000-0000-000000000000", "b2222222-0000-0000-0000-000000000000", "c3333333-0000-0000-0000-000000000000", "d4444444-0000-0000-0000-000000000000"
+	for _, s := range []struct{ id, ts, text string }{
+		{a, "2026-01-15T10:00:00Z", "fix the flaky websocket reconnect test in the app"},
+		{b, "2026-01-10T10:00:00Z", "the websocket reconnect test is flaky again, please fix it"},
+		{c, "2026-01-12T10:00:00Z", "update the release notes in the app changelog"},
+		{d, "2026-01-13T10:00:00Z", "bump the app version"},
+	} {
+		writeJSONL(t, filepath.Join(claudeHome, "projects", "-src-app", s.id+".jsonl"), line(s.id, s.id[:2], s.ts, s.text))
+	}
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	similar, err := idx.SimilarSessions(a, 3)
+	if err != nil {
+		t.Fatalf("similar sessions: %v", err)
+	}
+	if len(similar) != 1 || similar[0].ID != b {
+		t.Fatalf("expected only the earlier websocket session, got %+v", similar)
+	}
+	if none, err := idx.SimilarSessions(a, 0); err != nil || none != nil {
+		t.Errorf("n = 0 should return nothing, got %+v, %v", none, err)
+	}
+}
@@ -0,0 +1,138 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// fileBackup is one tracked file entry from a Claude file-history-snapshot
+// record. An empty backupFile means the file did not exist at that version.
+type fileBackup struct {
+	path       string
+	backupFile string
+	version    int
+	ts         *int64
+}
+
+// SnapshotVersion is one recorded state of a file. BackupPath is the
+// resolved backup location; it is empty when the file did not exist yet.
+type SnapshotVersion struct {
+	Version    int
+	BackupPath string
+	TS         sql.NullInt64
+}
+
+// FileSnapshot is the recorded history of one file a session touched,
+// versions in ascending order.
+type FileSnapshot struct {
+	Path     string
+	Versions []SnapshotVersion
+}
+
+func parseClaudeFileBackups(obj map[string]any) []fileBackup {
+	snap, _ := obj["snapshot"].(map[string]any)
+	if snap == nil {
+		return nil
+	}
+	tracked, _ := snap["trackedFileBackups"].(map[string]any)
+	out := make([]fileBackup, 0, len(tracked))
+	for path, raw := range tracked {
+		b, _ := raw.(map[string]any)
+		if b == nil || path == "" {
+			continue
+		}
+		version := 0
+		switch v := b["version"].(type) {
+		case float64:
+			version = int(v)
+		case string:
+			version, _ = strconv.Atoi(v)
+		}
+		out = append(out, fileBackup{
+			path:       path,
+			backupFile: asString(b["backupFileName"]),
+			version:    version,
+			ts:         parseUnix(b["backupTime"]),
+		})
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].path < out[b].path })
+	return out
+}
+
+func prepareSnapshotStmt(ctx context.Context, tx *sql.Tx) (*sql.Stmt, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO claude_file_snapshots(session_id, file_path, version, backup_file, ts, source_path)
+		VALUES(?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare file snapshot insert: %w", err)
+	}
+	return stmt, nil
+}
+
+// claudeHomeFromSourcePath returns the Claude home a transcript lives under
+// (<home>/projects/<project>/.../<session>.jsonl).
+func claudeHomeFromSourcePath(path string) string {
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == "projects" {
+			return filepath.Dir(dir)
+		}
+	}
+	return ""
+}
+
+// FileSnapshots returns the per-file backup history recorded by a Claude
+// session's file-history snapshots, ordered by file path.
+func (i *Indexer) FileSnapshots(sessionID string) ([]FileSnapshot, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`
+		SELECT file_path, version, COALESCE(backup_file, ''), ts, source_path
+		FROM claude_file_snapshots
+		WHERE session_id = ?
+		ORDER BY file_path, version
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("query file snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var out []FileSnapshot
+	for rows.Next() {
+		var path, backup, sourcePath string
+		var v SnapshotVersion
+		if err := rows.Scan(&path, &v.Version, &backup, &v.TS, &sourcePath); err != nil {
+			return nil, fmt.Errorf("scan file snapshot row: %w", err)
+		}
+		if backup != "" {
+			v.BackupPath = filepath.Join(claudeHomeFromSourcePath(sourcePath), "file-history", sessionID, backup)
+		}
+		if len(out) == 0 || out[len(out)-1].Path != path {
+			out = append(out, FileSnapshot{Path: path})
+		}
+		out[len(out)-1].Versions = append(out[len(out)-1].Versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate file snapshots: %w", err)
+	}
+	return out, nil
+}
+
+// ReadSnapshotVersion returns the content of a recorded file version. A
+// version without a backup is a file that did not exist yet and reads empty.
+func ReadSnapshotVersion(v SnapshotVersion) (string, bool, error) {
+	if v.BackupPath == "" {
+		return "", false, nil
+	}
+	b, err := os.ReadFile(v.BackupPath)
+	if err != nil {
+		return "", false, fmt.Errorf("read backup %s: %w", v.BackupPath, err)
+	}
+	return string(b), true, nil
+}
@@ -0,0 +1,36 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSnapshotsResolveBackups(t *testing.T) {
+	claudeHome := t.TempDir()
+	session := "44444444-4444-4444-4444-444444444444"
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	writeJSONL(t, filepath.Join(proj, session+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+session+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"edit main.go"}}`,
+		`{"type":"file-history-snapshot","messageId":"u1","snapshot":{"messageId":"u1","trackedFileBackups":{"/tmp/proj/main.go":{"backupFileName":"abc@v1","version":1,"backupTime":"2026-01-15T10:00:01Z"},"/tmp/proj/new.go":{"backupFileName":null,"version":1,"backupTime":"2026-01-15T10:00:01Z"}}},"isSnapshotUpdate":false}`,
+		`{"type":"file-history-snapshot","messageId":"u2","snapshot":{"messageId":"u2","trackedFileBackups":{"/tmp/proj/main.go":{"backupFileName":"abc@v2","version":2,"backupTime":"2026-01-15T10:05:00Z"}}},"isSnapshotUpdate":true}`,
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	files, err := idx.FileSnapshots(session)
+	if err != nil {
+		t.Fatalf("file snapshots: %v", err)
+	}
+	if len(files) != 2 || files[0].Path != "/tmp/proj/main.go" || files[1].Path != "/tmp/proj/new.go" {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	main := files[0].Versions
+	if len(main) != 2 || main[0].Version != 1 || main[1].Version != 2 {
+		t.Fatalf("unexpected versions: %+v", main)
+	}
+	if want := filepath.Join(claudeHome, "file-history", session, "abc@v2"); main[1].BackupPath != want {
+		t.Fatalf("backup path = %q, want %q", main[1].BackupPath, want)
+	}
+	if files[1].Versions[0].BackupPath != "" {
+		t.Fatalf("expected no backup for a file created by the session, got %+v", files[1].Versions[0])
+	}
+}
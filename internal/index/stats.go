@@ -0,0 +1,128 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Stats is a read-only summary of the index for health checks.
+type Stats struct {
+	LastIndexedTS    int64 // when BuildIndex last finished; 0 if never
+	SourceFiles      int   // session files currently on disk
+	PendingFiles     int   // files that are new or changed since they were ingested
+	SessionsBySource map[string]int
+	DBBytes          int64 // database plus WAL and shared-memory files
+	SchemaVersion    int
+	Newest           []WorkdirSession
+}
+
+// WorkdirSession is the most recent session recorded in a workdir.
+type WorkdirSession struct {
+	Workdir        string
+	SessionID      string
+	Source         string
+	LastActivityTS int64
+}
+
+// markIndexed records when an index pass completed.
+func (i *Indexer) markIndexed(ctx context.Context) error {
+	_, err := i.db.ExecContext(ctx, `INSERT OR REPLACE INTO meta(key, value) VALUES('last_indexed_at', ?)`,
+		strconv.FormatInt(time.Now().Unix(), 10))
+	if err != nil {
+		return fmt.Errorf("store index time: %w", err)
+	}
+	return nil
+}
+
+// Stats reports index freshness and counts without ingesting anything.
+func (i *Indexer) Stats(ctx context.Context) (Stats, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	st := Stats{SessionsBySource: map[string]int{}}
+
+	var last string
+	err := i.db.QueryRowContext(ctx, `SELECT value FROM meta WHERE key = 'last_indexed_at'`).Scan(&last)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return st, fmt.Errorf("read index time: %w", err)
+	}
+	st.LastIndexedTS, _ = strconv.ParseInt(last, 10, 64)
+	if err := i.db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&st.SchemaVersion); err != nil {
+		return st, fmt.Errorf("read schema version: %w", err)
+	}
+
+	sources, err := i.discover()
+	if err != nil {
+		return st, fmt.Errorf("discover sources: %w", err)
+	}
+	st.SourceFiles = len(sources)
+	for _, src := range sources {
+		info, err := os.Stat(src.Path)
+		if err != nil {
+			continue
+		}
+		meta, found, err := i.getIngestedMeta(src.Path)
+		if err != nil {
+			return st, err
+		}
+		if !found || meta.Size != info.Size() || meta.Mtime != info.ModTime().Unix() {
+			st.PendingFiles++
+		}
+	}
+
+	rows, err := i.db.QueryContext(ctx, `
+		SELECT source, COUNT(*) FROM sessions
+		WHERE COALESCE(message_count, 0) > 0 AND id NOT IN (`+hiddenSessionIDsQuery+`)
+		GROUP BY source
+	`)
+	if err != nil {
+		return st, fmt.Errorf("count sessions: %w", err)
+	}
+	for rows.Next() {
+		var source string
+		var n int
+		if err := rows.Scan(&source, &n); err != nil {
+			rows.Close()
+			return st, fmt.Errorf("scan session count: %w", err)
+		}
+		st.SessionsBySource[source] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("iterate session counts: %w", err)
+	}
+
+	rows, err = i.db.QueryContext(ctx, `
+		SELECT workdir, id, source, COALESCE(last_activity_ts, 0) FROM (
+			SELECT workdir, id, source, last_activity_ts,
+				ROW_NUMBER() OVER (PARTITION BY workdir ORDER BY last_activity_ts DESC, id) AS rn
+			FROM sessions
+			WHERE COALESCE(message_count, 0) > 0 AND COALESCE(workdir, '') != ''
+				AND id NOT IN (`+hiddenSessionIDsQuery+`)
+		)
+		WHERE rn = 1
+		ORDER BY last_activity_ts DESC, workdir
+	`)
+	if err != nil {
+		return st, fmt.Errorf("query newest sessions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var w WorkdirSession
+		if err := rows.Scan(&w.Workdir, &w.SessionID, &w.Source, &w.LastActivityTS); err != nil {
+			return st, fmt.Errorf("scan newest session: %w", err)
+		}
+		st.Newest = append(st.Newest, w)
+	}
+	if err := rows.Err(); err != nil {
+		return st, fmt.Errorf("iterate newest sessions: %w", err)
+	}
+
+	st.DBBytes = i.DBSize()
+	return st, nil
+}
@@ -0,0 +1,186 @@
+package index
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var claudeAgentFileRe = regexp.MustCompile(`^agent-(.+)\.jsonl$`)
+
+// Subagent is a Claude sidechain transcript spawned by a Task tool call in
+// its parent session.
+type Subagent struct {
+	Session  Session
+	Messages []Message
+}
+
+// Prompt returns the first user message, which is the prompt the parent's
+// Task call handed to the subagent.
+func (s Subagent) Prompt() string {
+	for _, m := range s.Messages {
+		if m.Role == "user" && m.Type == "message" {
+			return m.Content
+		}
+	}
+	return ""
+}
+
+// SubagentCall is a Task tool call found in a parent transcript.
+type SubagentCall struct {
+	Index       int // position in the message slice
+	Description string
+	Prompt      string
+	AgentType   string
+}
+
+// claudeSubagentSessionID derives a stable id for a sidechain transcript so
+// it is indexed apart from the parent session whose sessionId it shares.
+func claudeSubagentSessionID(parentID, agentID string) string {
+	return parentID + "/agent-" + agentID
+}
+
+func claudeAgentIDFromPath(path string) string {
+	if m := claudeAgentFileRe.FindStringSubmatch(logName(filepath.Base(path))); len(m) == 2 {
+		return m[1]
+	}
+	return ""
+}
+
+func prepareSubagentStmt(ctx context.Context, tx *sql.Tx) (*sql.Stmt, error) {
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR IGNORE INTO claude_subagents(session_id, parent_id, source_path) VALUES(?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare claude subagent insert: %w", err)
+	}
+	return stmt, nil
+}
+
+// FindSubagentCalls returns the Task tool calls in msgs, in order.
+func FindSubagentCalls(msgs []Message) []SubagentCall {
+	var calls []SubagentCall
+	for idx, m := range msgs {
+		if m.Type != "tool_use" {
+			continue
+		}
+		name, args, ok := strings.Cut(m.Content, ": ")
+		if !ok || (name != "Task" && name != "Agent") {
+			continue
+		}
+		var in struct {
+			Description  string `json:"description"`
+			Prompt       string `json:"prompt"`
+			SubagentType string `json:"subagent_type"`
+		}
+		if err := json.Unmarshal([]byte(args), &in); err != nil {
+			continue
+		}
+		calls = append(calls, SubagentCall{
+			Index:       idx,
+			Description: in.Description,
+			Prompt:      in.Prompt,
+			AgentType:   in.SubagentType,
+		})
+	}
+	return calls
+}
+
+// MatchSubagents pairs Task calls with the subagent transcripts they spawned,
+// returning call position -> subagent position. Calls are matched on the
+// prompt first; leftovers are paired in order with subagents that started no
+// earlier than the call.
+func MatchSubagents(msgs []Message, calls []SubagentCall, subs []Subagent) map[int]int {
+	out := make(map[int]int)
+	used := make([]bool, len(subs))
+	for ci, c := range calls {
+		want := normalizeContent(c.Prompt)
+		if want == "" {
+			continue
+		}
+		for si, s := range subs {
+			if !used[si] && normalizeContent(s.Prompt()) == want {
+				out[ci], used[si] = si, true
+				break
+			}
+		}
+	}
+	for ci, c := range calls {
+		if _, ok := out[ci]; ok {
+			continue
+		}
+		callTS := msgs[c.Index].TS
+		for si, s := range subs {
+			if used[si] {
+				continue
+			}
+			if callTS.Valid && s.Session.LastActivityTS > 0 && s.Session.LastActivityTS < callTS.Int64 {
+				continue
+			}
+			out[ci], used[si] = si, true
+			break
+		}
+	}
+	return out
+}
+
+// Subagents returns the sidechain transcripts spawned from sessionID, oldest
+// first.
+func (i *Indexer) Subagents(sessionID string) ([]Subagent, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	rows, err := i.db.Query(`
+		SELECT session_id FROM session_links
+		WHERE parent_id = ? AND kind = 'subagent'
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("list subagents: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan subagent row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate subagent rows: %w", err)
+	}
+
+	out := make([]Subagent, 0, len(ids))
+	for _, id := range ids {
+		s, err := i.getSession(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("load subagent %s: %w", id, err)
+		}
+		msgs, err := i.getMessages(id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Subagent{Session: s, Messages: msgs})
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return firstTS(out[a].Messages) < firstTS(out[b].Messages)
+	})
+	return out, nil
+}
+
+func firstTS(msgs []Message) int64 {
+	for _, m := range msgs {
+		if m.TS.Valid {
+			return m.TS.Int64
+		}
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSubagentsIndexedApartFromParent(t *testing.T) {
+	claudeHome := t.TempDir()
+	parent := "33333333-3333-3333-3333-333333333333"
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	writeJSONL(t, filepath.Join(proj, parent+".jsonl"),
+		`{"type":"user","uuid":"p1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"audit the repo"}}`,
+		`{"type":"assistant","uuid":"p2","parentUuid":"p1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:05Z","message":{"role":"assistant","content":[{"type":"text","text":"Delegating."},{"type":"tool_use","id":"toolu_1","name":"Task","input":{"description":"Find TODOs","prompt":"List every TODO in the repo","subagent_type":"general-purpose"}}]}}`,
+	)
+	writeJSONL(t, filepath.Join(proj, parent, "subagents", "agent-a1b2.jsonl"),
+		`{"type":"user","uuid":"s1","isSidechain":true,"agentId":"a1b2","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:06Z","message":{"role":"user","content":"List every TODO in the repo"}}`,
+		`{"type":"assistant","uuid":"s2","parentUuid":"s1","isSidechain":true,"agentId":"a1b2","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:09Z","message":{"role":"assistant","content":[{"type":"text","text":"Found 3 TODOs."}]}}`,
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+
+	sessions, err := idx.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != parent {
+		t.Fatalf("expected only the parent session to be listed, got %+v", sessions)
+	}
+	msgs, err := idx.GetMessages(parent)
+	if err != nil {
+		t.Fatalf("get messages: %v", err)
+	}
+	for _, m := range msgs {
+		if m.Content == "Found 3 TODOs." {
+			t.Fatalf("subagent transcript leaked into parent session")
+		}
+	}
+
+	subs, err := idx.Subagents(parent)
+	if err != nil {
+		t.Fatalf("subagents: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Session.ID != claudeSubagentSessionID(parent, "a1b2") {
+		t.Fatalf("unexpected subagents: %+v", subs)
+	}
+
+	calls := FindSubagentCalls(msgs)
+	if len(calls) != 1 || calls[0].Description != "Find TODOs" {
+		t.Fatalf("unexpected task calls: %+v", calls)
+	}
+	if got := MatchSubagents(msgs, calls, subs); got[0] != 0 || len(got) != 1 {
+		t.Fatalf("expected task call matched to subagent, got %v", got)
+	}
+}
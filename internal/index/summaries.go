@@ -0,0 +1,142 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SetSummary stores a generated summary of a session, written by model
+// when the session had messageCount messages. Summaries outlive a re-read
+// after a normalization change, but not --reindex, which starts a new
+// database; a session that has grown since is summarized again.
+func (i *Indexer) SetSummary(ctx context.Context, sessionID, summary, model string, messageCount int) error {
+	unlock, err := i.writeLock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	_, err = i.db.ExecContext(ctx, `
+		INSERT INTO session_summaries(session_id, summary, model, message_count, created_at)
+		VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(session_id) DO UPDATE SET
+			summary=excluded.summary,
+			model=excluded.model,
+			message_count=excluded.message_count,
+			created_at=excluded.created_at
+	`, sessionID, summary, model, messageCount, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("store summary of %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// SessionsToSummarize returns up to limit listed sessions, newest first,
+// that have no summary or have gained messages since theirs was written.
+// The scope applies as for ListSessions.
+func (i *Indexer) SessionsToSummarize(limit int) ([]Session, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if limit <= 0 {
+		limit = -1
+	}
+	scope, args := i.sessionConditions(sessionFilters{})
+	rows, err := i.db.Query(`
+		SELECT id, source, COALESCE(last_activity_ts, 0), COALESCE(message_count, 0), COALESCE(workdir, ''), COALESCE(preview, ''), COALESCE(activity, ''), COALESCE(branch, '')
+		FROM sessions
+		WHERE `+i.listedCondition("")+scope+`
+			AND COALESCE(message_count, 0) > 0
+			AND id NOT IN (SELECT session_id FROM session_summaries ss WHERE ss.message_count = COALESCE(sessions.message_count, 0))
+		ORDER BY COALESCE(last_activity_ts, 0) DESC, id
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions to summarize: %w", err)
+	}
+	return i.scanSessions(rows, nil)
+}
+
+// titleBatch bounds the session ids bound into one title query, below
+// SQLite's limit on query parameters.
+const titleBatch = 500
+
+// claudeTitles returns the Claude title of each session in ids that has
+// one. A session summarized again as it grew has several titles; the one
+// whose leaf came last is current.
+func (i *Indexer) claudeTitles(ids []string) (map[string]string, error) {
+	titles := make(map[string]string)
+	for start := 0; start < len(ids); start += titleBatch {
+		batch := ids[start:min(start+titleBatch, len(ids))]
+		args := make([]any, len(batch))
+		for n, id := range batch {
+			args[n] = id
+		}
+		rows, err := i.db.Query(`
+			SELECT e.session_id, t.title
+			FROM claude_entries e
+			JOIN claude_titles t ON t.leaf_uuid = e.uuid
+			WHERE e.session_id IN (?`+strings.Repeat(", ?", len(batch)-1)+`)
+			ORDER BY e.rowid
+		`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query claude titles: %w", err)
+		}
+		for rows.Next() {
+			var id, title string
+			if err := rows.Scan(&id, &title); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan claude title: %w", err)
+			}
+			titles[id] = title
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("iterate claude titles: %w", err)
+		}
+	}
+	return titles, nil
+}
+
+// attachSummaries fills Summary for sessions that have one, and Title from
+// Claude's summary records.
+func (i *Indexer) attachSummaries(sessions []Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	rows, err := i.db.Query(`SELECT session_id, summary FROM session_summaries`)
+	if err != nil {
+		return fmt.Errorf("query session summaries: %w", err)
+	}
+	defer rows.Close()
+	summaries := make(map[string]string)
+	for rows.Next() {
+		var id, summary string
+		if err := rows.Scan(&id, &summary); err != nil {
+			return fmt.Errorf("scan session summary: %w", err)
+		}
+		summaries[id] = summary
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate session summaries: %w", err)
+	}
+	var claude []string
+	for _, s := range sessions {
+		if s.Source == "claude" {
+			claude = append(claude, s.ID)
+		}
+	}
+	titles, err := i.claudeTitles(claude)
+	if err != nil {
+		return err
+	}
+	for n := range sessions {
+		sessions[n].Summary = summaries[sessions[n].ID]
+		sessions[n].Title = titles[sessions[n].ID]
+	}
+	return nil
+}
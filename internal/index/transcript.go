@@ -16,20 +16,45 @@ func FilterMessages(messages []Message, toggles TranscriptToggles) []Message {
 		}
 	}
 
+	var duplicateReasoning map[int]bool
+	if toggles.IncludeReasoning {
+		duplicateReasoning = findDuplicateReasoning(messages)
+	}
+
 	filtered := make([]Message, 0, len(messages))
-	for _, m := range messages {
+	for idx, m := range messages {
 		if strings.TrimSpace(m.Content) == "" {
 			continue
 		}
 		if isBoilerplateUserMessage(m) {
 			continue
 		}
+		if toggles.Role != "" {
+			if (m.Type == "message" || m.Type == "image") && m.Role == toggles.Role {
+				filtered = append(filtered, m)
+			}
+			continue
+		}
 
-		if m.Type == "message" && (m.Role == "user" || m.Role == "assistant") {
+		if m.Type == "message" && (m.Role == "user" || m.Role == "assistant") || m.Type == "image" {
 			filtered = append(filtered, m)
 			continue
 		}
 
+		if m.Type == "thinking" {
+			if toggles.IncludeThinking {
+				filtered = append(filtered, m)
+			}
+			continue
+		}
+
+		if m.Type == "reasoning" {
+			if toggles.IncludeReasoning && !duplicateReasoning[idx] {
+				filtered = append(filtered, m)
+			}
+			continue
+		}
+
 		if m.Type == "user_message" {
 			if !toggles.IncludeAborted {
 				continue
@@ -56,6 +81,55 @@ func FilterMessages(messages []Message, toggles TranscriptToggles) []Message {
 	return filtered
 }
 
+// findDuplicateReasoning marks the echoes of Codex reasoning. Each
+// reasoning response item is also recorded as agent_reasoning events, one
+// per summary part, in the same turn. Within a turn, a reasoning message
+// equal to one part of a fuller message is an echo, and of two equal
+// messages the later one is.
+func findDuplicateReasoning(messages []Message) map[int]bool {
+	type reasoning struct {
+		idx   int
+		norm  string
+		parts map[string]bool
+	}
+	dup := make(map[int]bool)
+	var turn []reasoning
+	mark := func() {
+		for a, ra := range turn {
+			for b, rb := range turn {
+				if a == b {
+					continue
+				}
+				if ra.norm == rb.norm && b < a || ra.norm != rb.norm && rb.parts[ra.norm] {
+					dup[ra.idx] = true
+					break
+				}
+			}
+		}
+		turn = turn[:0]
+	}
+	for idx, m := range messages {
+		if m.Type == "message" && m.Role == "user" {
+			mark()
+			continue
+		}
+		if m.Type != "reasoning" {
+			continue
+		}
+		r := reasoning{idx: idx, norm: normalizeContent(m.Content)}
+		// Summary parts are joined with blank lines by the parser.
+		if split := strings.Split(m.Content, "\n\n"); len(split) > 1 {
+			r.parts = make(map[string]bool, len(split))
+			for _, part := range split {
+				r.parts[normalizeContent(part)] = true
+			}
+		}
+		turn = append(turn, r)
+	}
+	mark()
+	return dup
+}
+
 func isToolMessage(m Message) bool {
 	if strings.Contains(strings.ToLower(m.Role), "tool") {
 		return true
@@ -103,5 +177,17 @@ func isNonConversationalPreviewContent(content string) bool {
 	if isBoilerplateUserContent(trimmed) {
 		return true
 	}
-	return strings.HasPrefix(strings.ToLower(trimmed), "# agents.md instructions for ")
+	lower := strings.ToLower(trimmed)
+	return strings.HasPrefix(lower, "# agents.md instructions for ") || strings.HasPrefix(lower, "data:image/")
+}
+
+// FirstPrompt returns the first user message that is an actual prompt
+// rather than environment boilerplate or injected instructions, or "".
+func FirstPrompt(messages []Message) string {
+	for _, m := range messages {
+		if m.Role == "user" && m.Type == "message" && !isNonConversationalPreviewContent(m.Content) {
+			return strings.TrimSpace(m.Content)
+		}
+	}
+	return ""
 }
@@ -52,3 +52,63 @@ func TestIsNonConversationalPreviewContent(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterMessagesKeepsOnlyTheChosenRole(t *testing.T) {
+	msgs := []Message{
+		{Role: "user", Type: "message", Content: "fix the flaky test"},
+		{Role: "assistant", Type: "thinking", Content: "look at the retry loop"},
+		{Role: "tool", Type: "tool_call", Content: "go test ./..."},
+		{Role: "assistant", Type: "message", Content: "fixed the retry loop"},
+		{Role: "user", Type: "message", Content: "thanks"},
+	}
+	all := TranscriptToggles{IncludeTools: true, IncludeThinking: true}
+
+	for role, want := range map[string][]string{
+		"user":      {"fix the flaky test", "thanks"},
+		"assistant": {"fixed the retry loop"},
+	} {
+		toggles := all
+		toggles.Role = role
+		out := FilterMessages(msgs, toggles)
+		if len(out) != len(want) {
+			t.Fatalf("%s: got %+v", role, out)
+		}
+		for i, m := range out {
+			if m.Content != want[i] {
+				t.Errorf("%s: message %d = %q, want %q", role, i, m.Content, want[i])
+			}
+		}
+	}
+	if out := FilterMessages(msgs, all); len(out) != len(msgs) {
+		t.Fatalf("no role filter: got %d of %d messages", len(out), len(msgs))
+	}
+}
+
+func TestFilterMessagesDropsOnlyReasoningEchoesOfTheSameTurn(t *testing.T) {
+	reasoning := func(text string) Message { return Message{Role: "assistant", Type: "reasoning", Content: text} }
+	prompt := Message{Role: "user", Type: "message", Content: "next"}
+	msgs := []Message{
+		reasoning("**Planning**\n\nCheck the tests first."),
+		reasoning("Check the tests first."),
+		reasoning("Check the tests"),
+		prompt,
+		reasoning("Check the tests first."),
+		reasoning("check the tests  first."),
+	}
+	got := FilterMessages(msgs, TranscriptToggles{IncludeReasoning: true})
+	var contents []string
+	for _, m := range got {
+		if m.Type == "reasoning" {
+			contents = append(contents, m.Content)
+		}
+	}
+	want := []string{"**Planning**\n\nCheck the tests first.", "Check the tests", "Check the tests first."}
+	if len(contents) != len(want) {
+		t.Fatalf("reasoning = %q, want %q", contents, want)
+	}
+	for n := range want {
+		if contents[n] != want[n] {
+			t.Fatalf("reasoning = %q, want %q", contents, want)
+		}
+	}
+}
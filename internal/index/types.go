@@ -8,7 +8,36 @@ type Session struct {
 	LastActivityTS int64
 	MessageCount   int
 	Workdir        string
-	Preview        string
+	// Branch is the git branch the session last ran on, as the agent
+	// recorded it; empty when unknown.
+	Branch  string
+	Preview string
+	// Summary is a model-written summary of the session, empty until the
+	// summarize command writes one. Listings and GetSession fill it.
+	Summary string
+	// Title is the title Claude Code gave the session in its latest summary
+	// record; empty for Codex sessions and until Claude writes one.
+	// Listings and GetSession fill it.
+	Title string
+	// Snippet is an excerpt around the session's first search hit, with
+	// hits between MatchStart and MatchEnd. Only searches fill it.
+	Snippet string
+	// Matches is how many messages matched the search; zero outside one.
+	Matches int
+	// Tags are the session's annotation tags. The index never fills them;
+	// the UI does before exporting.
+	Tags []string
+	// ModTime is the newest modification time (unix seconds) of the
+	// session's source files as of their last ingest. Listings fill it.
+	ModTime int64
+	// Activity is message volume over the session's lifetime.
+	Activity Activity
+	// SourcePaths lists every file the session was read from, including
+	// collapsed duplicate copies. Only GetSession fills it.
+	SourcePaths []string
+	// Usage is token usage per model, including subagents. ListSessions and
+	// GetSession fill it.
+	Usage []Usage
 }
 
 type Message struct {
@@ -21,10 +50,34 @@ type Message struct {
 	Source     string
 	SourcePath string
 	Workdir    string
+	// CallID pairs tool calls with their results; empty when the source
+	// records none.
+	CallID string
 }
 
 type TranscriptToggles struct {
-	IncludeTools   bool
-	IncludeAborted bool
-	IncludeEvents  bool
+	IncludeTools     bool
+	IncludeAborted   bool
+	IncludeEvents    bool
+	IncludeThinking  bool
+	IncludeReasoning bool
+	// Role, when set to "user" or "assistant", keeps only the conversational
+	// messages of that role, whatever the other toggles say.
+	Role string
+	// Timestamps adds each turn's time, and the time since the previous
+	// turn, to its heading. It changes rendering only, not which messages
+	// are kept.
+	Timestamps bool
+	// NumberTurns labels each prompt heading with its turn number, counting
+	// on from TurnsBefore prompts that precede the messages rendered. The
+	// viewer sets it so turns can be referred to and jumped to.
+	NumberTurns bool
+	TurnsBefore int
+}
+
+// WorkdirSummary is a distinct session workdir with how many sessions use it.
+type WorkdirSummary struct {
+	Path           string
+	SessionCount   int
+	LastActivityTS int64
 }
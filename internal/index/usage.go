@@ -0,0 +1,218 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Usage is the token usage a session recorded against one model. Input
+// excludes cached tokens, which are counted separately because they are
+// billed at different rates.
+type Usage struct {
+	Model            string
+	InputTokens      int64
+	OutputTokens     int64
+	CacheReadTokens  int64
+	CacheWriteTokens int64
+}
+
+// Total returns all tokens counted in u.
+func (u Usage) Total() int64 {
+	return u.InputTokens + u.OutputTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// usageRecord is usage extracted from one record. Records sharing a key
+// within a session replace each other: Claude repeats a message's usage on
+// every content-block record, and Codex reports running totals.
+type usageRecord struct {
+	key       string
+	sessionID string
+	Usage
+}
+
+// codexUsageKey is the key of a Codex session's running token totals.
+const codexUsageKey = "codex:total"
+
+// parseClaudeUsage reads message.usage from an assistant record.
+func parseClaudeUsage(obj map[string]any, sessionID, uuid string) *usageRecord {
+	msg, _ := obj["message"].(map[string]any)
+	if msg == nil {
+		return nil
+	}
+	usage, _ := msg["usage"].(map[string]any)
+	if usage == nil {
+		return nil
+	}
+	model := asString(msg["model"])
+	if model == "<synthetic>" {
+		return nil
+	}
+	key := asString(msg["id"])
+	if key == "" {
+		key = uuid
+	}
+	rec := &usageRecord{key: key, sessionID: sessionID, Usage: Usage{
+		Model:            model,
+		InputTokens:      asInt64(usage["input_tokens"]),
+		OutputTokens:     asInt64(usage["output_tokens"]),
+		CacheReadTokens:  asInt64(usage["cache_read_input_tokens"]),
+		CacheWriteTokens: asInt64(usage["cache_creation_input_tokens"]),
+	}}
+	if key == "" || rec.Total() == 0 {
+		return nil
+	}
+	return rec
+}
+
+// parseCodexUsage reads the running totals from a token_count event, or the
+// model from a turn_context record. Other lines are skipped without a full
+// JSON decode since they are the bulk of a rollout.
+func parseCodexUsage(line []byte, sourcePath string) (rec *usageRecord, model string) {
+	if !bytes.Contains(line, []byte(`"token_count"`)) && !bytes.Contains(line, []byte(`"turn_context"`)) {
+		return nil, ""
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return nil, ""
+	}
+	typ := asString(firstByPath(obj, []string{"type"}))
+	if typ == "turn_context" {
+		return nil, asString(firstByPath(obj, []string{"payload", "model"}))
+	}
+	if typ != "event_msg" || asString(firstByPath(obj, []string{"payload", "type"})) != "token_count" {
+		return nil, ""
+	}
+	total, _ := firstByPath(obj, []string{"payload", "info", "total_token_usage"}).(map[string]any)
+	if total == nil {
+		return nil, ""
+	}
+	input := asInt64(total["input_tokens"])
+	cached := asInt64(total["cached_input_tokens"])
+	return &usageRecord{key: codexUsageKey, sessionID: extractSessionID(obj, sourcePath), Usage: Usage{
+		InputTokens:     max(input-cached, 0),
+		OutputTokens:    asInt64(total["output_tokens"]),
+		CacheReadTokens: cached,
+	}}, ""
+}
+
+func asInt64(v any) int64 {
+	switch t := v.(type) {
+	case float64:
+		return int64(t)
+	case json.Number:
+		n, _ := t.Int64()
+		return n
+	}
+	return 0
+}
+
+func prepareUsageStmt(ctx context.Context, tx *sql.Tx) (*sql.Stmt, error) {
+	// A record without a model keeps the model stored earlier, since Codex
+	// names the model in turn_context records that may precede this pass.
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO session_usage(session_id, usage_key, model, input_tokens, output_tokens, cache_read_tokens, cache_write_tokens, source_path)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(session_id, usage_key) DO UPDATE SET
+			model = COALESCE(NULLIF(excluded.model, ''), session_usage.model),
+			input_tokens = excluded.input_tokens,
+			output_tokens = excluded.output_tokens,
+			cache_read_tokens = excluded.cache_read_tokens,
+			cache_write_tokens = excluded.cache_write_tokens,
+			source_path = excluded.source_path
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare usage insert: %w", err)
+	}
+	return stmt, nil
+}
+
+func execUsage(ctx context.Context, stmt *sql.Stmt, rec *usageRecord, model, sourcePath string) {
+	if rec.Model == "" {
+		rec.Model = model
+	}
+	_, _ = stmt.ExecContext(ctx, rec.sessionID, rec.key, rec.Model, rec.InputTokens, rec.OutputTokens, rec.CacheReadTokens, rec.CacheWriteTokens, sourcePath)
+}
+
+// sessionUsageQuery sums usage per session and model, rolling subagent
+// usage into the parent session that spawned it.
+const sessionUsageQuery = `
+	SELECT COALESCE(l.parent_id, u.session_id) AS sid, u.model,
+		SUM(u.input_tokens), SUM(u.output_tokens), SUM(u.cache_read_tokens), SUM(u.cache_write_tokens)
+	FROM session_usage u
+	LEFT JOIN session_links l ON l.session_id = u.session_id AND l.kind = 'subagent'
+`
+
+// SessionUsage returns a session's token usage per model, including its
+// subagents.
+func (i *Indexer) SessionUsage(sessionID string) ([]Usage, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.sessionUsage(sessionID)
+}
+
+func (i *Indexer) sessionUsage(sessionID string) ([]Usage, error) {
+	all, err := i.usageBySession(`WHERE COALESCE(l.parent_id, u.session_id) = ?`, sessionID)
+	return all[sessionID], err
+}
+
+func (i *Indexer) usageBySession(where string, args ...any) (map[string][]Usage, error) {
+	rows, err := i.db.Query(sessionUsageQuery+where+` GROUP BY sid, u.model`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query usage: %w", err)
+	}
+	defer rows.Close()
+
+	out := map[string][]Usage{}
+	for rows.Next() {
+		var sid string
+		var u Usage
+		if err := rows.Scan(&sid, &u.Model, &u.InputTokens, &u.OutputTokens, &u.CacheReadTokens, &u.CacheWriteTokens); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		out[sid] = append(out[sid], u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate usage: %w", err)
+	}
+	for _, us := range out {
+		sort.Slice(us, func(a, b int) bool { return us[a].Total() > us[b].Total() })
+	}
+	return out, nil
+}
+
+// attachUsage fills Usage on each session from a single grouped query.
+func (i *Indexer) attachUsage(sessions []Session) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	all, err := i.usageBySession("")
+	if err != nil {
+		return err
+	}
+	for idx := range sessions {
+		sessions[idx].Usage = all[sessions[idx].ID]
+	}
+	return nil
+}
+
+// SumUsage totals usage across models.
+func SumUsage(usage []Usage) Usage {
+	var total Usage
+	var models []string
+	for _, u := range usage {
+		total.InputTokens += u.InputTokens
+		total.OutputTokens += u.OutputTokens
+		total.CacheReadTokens += u.CacheReadTokens
+		total.CacheWriteTokens += u.CacheWriteTokens
+		if u.Model != "" {
+			models = append(models, u.Model)
+		}
+	}
+	total.Model = strings.Join(models, ", ")
+	return total
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionUsageDedupesAndRollsUpSubagents(t *testing.T) {
+	claudeHome := t.TempDir()
+	proj := filepath.Join(claudeHome, "projects", "-tmp-proj")
+	parent := "88888888-8888-8888-8888-888888888888"
+	usage := `"usage":{"input_tokens":10,"output_tokens":100,"cache_read_input_tokens":1000,"cache_creation_input_tokens":50}`
+	writeJSONL(t, filepath.Join(proj, parent+".jsonl"),
+		`{"type":"user","uuid":"u1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:00Z","message":{"role":"user","content":"count the TODOs"}}`,
+		// Claude writes one record per content block, repeating the usage.
+		`{"type":"assistant","uuid":"a1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"text","text":"Checking."}],`+usage+`}}`,
+		`{"type":"assistant","uuid":"a2","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:05Z","message":{"id":"msg_1","model":"claude-sonnet-4-5","role":"assistant","content":[{"type":"tool_use","name":"Task","input":{"prompt":"List every TODO"}}],`+usage+`}}`,
+	)
+	writeJSONL(t, filepath.Join(proj, parent, "subagents", "agent-x1.jsonl"),
+		`{"type":"user","uuid":"s1","isSidechain":true,"agentId":"x1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:06Z","message":{"role":"user","content":"List every TODO"}}`,
+		`{"type":"assistant","uuid":"s2","isSidechain":true,"agentId":"x1","sessionId":"`+parent+`","timestamp":"2026-01-15T10:00:09Z","message":{"id":"msg_2","model":"claude-haiku-4-5","role":"assistant","content":[{"type":"text","text":"Found 3."}],"usage":{"input_tokens":5,"output_tokens":7}}}`,
+	)
+
+	idx := newTestIndexer(t, t.TempDir(), claudeHome)
+	s, err := idx.GetSession(parent)
+	if err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if len(s.Usage) != 2 {
+		t.Fatalf("expected parent and subagent models, got %+v", s.Usage)
+	}
+	main := s.Usage[0]
+	if main.Model != "claude-sonnet-4-5" || main.InputTokens != 10 || main.OutputTokens != 100 || main.CacheReadTokens != 1000 || main.CacheWriteTokens != 50 {
+		t.Fatalf("repeated usage should be counted once: %+v", main)
+	}
+	if s.Usage[1].Model != "claude-haiku-4-5" || s.Usage[1].Total() != 12 {
+		t.Fatalf("unexpected subagent usage: %+v", s.Usage[1])
+	}
+
+	sessions, err := idx.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions) != 1 || len(sessions[0].Usage) != 2 {
+		t.Fatalf("expected usage on listed session, got %+v", sessions)
+	}
+
+	first, err := idx.FirstActivity()
+	if err != nil {
+		t.Fatalf("first activity: %v", err)
+	}
+	if want := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC).Unix(); first[parent] != want {
+		t.Fatalf("first activity = %d, want %d", first[parent], want)
+	}
+}
+
+func TestCodexUsageKeepsLatestTotals(t *testing.T) {
+	codexHome := t.TempDir()
+	id := "019ac5e9-684f-7741-9974-4246554edb05"
+	path := filepath.Join(codexHome, "sessions", "2025", "11", "27", "rollout-2025-11-27T09-23-19-"+id+".jsonl")
+	writeJSONL(t, path,
+		`{"timestamp":"2025-11-27T09:23:19Z","type":"session_meta","payload":{"id":"`+id+`","cwd":"/tmp/proj"}}`,
+		`{"timestamp":"2025-11-27T09:23:20Z","type":"turn_context","payload":{"cwd":"/tmp/proj","model":"gpt-5-codex"}}`,
+		`{"timestamp":"2025-11-27T09:23:21Z","type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"fix the build"}]}}`,
+		`{"timestamp":"2025-11-27T09:23:30Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":1000,"cached_input_tokens":400,"output_tokens":50}}}}`,
+		`{"timestamp":"2025-11-27T09:24:30Z","type":"event_msg","payload":{"type":"token_count","info":{"total_token_usage":{"input_tokens":3000,"cached_input_tokens":2000,"output_tokens":90}}}}`,
+		`{"timestamp":"2025-11-27T09:24:31Z","type":"event_msg","payload":{"type":"token_count","info":null}}`,
+	)
+
+	idx := newTestIndexer(t, codexHome)
+	usage, err := idx.SessionUsage(id)
+	if err != nil {
+		t.Fatalf("session usage: %v", err)
+	}
+	if len(usage) != 1 {
+		t.Fatalf("expected one usage row, got %+v", usage)
+	}
+	u := usage[0]
+	if u.Model != "gpt-5-codex" || u.InputTokens != 1000 || u.CacheReadTokens != 2000 || u.OutputTokens != 90 {
+		t.Fatalf("unexpected codex usage: %+v", u)
+	}
+}
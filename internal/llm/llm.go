@@ -0,0 +1,187 @@
+// Package llm talks to the language models that write session summaries
+// and embed messages for semantic search: a local Ollama server or an
+// OpenAI-compatible API.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Backends a spec may name.
+const (
+	Ollama = "ollama"
+	OpenAI = "openai"
+)
+
+// DefaultURL is where each backend is reached when no URL is configured.
+var DefaultURL = map[string]string{
+	Ollama: "http://localhost:11434",
+	OpenAI: "https://api.openai.com/v1",
+}
+
+// APIKeyEnv holds the key sent to OpenAI-compatible APIs.
+const APIKeyEnv = "OPENAI_API_KEY"
+
+// Client sends prompts to one model.
+type Client struct {
+	Backend string
+	Model   string
+	URL     string
+	// APIKey is sent as a bearer token to OpenAI-compatible APIs.
+	APIKey string
+	HTTP   *http.Client
+}
+
+// ParseSpec splits a "backend:model" spec such as "ollama:llama3.2" or
+// "openai:gpt-4o-mini".
+func ParseSpec(spec string) (backend, model string, err error) {
+	backend, model, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok || model == "" {
+		return "", "", fmt.Errorf("invalid model %q: want backend:model, e.g. ollama:llama3.2 or openai:gpt-4o-mini", spec)
+	}
+	if _, known := DefaultURL[backend]; !known {
+		return "", "", fmt.Errorf("invalid model %q: backend must be %s or %s", spec, Ollama, OpenAI)
+	}
+	return backend, model, nil
+}
+
+// New returns a client for spec. An empty url uses the backend's default;
+// OpenAI-compatible APIs take their key from $OPENAI_API_KEY.
+func New(spec, url string) (*Client, error) {
+	backend, model, err := ParseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if url == "" {
+		url = DefaultURL[backend]
+	}
+	c := &Client{Backend: backend, Model: model, URL: strings.TrimRight(url, "/"), HTTP: &http.Client{Timeout: 5 * time.Minute}}
+	if backend == OpenAI {
+		if c.APIKey = os.Getenv(APIKeyEnv); c.APIKey == "" {
+			return nil, fmt.Errorf("%s needs an API key in $%s", spec, APIKeyEnv)
+		}
+	}
+	return c, nil
+}
+
+// Name is the spec the client was made from.
+func (c *Client) Name() string {
+	return c.Backend + ":" + c.Model
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Complete sends a system instruction and a prompt, and returns the
+// model's reply.
+func (c *Client) Complete(ctx context.Context, system, prompt string) (string, error) {
+	msgs := []chatMessage{{Role: "system", Content: system}, {Role: "user", Content: prompt}}
+	var reply string
+	switch c.Backend {
+	case Ollama:
+		var resp struct {
+			Message chatMessage `json:"message"`
+		}
+		body := map[string]any{"model": c.Model, "messages": msgs, "stream": false}
+		if err := c.post(ctx, "/api/chat", body, &resp); err != nil {
+			return "", err
+		}
+		reply = resp.Message.Content
+	case OpenAI:
+		var resp struct {
+			Choices []struct {
+				Message chatMessage `json:"message"`
+			} `json:"choices"`
+		}
+		if err := c.post(ctx, "/chat/completions", map[string]any{"model": c.Model, "messages": msgs}, &resp); err != nil {
+			return "", err
+		}
+		if len(resp.Choices) > 0 {
+			reply = resp.Choices[0].Message.Content
+		}
+	default:
+		return "", fmt.Errorf("unknown backend %q", c.Backend)
+	}
+	if reply = strings.TrimSpace(reply); reply == "" {
+		return "", errors.New(c.Name() + " returned an empty reply")
+	}
+	return reply, nil
+}
+
+// Embed returns one vector per text, in order.
+func (c *Client) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var out [][]float32
+	switch c.Backend {
+	case Ollama:
+		var resp struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := c.post(ctx, "/api/embed", map[string]any{"model": c.Model, "input": texts}, &resp); err != nil {
+			return nil, err
+		}
+		out = resp.Embeddings
+	case OpenAI:
+		var resp struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := c.post(ctx, "/embeddings", map[string]any{"model": c.Model, "input": texts}, &resp); err != nil {
+			return nil, err
+		}
+		out = make([][]float32, len(resp.Data))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(out) {
+				return nil, fmt.Errorf("%s returned embedding %d of %d", c.Name(), d.Index, len(out))
+			}
+			out[d.Index] = d.Embedding
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend %q", c.Backend)
+	}
+	if len(out) != len(texts) {
+		return nil, fmt.Errorf("%s returned %d embeddings for %d texts", c.Name(), len(out), len(texts))
+	}
+	return out, nil
+}
+
+// post sends body as JSON to path and decodes the reply into out.
+func (c *Client) post(ctx context.Context, path string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", c.Name(), err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("build %s request: %w", c.Name(), err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("call %s: %w", c.Name(), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("call %s: %s: %s", c.Name(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode %s reply: %w", c.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	backend, model, err := ParseSpec("ollama:llama3.2:3b")
+	if err != nil || backend != Ollama || model != "llama3.2:3b" {
+		t.Fatalf("ParseSpec = %q, %q, %v", backend, model, err)
+	}
+	for _, bad := range []string{"", "ollama", "ollama:", "claude:haiku"} {
+		if _, _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCompleteOllama(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model    string        `json:"model"`
+			Messages []chatMessage `json:"messages"`
+			Stream   bool          `json:"stream"`
+		}
+		if r.URL.Path != "/api/chat" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Model != "llama3.2" || req.Stream || len(req.Messages) != 2 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"message": map[string]string{"role": "assistant", "content": " Fixed the build. "}})
+	}))
+	defer srv.Close()
+
+	c, err := New("ollama:llama3.2", srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Complete(context.Background(), "summarize", "transcript")
+	if err != nil || got != "Fixed the build." {
+		t.Fatalf("Complete = %q, %v", got, err)
+	}
+}
+
+func TestCompleteOpenAI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"choices": []any{map[string]any{"message": map[string]string{"content": "Added retries."}}}})
+	}))
+	defer srv.Close()
+
+	t.Setenv(APIKeyEnv, "")
+	if _, err := New("openai:gpt-4o-mini", srv.URL); err == nil {
+		t.Fatal("expected an error without an API key")
+	}
+	t.Setenv(APIKeyEnv, "sk-test")
+	c, err := New("openai:gpt-4o-mini", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.Complete(context.Background(), "summarize", "transcript"); err != nil || got != "Added retries." {
+		t.Fatalf("Complete = %q, %v", got, err)
+	}
+	c.APIKey = "wrong"
+	if _, err := c.Complete(context.Background(), "summarize", "transcript"); err == nil {
+		t.Fatal("expected the 401 to surface")
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/embed":
+			json.NewEncoder(w).Encode(map[string]any{"embeddings": [][]float32{{1, 0}, {0, 1}}})
+		case "/embeddings":
+			// Out of order, as the API allows.
+			json.NewEncoder(w).Encode(map[string]any{"data": []any{
+				map[string]any{"index": 1, "embedding": []float32{0, 1}},
+				map[string]any{"index": 0, "embedding": []float32{1, 0}},
+			}})
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv(APIKeyEnv, "sk-test")
+	for _, spec := range []string{"ollama:nomic-embed-text", "openai:text-embedding-3-small"} {
+		c, err := New(spec, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.Embed(context.Background(), []string{"flaky websocket test", "release notes"})
+		if err != nil || len(got) != 2 || got[0][0] != 1 || got[1][1] != 1 {
+			t.Errorf("%s: Embed = %v, %v", spec, got, err)
+		}
+	}
+	c, _ := New("ollama:nomic-embed-text", srv.URL)
+	if _, err := c.Embed(context.Background(), []string{"a", "b", "c"}); err == nil {
+		t.Error("expected a count mismatch to fail")
+	}
+}
@@ -0,0 +1,87 @@
+// Package logging builds the structured logger agent-trace writes to
+// --log-file. Without a log file, logging is discarded: the TUI owns the
+// terminal, so nothing may go to stderr.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Formats lists the accepted --log-format values.
+var Formats = []string{"logfmt", "json"}
+
+// ParseLevel parses debug, info, warn or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q: use debug, info, warn or error", s)
+}
+
+// ValidateFormat checks a --log-format value.
+func ValidateFormat(s string) error {
+	for _, f := range Formats {
+		if s == f {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid log format %q: use %s", s, strings.Join(Formats, " or "))
+}
+
+// New returns a logger writing records at level and above to w as logfmt
+// or JSON lines.
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Open appends to the log file at path, creating it and its directory as
+// needed. An empty path yields Discard. The returned func closes the file.
+func Open(path, level, format string) (*slog.Logger, func() error, error) {
+	if path == "" {
+		return Discard(), func() error { return nil }, nil
+	}
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ValidateFormat(format); err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, fmt.Errorf("create log dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open log file: %w", err)
+	}
+	return New(f, lvl, format), f.Close, nil
+}
+
+// Discard returns a logger that drops every record without formatting it.
+func Discard() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenWritesRecordsAtLevel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "agent-trace.log")
+	for _, format := range Formats {
+		logger, closeLog, err := Open(path, "warn", format)
+		if err != nil {
+			t.Fatalf("open %s: %v", format, err)
+		}
+		logger.Info("dropped")
+		logger.Warn("skipped source file", "path", "/tmp/a.jsonl")
+		if err := closeLog(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one record per format, got:\n%s", data)
+	}
+	if !strings.Contains(lines[0], `level=WARN msg="skipped source file" path=/tmp/a.jsonl`) {
+		t.Fatalf("unexpected logfmt record %q", lines[0])
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil {
+		t.Fatalf("json record %q: %v", lines[1], err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "skipped source file" || rec["path"] != "/tmp/a.jsonl" {
+		t.Fatalf("unexpected json record %v", rec)
+	}
+}
+
+func TestOpenWithoutPathDiscards(t *testing.T) {
+	logger, closeLog, err := Open("", "debug", "logfmt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeLog()
+	if logger.Enabled(context.Background(), slog.LevelError) {
+		t.Fatal("expected a logger without a file to discard everything")
+	}
+}
+
+func TestParseLevelAndFormat(t *testing.T) {
+	if lvl, err := ParseLevel("DEBUG"); err != nil || lvl != slog.LevelDebug {
+		t.Fatalf("ParseLevel(DEBUG) = %v, %v", lvl, err)
+	}
+	if _, err := ParseLevel("loud"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+	if err := ValidateFormat("xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
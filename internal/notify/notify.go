@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+var ErrToolNotFound = errors.New("notification tool not found")
+
+type Command struct {
+	Path string
+	Args []string
+}
+
+// SelectCommand returns the command that shows a desktop notification
+// with title and body: osascript on macOS, notify-send on Linux.
+func SelectCommand(goos string, lookPath func(string) (string, error), title, body string) (Command, error) {
+	switch goos {
+	case "darwin":
+		path, err := lookPath("osascript")
+		if err != nil {
+			return Command{}, ErrToolNotFound
+		}
+		script := "display notification " + appleScriptString(body) + " with title " + appleScriptString(title)
+		return Command{Path: path, Args: []string{"-e", script}}, nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		path, err := lookPath("notify-send")
+		if err != nil {
+			return Command{}, ErrToolNotFound
+		}
+		return Command{Path: path, Args: []string{"--app-name=agent-trace", "--", title, body}}, nil
+	default:
+		return Command{}, ErrToolNotFound
+	}
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Send shows a desktop notification.
+func Send(ctx context.Context, title, body string) error {
+	cmdDef, err := SelectCommand(runtime.GOOS, exec.LookPath, title, body)
+	if err != nil {
+		return err
+	}
+	if out, err := exec.CommandContext(ctx, cmdDef.Path, cmdDef.Args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("notification command failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSelectCommandDarwinQuotesAppleScript(t *testing.T) {
+	cmd, err := SelectCommand("darwin", func(name string) (string, error) {
+		if name == "osascript" {
+			return "/usr/bin/osascript", nil
+		}
+		return "", errors.New("not found")
+	}, "agent-trace", `said "done" \o/`)
+	if err != nil {
+		t.Fatalf("expected command, got error: %v", err)
+	}
+	want := []string{"-e", `display notification "said \"done\" \\o/" with title "agent-trace"`}
+	if cmd.Path != "/usr/bin/osascript" || !reflect.DeepEqual(cmd.Args, want) {
+		t.Fatalf("unexpected command: %#v", cmd)
+	}
+}
+
+func TestSelectCommandLinux(t *testing.T) {
+	cmd, err := SelectCommand("linux", func(name string) (string, error) {
+		if name == "notify-send" {
+			return "/usr/bin/notify-send", nil
+		}
+		return "", errors.New("not found")
+	}, "agent-trace", "-v is not a flag")
+	if err != nil {
+		t.Fatalf("expected command, got error: %v", err)
+	}
+	if want := []string{"--app-name=agent-trace", "--", "agent-trace", "-v is not a flag"}; !reflect.DeepEqual(cmd.Args, want) {
+		t.Fatalf("unexpected args: %#v", cmd.Args)
+	}
+}
+
+func TestSelectCommandMissingTool(t *testing.T) {
+	_, err := SelectCommand("linux", func(string) (string, error) { return "", errors.New("not found") }, "t", "b")
+	if !errors.Is(err, ErrToolNotFound) {
+		t.Fatalf("expected ErrToolNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+// Package pricing estimates what a session cost from its token usage.
+package pricing
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// Price is a model's rate in USD per million tokens.
+type Price struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read,omitempty"`
+	CacheWrite float64 `json:"cache_write,omitempty"`
+}
+
+// Table maps model name prefixes to prices. The longest matching prefix
+// wins, so "claude-opus-4-5" can be priced apart from "claude-opus-4".
+type Table map[string]Price
+
+// Default returns the built-in list prices. They are estimates and go stale;
+// override them with the "pricing" config key.
+func Default() Table {
+	return Table{
+		"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+		"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+		"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+		"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+		"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125},
+		"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025},
+		"gpt-4.1":           {Input: 2, Output: 8, CacheRead: 0.5},
+		"o3":                {Input: 2, Output: 8, CacheRead: 0.5},
+		"o4-mini":           {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+		"codex-mini":        {Input: 1.5, Output: 6, CacheRead: 0.375},
+	}
+}
+
+// Merge returns a copy of t with the entries of over added or replaced.
+func (t Table) Merge(over Table) Table {
+	out := make(Table, len(t)+len(over))
+	for k, v := range t {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+// Lookup finds the price for model by longest prefix, ignoring case.
+func (t Table) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	best, found := "", false
+	for prefix := range t {
+		if strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+			best, found = prefix, true
+		}
+	}
+	return t[best], found
+}
+
+// Estimate returns the cost of usage in USD. complete is false when some
+// tokens were recorded against a model with no price; the estimate then
+// covers only the priced models.
+func (t Table) Estimate(usage []index.Usage) (usd float64, complete bool) {
+	complete = true
+	for _, u := range usage {
+		p, ok := t.Lookup(u.Model)
+		if !ok {
+			if u.Total() > 0 {
+				complete = false
+			}
+			continue
+		}
+		usd += (float64(u.InputTokens)*p.Input +
+			float64(u.OutputTokens)*p.Output +
+			float64(u.CacheReadTokens)*p.CacheRead +
+			float64(u.CacheWriteTokens)*p.CacheWrite) / 1e6
+	}
+	return usd, complete
+}
+
+// FormatCost renders an estimate like "~$0.42", with a trailing "+" when
+// part of the usage could not be priced.
+func FormatCost(usd float64, complete bool) string {
+	s := fmt.Sprintf("~$%.2f", usd)
+	if usd > 0 && usd < 0.005 {
+		s = "<$0.01"
+	}
+	if !complete {
+		s += "+"
+	}
+	return s
+}
+
+// FormatTokens abbreviates a token count: 950, 12.3k, 4.5M.
+func FormatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestLookupPrefersLongestPrefix(t *testing.T) {
+	table := Default()
+	if p, ok := table.Lookup("claude-opus-4-5-20251101"); !ok || p.Input != 5 {
+		t.Fatalf("expected opus 4.5 price, got %+v %v", p, ok)
+	}
+	if p, ok := table.Lookup("claude-opus-4-1-20250805"); !ok || p.Input != 15 {
+		t.Fatalf("expected opus 4 price, got %+v %v", p, ok)
+	}
+	if _, ok := table.Lookup("mystery-model"); ok {
+		t.Fatal("expected no price for unknown model")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	table := Table{"m": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75}}.Merge(Table{"M-Big": {Input: 10, Output: 10}})
+	usd, complete := table.Estimate([]index.Usage{
+		{Model: "m-1", InputTokens: 1_000_000, OutputTokens: 100_000, CacheReadTokens: 1_000_000, CacheWriteTokens: 200_000},
+		{Model: "m-big", InputTokens: 100_000},
+		{Model: "other", OutputTokens: 10},
+	})
+	if want := 3 + 1.5 + 0.3 + 0.75 + 1.0; math.Abs(usd-want) > 1e-9 {
+		t.Fatalf("usd = %v, want %v", usd, want)
+	}
+	if complete {
+		t.Fatal("expected incomplete estimate with an unpriced model")
+	}
+	if got := FormatCost(usd, complete); got != "~$6.55+" {
+		t.Fatalf("FormatCost = %q", got)
+	}
+}
+
+func TestFormatTokens(t *testing.T) {
+	for n, want := range map[int64]string{950: "950", 12_345: "12.3k", 4_500_000: "4.5M"} {
+		if got := FormatTokens(n); got != want {
+			t.Fatalf("FormatTokens(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
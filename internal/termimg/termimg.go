@@ -0,0 +1,182 @@
+// Package termimg displays images inline using terminal graphics protocols
+// (kitty and iTerm2), with a fallback to the system image viewer.
+package termimg
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	// Registered so non-PNG attachments can be re-encoded for kitty.
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+var ErrViewerNotFound = errors.New("image viewer not found")
+
+// Protocol is a terminal graphics protocol.
+type Protocol string
+
+const (
+	None   Protocol = "none"
+	Kitty  Protocol = "kitty"
+	ITerm2 Protocol = "iterm2"
+)
+
+// kittyChunk is the maximum payload size of one kitty graphics escape.
+const kittyChunk = 4096
+
+// ParseProtocol validates an image_protocol setting. "auto" and "" mean
+// detect from the environment.
+func ParseProtocol(setting string) (Protocol, bool, error) {
+	switch strings.ToLower(strings.TrimSpace(setting)) {
+	case "", "auto":
+		return "", true, nil
+	case "kitty":
+		return Kitty, false, nil
+	case "iterm2", "iterm":
+		return ITerm2, false, nil
+	case "none", "off":
+		return None, false, nil
+	}
+	return "", false, fmt.Errorf("unknown image protocol %q (want auto, kitty, iterm2 or none)", setting)
+}
+
+// Detect picks the graphics protocol for the terminal described by getenv.
+// Inside tmux or screen it returns None, since both swallow the escapes
+// unless passthrough is configured; set the protocol explicitly there.
+func Detect(getenv func(string) string) Protocol {
+	if getenv("TMUX") != "" || strings.HasPrefix(getenv("TERM"), "screen") {
+		return None
+	}
+	term := getenv("TERM")
+	program := getenv("TERM_PROGRAM")
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", program == "ghostty":
+		return Kitty
+	case program == "iTerm.app", program == "WezTerm", getenv("LC_TERMINAL") == "iTerm2":
+		return ITerm2
+	}
+	return None
+}
+
+// Resolve turns an image_protocol setting into the protocol to use in the
+// current process environment.
+func Resolve(setting string) (Protocol, error) {
+	p, auto, err := ParseProtocol(setting)
+	if err != nil {
+		return None, err
+	}
+	if auto {
+		return Detect(os.Getenv), nil
+	}
+	return p, nil
+}
+
+// Encode writes data, an encoded image of the given MIME type, to w as a
+// graphics escape sequence for p.
+func Encode(w io.Writer, p Protocol, data []byte, mime string) error {
+	switch p {
+	case Kitty:
+		return encodeKitty(w, data, mime)
+	case ITerm2:
+		_, err := fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;preserveAspectRatio=1:%s\a",
+			len(data), base64.StdEncoding.EncodeToString(data))
+		return err
+	}
+	return fmt.Errorf("protocol %q cannot display images", p)
+}
+
+// encodeKitty transmits a PNG (kitty's f=100 format) in chunks; other
+// formats are decoded and re-encoded as PNG first.
+func encodeKitty(w io.Writer, data []byte, mime string) error {
+	if !strings.EqualFold(mime, "image/png") {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("decode %s: %w", mime, err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return fmt.Errorf("encode png: %w", err)
+		}
+		data = buf.Bytes()
+	}
+	payload := base64.StdEncoding.EncodeToString(data)
+	for first := true; first || payload != ""; first = false {
+		chunk := payload
+		if len(chunk) > kittyChunk {
+			chunk = chunk[:kittyChunk]
+		}
+		payload = payload[len(chunk):]
+		more := 0
+		if payload != "" {
+			more = 1
+		}
+		ctrl := fmt.Sprintf("m=%d", more)
+		if first {
+			ctrl = "f=100,a=T," + ctrl
+		}
+		if _, err := fmt.Fprintf(w, "\x1b_G%s;%s\x1b\\", ctrl, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command is an external program that opens a file.
+type Command struct {
+	Path string
+	Args []string
+}
+
+// SelectViewer returns the system opener for goos.
+func SelectViewer(goos string, lookPath func(string) (string, error)) (Command, error) {
+	var name string
+	switch goos {
+	case "darwin":
+		name = "open"
+	case "linux", "freebsd", "openbsd", "netbsd":
+		name = "xdg-open"
+	default:
+		return Command{}, ErrViewerNotFound
+	}
+	path, err := lookPath(name)
+	if err != nil {
+		return Command{}, ErrViewerNotFound
+	}
+	return Command{Path: path}, nil
+}
+
+// OpenExternal writes data to a temporary file and hands it to the system
+// image viewer. The file is left behind for the viewer to read.
+func OpenExternal(ctx context.Context, data []byte, ext string) (string, error) {
+	cmdDef, err := SelectViewer(runtime.GOOS, exec.LookPath)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "agent-trace-*."+ext)
+	if err != nil {
+		return "", fmt.Errorf("create temp image: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write temp image: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("write temp image: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, cmdDef.Path, append(cmdDef.Args, f.Name())...)
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("image viewer failed: %w", err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,113 @@
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"strings"
+	"testing"
+)
+
+func envOf(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestDetect(t *testing.T) {
+	cases := []struct {
+		env  map[string]string
+		want Protocol
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{map[string]string{"KITTY_WINDOW_ID": "1", "TERM": "xterm-256color"}, Kitty},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app"}, ITerm2},
+		{map[string]string{"TERM_PROGRAM": "WezTerm"}, ITerm2},
+		{map[string]string{"TERM_PROGRAM": "iTerm.app", "TMUX": "/tmp/tmux-1/default,1,0"}, None},
+		{map[string]string{"TERM": "xterm-256color"}, None},
+	}
+	for _, tc := range cases {
+		if got := Detect(envOf(tc.env)); got != tc.want {
+			t.Fatalf("Detect(%v) = %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	if _, auto, err := ParseProtocol(""); err != nil || !auto {
+		t.Fatalf("empty setting should mean auto, got auto=%v err=%v", auto, err)
+	}
+	if p, auto, err := ParseProtocol("Kitty"); err != nil || auto || p != Kitty {
+		t.Fatalf("unexpected result for kitty: %q %v %v", p, auto, err)
+	}
+	if _, _, err := ParseProtocol("sixel"); err == nil {
+		t.Fatal("expected error for unsupported protocol")
+	}
+}
+
+func TestEncodeITerm2(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, ITerm2, []byte("png-bytes"), "image/png"); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := "\x1b]1337;File=inline=1;size=9;preserveAspectRatio=1:" + base64.StdEncoding.EncodeToString([]byte("png-bytes")) + "\a"
+	if buf.String() != want {
+		t.Fatalf("unexpected escape: %q", buf.String())
+	}
+}
+
+func TestEncodeKittyChunksAndConvertsJPEG(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			img.Set(x, y, color.RGBA{uint8(x * 4), uint8(y * 4), uint8(x ^ y), 255})
+		}
+	}
+	var src bytes.Buffer
+	if err := jpeg.Encode(&src, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, Kitty, src.Bytes(), "image/jpeg"); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	chunks := strings.Split(strings.TrimSuffix(buf.String(), "\x1b\\"), "\x1b\\")
+	if len(chunks) < 2 {
+		t.Fatalf("expected payload split across chunks, got %d", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[0], "\x1b_Gf=100,a=T,m=1;") {
+		t.Fatalf("unexpected first chunk header: %q", chunks[0][:24])
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasPrefix(last, "\x1b_Gm=0;") {
+		t.Fatalf("last chunk should close the transfer: %q", last[:10])
+	}
+
+	var payload strings.Builder
+	for _, c := range chunks {
+		payload.WriteString(c[strings.Index(c, ";")+1:])
+	}
+	data, err := base64.StdEncoding.DecodeString(payload.String())
+	if err != nil {
+		t.Fatalf("payload is not base64: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		t.Fatal("kitty payload should be re-encoded as PNG")
+	}
+}
+
+func TestSelectViewer(t *testing.T) {
+	cmd, err := SelectViewer("linux", func(name string) (string, error) {
+		if name == "xdg-open" {
+			return "/usr/bin/xdg-open", nil
+		}
+		return "", errors.New("not found")
+	})
+	if err != nil || cmd.Path != "/usr/bin/xdg-open" {
+		t.Fatalf("unexpected viewer: %+v %v", cmd, err)
+	}
+	if _, err := SelectViewer("windows", nil); !errors.Is(err, ErrViewerNotFound) {
+		t.Fatalf("expected ErrViewerNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"agent-trace/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// agentCLIs records, per source, the resume and handoff programs that were
+// not found on PATH at startup.
+type agentCLIs struct {
+	resume  map[string]string
+	handoff map[string]string
+}
+
+func detectAgentCLIs(cfg config.AppConfig, lookPath func(string) (string, error)) agentCLIs {
+	resume, handoff := cfg.ResumeCommands, cfg.HandoffCommands
+	if resume == nil {
+		resume, _ = config.ResumeCommands(nil)
+	}
+	if handoff == nil {
+		handoff, _ = config.HandoffCommands(nil)
+	}
+	return agentCLIs{resume: missingPrograms(resume, lookPath), handoff: missingPrograms(handoff, lookPath)}
+}
+
+// missingPrograms maps each source whose command names a program that
+// lookPath can't find to that program.
+func missingPrograms(commands map[string]config.CommandTemplate, lookPath func(string) (string, error)) map[string]string {
+	missing := make(map[string]string)
+	for source, ct := range commands {
+		prog := ct.Program()
+		if prog == "" {
+			continue
+		}
+		if _, err := lookPath(prog); err != nil {
+			missing[source] = prog
+		}
+	}
+	return missing
+}
+
+// annotate marks the resume and handoff help with the sources they can't
+// serve.
+func (a agentCLIs) annotate(keys *keyMap) {
+	if len(a.resume) > 0 {
+		note := " (no " + strings.Join(sortedValues(a.resume), ", ") + ")"
+		keys.Resume.SetHelp(keys.Resume.Help().Key, keys.Resume.Help().Desc+note)
+		keys.ResumeSpawn.SetHelp(keys.ResumeSpawn.Help().Key, keys.ResumeSpawn.Help().Desc+note)
+	}
+	if len(a.handoff) > 0 {
+		note := " (no " + strings.Join(sortedValues(a.handoff), ", ") + ")"
+		keys.Handoff.SetHelp(keys.Handoff.Help().Key, keys.Handoff.Help().Desc+note)
+	}
+}
+
+// requireAgent returns a status message when the program for source is
+// missing, or nil when the action can go ahead.
+func requireAgent(missing map[string]string, source, action, configKey string) tea.Cmd {
+	prog, ok := missing[source]
+	if !ok {
+		return nil
+	}
+	text := fmt.Sprintf("Can't %s: %s is not on PATH. Install it, or set %q in the config to use a wrapper", action, prog, configKey)
+	return func() tea.Msg { return statusMsg{text: text} }
+}
+
+// sortedValues lists the distinct values of m in order.
+func sortedValues(m map[string]string) []string {
+	seen := make(map[string]bool, len(m))
+	out := make([]string, 0, len(m))
+	for _, v := range m {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
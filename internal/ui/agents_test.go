@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+)
+
+func TestDetectAgentCLIs(t *testing.T) {
+	resume, err := config.ResumeCommands(map[string]string{"aider": "{{.Source}} --restore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	onlyClaude := func(name string) (string, error) {
+		if name == "claude" {
+			return "/usr/bin/claude", nil
+		}
+		return "", errors.New("not found")
+	}
+	agents := detectAgentCLIs(config.AppConfig{ResumeCommands: resume}, onlyClaude)
+	if len(agents.resume) != 1 || agents.resume["codex"] != "codex" {
+		t.Fatalf("missing resume programs = %v", agents.resume)
+	}
+	if len(agents.handoff) != 1 || agents.handoff["codex"] != "codex" {
+		t.Fatalf("missing handoff programs = %v", agents.handoff)
+	}
+
+	keys := defaultKeys()
+	agents.annotate(&keys)
+	if !strings.Contains(keys.Resume.Help().Desc, "(no codex)") {
+		t.Fatalf("resume help not annotated: %q", keys.Resume.Help().Desc)
+	}
+
+	if requireAgent(agents.resume, "claude", "resume", "resume") != nil {
+		t.Fatal("claude resume should be allowed")
+	}
+	cmd := requireAgent(agents.resume, "codex", "resume", "resume")
+	if cmd == nil {
+		t.Fatal("codex resume should be gated")
+	}
+	if msg, ok := cmd().(statusMsg); !ok || !strings.Contains(msg.text, "codex is not on PATH") {
+		t.Fatalf("unexpected gate message %#v", cmd())
+	}
+}
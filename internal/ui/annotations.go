@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// annotateField is the annotation currently being edited in the status bar.
+type annotateField int
+
+const (
+	annotateNone annotateField = iota
+	annotateAlias
+	annotateNote
+	annotateTags
+)
+
+type annotationMsg struct {
+	ann index.Annotation
+	err error
+}
+
+func (m *Model) startAnnotate(field annotateField) {
+	if m.selectedID == "" {
+		return
+	}
+	a := m.annotations[m.selectedID]
+	m.annotating = field
+	switch field {
+	case annotateAlias:
+		m.annotateInput.Prompt = "rename: "
+		m.annotateInput.SetValue(a.Alias)
+	case annotateNote:
+		m.annotateInput.Prompt = "note: "
+		m.annotateInput.SetValue(a.Note)
+	case annotateTags:
+		m.annotateInput.Prompt = "tags: "
+		m.annotateInput.SetValue(strings.Join(a.Tags, ", "))
+	}
+	m.annotateInput.CursorEnd()
+	m.annotateInput.Focus()
+}
+
+func (m Model) updateAnnotate(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.annotating = annotateNone
+		m.annotateInput.Blur()
+		return m, nil
+	case "enter":
+		field := m.annotating
+		m.annotating = annotateNone
+		m.annotateInput.Blur()
+		a := m.annotations[m.selectedID]
+		a.SessionID = m.selectedID
+		value := strings.TrimSpace(m.annotateInput.Value())
+		switch field {
+		case annotateAlias:
+			a.Alias = value
+		case annotateNote:
+			a.Note = value
+		case annotateTags:
+			a.Tags = index.ParseTags(value)
+		}
+		return m, m.saveAnnotationCmd(a)
+	}
+	var cmd tea.Cmd
+	m.annotateInput, cmd = m.annotateInput.Update(msg)
+	return m, cmd
+}
+
+func (m Model) toggleBookmarkCmd(sessionID string) tea.Cmd {
+	a := m.annotations[sessionID]
+	a.SessionID = sessionID
+	a.Bookmarked = !a.Bookmarked
+	return m.saveAnnotationCmd(a)
+}
+
+func (m Model) saveAnnotationCmd(a index.Annotation) tea.Cmd {
+	return func() tea.Msg {
+		saved, err := m.indexer.SetAnnotation(a)
+		return annotationMsg{ann: saved, err: err}
+	}
+}
+
+func (m *Model) applyAnnotation(a index.Annotation) tea.Cmd {
+	prevNote := m.annotations[a.SessionID].Note
+	if a.Empty() {
+		delete(m.annotations, a.SessionID)
+	} else {
+		m.annotations[a.SessionID] = a
+	}
+	for idx, it := range m.list.Items() {
+		if item, ok := it.(sessionItem); ok && item.s.ID == a.SessionID {
+			item.ann = a
+			m.list.SetItem(idx, item)
+		}
+	}
+	if a.Note == prevNote {
+		return nil
+	}
+	// The note is shown above the transcript, so cached renders are stale.
+	m.forgetRenders(a.SessionID)
+	if a.SessionID == m.selectedID && !m.doc.active() {
+		return m.renderSelected(true)
+	}
+	return nil
+}
+
+// annotationSummary is the tag and note suffix shown in list descriptions.
+func annotationSummary(a index.Annotation) string {
+	var parts []string
+	if len(a.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(a.Tags, " #"))
+	}
+	if a.Note != "" {
+		parts = append(parts, "✎")
+	}
+	return strings.Join(parts, " ")
+}
+
+func prependNote(md, note string) string {
+	if note == "" {
+		return md
+	}
+	lines := strings.Split(note, "\n")
+	return "> **Note:** " + strings.Join(lines, "\n> ") + "\n\n" + md
+}
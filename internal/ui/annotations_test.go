@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+)
+
+func TestApplyAnnotationUpdatesListItem(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.applySessions([]index.Session{{ID: "s1", Source: "claude", Workdir: "/tmp/proj", MessageCount: 2}})
+	m.rendered["s1|w=80"] = "stale"
+
+	m.applyAnnotation(index.Annotation{SessionID: "s1", Alias: "auth fix", Note: "revisit", Tags: []string{"auth"}, Bookmarked: true})
+
+	item := m.list.Items()[0].(sessionItem)
+	if !strings.HasSuffix(item.Title(), "auth fix") || !strings.Contains(item.Title(), "★") {
+		t.Fatalf("expected alias and bookmark in title, got %q", item.Title())
+	}
+	if !strings.Contains(item.Description(), "#auth") {
+		t.Fatalf("expected tags in description, got %q", item.Description())
+	}
+	if _, ok := m.rendered["s1|w=80"]; ok {
+		t.Fatal("expected cached render dropped after note change")
+	}
+
+	m.applyAnnotation(index.Annotation{SessionID: "s1"})
+	if _, ok := m.annotations["s1"]; ok {
+		t.Fatal("expected cleared annotation removed")
+	}
+}
+
+func TestRenameTakesPrecedenceOverClaudeTitle(t *testing.T) {
+	s := index.Session{ID: "s1", Source: "claude", Workdir: "/tmp/proj", Title: "Flaky websocket test fix"}
+	if got := (sessionItem{s: s}).Title(); !strings.HasSuffix(got, "proj · Flaky websocket test fix") {
+		t.Fatalf("expected repo and Claude's title, got %q", got)
+	}
+	item := sessionItem{s: s, ann: index.Annotation{Alias: "ws retry"}}
+	if got := item.Title(); !strings.HasSuffix(got, "ws retry") || strings.Contains(got, "Flaky") {
+		t.Fatalf("expected the rename to replace the title, got %q", got)
+	}
+	s.Workdir = ""
+	if got := (sessionItem{s: s}).Title(); !strings.HasSuffix(got, " Flaky websocket test fix") {
+		t.Fatalf("expected Claude's title without a workdir, got %q", got)
+	}
+}
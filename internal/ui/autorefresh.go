@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"context"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type refreshTickMsg struct{}
+
+// refreshDoneMsg reports a periodic re-index and the loaded sessions that
+// gained messages in it.
+type refreshDoneMsg struct {
+	changed []string
+	err     error
+}
+
+// refreshTick schedules the next periodic re-index, if one is configured.
+func (m Model) refreshTick() tea.Cmd {
+	if m.cfg.RefreshInterval <= 0 {
+		return nil
+	}
+	return tea.Tick(m.cfg.RefreshInterval, func(time.Time) tea.Msg { return refreshTickMsg{} })
+}
+
+// autoRefreshCmd runs the incremental index pass in the background, or
+// relies on the daemon's when one runs, and reports which sessions with a
+// loaded transcript now have more messages than were loaded.
+func (m Model) autoRefreshCmd() tea.Cmd {
+	counts := make(map[string]int, len(m.messages))
+	for id := range m.messages {
+		counts[id] = m.sessions[id].MessageCount
+	}
+	daemon := m.daemonPID > 0
+	return func() tea.Msg {
+		if !daemon {
+			if _, err := m.indexer.BuildIndex(context.Background()); err != nil {
+				return refreshDoneMsg{err: err}
+			}
+		}
+		var msg refreshDoneMsg
+		for id, n := range counts {
+			s, err := m.indexer.GetSession(id)
+			if err != nil {
+				continue
+			}
+			if s.MessageCount != n {
+				msg.changed = append(msg.changed, id)
+			}
+		}
+		return msg
+	}
+}
+
+func (m *Model) applyAutoRefresh(msg refreshDoneMsg) tea.Cmd {
+	if msg.err != nil {
+		m.log.Warn("periodic re-index failed", "err", msg.err)
+		m.status = "Re-index failed: " + msg.err.Error()
+		return m.refreshTick()
+	}
+	for _, id := range msg.changed {
+		m.forgetRenders(id)
+		delete(m.messages, id)
+		m.setPartial(id, false)
+	}
+	m.log.Debug("periodic re-index", "changed", len(msg.changed))
+	return tea.Batch(m.sessionsCmd(m.searchQuery), m.refreshTick())
+}
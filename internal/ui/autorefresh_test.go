@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+)
+
+func TestAutoRefreshReloadsChangedTranscriptsAndReschedules(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	if m.refreshTick() != nil {
+		t.Fatal("expected no periodic re-index without an interval")
+	}
+
+	m = NewModel(config.AppConfig{RefreshInterval: 5 * time.Minute}, nil, nil)
+	m.messages["a"] = []index.Message{{Role: "user", Content: "hi"}}
+	m.messages["b"] = []index.Message{{Role: "user", Content: "hello"}}
+	if cmd := m.applyAutoRefresh(refreshDoneMsg{changed: []string{"a"}}); cmd == nil {
+		t.Fatal("expected the list reloaded and the next re-index scheduled")
+	}
+	if _, ok := m.messages["a"]; ok {
+		t.Fatal("expected the changed transcript dropped for reloading")
+	}
+	if _, ok := m.messages["b"]; !ok {
+		t.Fatal("expected the unchanged transcript kept")
+	}
+
+	if cmd := m.applyAutoRefresh(refreshDoneMsg{err: errors.New("locked")}); cmd == nil {
+		t.Fatal("expected a failed re-index to be retried on the next tick")
+	}
+	if m.status != "Re-index failed: locked" {
+		t.Fatalf("status = %q", m.status)
+	}
+}
@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+
+	"agent-trace/internal/config"
+
+	"github.com/charmbracelet/glamour"
+)
+
+// renderChunkSize is the markdown size above which a document is rendered
+// in pieces. Glamour's cost grows faster than linearly with input size, so
+// several small renders, run in parallel, beat one large one.
+const renderChunkSize = 64 * 1024
+
+// renderMarkdown renders md with Glamour, returning it unchanged when
+// Glamour fails. Large documents are split at turn headings and rendered
+// chunk by chunk; a chunk over maxChunk bytes (say, one enormous tool
+// output) is shown as plain text, unless maxChunk is 0. style is a
+// built-in style name or a style JSON path.
+func renderMarkdown(md string, wrap int, style string, maxChunk int) string {
+	if style == "" {
+		style = config.DefaultGlamourStyle
+	}
+	chunks := splitMarkdownChunks(md, renderChunkSize)
+	out := make([]string, len(chunks))
+	workers := min(len(chunks), runtime.GOMAXPROCS(0))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// A renderer per worker: TermRenderer is not safe for
+			// concurrent use.
+			r, err := glamour.NewTermRenderer(
+				glamour.WithStylePath(style),
+				glamour.WithWordWrap(wrap),
+			)
+			for n := range next {
+				out[n] = renderChunk(r, err, chunks[n], maxChunk)
+			}
+		}()
+	}
+	for n := range chunks {
+		next <- n
+	}
+	close(next)
+	wg.Wait()
+	for n := 1; n < len(out); n++ {
+		// Each render carries the document's top margin; keep one.
+		out[n] = strings.TrimPrefix(out[n], "\n")
+	}
+	return strings.Join(out, "")
+}
+
+func renderChunk(r *glamour.TermRenderer, rendererErr error, md string, maxChunk int) string {
+	if rendererErr != nil || (maxChunk > 0 && len(md) > maxChunk) {
+		return md
+	}
+	rendered, err := r.Render(md)
+	if err != nil {
+		return md
+	}
+	return rendered
+}
+
+// splitMarkdownChunks cuts md into pieces of roughly size bytes. Cuts fall
+// only before a top-level "## " turn heading outside fenced code, so each
+// piece is valid markdown on its own; a document without such headings
+// stays whole.
+func splitMarkdownChunks(md string, size int) []string {
+	if len(md) <= size {
+		return []string{md}
+	}
+	var chunks []string
+	start, pos := 0, 0
+	fence := ""
+	prevBlank := true
+	for pos < len(md) {
+		end := strings.IndexByte(md[pos:], '\n')
+		if end < 0 {
+			end = len(md)
+		} else {
+			end += pos + 1
+		}
+		line := md[pos:end]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			for _, c := range trimmed[3:] {
+				if c != rune(fence[0]) {
+					break
+				}
+				fence += string(c)
+			}
+		case prevBlank && strings.HasPrefix(line, "## ") && pos-start >= size:
+			chunks = append(chunks, md[start:pos])
+			start = pos
+		}
+		prevBlank = trimmed == ""
+		pos = end
+	}
+	return append(chunks, md[start:])
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"agent-trace/internal/config"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestSplitMarkdownChunksCutsOnlyAtTurnHeadings(t *testing.T) {
+	turn := "## You\n\nhello\n\n## Tool (exec)\n\n```\n\n## not a heading, inside a fence\n```\n\n"
+	md := strings.Repeat(turn, 200)
+	chunks := splitMarkdownChunks(md, 1024)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	if strings.Join(chunks, "") != md {
+		t.Fatal("chunks do not add back up to the document")
+	}
+	for n, c := range chunks {
+		if !strings.HasPrefix(c, "## You") && !strings.HasPrefix(c, "## Tool") {
+			t.Fatalf("chunk %d does not start at a turn heading: %q", n, c[:min(len(c), 40)])
+		}
+		if strings.Count(c, "```")%2 != 0 {
+			t.Fatalf("chunk %d splits a code fence", n)
+		}
+	}
+
+	if got := splitMarkdownChunks("small", 1024); len(got) != 1 {
+		t.Fatalf("expected a small document whole, got %d chunks", len(got))
+	}
+}
+
+func TestRenderMarkdownFormatsLargeTranscriptsInChunks(t *testing.T) {
+	const turns = 2000
+	md := strings.Repeat("## You\n\nplease **refactor** the parser\n\n## Claude\n\ndone, see `parser.go`\n\n", turns)
+	if len(md) <= 2*renderChunkSize {
+		t.Fatalf("test document too small to chunk: %d bytes", len(md))
+	}
+	out := renderMarkdown(md, 80, "dark", config.DefaultDisplayLimits().RenderChars)
+	if !strings.Contains(out, "\x1b[") || strings.Contains(out, "**refactor**") {
+		t.Fatal("expected a large transcript to be rendered, not shown raw")
+	}
+	if n := strings.Count(out, "refactor"); n != turns {
+		t.Fatalf("expected every turn rendered once, found %d", n)
+	}
+}
+
+func TestSanitizeMarkdownForDisplayHonorsLimits(t *testing.T) {
+	md := strings.Repeat("x", 100) + "\n" + strings.Repeat("y\n", 100)
+	got := sanitizeMarkdownForDisplay(md, nil, config.DisplayLimits{LineChars: 20, TotalChars: 120})
+	if !strings.Contains(got, "[line truncated 80 chars]") {
+		t.Fatalf("expected the long line clamped, got %q", got[:min(len(got), 80)])
+	}
+	if !strings.Contains(got, "[transcript truncated for display") {
+		t.Fatal("expected the transcript truncated")
+	}
+	if got := sanitizeMarkdownForDisplay(md, nil, config.DisplayLimits{}); got != md {
+		t.Fatal("expected zero limits to leave the transcript whole")
+	}
+}
+
+func TestRenderMarkdownShowsChunksOverTheLimitPlain(t *testing.T) {
+	md := "please **refactor** the parser\n"
+	if got := renderMarkdown(md, 80, "dark", 10); got != md {
+		t.Fatalf("expected an oversized chunk shown as is, got %q", got)
+	}
+	if got := renderMarkdown(md, 80, "dark", 0); strings.Contains(got, "**refactor**") {
+		t.Fatal("expected no limit to render the chunk")
+	}
+}
+
+func TestClampLongLinesKeepsRunesAndEscapesWhole(t *testing.T) {
+	line := "\x1b[31m" + strings.Repeat("é", 30) + "\x1b[1m" + strings.Repeat("世", 30) + "\x1b[0m"
+	got := clampLongLines(line, 20)
+	if !utf8.ValidString(got) {
+		t.Fatalf("expected valid UTF-8, got %q", got)
+	}
+	for _, seq := range []string{"\x1b[31m", "\x1b[1m", "\x1b[0m"} {
+		if !strings.Contains(got, seq) {
+			t.Fatalf("expected %q kept, got %q", seq, got)
+		}
+	}
+	plain := ansi.Strip(got)
+	if !strings.HasPrefix(plain, strings.Repeat("é", 10)+"... [line truncated 70 chars] ...") {
+		t.Fatalf("unexpected head: %q", plain)
+	}
+	if !strings.HasSuffix(plain, strings.Repeat("世", 5)) {
+		t.Fatalf("unexpected tail: %q", plain)
+	}
+	if short := "\x1b[31m" + strings.Repeat("é", 15) + "\x1b[0m"; clampLongLines(short, 20) != short {
+		t.Fatal("expected a line that fits in cells to stay whole")
+	}
+}
@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// codeBrowserLimit caps how many code blocks the code browser lists.
+const codeBrowserLimit = 5000
+
+type codeBlocksMsg struct {
+	blocks []index.CodeBlock
+	err    error
+}
+
+func (m Model) codeBlocksCmd() tea.Cmd {
+	return func() tea.Msg {
+		blocks, err := m.indexer.CodeBlocks(codeBrowserLimit)
+		return codeBlocksMsg{blocks: blocks, err: err}
+	}
+}
+
+// codeBlockItems lists code blocks for the picker: language, repo and time,
+// then the first line of code. Language and repo lead the label so typing
+// either filters by it.
+func codeBlockItems(blocks []index.CodeBlock) []pickerItem {
+	items := make([]pickerItem, 0, len(blocks))
+	for n, b := range blocks {
+		lang := b.Lang
+		if lang == "" {
+			lang = "text"
+		}
+		repo := "-"
+		if b.Workdir != "" {
+			repo = filepath.Base(b.Workdir)
+		}
+		lines := strings.Split(strings.Trim(b.Code, "\n"), "\n")
+		detail := strings.TrimSpace(lines[0])
+		if len(lines) > 1 {
+			detail += fmt.Sprintf("  (%d lines)", len(lines))
+		}
+		label := lang + "  " + repo + "  " + index.FormatUnix(b.TS)
+		items = append(items, pickerItem{label: label, detail: detail, value: strconv.Itoa(n)})
+	}
+	return items
+}
+
+// openCodeBrowser lists the code blocks mined from agent replies in a
+// picker; choosing one copies it.
+func (m *Model) openCodeBrowser(msg codeBlocksMsg) {
+	if msg.err != nil {
+		m.status = "Code blocks failed: " + msg.err.Error()
+		m.log.Error("code block browser failed", "err", msg.err)
+		return
+	}
+	if len(msg.blocks) == 0 {
+		m.status = "No code blocks in agent replies"
+		return
+	}
+	m.codeBlocks = msg.blocks
+	title := strconv.Itoa(len(msg.blocks)) + " code blocks, enter copies"
+	if len(msg.blocks) == codeBrowserLimit {
+		title = "Latest " + title
+	}
+	m.picker = newPicker(pickerCode, title, codeBlockItems(msg.blocks))
+}
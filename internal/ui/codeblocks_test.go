@@ -0,0 +1,20 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestCodeBlockItemsLeadWithLanguageAndRepo(t *testing.T) {
+	items := codeBlockItems([]index.CodeBlock{
+		{Lang: "go", Workdir: "/src/api", Code: "func main() {\n}\n"},
+		{Code: "make test"},
+	})
+	if items[0].label != "go  api  n/a" || items[0].detail != "func main() {  (2 lines)" {
+		t.Errorf("first item = %+v", items[0])
+	}
+	if items[1].label != "text  -  n/a" || items[1].detail != "make test" {
+		t.Errorf("second item = %+v", items[1])
+	}
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-trace/internal/config"
+)
+
+// fileCheckTTL bounds how long a RequireFile lookup is trusted, so renders
+// skip the filesystem but a file added or removed mid-session is noticed.
+const fileCheckTTL = 30 * time.Second
+
+type collapseRule struct {
+	name        string
+	start       *regexp.Regexp
+	end         string
+	requireFile string
+}
+
+// collapser hides injected instruction blocks behind one-line hints. It is
+// shared by render goroutines, so the file-existence cache is locked.
+type collapser struct {
+	rules []collapseRule
+
+	mu     sync.Mutex
+	checks map[string]fileCheck
+}
+
+type fileCheck struct {
+	exists bool
+	at     time.Time
+}
+
+// newCollapser compiles rules, which config has already validated; nil
+// means the built-in rules.
+func newCollapser(rules []config.CollapseRule) *collapser {
+	if rules == nil {
+		rules = config.DefaultCollapseRules()
+	}
+	c := &collapser{checks: make(map[string]fileCheck)}
+	for _, r := range rules {
+		re, err := regexp.Compile(`(?m)^(?:` + r.Start + `)`)
+		if err != nil {
+			continue
+		}
+		c.rules = append(c.rules, collapseRule{name: r.Name, start: re, end: r.End, requireFile: r.RequireFile})
+	}
+	return c
+}
+
+// collapse replaces every block matched by a rule with a hint.
+func (c *collapser) collapse(md string) string {
+	for _, r := range c.rules {
+		var b strings.Builder
+		pos := 0
+		for pos < len(md) {
+			loc := r.start.FindStringSubmatchIndex(md[pos:])
+			if loc == nil {
+				break
+			}
+			matchStart, matchEnd := pos+loc[0], pos+loc[1]
+			endRel := strings.Index(md[matchEnd:], r.end)
+			if endRel < 0 {
+				break
+			}
+			if !c.ruleApplies(r, md, pos, loc) {
+				next := max(matchEnd, matchStart+1)
+				b.WriteString(md[pos:next])
+				pos = next
+				continue
+			}
+			b.WriteString(md[pos:matchStart])
+			b.WriteString("\n> [" + r.name + " collapsed. Press `a` to expand.]\n")
+			pos = matchEnd + endRel + len(r.end)
+		}
+		b.WriteString(md[pos:])
+		md = b.String()
+	}
+	return md
+}
+
+func (c *collapser) ruleApplies(r collapseRule, md string, pos int, loc []int) bool {
+	if r.requireFile == "" {
+		return true
+	}
+	if len(loc) < 4 || loc[2] < 0 {
+		return false
+	}
+	dir := strings.Trim(strings.TrimSpace(md[pos+loc[2]:pos+loc[3]]), "`'\"")
+	if dir == "" {
+		return false
+	}
+	return c.fileExists(filepath.Join(dir, r.requireFile))
+}
+
+func (c *collapser) fileExists(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if chk, ok := c.checks[path]; ok && time.Since(chk.at) < fileCheckTTL {
+		return chk.exists
+	}
+	st, err := os.Stat(path)
+	exists := err == nil && !st.IsDir()
+	c.checks[path] = fileCheck{exists: exists, at: time.Now()}
+	return exists
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/config"
+)
+
+func TestCollapserHandlesCustomRulesAndRepeatedBlocks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "AGENTS.md"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules := append(config.DefaultCollapseRules(), config.CollapseRule{
+		Name:  "Team preamble",
+		Start: `=== TEAM RULES ===`,
+		End:   "=== END ===",
+	})
+	c := newCollapser(rules)
+
+	agents := "# AGENTS.md instructions for " + dir + "\n<INSTRUCTIONS>\nbody\n</INSTRUCTIONS>\n"
+	in := "## You\n" + agents + "first\n## You\n" + agents + "second\n## You\n=== TEAM RULES ===\nbe nice\n=== END ===\nthird\n"
+	out := c.collapse(in)
+	if n := strings.Count(out, "AGENTS.md instructions collapsed"); n != 2 {
+		t.Fatalf("expected both AGENTS blocks collapsed, got %d:\n%s", n, out)
+	}
+	if !strings.Contains(out, "Team preamble collapsed") || strings.Contains(out, "be nice") {
+		t.Fatalf("expected the custom block collapsed:\n%s", out)
+	}
+	for _, want := range []string{"first", "second", "third"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q kept:\n%s", want, out)
+		}
+	}
+}
+
+func TestCollapserCachesFileChecks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "AGENTS.md")
+	c := newCollapser(nil)
+	if c.fileExists(path) {
+		t.Fatal("file should not exist yet")
+	}
+	if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if c.fileExists(path) {
+		t.Fatal("expected the cached result within the TTL")
+	}
+	c.checks[path] = fileCheck{at: time.Now().Add(-fileCheckTTL)}
+	if !c.fileExists(path) {
+		t.Fatal("expected a fresh check after the TTL")
+	}
+}
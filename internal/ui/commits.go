@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// shortSHA trims a commit hash to the seven characters git shows by default.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// prependCommits adds a "Commits:" line naming the commits the session
+// made above the transcript.
+func prependCommits(md string, commits []index.Commit) string {
+	if len(commits) == 0 {
+		return md
+	}
+	shas := make([]string, len(commits))
+	for n, c := range commits {
+		shas[n] = "`" + shortSHA(c.SHA) + "`"
+	}
+	return "> **Commits:** " + strings.Join(shas, ", ") + "\n\n" + md
+}
+
+// commitList formats commits as a Markdown list for a PR description.
+func commitList(commits []index.Commit) string {
+	var b strings.Builder
+	for _, c := range commits {
+		b.WriteString("- " + shortSHA(c.SHA) + " " + c.Subject + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestCommitHeaderAndList(t *testing.T) {
+	commits := []index.Commit{
+		{SHA: "1a2b3c4d5e6f", Subject: "Fix the build"},
+		{SHA: "9f8e7d6", Subject: "Add tests"},
+	}
+	if got, want := prependCommits("body", commits), "> **Commits:** `1a2b3c4`, `9f8e7d6`\n\nbody"; got != want {
+		t.Errorf("prependCommits = %q, want %q", got, want)
+	}
+	if got := prependCommits("body", nil); got != "body" {
+		t.Errorf("prependCommits without commits = %q", got)
+	}
+	if got, want := commitList(commits), "- 1a2b3c4 Fix the build\n- 9f8e7d6 Add tests\n"; got != want {
+		t.Errorf("commitList = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"agent-trace/internal/clipboard"
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copyMenuItems lists the short session details `y` can copy.
+func copyMenuItems(session index.Session, resume []string, commits []index.Commit) []pickerItem {
+	items := []pickerItem{{label: "session ID", detail: session.ID, value: session.ID}}
+	if session.Workdir != "" {
+		items = append(items, pickerItem{label: "workdir", detail: session.Workdir, value: session.Workdir})
+	}
+	if len(resume) > 0 {
+		cmd := shellJoin(resume)
+		if session.Workdir != "" {
+			cmd = "cd " + shellJoin([]string{session.Workdir}) + " && " + cmd
+		}
+		items = append(items, pickerItem{label: "resume command", detail: cmd, value: cmd})
+	}
+	if len(commits) > 0 {
+		detail := fmt.Sprintf("%d commits", len(commits))
+		if len(commits) == 1 {
+			detail = "1 commit"
+		}
+		items = append(items, pickerItem{label: "commit list", detail: detail, value: commitList(commits)})
+	}
+	return items
+}
+
+func (m *Model) openCopyMenu(sessionID string, msgs []index.Message) {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return
+	}
+	resume, _ := resumeArgv(m.cfg.ResumeCommands, session)
+	m.picker = newPicker(pickerCopy, "Copy to clipboard", copyMenuItems(session, resume, index.ExtractCommits(msgs)))
+}
+
+// copyTextCmd puts text on the clipboard; what names it in the status line.
+func copyTextCmd(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return copyMsg{what: what, err: clipboard.Copy(ctx, text)}
+	}
+}
+
+// copyTranscriptCmd copies the session's transcript markdown, filtered by
+// the current toggles, as an export would write it.
+func (m Model) copyTranscriptCmd(sessionID string) tea.Cmd {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	msgs, partial := m.messages[sessionID], m.partial[sessionID]
+	toggles := m.transcriptToggles()
+
+	return func() tea.Msg {
+		msgs, err := m.allMessages(sessionID, msgs, partial)
+		if err != nil {
+			return copyMsg{err: err}
+		}
+		md := export.BuildTranscriptMarkdown(msgs, toggles, session.Source)
+		if strings.TrimSpace(md) == "" {
+			return copyMsg{err: errors.New("nothing to copy with the current filters")}
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		return copyMsg{what: "transcript", err: clipboard.Copy(ctx, md)}
+	}
+}
@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
+)
+
+func TestCopyMenuItems(t *testing.T) {
+	session := index.Session{ID: "abc", Source: "codex", Workdir: "/src/my app"}
+	items := copyMenuItems(session, []string{"codex", "resume", "abc"}, []index.Commit{{SHA: "1a2b3c4", Subject: "Fix"}})
+	want := []string{"abc", "/src/my app", "cd '/src/my app' && codex resume abc", "- 1a2b3c4 Fix\n"}
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for n, item := range items {
+		if item.value != want[n] {
+			t.Errorf("item %d (%s) = %q, want %q", n, item.label, item.value, want[n])
+		}
+	}
+
+	items = copyMenuItems(index.Session{ID: "x"}, nil, nil)
+	if len(items) != 1 || items[0].label != "session ID" {
+		t.Fatalf("without workdir or resume command: %+v", items)
+	}
+}
+
+func TestSnippetUsageIsOptIn(t *testing.T) {
+	session := index.Session{ID: "abc", Source: "claude", Usage: []index.Usage{{Model: "claude-sonnet-4-5", InputTokens: 40_000, OutputTokens: 2_000}}}
+	msgs := []index.Message{
+		{Role: "assistant", Type: "tool_use", Content: "Bash: {}"},
+		{Role: "user", Type: "tool_result", Content: "ok"},
+		{Role: "assistant", Type: "tool_use", Content: "Read: {}"},
+	}
+	m := Model{}
+	if got := m.snippetUsage(session, msgs); got != "" {
+		t.Fatalf("expected no usage line by default, got %q", got)
+	}
+	if strings.Contains(buildPRSnippet(session, msgs, "/x/docs/claude/abc.md", ""), "Usage") {
+		t.Fatal("expected the PR snippet without a usage line")
+	}
+	m.cfg = config.AppConfig{SnippetUsage: true, Pricing: pricing.Default()}
+	got := m.snippetUsage(session, msgs)
+	if !strings.HasPrefix(got, "~42.0k tokens, ~$") || !strings.HasSuffix(got, ", 2 tool calls") {
+		t.Fatalf("unexpected usage line %q", got)
+	}
+	if !strings.Contains(buildPRSnippet(session, msgs, "/x/docs/claude/abc.md", got), "- Usage: "+got+"\n") {
+		t.Fatal("expected the usage line in the PR snippet")
+	}
+}
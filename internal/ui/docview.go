@@ -0,0 +1,233 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/compare"
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// docView is an alternate markdown document shown in the transcript pane in
+// place of the selected session (session diffs and similar derived views).
+// Esc, or moving the list selection, returns to the transcript.
+type docView struct {
+	key   string
+	title string
+	md    string
+}
+
+type docMsg struct {
+	doc docView
+	err error
+}
+
+func (d docView) active() bool {
+	return d.key != ""
+}
+
+func (m Model) docCacheKey() string {
+	return fmt.Sprintf("doc:%s|w=%d|st=%s", m.doc.key, m.viewport.Width, m.glamourStyle)
+}
+
+func (m *Model) renderDoc(force bool) tea.Cmd {
+	cacheKey := m.docCacheKey()
+	if !force {
+		if rendered, ok := m.rendered[cacheKey]; ok {
+			m.setViewportFromRendered(cacheKey, rendered, false)
+			return nil
+		}
+	}
+	m.rendering = true
+	m.renderNonce++
+	nonce := m.renderNonce
+	m.viewport.SetContent("Rendering " + m.doc.title + "...")
+	wrap := max(m.viewport.Width-2, 20)
+	docKey, md, style, limits := "doc:"+m.doc.key, m.doc.md, m.glamourStyle, m.cfg.Display
+	return func() tea.Msg {
+		return renderMsg{
+			sessionID: docKey,
+			cacheKey:  cacheKey,
+			rendered:  renderMarkdown(sanitizeMarkdownForDisplay(md, nil, limits), wrap, style, limits.RenderChars),
+			nonce:     nonce,
+		}
+	}
+}
+
+func (m *Model) openDoc(doc docView) tea.Cmd {
+	m.doc = doc
+	m.focusOnList = false
+	m.status = "Viewing " + doc.title + " (esc to return)"
+	return m.renderDoc(false)
+}
+
+func (m *Model) closeDoc() tea.Cmd {
+	if !m.doc.active() {
+		return nil
+	}
+	m.doc = docView{}
+	return m.renderSelected(false)
+}
+
+func (m *Model) toggleMark(sessionID string) {
+	for idx, id := range m.marked {
+		if id == sessionID {
+			m.marked = append(m.marked[:idx:idx], m.marked[idx+1:]...)
+			m.status = fmt.Sprintf("Unmarked %s (%d/2 marked)", shorten(sessionID, 18), len(m.marked))
+			m.refreshMarkedItems()
+			return
+		}
+	}
+	m.marked = append(m.marked, sessionID)
+	if len(m.marked) > 2 {
+		m.marked = m.marked[len(m.marked)-2:]
+	}
+	m.status = fmt.Sprintf("Marked %s (%d/2 marked)", shorten(sessionID, 18), len(m.marked))
+	if len(m.marked) == 2 {
+		m.status += " - press D to diff"
+	}
+	m.refreshMarkedItems()
+}
+
+func (m *Model) isMarked(sessionID string) bool {
+	for _, id := range m.marked {
+		if id == sessionID {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Model) refreshMarkedItems() {
+	for idx, it := range m.list.Items() {
+		item, ok := it.(sessionItem)
+		if !ok {
+			continue
+		}
+		if marked := m.isMarked(item.s.ID); marked != item.marked {
+			item.marked = marked
+			m.list.SetItem(idx, item)
+		}
+	}
+}
+
+func (m Model) diffCmd() tea.Cmd {
+	if len(m.marked) != 2 {
+		return nil
+	}
+	aID, bID := m.marked[0], m.marked[1]
+	toggles := m.transcriptToggles()
+	return func() tea.Msg {
+		a, aMsgs, err := m.loadSession(aID)
+		if err != nil {
+			return docMsg{err: err}
+		}
+		b, bMsgs, err := m.loadSession(bID)
+		if err != nil {
+			return docMsg{err: err}
+		}
+		return docMsg{doc: docView{
+			key:   fmt.Sprintf("diff|%s|%s|%+v", aID, bID, toggles),
+			title: "session diff",
+			md:    compare.Markdown(a, b, aMsgs, bMsgs, toggles),
+		}}
+	}
+}
+
+func (m Model) threadCmd(sessionID string) tea.Cmd {
+	toggles := m.transcriptToggles()
+	return func() tea.Msg {
+		chain, err := m.indexer.SessionChain(sessionID)
+		if err != nil {
+			return docMsg{err: err}
+		}
+		if len(chain) < 2 {
+			return statusMsg{text: "Session is not part of a continued thread"}
+		}
+		msgs := make([][]index.Message, 0, len(chain))
+		key := "thread"
+		for _, s := range chain {
+			sm, err := m.indexer.GetMessages(s.ID)
+			if err != nil {
+				return docMsg{err: err}
+			}
+			msgs = append(msgs, sm)
+			key += "|" + s.ID
+		}
+		return docMsg{doc: docView{
+			key:   fmt.Sprintf("%s|%+v", key, toggles),
+			title: fmt.Sprintf("thread of %d sessions", len(chain)),
+			md:    export.BuildThreadMarkdown(chain, msgs, toggles),
+		}}
+	}
+}
+
+func (m Model) fileChangesCmd(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		s, err := m.indexer.GetSession(sessionID)
+		if err != nil {
+			return docMsg{err: err}
+		}
+		if s.Source != "claude" {
+			return statusMsg{text: "File snapshots are only recorded by Claude sessions"}
+		}
+		files, err := m.indexer.FileSnapshots(sessionID)
+		if err != nil {
+			return docMsg{err: err}
+		}
+		return docMsg{doc: docView{
+			key:   "files|" + sessionID,
+			title: "files changed",
+			md:    compare.SnapshotMarkdown(s, files),
+		}}
+	}
+}
+
+func (m Model) ingestIssuesCmd() tea.Cmd {
+	return func() tea.Msg {
+		issues, err := m.indexer.IngestIssues()
+		if err != nil {
+			return docMsg{err: err}
+		}
+		if len(issues) == 0 {
+			return statusMsg{text: "No index problems recorded"}
+		}
+		md := ingestIssuesMarkdown(issues)
+		return docMsg{doc: docView{
+			key:   fmt.Sprintf("issues|%x", sha256.Sum256([]byte(md))),
+			title: "index problems",
+			md:    md,
+		}}
+	}
+}
+
+// ingestIssuesMarkdown lists files the indexer skipped or read only in part.
+func ingestIssuesMarkdown(issues []index.IngestIssue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Index problems\n\n%d file(s) were skipped or had lines that did not parse. Fix or remove them, then press `L` on an affected session or run `agent-trace doctor`.\n", len(issues))
+	for _, is := range issues {
+		fmt.Fprintf(&b, "\n## `%s`\n\n", is.Path)
+		for _, line := range is.Describe() {
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// loadSession fetches a session and its messages from the index; it runs
+// inside commands, so it never touches the model's caches.
+func (m Model) loadSession(sessionID string) (index.Session, []index.Message, error) {
+	s, err := m.indexer.GetSession(sessionID)
+	if err != nil {
+		return index.Session{}, nil, fmt.Errorf("load session %s: %w", sessionID, err)
+	}
+	msgs, err := m.indexer.GetMessages(sessionID)
+	if err != nil {
+		return index.Session{}, nil, err
+	}
+	return s, msgs, nil
+}
@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestToggleMarkKeepsLastTwo(t *testing.T) {
+	m := Model{list: list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 20)}
+	m.applySessions([]index.Session{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+
+	m.toggleMark("a")
+	m.toggleMark("b")
+	m.toggleMark("c")
+	if len(m.marked) != 2 || m.marked[0] != "b" || m.marked[1] != "c" {
+		t.Fatalf("expected the two most recent marks, got %v", m.marked)
+	}
+
+	m.toggleMark("b")
+	if len(m.marked) != 1 || m.marked[0] != "c" {
+		t.Fatalf("expected toggling a marked session to unmark it, got %v", m.marked)
+	}
+	for _, it := range m.list.Items() {
+		item := it.(sessionItem)
+		if item.marked != (item.s.ID == "c") {
+			t.Fatalf("list item %s has stale marked=%v", item.s.ID, item.marked)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorArgv builds the argv that opens path at line in $VISUAL or $EDITOR
+// (vi when neither is set). VS Code style editors take -g path:line:col;
+// everything else gets the +line convention.
+func editorArgv(path string, line, col int, getenv func(string) string) []string {
+	editor := ""
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(getenv(env)); v != "" {
+			editor = v
+			break
+		}
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	argv := strings.Fields(editor)
+	switch filepath.Base(argv[0]) {
+	case "code", "code-insiders", "cursor", "codium", "windsurf":
+		target := path + ":" + strconv.Itoa(line)
+		if col > 0 {
+			target += ":" + strconv.Itoa(col)
+		}
+		return append(argv, "-g", target)
+	}
+	return append(argv, "+"+strconv.Itoa(line), path)
+}
+
+// existingFileRefs keeps the references whose path is a file on disk.
+func existingFileRefs(refs []index.FileRef, home string) []index.FileRef {
+	var out []index.FileRef
+	for _, r := range refs {
+		if info, err := os.Stat(r.AbsPath(home)); err == nil && !info.IsDir() {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// openFileRefs offers the path:line references in the session's tool output,
+// opening the only one directly.
+func (m *Model) openFileRefs(msgs []index.Message) tea.Cmd {
+	home, _ := os.UserHomeDir()
+	refs := existingFileRefs(index.ExtractFileRefs(msgs), home)
+	switch len(refs) {
+	case 0:
+		m.status = "No file:line references in tool output"
+		return nil
+	case 1:
+		return m.openFileRefCmd(refs[0])
+	}
+	m.fileRefs = refs
+	items := make([]pickerItem, 0, len(refs))
+	for idx, r := range refs {
+		label := fmt.Sprintf("%s:%d", r.Path, r.Line)
+		if r.Col > 0 {
+			label += fmt.Sprintf(":%d", r.Col)
+		}
+		items = append(items, pickerItem{label: label, detail: r.AbsPath(home), value: strconv.Itoa(idx)})
+	}
+	m.picker = newPicker(pickerFileRef, "Open file:line", items)
+	return nil
+}
+
+// openFileRefCmd suspends the TUI and opens the reference in the editor,
+// from the workdir the output was printed in.
+func (m Model) openFileRefCmd(ref index.FileRef) tea.Cmd {
+	home, _ := os.UserHomeDir()
+	path := ref.AbsPath(home)
+	argv := editorArgv(path, ref.Line, ref.Col, os.Getenv)
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if info, err := os.Stat(ref.Workdir); err == nil && info.IsDir() {
+		cmd.Dir = ref.Workdir
+	}
+	m.log.Info("opening file reference", "path", path, "line", ref.Line, "command", argv)
+	target := fmt.Sprintf("%s:%d", path, ref.Line)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", argv[0], err)
+		}
+		return openMsg{dir: target, err: err}
+	})
+}
@@ -0,0 +1,44 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestEditorArgv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	cases := []struct {
+		vars map[string]string
+		want []string
+	}{
+		{map[string]string{"EDITOR": "nvim"}, []string{"nvim", "+12", "/src/main.go"}},
+		{map[string]string{"VISUAL": "code --wait", "EDITOR": "vi"}, []string{"code", "--wait", "-g", "/src/main.go:12:5"}},
+		{nil, []string{"vi", "+12", "/src/main.go"}},
+	}
+	for _, c := range cases {
+		if got := editorArgv("/src/main.go", 12, 5, env(c.vars)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("editorArgv(%v) = %q, want %q", c.vars, got, c.want)
+		}
+	}
+}
+
+func TestExistingFileRefs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	refs := []index.FileRef{
+		{Path: "main.go", Line: 1, Workdir: dir},
+		{Path: "example.com", Line: 443, Workdir: dir},
+	}
+	got := existingFileRefs(refs, "")
+	if len(got) != 1 || got[0].Path != "main.go" {
+		t.Fatalf("existingFileRefs = %+v", got)
+	}
+}
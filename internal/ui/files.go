@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// maxHeaderFiles caps how many touched files the transcript header names.
+const maxHeaderFiles = 12
+
+// prependFilesTouched adds a "Files touched:" line above the transcript,
+// edited files first.
+func prependFilesTouched(md string, files []index.FileTouch) string {
+	if len(files) == 0 {
+		return md
+	}
+	var edited, read []string
+	for _, f := range files {
+		if f.Edited {
+			edited = append(edited, "`"+f.Path+"` (edited)")
+		} else {
+			read = append(read, "`"+f.Path+"`")
+		}
+	}
+	names := append(edited, read...)
+	if len(names) > maxHeaderFiles {
+		names = append(names[:maxHeaderFiles], fmt.Sprintf("and %d more", len(names)-maxHeaderFiles))
+	}
+	return "> **Files touched:** " + strings.Join(names, ", ") + "\n\n" + md
+}
@@ -0,0 +1,27 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestPrependFilesTouched(t *testing.T) {
+	files := []index.FileTouch{{Path: "go.mod"}, {Path: "main.go", Edited: true}}
+	if got, want := prependFilesTouched("body", files), "> **Files touched:** `main.go` (edited), `go.mod`\n\nbody"; got != want {
+		t.Errorf("prependFilesTouched = %q, want %q", got, want)
+	}
+	if got := prependFilesTouched("body", nil); got != "body" {
+		t.Errorf("prependFilesTouched without files = %q", got)
+	}
+
+	files = nil
+	for n := 0; n < maxHeaderFiles+3; n++ {
+		files = append(files, index.FileTouch{Path: fmt.Sprintf("f%d", n)})
+	}
+	if got := prependFilesTouched("", files); !strings.Contains(got, ", and 3 more\n") {
+		t.Errorf("expected the list to be capped:\n%s", got)
+	}
+}
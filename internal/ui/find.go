@@ -0,0 +1,79 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// highlightQuery is what the transcript highlights and n/p step through:
+// the local find query while one is set, otherwise the session search
+// unless it is semantic.
+func (m Model) highlightQuery() string {
+	if q := strings.TrimSpace(m.findQuery); q != "" {
+		return q
+	}
+	if _, ok := semanticQuery(m.searchQuery); ok {
+		// Semantic hits need not share any words with the query.
+		return ""
+	}
+	return strings.TrimSpace(m.searchQuery)
+}
+
+func (m *Model) startFind() {
+	m.findMode = true
+	m.findInput.SetValue(m.findQuery)
+	m.findInput.CursorEnd()
+	m.findInput.Focus()
+}
+
+// clearFind drops the local find, going back to highlighting the session
+// search if there is one.
+func (m *Model) clearFind() {
+	m.findMode = false
+	m.findQuery = ""
+	m.findInput.SetValue("")
+	m.findInput.Blur()
+	m.refreshViewportFromCache()
+}
+
+// updateFind edits the find query, re-highlighting the transcript as it
+// changes. The session list is left alone.
+func (m Model) updateFind(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.clearFind()
+		return m, nil
+	case "enter":
+		m.findMode = false
+		m.findInput.Blur()
+		if m.findQuery != "" && len(m.matchLines) == 0 {
+			m.status = "No matches for " + m.findQuery + " in this transcript"
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.findInput, cmd = m.findInput.Update(msg)
+	if q := strings.TrimSpace(m.findInput.Value()); q != m.findQuery {
+		m.findQuery = q
+		m.refreshViewportFromCache()
+		m.jumpToMatchBelow(m.viewport.YOffset)
+	}
+	return m, cmd
+}
+
+// jumpToMatchBelow scrolls to the first match at or below line, wrapping to
+// the first match, so find-as-you-type moves forward from where you are.
+func (m *Model) jumpToMatchBelow(line int) {
+	if len(m.matchLines) == 0 {
+		return
+	}
+	m.matchIndex = 0
+	for n, l := range m.matchLines {
+		if l >= line {
+			m.matchIndex = n
+			break
+		}
+	}
+	m.viewport.SetYOffset(m.clampViewportOffset(m.matchLines[m.matchIndex]))
+}
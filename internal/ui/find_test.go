@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFindHighlightsTranscriptWithoutSearching(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.viewport.Width, m.viewport.Height = 40, 2
+	m.selectedID = "s"
+	m.searchQuery = "deploy"
+	lines := []string{"deploy the widget", "", "", "widget ready", "", "", "widget shipped"}
+	m.rendered[m.renderCacheKey("s")] = strings.Join(lines, "\n")
+	m.refreshViewportFromCache()
+	if m.matchCount != 1 {
+		t.Fatalf("expected the search query highlighted first, got %d matches", m.matchCount)
+	}
+
+	var model tea.Model = m
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	for _, r := range "widget" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	if m.findMode || m.findQuery != "widget" || m.matchCount != 3 {
+		t.Fatalf("find = %q (mode %t), %d matches", m.findQuery, m.findMode, m.matchCount)
+	}
+	if m.searchQuery != "deploy" {
+		t.Fatalf("search query changed to %q", m.searchQuery)
+	}
+
+	m.focusOnList = false
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if m = model.(Model); m.matchIndex != 1 {
+		t.Fatalf("n should step to the next find match, at %d", m.matchIndex)
+	}
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = model.(Model); m.findQuery != "" || m.matchCount != 1 {
+		t.Fatalf("esc should clear the find and restore search highlights, got %q with %d matches", m.findQuery, m.matchCount)
+	}
+}
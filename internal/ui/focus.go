@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"errors"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// focusRequest names the session the TUI starts on: ref (an ID, unique ID
+// prefix or alias), or with latest the most recent session, under dir
+// when it is set.
+type focusRequest struct {
+	ref    string
+	latest bool
+	dir    string
+}
+
+func (r focusRequest) describe() string {
+	switch {
+	case !r.latest:
+		return r.ref
+	case r.dir != "":
+		return "the latest session under " + r.dir
+	}
+	return "the latest session"
+}
+
+type focusMsg struct {
+	req     focusRequest
+	session index.Session
+	err     error
+}
+
+// FocusSession makes the TUI select ref (an ID, unique ID prefix or alias)
+// with its transcript open once the session list first loads.
+func (m *Model) FocusSession(ref string) {
+	m.focus = &focusRequest{ref: ref}
+}
+
+// FocusLatest is FocusSession for the most recently active session, limited
+// to workdirs under dir when it is not empty. The lookup waits for the
+// first index pass, so a session that just ended is found.
+func (m *Model) FocusLatest(dir string) {
+	m.focus = &focusRequest{latest: true, dir: dir}
+}
+
+func (m Model) focusSessionCmd(req focusRequest) tea.Cmd {
+	return func() tea.Msg {
+		var s index.Session
+		var err error
+		if req.latest {
+			s, err = m.indexer.LatestSession(req.dir)
+		} else {
+			s, err = m.indexer.ResolveSession(req.ref)
+		}
+		return focusMsg{req: req, session: s, err: err}
+	}
+}
+
+// applyFocus selects the resolved session, adding it to the list when it
+// lies beyond the loaded pages, and moves focus to its transcript.
+func (m *Model) applyFocus(msg focusMsg) tea.Cmd {
+	if msg.err != nil {
+		if errors.Is(msg.err, index.ErrSessionNotFound) {
+			m.status = "No session matches " + msg.req.describe()
+		} else {
+			m.status = "Could not open session: " + msg.err.Error()
+		}
+		m.log.Error("opening session failed", "session", msg.req.describe(), "err", msg.err)
+		return nil
+	}
+	s := msg.session
+	if _, ok := m.allSessions[s.ID]; !ok {
+		m.allSessions[s.ID] = s
+	}
+	if m.quickFoldActive() && s.MessageCount < m.cfg.QuickUnder {
+		m.quickExpanded = true
+	}
+	m.selectedID = s.ID
+	m.applySessionsFromMap()
+	if m.selectedID != s.ID {
+		m.status = "Session " + s.ID + " is hidden by the current filters"
+		return nil
+	}
+	m.focusOnList = false
+	m.doc = docView{}
+	return tea.Batch(m.transcriptCmd(s.ID), m.renderSelected(false))
+}
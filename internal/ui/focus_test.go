@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestApplyFocusSelectsSessionBeyondLoadedPage(t *testing.T) {
+	m := Model{
+		list:        list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 20),
+		keys:        defaultKeys(),
+		log:         logging.Discard(),
+		focusOnList: true,
+		sessions:    map[string]index.Session{},
+		messages:    map[string][]index.Message{},
+		rendered:    map[string]string{},
+	}
+	var loaded []index.Session
+	for n := 0; n < 3; n++ {
+		loaded = append(loaded, index.Session{ID: fmt.Sprintf("s%d", n), MessageCount: 5, LastActivityTS: int64(100 - n)})
+	}
+	m.applySessions(loaded)
+
+	old := index.Session{ID: "old", MessageCount: 5, LastActivityTS: 1}
+	m.applyFocus(focusMsg{req: focusRequest{ref: "ol"}, session: old})
+	if m.selectedID != "old" || m.focusOnList {
+		t.Fatalf("selected %q, focus on list %t", m.selectedID, m.focusOnList)
+	}
+	if len(m.list.Items()) != 4 {
+		t.Fatalf("expected the focused session added to the list, got %d items", len(m.list.Items()))
+	}
+
+	m.applyFocus(focusMsg{req: focusRequest{ref: "nope"}, err: fmt.Errorf("%w: nope", index.ErrSessionNotFound)})
+	if m.selectedID != "old" || !strings.Contains(m.status, "No session matches nope") {
+		t.Fatalf("selected %q, status %q", m.selectedID, m.status)
+	}
+}
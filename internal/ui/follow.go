@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// followInterval is how often a followed session's files are checked for
+// appended lines.
+const followInterval = 2 * time.Second
+
+// followTickMsg and followMsg carry the generation of the follow loop
+// that scheduled them; stopping and restarting within one interval would
+// otherwise leave two loops polling.
+type followTickMsg struct{ gen int }
+
+// followMsg reports one check of a followed session.
+type followMsg struct {
+	gen       int
+	sessionID string
+	stored    int
+	err       error
+}
+
+// toggleFollow starts or stops following the selected session: like
+// `tail -f`, lines the agent appends are read in as they arrive and the
+// transcript stays pinned to its end.
+func (m *Model) toggleFollow() tea.Cmd {
+	if m.following {
+		m.following = false
+		m.status = "Stopped following"
+		return nil
+	}
+	if m.selectedID == "" {
+		return nil
+	}
+	m.following = true
+	m.followGen++
+	m.status = "Following " + shorten(m.selectedID, 18) + " (ctrl+t to stop)"
+	m.viewport.GotoBottom()
+	return m.followCmd()
+}
+
+func followTick(gen int) tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg { return followTickMsg{gen: gen} })
+}
+
+// followCmd reads what was appended to the selected session's files. Each
+// check schedules the next, until following stops.
+func (m Model) followCmd() tea.Cmd {
+	if !m.following || m.selectedID == "" {
+		return nil
+	}
+	sessionID, gen := m.selectedID, m.followGen
+	return func() tea.Msg {
+		n, err := m.indexer.FollowSession(context.Background(), sessionID)
+		return followMsg{gen: gen, sessionID: sessionID, stored: n, err: err}
+	}
+}
+
+func (m *Model) applyFollow(msg followMsg) tea.Cmd {
+	if !m.following {
+		return nil
+	}
+	// A check from a loop stopped and restarted since still reloads what
+	// it read, but only the current loop schedules the next one.
+	var next tea.Cmd
+	if msg.gen == m.followGen {
+		next = followTick(msg.gen)
+	}
+	if msg.err != nil {
+		m.log.Warn("following session failed", "session", msg.sessionID, "err", msg.err)
+		m.status = "Follow: " + msg.err.Error()
+		return next
+	}
+	if msg.stored == 0 || msg.sessionID != m.selectedID {
+		return next
+	}
+	m.status = fmt.Sprintf("Following: %d new message(s)", msg.stored)
+	m.forgetRenders(msg.sessionID)
+	delete(m.messages, msg.sessionID)
+	m.setPartial(msg.sessionID, false)
+	return tea.Batch(m.sessionsCmd(m.searchQuery), next)
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFollowPinsTranscriptToBottomAndReloadsOnNewLines(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.viewport.Width, m.viewport.Height = 40, 3
+	m.selectedID = "s"
+	m.messages["s"] = []index.Message{{Role: "user", Content: "hi"}}
+	rendered := strings.Repeat("line\n", 20) + "last"
+
+	var model tea.Model = m
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = model.(Model)
+	if !m.following || cmd == nil {
+		t.Fatal("expected ctrl+t to start following")
+	}
+	m.setViewportFromRendered(m.renderCacheKey("s"), rendered, true)
+	if !m.viewport.AtBottom() {
+		t.Fatal("expected the followed transcript pinned to the bottom")
+	}
+
+	if cmd := m.applyFollow(followMsg{gen: m.followGen, sessionID: "s"}); cmd == nil {
+		t.Fatal("expected another check scheduled")
+	}
+	if _, ok := m.messages["s"]; !ok {
+		t.Fatal("expected the transcript kept when nothing was appended")
+	}
+	m.applyFollow(followMsg{gen: m.followGen, sessionID: "s", stored: 2})
+	if _, ok := m.messages["s"]; ok {
+		t.Fatal("expected the transcript reloaded after new lines")
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if model.(Model).following {
+		t.Fatal("expected ctrl+t again to stop following")
+	}
+}
+
+func TestFollowRestartDropsTheOldLoop(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.selectedID = "s"
+	m.toggleFollow()
+	old := m.followGen
+	m.toggleFollow()
+	m.toggleFollow()
+	if cmd := m.applyFollow(followMsg{gen: old, sessionID: "s"}); cmd != nil {
+		t.Fatal("expected the stopped loop's check not to schedule another")
+	}
+	if cmd := m.applyFollow(followMsg{gen: m.followGen, sessionID: "s"}); cmd == nil {
+		t.Fatal("expected the current loop to keep checking")
+	}
+	if _, cmd := m.Update(followTickMsg{gen: old}); cmd != nil {
+		t.Fatal("expected a stale tick dropped")
+	}
+}
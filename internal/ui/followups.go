@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"path/filepath"
+	"strconv"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// followUpLimit caps how many follow-ups the follow-up list shows.
+const followUpLimit = 2000
+
+type followUpsMsg struct {
+	items []index.FollowUp
+	err   error
+}
+
+func (m Model) followUpsCmd() tea.Cmd {
+	return func() tea.Msg {
+		items, err := m.indexer.FollowUps(followUpLimit)
+		return followUpsMsg{items: items, err: err}
+	}
+}
+
+// followUpItems lists follow-ups for the picker under the session they
+// came from.
+func (m Model) followUpItems(items []index.FollowUp) []pickerItem {
+	out := make([]pickerItem, 0, len(items))
+	for n, f := range items {
+		s, ok := m.allSessions[f.SessionID]
+		if !ok {
+			s = index.Session{ID: f.SessionID, Workdir: f.Workdir}
+		}
+		label := sessionItem{s: s, ann: m.annotations[f.SessionID]}.Title()
+		if f.Workdir != "" {
+			label += "  " + filepath.Base(f.Workdir)
+		}
+		label += "  " + index.FormatUnix(f.TS)
+		out = append(out, pickerItem{label: label, detail: f.Text, value: strconv.Itoa(n)})
+	}
+	return out
+}
+
+// openFollowUps lists the follow-ups agents left across sessions in a
+// picker; choosing one opens the message it came from.
+func (m *Model) openFollowUps(msg followUpsMsg) {
+	if msg.err != nil {
+		m.status = "Follow-ups failed: " + msg.err.Error()
+		m.log.Error("follow-up list failed", "err", msg.err)
+		return
+	}
+	if len(msg.items) == 0 {
+		m.status = "No follow-ups or TODOs in agent replies"
+		return
+	}
+	m.followUps = msg.items
+	title := strconv.Itoa(len(msg.items)) + " follow-ups"
+	if len(msg.items) == followUpLimit {
+		title = "Latest " + title
+	}
+	m.picker = newPicker(pickerFollowUp, title, m.followUpItems(msg.items))
+}
@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestFollowUpItemsNameTheirSession(t *testing.T) {
+	m := Model{allSessions: map[string]index.Session{"s1": {ID: "s1", Preview: "fix the retry loop"}}}
+	items := m.followUpItems([]index.FollowUp{
+		{SessionID: "s1", Workdir: "/src/api", Text: "Tune the backoff"},
+		{SessionID: "gone", Text: "Update the changelog"},
+	})
+	if !strings.HasSuffix(items[0].label, "  api  n/a") || items[0].detail != "Tune the backoff" || items[0].value != "0" {
+		t.Errorf("first item = %+v", items[0])
+	}
+	if items[1].detail != "Update the changelog" || items[1].value != "1" {
+		t.Errorf("second item = %+v", items[1])
+	}
+}
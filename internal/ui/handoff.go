@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handoffData is what handoff command templates can refer to. SessionID,
+// Workdir and Source describe the session being handed off.
+type handoffData struct {
+	resumeData
+	Prompt         string
+	TranscriptPath string
+}
+
+// handoffReadyMsg carries the command to run once the transcript is
+// exported.
+type handoffReadyMsg struct {
+	sessionID string
+	target    string
+	argv      []string
+	dir       string
+	err       error
+}
+
+// handoffTarget is the agent a session from source is handed off to.
+func handoffTarget(source string) string {
+	switch source {
+	case "claude":
+		return "codex"
+	case "codex":
+		return "claude"
+	}
+	return ""
+}
+
+func handoffPrompt(session index.Session, transcriptPath string) string {
+	from := "Codex"
+	if session.Source == "claude" {
+		from = "Claude"
+	}
+	return fmt.Sprintf("Continue the work of an earlier %s session (%s) in this directory. "+
+		"Its transcript is at %s: read it first, then pick up where it left off. "+
+		"Summarize the state you found before making changes.", from, session.ID, transcriptPath)
+}
+
+// handoffArgv expands the handoff command of the target agent.
+func handoffArgv(commands map[string]config.CommandTemplate, session index.Session, transcriptPath string) ([]string, string, error) {
+	target := handoffTarget(session.Source)
+	if commands == nil {
+		var err error
+		if commands, err = config.HandoffCommands(nil); err != nil {
+			return nil, target, err
+		}
+	}
+	tmpl, ok := commands[target]
+	if !ok {
+		return nil, target, fmt.Errorf("no handoff command for %s sessions", session.Source)
+	}
+	argv, err := tmpl.Expand(handoffData{
+		resumeData:     resumeData{SessionID: session.ID, Workdir: session.Workdir, Source: session.Source},
+		Prompt:         handoffPrompt(session, transcriptPath),
+		TranscriptPath: transcriptPath,
+	})
+	return argv, target, err
+}
+
+// handoffCmd exports the full transcript, then hands back the command that
+// starts the other agent in the session's workdir.
+func (m Model) handoffCmd(sessionID string) tea.Cmd {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	session.Tags = m.annotations[sessionID].Tags
+	msgs, partial := m.messages[sessionID], m.partial[sessionID]
+	toggles := m.transcriptToggles()
+	commands := m.cfg.HandoffCommands
+
+	return func() tea.Msg {
+		msgs, err := m.allMessages(sessionID, msgs, partial)
+		if err != nil {
+			return handoffReadyMsg{sessionID: sessionID, err: err}
+		}
+		path, _, err := m.exporter.Export(session, msgs, toggles)
+		if err != nil {
+			return handoffReadyMsg{sessionID: sessionID, err: err}
+		}
+		argv, target, err := handoffArgv(commands, session, path)
+		return handoffReadyMsg{sessionID: sessionID, target: target, argv: argv, dir: session.Workdir, err: err}
+	}
+}
+
+// runHandoff suspends the TUI and runs the other agent. Its exit is
+// reported like a resume.
+func (m Model) runHandoff(msg handoffReadyMsg) tea.Cmd {
+	cmd := exec.Command(msg.argv[0], msg.argv[1:]...)
+	cmd.Dir = msg.dir
+	m.log.Info("handing off session", "session", msg.sessionID, "to", msg.target, "command", msg.argv[0], "dir", msg.dir)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return resumeMsg{err: err}
+	})
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+)
+
+func TestHandoffArgv(t *testing.T) {
+	session := index.Session{ID: "s1", Source: "claude", Workdir: "/src/app"}
+	argv, target, err := handoffArgv(nil, session, "/src/app/docs/claude/s1.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "codex" || len(argv) != 2 || argv[0] != "codex" {
+		t.Fatalf("target %q, argv %q", target, argv)
+	}
+	if !strings.Contains(argv[1], "/src/app/docs/claude/s1.md") || !strings.Contains(argv[1], "Claude session (s1)") {
+		t.Fatalf("prompt misses the transcript: %q", argv[1])
+	}
+
+	cmds, err := config.HandoffCommands(map[string]string{"claude": "cl --append-system-prompt {{.Prompt}} --add-dir {{.Workdir}}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	argv, target, err = handoffArgv(cmds, index.Session{ID: "s2", Source: "codex", Workdir: "/w"}, "/w/s2.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != "claude" || argv[0] != "cl" || argv[3] != "--add-dir" || argv[4] != "/w" {
+		t.Fatalf("override: target %q, argv %q", target, argv)
+	}
+
+	if _, _, err := handoffArgv(nil, index.Session{ID: "s3", Source: "other"}, "/x.md"); err == nil {
+		t.Fatal("expected an error for a source without a handoff target")
+	}
+}
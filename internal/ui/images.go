@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"agent-trace/internal/export"
+	"agent-trace/internal/index"
+	"agent-trace/internal/termimg"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionImage is an image attachment decoded from a transcript data URI.
+type sessionImage struct {
+	mime string
+	data []byte
+	role string
+	ts   int64
+}
+
+// collectImages decodes every embedded data-URI image in msgs, in
+// transcript order. Payloads that are not valid base64 are skipped.
+func collectImages(msgs []index.Message) []sessionImage {
+	var out []sessionImage
+	for _, msg := range msgs {
+		export.ReplaceImageData(msg.Content, func(mime, payload string) string {
+			clean := strings.NewReplacer("\n", "", "\r", "").Replace(payload)
+			data, err := base64.StdEncoding.DecodeString(clean)
+			if err != nil {
+				data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(clean, "="))
+			}
+			if err == nil && len(data) > 0 {
+				out = append(out, sessionImage{mime: mime, data: data, role: msg.Role, ts: msg.TS.Int64})
+			}
+			return ""
+		})
+	}
+	return out
+}
+
+func (m *Model) openImages(msgs []index.Message) tea.Cmd {
+	images := collectImages(msgs)
+	switch len(images) {
+	case 0:
+		m.status = "No images in this session"
+		return nil
+	case 1:
+		return m.showImage(images[0], 1, 1)
+	}
+	m.images = images
+	items := make([]pickerItem, 0, len(images))
+	for idx, img := range images {
+		items = append(items, pickerItem{
+			label:  fmt.Sprintf("image %d", idx+1),
+			detail: fmt.Sprintf("%s | %s | %s | %s", img.role, strings.TrimPrefix(img.mime, "image/"), formatBytes(len(img.data)), index.FormatUnix(img.ts)),
+			value:  strconv.Itoa(idx),
+		})
+	}
+	m.picker = newPicker(pickerImage, "Preview image", items)
+	return nil
+}
+
+// showImage draws img inline when the terminal speaks a graphics protocol
+// and otherwise hands it to the system image viewer.
+func (m *Model) showImage(img sessionImage, n, total int) tea.Cmd {
+	if m.imageProtocol == termimg.None {
+		return func() tea.Msg {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			path, err := termimg.OpenExternal(ctx, img.data, export.ImageExt(img.mime))
+			if err != nil {
+				m.log.Error("image preview failed", "err", err)
+				return statusMsg{text: "Image preview failed: " + err.Error()}
+			}
+			return statusMsg{text: "Opened image in viewer: " + path}
+		}
+	}
+	viewer := &imageViewer{protocol: m.imageProtocol, img: img, caption: fmt.Sprintf("image %d of %d", n, total)}
+	return tea.Exec(viewer, func(err error) tea.Msg {
+		if err != nil {
+			m.log.Error("image preview failed", "err", err)
+			return statusMsg{text: "Image preview failed: " + err.Error()}
+		}
+		return statusMsg{text: fmt.Sprintf("Viewed image %d of %d", n, total)}
+	})
+}
+
+// imageViewer is a tea.ExecCommand that takes over the terminal to draw one
+// image and waits for enter before handing control back to the TUI.
+type imageViewer struct {
+	protocol termimg.Protocol
+	img      sessionImage
+	caption  string
+	stdin    io.Reader
+	stdout   io.Writer
+}
+
+func (v *imageViewer) SetStdin(r io.Reader)  { v.stdin = r }
+func (v *imageViewer) SetStdout(w io.Writer) { v.stdout = w }
+func (v *imageViewer) SetStderr(io.Writer)   {}
+
+func (v *imageViewer) Run() error {
+	if _, err := io.WriteString(v.stdout, "\x1b[2J\x1b[H"); err != nil {
+		return err
+	}
+	if err := termimg.Encode(v.stdout, v.protocol, v.img.data, v.img.mime); err != nil {
+		return err
+	}
+	fmt.Fprintf(v.stdout, "\r\n\r\n%s (%s, %s) - press enter to return ", v.caption, v.img.mime, formatBytes(len(v.img.data)))
+	_, _ = bufio.NewReader(v.stdin).ReadString('\n')
+	if v.protocol == termimg.Kitty {
+		// Kitty keeps placements on screen until they are deleted.
+		_, _ = io.WriteString(v.stdout, "\x1b_Ga=d\x1b\\")
+	}
+	return nil
+}
+
+func formatBytes(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
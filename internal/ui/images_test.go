@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestCollectImages(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Content: "look: data:image/png;base64,aGVsbG8= and data:image/gif;base64,d29ybGQ"},
+		{Role: "assistant", Content: "no images here"},
+		{Role: "user", Content: "data:image/png;base64,!!!"},
+	}
+	images := collectImages(msgs)
+	if len(images) != 2 {
+		t.Fatalf("expected 2 decodable images, got %d", len(images))
+	}
+	if images[0].mime != "image/png" || string(images[0].data) != "hello" {
+		t.Fatalf("unexpected first image: %q %q", images[0].mime, images[0].data)
+	}
+	if images[1].mime != "image/gif" || string(images[1].data) != "world" {
+		t.Fatalf("unexpected second image: %q %q", images[1].mime, images[1].data)
+	}
+}
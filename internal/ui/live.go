@@ -0,0 +1,16 @@
+package ui
+
+import (
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+// liveWindow is how recently a session's files must have changed for it
+// to count as an agent run still in flight.
+const liveWindow = 3 * time.Minute
+
+// isLive reports whether s was written to within liveWindow of now.
+func isLive(s index.Session, now time.Time) bool {
+	return s.ModTime > 0 && now.Sub(time.Unix(s.ModTime, 0)) < liveWindow
+}
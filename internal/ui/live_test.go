@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestLiveIndicatorMarksRecentlyWrittenSessions(t *testing.T) {
+	now := time.Now()
+	recent := sessionItem{s: index.Session{ID: "a", ModTime: now.Add(-time.Minute).Unix()}}
+	if !strings.Contains(ansi.Strip(recent.Title()), "◉ live") {
+		t.Fatalf("expected a live marker, got %q", recent.Title())
+	}
+	for _, s := range []index.Session{{ID: "b", ModTime: now.Add(-time.Hour).Unix()}, {ID: "c"}} {
+		if title := (sessionItem{s: s}).Title(); strings.Contains(title, "live") {
+			t.Fatalf("expected no live marker for %s, got %q", s.ID, title)
+		}
+	}
+}
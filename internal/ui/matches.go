@@ -0,0 +1,124 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// matchBrowserLimit caps how many messages the match browser lists.
+const matchBrowserLimit = 500
+
+type matchesMsg struct {
+	query   string
+	matches []index.MessageMatch
+	err     error
+}
+
+// matchJump is a message to scroll to once its session's transcript is
+// reloaded and rendered.
+type matchJump struct {
+	sessionID string
+	messageID int64
+	loaded    bool
+}
+
+func (m Model) searchMatchesCmd(query string) tea.Cmd {
+	return func() tea.Msg {
+		matches, err := m.indexer.SearchMessages(query, matchBrowserLimit)
+		return matchesMsg{query: query, matches: matches, err: err}
+	}
+}
+
+// openMatchBrowser lists the matching messages across sessions in a picker.
+func (m *Model) openMatchBrowser(msg matchesMsg) {
+	if msg.err != nil {
+		m.status = "Search failed: " + msg.err.Error()
+		m.log.Error("message search failed", "query", msg.query, "err", msg.err)
+		return
+	}
+	if len(msg.matches) == 0 {
+		m.status = "No messages match " + msg.query
+		return
+	}
+	m.matches = msg.matches
+	items := make([]pickerItem, 0, len(msg.matches))
+	for n, mm := range msg.matches {
+		s, ok := m.allSessions[mm.SessionID]
+		if !ok {
+			s = index.Session{ID: mm.SessionID}
+		}
+		label := sessionItem{s: s, ann: m.annotations[mm.SessionID]}.Title() + "  " + index.FormatUnix(mm.TS.Int64) + " " + mm.Role
+		items = append(items, pickerItem{label: label, detail: mm.Snippet, value: strconv.Itoa(n)})
+	}
+	title := strconv.Itoa(len(msg.matches)) + " matching messages"
+	if len(msg.matches) == matchBrowserLimit {
+		title = "Top " + title
+	}
+	m.picker = newPicker(pickerMatch, title, items)
+}
+
+// jumpToMessage opens the session a match belongs to; the transcript
+// scrolls to the message once it is rendered.
+func (m *Model) jumpToMessage(mm index.MessageMatch) tea.Cmd {
+	m.matchJump = &matchJump{sessionID: mm.SessionID, messageID: mm.MessageID}
+	return m.focusSessionCmd(focusRequest{ref: mm.SessionID})
+}
+
+// applyMatchJump scrolls a freshly shown transcript to the pending match.
+// rendered is the viewport content.
+func (m *Model) applyMatchJump(rendered string) {
+	jump := m.matchJump
+	if jump == nil || !jump.loaded || jump.sessionID != m.selectedID || m.doc.active() {
+		return
+	}
+	m.matchJump = nil
+	var content string
+	found := false
+	for _, msg := range m.messages[jump.sessionID] {
+		if msg.ID == jump.messageID {
+			content, found = msg.Content, true
+			break
+		}
+	}
+	if !found {
+		m.status = "The match lies before the loaded part of the transcript"
+		return
+	}
+	if len(m.matchLines) == 0 {
+		return
+	}
+	n := matchLineFor(strings.Split(ansi.Strip(rendered), "\n"), m.matchLines, content)
+	if n < 0 {
+		return
+	}
+	m.matchIndex = n
+	m.viewport.SetYOffset(m.clampViewportOffset(m.matchLines[n]))
+}
+
+// matchLineFor picks the entry of matchLines whose rendered line is part of
+// content, comparing letters and digits only since rendering drops markup
+// and rewraps text. It returns -1 when no line is distinctive enough.
+func matchLineFor(lines []string, matchLines []int, content string) int {
+	want := plainWords(content)
+	for n, ln := range matchLines {
+		if ln < 0 || ln >= len(lines) {
+			continue
+		}
+		if got := plainWords(lines[ln]); len(got) >= 12 && strings.Contains(want, got) {
+			return n
+		}
+	}
+	return -1
+}
+
+func plainWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMatchLineFor(t *testing.T) {
+	lines := []string{
+		"  ## User",
+		"  please deploy the widget to",
+		"  staging",
+		"  ## Assistant",
+		"  The **widget** deploy finished.",
+	}
+	matchLines := []int{1, 4}
+	if got := matchLineFor(lines, matchLines, "The `widget` deploy finished."); got != 1 {
+		t.Errorf("matchLineFor = %d, want 1", got)
+	}
+	if got := matchLineFor(lines, matchLines, "please deploy the widget to staging"); got != 0 {
+		t.Errorf("matchLineFor = %d, want 0", got)
+	}
+	if got := matchLineFor(lines, matchLines, "something else"); got != -1 {
+		t.Errorf("matchLineFor = %d, want -1", got)
+	}
+}
+
+func TestSessionDescriptionShowsSearchSnippet(t *testing.T) {
+	item := sessionItem{s: index.Session{ID: "s", Preview: "first prompt", Matches: 3, Snippet: "the " + index.MatchStart + "gizmo" + index.MatchEnd + " is ready"}}
+	desc := ansi.Strip(item.Description())
+	if !strings.HasSuffix(desc, " | 3 matches | the gizmo is ready") || strings.Contains(desc, "first prompt") {
+		t.Fatalf("expected the snippet instead of the preview, got %q", desc)
+	}
+	item.s.Snippet, item.s.Matches = "", 0
+	if desc := item.Description(); !strings.HasSuffix(desc, " | first prompt") {
+		t.Fatalf("expected the preview without a search, got %q", desc)
+	}
+	item.s.Summary = "Fixed the gizmo."
+	if desc := item.Description(); !strings.HasSuffix(desc, " | Fixed the gizmo.") {
+		t.Fatalf("expected the summary over the preview, got %q", desc)
+	}
+}
+
+func TestSessionDescriptionRelativeTime(t *testing.T) {
+	s := index.Session{ID: "s", LastActivityTS: time.Now().Add(-3 * time.Hour).Unix(), MessageCount: 4}
+	if desc := (sessionItem{s: s, relative: true}).Description(); !strings.HasPrefix(desc, "last 3h ago | 4 msgs") {
+		t.Fatalf("expected a relative time, got %q", desc)
+	}
+	if desc := (sessionItem{s: s}).Description(); !strings.HasPrefix(desc, "last "+index.FormatUnix(s.LastActivityTS)) {
+		t.Fatalf("expected an absolute time by default, got %q", desc)
+	}
+}
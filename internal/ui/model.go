@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,6 +18,9 @@ import (
 	"agent-trace/internal/export"
 	"agent-trace/internal/highlight"
 	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+	"agent-trace/internal/pricing"
+	"agent-trace/internal/termimg"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
@@ -25,7 +29,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/styles"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 )
@@ -40,30 +44,85 @@ type Model struct {
 	help     help.Model
 	spinner  spinner.Model
 	search   textinput.Model
-	keys     keyMap
+	// findInput edits findQuery, a ctrl+f find within the open transcript.
+	findInput textinput.Model
+	// gotoInput edits the turn number a : jump goes to.
+	gotoInput textinput.Model
+	keys      keyMap
 
 	width  int
 	height int
 
-	indexing        bool
-	searchMode      bool
-	searchQuery     string
-	focusOnList     bool
-	includeTools    bool
-	includeAborted  bool
-	includeEvents   bool
-	collapseAgents  bool
-	sortOldestFirst bool
-	groupByWorktree bool
-	sourceFilter    int // 0=all, 1=claude only, 2=codex only
-	showKeyHelp     bool
-	rendering       bool
-	renderNonce     int
+	indexing         bool
+	indexProgress    index.IndexProgress
+	searchMode       bool
+	findMode         bool
+	gotoMode         bool
+	findQuery        string
+	following        bool
+	followGen        int             // tags follow checks, so a restarted loop drops the old one's
+	showEmpty        bool            // list sessions with no conversational messages
+	watched          map[string]bool // sessions whose new replies notify
+	watchGen         int             // tags watch checks, so a restarted loop drops the old one's
+	searchQuery      string
+	focusOnList      bool
+	includeTools     bool
+	includeAborted   bool
+	includeEvents    bool
+	includeThinking  bool
+	includeReasoning bool
+	showTimestamps   bool
+	expandSubagents  bool
+	collapseAgents   bool
+	collapser        *collapser
+	sortOldestFirst  bool
+	groupByWorktree  bool
+	quickExpanded    bool
+	sourceFilter     int    // 0=all, 1=claude only, 2=codex only
+	roleFilter       string // "" for every role, else "user" or "assistant"
+	workdirFilter    string
+	showKeyHelp      bool
+	picker           picker
+	doc              docView
+	replay           replayState
+	marked           []string
+	rendering        bool
+	renderNonce      int
+	glamourStyle     string
+	toggleSource     string // source whose toggle profile was applied last
+	imageProtocol    termimg.Protocol
+	images           []sessionImage       // candidates shown by the image picker
+	fileRefs         []index.FileRef      // candidates shown by the file:line picker
+	prompts          []index.Prompt       // candidates shown by the prompt library
+	codeBlocks       []index.CodeBlock    // candidates shown by the code block browser
+	followUps        []index.FollowUp     // candidates shown by the follow-up list
+	matches          []index.MessageMatch // candidates shown by the match browser
+	matchJump        *matchJump           // match to scroll to once its transcript renders
+	annotating       annotateField
+	annotateInput    textinput.Model
+	sidePane         sidePane
+	safeOverride     map[string]bool // per-session safe-render choice, overriding the config
+	daemonPID        int             // a running daemon keeps the index fresh; skip BuildIndex
+	log              *slog.Logger    // full errors behind the truncated status line
+	embedder         index.Embedder  // embeds ~ queries; nil without --embedder
+	agents           agentCLIs       // agent CLIs missing from PATH
+	focus            *focusRequest   // session to select once the list loads
+	pager            sessionPager
+	partial          map[string]bool // sessions with only the newest messages loaded
+	loadingOlder     bool
+	olderAnchor      int // lines from the bottom to keep at the top after older messages render
+	viewMode         viewMode
 
 	selectedID  string
 	allSessions map[string]index.Session
 	sessions    map[string]index.Session
 	messages    map[string][]index.Message
+	subagents   map[string][]index.Subagent
+	similar     map[string][]index.Session
+	// turnsBefore counts the prompts before the loaded window of a windowed
+	// transcript, so its turns are numbered from the session's start.
+	turnsBefore map[string]int
+	annotations map[string]index.Annotation
 	rendered    map[string]string
 	highlighted map[string]highlight.Result
 	matchLines  []int
@@ -78,18 +137,32 @@ type indexDoneMsg struct {
 	result index.IndexResult
 	err    error
 }
+type indexProgressMsg struct {
+	progress index.IndexProgress
+	ch       chan tea.Msg
+}
 type sessionsMsg struct {
-	sessions []index.Session
-	err      error
+	query       string
+	limit       int
+	sessions    []index.Session
+	annotations map[string]index.Annotation
+	err         error
 }
 type transcriptMsg struct {
-	session index.Session
-	msgs    []index.Message
-	err     error
+	session   index.Session
+	msgs      []index.Message
+	older     bool // msgs is the newest window; earlier messages remain
+	subagents []index.Subagent
+	similar   []index.Session
+	// turnsBefore is how many prompts precede msgs when older is set.
+	turnsBefore int
+	err         error
 }
 type exportMsg struct {
 	path string
-	err  error
+	// unchanged is set when the existing export already matched.
+	unchanged bool
+	err       error
 }
 type renderMsg struct {
 	sessionID string
@@ -99,15 +172,32 @@ type renderMsg struct {
 	err       error
 }
 type copyMsg struct {
-	err error
+	what string
+	err  error
 }
 type resumeMsg struct {
 	err error
 }
+type statusMsg struct {
+	text string
+}
+type refreshMsg struct {
+	sessionID string
+	files     int
+	err       error
+}
+type workdirsMsg struct {
+	workdirs []index.WorkdirSummary
+	err      error
+}
 
 type sessionItem struct {
 	s            index.Session
+	ann          index.Annotation
+	cost         string // formatted estimate, empty without usage data
 	groupDivider bool
+	marked       bool
+	relative     bool // last activity as "2h ago"
 }
 
 func (i sessionItem) Title() string {
@@ -115,22 +205,65 @@ func (i sessionItem) Title() string {
 	if i.groupDivider {
 		prefix = "┈ "
 	}
+	if i.marked {
+		prefix += markedStyle.Render("◆") + " "
+	}
+	if i.ann.Bookmarked {
+		prefix += markedStyle.Render("★") + " "
+	}
 	dot := codexDotStyle.Render("○") + " "
 	if i.s.Source == "claude" {
 		dot = claudeDotStyle.Render("●") + " "
 	}
 	prefix += dot
+	if isLive(i.s, time.Now()) {
+		prefix += liveStyle.Render("◉ live") + " "
+	}
+	// The user's own name wins; Claude's title keeps the repo beside it.
+	if i.ann.Alias != "" {
+		return prefix + i.ann.Alias
+	}
 	if i.s.Workdir != "" {
 		base := filepath.Base(i.s.Workdir)
 		if base != "." && base != "/" {
+			if i.s.Title != "" {
+				return prefix + base + " · " + i.s.Title
+			}
 			return prefix + base
 		}
 	}
+	if i.s.Title != "" {
+		return prefix + i.s.Title
+	}
 	return prefix + shorten(i.s.ID, 28)
 }
 
 func (i sessionItem) Description() string {
-	meta := fmt.Sprintf("last %s | %d msgs", index.FormatUnix(i.s.LastActivityTS), i.s.MessageCount)
+	meta := fmt.Sprintf("last %s | %d msgs", formatLast(i.s.LastActivityTS, i.relative), i.s.MessageCount)
+	switch {
+	case i.s.Matches == 1:
+		meta += " | 1 match"
+	case i.s.Matches > 1:
+		meta += fmt.Sprintf(" | %d matches", i.s.Matches)
+	}
+	if i.s.Branch != "" {
+		meta += " | ⎇ " + i.s.Branch
+	}
+	if spark := sparkline(i.s.Activity); spark != "" {
+		meta += " | " + spark
+	}
+	if i.cost != "" {
+		meta += " | " + i.cost
+	}
+	if summary := annotationSummary(i.ann); summary != "" {
+		meta += " | " + summary
+	}
+	if i.s.Snippet != "" {
+		return meta + " | " + renderHits(i.s.Snippet, nil)
+	}
+	if i.s.Summary != "" {
+		return meta + " | " + i.s.Summary
+	}
 	if i.s.Preview == "" {
 		return meta
 	}
@@ -138,7 +271,7 @@ func (i sessionItem) Description() string {
 }
 
 func (i sessionItem) FilterValue() string {
-	return strings.ToLower(i.s.ID + " " + i.s.Preview + " " + i.s.Workdir)
+	return strings.ToLower(i.s.ID + " " + i.s.Preview + " " + i.s.Summary + " " + i.s.Title + " " + i.s.Workdir + " " + i.s.Branch + " " + i.ann.Alias + " " + strings.Join(i.ann.Tags, " "))
 }
 
 func NewModel(cfg config.AppConfig, idx *index.Indexer, exp *export.Exporter) Model {
@@ -164,29 +297,61 @@ func NewModel(cfg config.AppConfig, idx *index.Indexer, exp *export.Exporter) Mo
 	ti.Prompt = "/ "
 	ti.CharLimit = 256
 
+	fi := textinput.New()
+	fi.Placeholder = "Find in transcript..."
+	fi.Prompt = "find: "
+	fi.CharLimit = 256
+
+	gi := textinput.New()
+	gi.Placeholder = "number"
+	gi.Prompt = "turn: "
+	gi.CharLimit = 7
+
+	ai := textinput.New()
+	ai.CharLimit = 1024
+
 	m := Model{
-		cfg:      cfg,
-		indexer:  idx,
-		exporter: exp,
-		list:     l,
-		viewport: vp,
-		help:     h,
-		spinner:  sp,
-		search:   ti,
-		keys:     defaultKeys(),
+		cfg:       cfg,
+		indexer:   idx,
+		exporter:  exp,
+		list:      l,
+		viewport:  vp,
+		help:      h,
+		spinner:   sp,
+		search:    ti,
+		findInput: fi,
+		gotoInput: gi,
+		keys:      defaultKeys(),
+		log:       logging.Discard(),
+
+		annotateInput: ai,
+
+		glamourStyle:  resolveAutoStyle(cfg.GlamourStyle),
+		imageProtocol: resolveImageProtocol(cfg.ImageProtocol),
+		sidePane:      parseSidePane(cfg.State.SidePane),
 
 		indexing:        true,
 		focusOnList:     true,
 		collapseAgents:  true,
+		collapser:       newCollapser(cfg.CollapseRules),
 		sortOldestFirst: false,
 		groupByWorktree: false,
+		showEmpty:       cfg.ShowEmpty,
 		allSessions:     make(map[string]index.Session),
 		sessions:        make(map[string]index.Session),
 		messages:        make(map[string][]index.Message),
+		subagents:       make(map[string][]index.Subagent),
+		similar:         make(map[string][]index.Session),
+		turnsBefore:     make(map[string]int),
+		annotations:     make(map[string]index.Annotation),
+		safeOverride:    make(map[string]bool),
+		watched:         make(map[string]bool),
 		rendered:        make(map[string]string),
 		highlighted:     make(map[string]highlight.Result),
 		matchIndex:      -1,
 	}
+	m.agents = detectAgentCLIs(cfg, exec.LookPath)
+	m.agents.annotate(&m.keys)
 	return m
 }
 
@@ -194,17 +359,67 @@ func (m Model) Init() tea.Cmd {
 	return tea.Batch(m.spinner.Tick, m.indexCmd())
 }
 
+// UseDaemon tells the model a daemon (pid) is keeping the index fresh, so
+// startup lists sessions straight away instead of indexing first.
+func (m *Model) UseDaemon(pid int) {
+	m.daemonPID = pid
+}
+
+// UseLogger records failures and user commands to l.
+func (m *Model) UseLogger(l *slog.Logger) {
+	m.log = l
+}
+
 func (m Model) indexCmd() tea.Cmd {
-	return func() tea.Msg {
-		result, err := m.indexer.BuildIndex(context.Background())
-		return indexDoneMsg{result: result, err: err}
+	if m.daemonPID > 0 {
+		return func() tea.Msg { return indexDoneMsg{} }
+	}
+	// Plain progress updates are dropped while the last one is still
+	// unread; interim session lists and the done message always arrive.
+	ch := make(chan tea.Msg, 1)
+	go func() {
+		result, err := m.indexer.BuildIndexProgress(context.Background(), func(p index.IndexProgress) {
+			msg := indexProgressMsg{progress: p, ch: ch}
+			if p.Sessions != nil {
+				ch <- msg
+				return
+			}
+			select {
+			case ch <- msg:
+			default:
+			}
+		})
+		ch <- indexDoneMsg{result: result, err: err}
+	}()
+	return waitIndexMsg(ch)
+}
+
+func waitIndexMsg(ch chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-ch }
+}
+
+// indexProgressLabel is the status shown while indexing, e.g.
+// "indexing 412/1890 files, 9214 msgs (rollout-….jsonl)".
+func indexProgressLabel(p index.IndexProgress) string {
+	if p.TotalFiles == 0 {
+		return "indexing..."
 	}
+	label := fmt.Sprintf("indexing %d/%d files, %d msgs", p.Files, p.TotalFiles, p.Messages)
+	if p.Path == "" {
+		return label + ", summarizing sessions..."
+	}
+	return label + " (" + shorten(filepath.Base(p.Path), 32) + ")"
 }
 
 func (m Model) sessionsCmd(query string) tea.Cmd {
+	limit := m.browseLimit(query)
 	return func() tea.Msg {
-		s, err := m.indexer.ListSessions(query, 500)
-		return sessionsMsg{sessions: s, err: err}
+		s, err := m.listSessions(query, limit)
+		if err != nil {
+			return sessionsMsg{err: err}
+		}
+		a, err := m.indexer.Annotations()
+		return sessionsMsg{query: query, limit: limit, sessions: s, annotations: a, err: err}
 	}
 }
 
@@ -217,11 +432,38 @@ func (m Model) transcriptCmd(sessionID string) tea.Cmd {
 		if err != nil {
 			return transcriptMsg{err: err}
 		}
-		msgs, err := m.indexer.GetMessages(sessionID)
+		msgs, older, err := m.indexer.GetMessagesBefore(sessionID, nil, transcriptWindow)
+		if err != nil {
+			return transcriptMsg{err: err}
+		}
+		subs, err := m.indexer.Subagents(sessionID)
 		if err != nil {
 			return transcriptMsg{err: err}
 		}
-		return transcriptMsg{session: s, msgs: msgs}
+		turnsBefore := 0
+		if older && len(msgs) > 0 {
+			if turnsBefore, err = m.indexer.CountPromptsBefore(sessionID, msgs[0]); err != nil {
+				return transcriptMsg{err: err}
+			}
+		}
+		similar, err := m.indexer.SimilarSessions(sessionID, m.cfg.Similar)
+		if err != nil {
+			// Suggestions are a nicety; the transcript still loads.
+			m.log.Warn("similar sessions failed", "session", sessionID, "err", err)
+		}
+		return transcriptMsg{session: s, msgs: msgs, older: older, subagents: subs, similar: similar, turnsBefore: turnsBefore}
+	}
+}
+
+func (m Model) transcriptToggles() index.TranscriptToggles {
+	return index.TranscriptToggles{
+		IncludeTools:     m.includeTools,
+		IncludeAborted:   m.includeAborted,
+		IncludeEvents:    m.includeEvents,
+		IncludeThinking:  m.includeThinking,
+		IncludeReasoning: m.includeReasoning,
+		Role:             m.roleFilter,
+		Timestamps:       m.showTimestamps,
 	}
 }
 
@@ -229,21 +471,45 @@ func (m Model) exportCmd(sessionID string) tea.Cmd {
 	if sessionID == "" {
 		return nil
 	}
-	msgs := m.messages[sessionID]
+	msgs, partial := m.messages[sessionID], m.partial[sessionID]
 	session := m.sessions[sessionID]
-	toggles := index.TranscriptToggles{
-		IncludeTools:   m.includeTools,
-		IncludeAborted: m.includeAborted,
-		IncludeEvents:  m.includeEvents,
+	session.Tags = m.annotations[sessionID].Tags
+	toggles := m.transcriptToggles()
+
+	return func() tea.Msg {
+		msgs, err := m.allMessages(sessionID, msgs, partial)
+		if err != nil {
+			return exportMsg{err: err}
+		}
+		path, written, err := m.exporter.Export(session, msgs, toggles)
+		return exportMsg{path: path, unchanged: err == nil && !written, err: err}
 	}
+}
+
+func (m Model) exportCommandsCmd(sessionID string) tea.Cmd {
+	if sessionID == "" {
+		return nil
+	}
+	msgs, partial := m.messages[sessionID], m.partial[sessionID]
+	session := m.sessions[sessionID]
 
 	return func() tea.Msg {
-		path, err := m.exporter.Export(session, msgs, toggles)
+		msgs, err := m.allMessages(sessionID, msgs, partial)
+		if err != nil {
+			return exportMsg{err: err}
+		}
+		path, err := m.exporter.ExportCommands(session, msgs)
 		return exportMsg{path: path, err: err}
 	}
 }
 
-func (m Model) copyCmd(sessionID string) tea.Cmd {
+// snippetBuilder formats a session summary for pasting elsewhere.
+// usage is the opt-in usage line, "" when off.
+type snippetBuilder func(session index.Session, msgs []index.Message, exportPath, usage string) string
+
+// copyCmd exports the session and copies build's summary of it; what names
+// the summary in the status line.
+func (m Model) copyCmd(sessionID, what string, build snippetBuilder) tea.Cmd {
 	if sessionID == "" {
 		return nil
 	}
@@ -255,25 +521,43 @@ func (m Model) copyCmd(sessionID string) tea.Cmd {
 	if !ok {
 		return nil
 	}
-	toggles := index.TranscriptToggles{
-		IncludeTools:   m.includeTools,
-		IncludeAborted: m.includeAborted,
-		IncludeEvents:  m.includeEvents,
-	}
+	session.Tags = m.annotations[sessionID].Tags
+	toggles := m.transcriptToggles()
+	partial := m.partial[sessionID]
 
 	return func() tea.Msg {
-		path, err := m.exporter.Export(session, msgs, toggles)
+		msgs, err := m.allMessages(sessionID, msgs, partial)
 		if err != nil {
 			return copyMsg{err: err}
 		}
-		snippet := buildPRSnippet(session, msgs, path)
+		path, _, err := m.exporter.Export(session, msgs, toggles)
+		if err != nil {
+			return copyMsg{err: err}
+		}
+		snippet := build(session, msgs, path, m.snippetUsage(session, msgs))
 
 		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 		defer cancel()
 		if err := clipboard.Copy(ctx, snippet); err != nil {
 			return copyMsg{err: err}
 		}
-		return copyMsg{}
+		return copyMsg{what: what}
+	}
+}
+
+// refreshCmd re-reads the session's files from disk even when their size
+// and mtime suggest nothing changed.
+func (m Model) refreshCmd(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		n, err := m.indexer.RefreshSession(context.Background(), sessionID)
+		return refreshMsg{sessionID: sessionID, files: n, err: err}
+	}
+}
+
+func (m Model) workdirsCmd() tea.Cmd {
+	return func() tea.Msg {
+		w, err := m.indexer.ListWorkdirs()
+		return workdirsMsg{workdirs: w, err: err}
 	}
 }
 
@@ -282,18 +566,18 @@ func (m Model) resumeCmd(sessionID string) tea.Cmd {
 	if !ok {
 		return nil
 	}
-	var cmd *exec.Cmd
-	switch session.Source {
-	case "claude":
-		cmd = exec.Command("claude", "--resume", sessionID)
-	case "codex":
-		cmd = exec.Command("codex", "resume", sessionID)
-	default:
+	argv, err := resumeArgv(m.cfg.ResumeCommands, session)
+	if err != nil {
+		return func() tea.Msg { return resumeMsg{err: err} }
+	}
+	if argv == nil {
 		return nil
 	}
+	cmd := exec.Command(argv[0], argv[1:]...)
 	if session.Workdir != "" {
 		cmd.Dir = session.Workdir
 	}
+	m.log.Info("resuming session", "session", sessionID, "command", cmd.Args, "dir", cmd.Dir)
 	return tea.ExecProcess(cmd, func(err error) tea.Msg {
 		return resumeMsg{err: err}
 	})
@@ -308,46 +592,110 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.resize()
 		cmds = append(cmds, m.renderSelected(true))
 
+	case indexProgressMsg:
+		m.indexProgress = msg.progress
+		cmds = append(cmds, waitIndexMsg(msg.ch))
+		if msg.progress.Sessions != nil && strings.TrimSpace(m.searchQuery) == "" && !m.searchMode {
+			prev := m.selectedID
+			m.applySessions(msg.progress.Sessions)
+			if m.selectedID != prev {
+				cmds = append(cmds, m.transcriptCmd(m.selectedID), m.renderSelected(false))
+			}
+		}
+
 	case indexDoneMsg:
 		m.indexing = false
 		if msg.err != nil {
 			m.err = msg.err
 			m.status = "Indexing failed: " + msg.err.Error()
+			m.log.Error("indexing failed", "err", msg.err)
 		} else {
 			m.status = "Index ready"
+			if m.daemonPID > 0 {
+				m.status = fmt.Sprintf("Index kept fresh by daemon (pid %d)", m.daemonPID)
+			}
 			if msg.result.Skipped > 0 {
 				m.status = fmt.Sprintf("Index ready (%d file(s) skipped)", msg.result.Skipped)
+				m.log.Warn("index ready with skipped files", "skipped", msg.result.Skipped)
 			}
 			cmds = append(cmds, m.sessionsCmd(m.searchQuery))
 		}
+		cmds = append(cmds, m.refreshTick())
+
+	case refreshTickMsg:
+		if m.indexing {
+			cmds = append(cmds, m.refreshTick())
+			break
+		}
+		cmds = append(cmds, m.autoRefreshCmd())
+
+	case refreshDoneMsg:
+		cmds = append(cmds, m.applyAutoRefresh(msg))
 
 	case sessionsMsg:
 		if msg.err != nil {
 			m.err = msg.err
 			m.status = "Session query failed"
+			m.log.Error("session query failed", "query", m.searchQuery, "err", msg.err)
 			break
 		}
+		if msg.annotations != nil {
+			m.annotations = msg.annotations
+		}
+		m.resetPager(msg.query, msg.sessions, msg.limit)
 		m.applySessions(msg.sessions)
 		if m.selectedID != "" {
 			cmds = append(cmds, m.transcriptCmd(m.selectedID))
 		}
+		if m.focus != nil {
+			cmds = append(cmds, m.focusSessionCmd(*m.focus))
+			m.focus = nil
+		}
+
+	case focusMsg:
+		cmds = append(cmds, m.applyFocus(msg))
+
+	case matchesMsg:
+		m.openMatchBrowser(msg)
+
+	case promptsMsg:
+		m.openPromptLibrary(msg)
+
+	case codeBlocksMsg:
+		m.openCodeBrowser(msg)
+
+	case followUpsMsg:
+		m.openFollowUps(msg)
 
 	case transcriptMsg:
 		if msg.err != nil {
 			m.err = msg.err
 			m.status = "Transcript load failed"
+			m.log.Error("transcript load failed", "err", msg.err)
 			break
 		}
 		m.sessions[msg.session.ID] = msg.session
 		m.messages[msg.session.ID] = msg.msgs
+		m.setPartial(msg.session.ID, msg.older)
+		m.subagents[msg.session.ID] = msg.subagents
+		m.similar[msg.session.ID] = msg.similar
+		m.turnsBefore[msg.session.ID] = msg.turnsBefore
+		if m.matchJump != nil && m.matchJump.sessionID == msg.session.ID {
+			m.matchJump.loaded = true
+		}
 		if m.selectedID == msg.session.ID {
 			cmds = append(cmds, m.renderSelected(true))
 		}
 
 	case exportMsg:
-		if msg.err != nil {
+		if errors.Is(msg.err, export.ErrNoCommands) {
+			m.status = "No shell commands recorded in this session"
+		} else if msg.err != nil {
 			m.err = msg.err
 			m.status = "Export failed: " + msg.err.Error()
+			m.log.Error("export failed", "err", msg.err)
+		} else if msg.unchanged {
+			m.status = "Export unchanged: " + msg.path
 		} else {
 			m.status = "Exported: " + msg.path
 		}
@@ -355,20 +703,114 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case copyMsg:
 		if msg.err != nil {
 			m.err = msg.err
+			m.log.Error("copy failed", "err", msg.err)
 			if errors.Is(msg.err, clipboard.ErrToolNotFound) {
 				m.status = "Could not copy: clipboard tool not found"
 			} else {
 				m.status = "Could not copy: " + msg.err.Error()
 			}
 		} else {
-			m.status = "Copied PR snippet to clipboard"
+			m.status = "Copied " + msg.what + " to clipboard"
 		}
 
 	case resumeMsg:
 		if msg.err != nil {
 			m.status = "Resume error: " + msg.err.Error()
+			m.log.Error("resume failed", "err", msg.err)
+		}
+
+	case handoffReadyMsg:
+		if msg.err != nil {
+			m.status = "Handoff failed: " + msg.err.Error()
+			m.log.Error("handoff failed", "session", msg.sessionID, "err", msg.err)
+			break
+		}
+		m.status = "Handing off to " + msg.target
+		return m, m.runHandoff(msg)
+
+	case spawnMsg:
+		if msg.err != nil {
+			m.status = "Resume error: " + msg.err.Error()
+			m.log.Error("resume in new window failed", "session", msg.sessionID, "err", msg.err)
+			break
+		}
+		m.status = "Resumed in a new window"
+
+	case refreshMsg:
+		if msg.err != nil {
+			m.status = "Refresh failed: " + msg.err.Error()
+			m.log.Error("session refresh failed", "session", msg.sessionID, "err", msg.err)
+			break
+		}
+		m.status = fmt.Sprintf("Re-read %d file(s) from disk", msg.files)
+		m.forgetRenders(msg.sessionID)
+		delete(m.messages, msg.sessionID)
+		m.setPartial(msg.sessionID, false)
+		cmds = append(cmds, m.sessionsCmd(m.searchQuery))
+
+	case openMsg:
+		if msg.err != nil {
+			m.status = "Could not open " + msg.dir + ": " + msg.err.Error()
+			m.log.Error("opening failed", "target", msg.dir, "err", msg.err)
+			break
+		}
+		m.status = "Opened " + msg.dir
+
+	case statusMsg:
+		m.status = msg.text
+
+	case followTickMsg:
+		if msg.gen == m.followGen {
+			cmds = append(cmds, m.followCmd())
 		}
 
+	case followMsg:
+		cmds = append(cmds, m.applyFollow(msg))
+	case watchTickMsg:
+		if msg.gen == m.watchGen {
+			cmds = append(cmds, m.watchCmd())
+		}
+	case watchMsg:
+		cmds = append(cmds, m.applyWatch(msg))
+
+	case annotationMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = "Could not save annotation: " + msg.err.Error()
+			m.log.Error("saving annotation failed", "err", msg.err)
+			break
+		}
+		m.status = "Saved annotation for " + shorten(msg.ann.SessionID, 18)
+		cmds = append(cmds, m.applyAnnotation(msg.ann))
+
+	case replayRunMsg:
+		m.applyReplayRun(msg)
+
+	case docMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = "Could not build view: " + msg.err.Error()
+			m.log.Error("building view failed", "err", msg.err)
+			break
+		}
+		cmds = append(cmds, m.openDoc(msg.doc))
+
+	case moreSessionsMsg:
+		cmds = append(cmds, m.applyMoreSessions(msg))
+
+	case workdirsMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			m.status = "Workdir query failed"
+			m.log.Error("workdir query failed", "err", msg.err)
+			break
+		}
+		if len(msg.workdirs) == 0 {
+			m.status = "No workdirs recorded"
+			break
+		}
+		m.openWorkdirPicker(msg.workdirs)
+
 	case renderMsg:
 		if msg.nonce != m.renderNonce {
 			break
@@ -377,18 +819,47 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			m.err = msg.err
 			m.status = "Render failed: " + msg.err.Error()
+			m.log.Error("render failed", "session", msg.sessionID, "err", msg.err)
 			break
 		}
 		m.rendered[msg.cacheKey] = msg.rendered
-		if m.selectedID == msg.sessionID {
+		if m.doc.active() {
+			if "doc:"+m.doc.key == msg.sessionID {
+				m.setViewportFromRendered(msg.cacheKey, msg.rendered, true)
+			}
+		} else if m.selectedID == msg.sessionID {
 			m.setViewportFromRendered(msg.cacheKey, msg.rendered, true)
+			m.restoreOlderAnchor()
 		}
 
+	case olderMessagesMsg:
+		cmds = append(cmds, m.applyOlderMessages(msg))
+
+	case fullMessagesMsg:
+		cmds = append(cmds, m.applyFullMessages(msg))
+
 	case tea.KeyMsg:
 		if m.helpOverlayActive() && !key.Matches(msg, m.keys.ToggleHelp) && !key.Matches(msg, m.keys.Quit) {
 			return m, nil
 		}
 
+		if m.picker.active() {
+			return m.updatePicker(msg)
+		}
+		if m.annotating != annotateNone {
+			return m.updateAnnotate(msg)
+		}
+		// Replay keeps q for leaving replay, but ctrl+c still quits.
+		if m.replay.active() && !m.searchMode && msg.Type != tea.KeyCtrlC {
+			return m.updateReplay(msg)
+		}
+		if m.findMode {
+			return m.updateFind(msg)
+		}
+		if m.gotoMode {
+			return m.updateGotoTurn(msg)
+		}
+
 		if m.searchMode {
 			if key.Matches(msg, m.keys.ToggleHelp) {
 				m.toggleHelpOverlay()
@@ -416,6 +887,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.search, cmd = m.search.Update(msg)
 			cmds = append(cmds, cmd)
 			after := strings.TrimSpace(m.search.Value())
+			if _, semantic := semanticQuery(after); semantic {
+				// Each semantic search calls the embedder; wait for enter.
+				return m, tea.Batch(cmds...)
+			}
 			if after != strings.TrimSpace(before) {
 				m.searchQuery = after
 				m.refreshViewportFromCache()
@@ -433,6 +908,15 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.search.CursorEnd()
 			m.search.Focus()
 			return m, nil
+		case key.Matches(msg, m.keys.Find):
+			m.startFind()
+			return m, nil
+		case key.Matches(msg, m.keys.GotoTurn):
+			m.startGotoTurn()
+			return m, nil
+		case key.Matches(msg, m.keys.TOC):
+			m.openTOC()
+			return m, nil
 		case key.Matches(msg, m.keys.Tab):
 			m.focusOnList = !m.focusOnList
 			return m, nil
@@ -461,12 +945,40 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.status = "Grouping: " + m.groupingLabel()
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.ToggleEmpty):
+			m.showEmpty = !m.showEmpty
+			m.indexer.SetShowEmpty(m.showEmpty)
+			if m.showEmpty {
+				m.status = "Listing empty sessions too (0 msgs)"
+			} else {
+				m.status = "Empty sessions hidden"
+			}
+			return m, m.sessionsCmd(m.searchQuery)
+		case key.Matches(msg, m.keys.ToggleQuick):
+			if m.cfg.QuickUnder <= 0 {
+				m.status = "Quick sessions are not folded (set --quick-under)"
+				return m, nil
+			}
+			m.quickExpanded = !m.quickExpanded
+			if m.quickExpanded {
+				m.status = "Quick sessions: expanded"
+			} else {
+				m.status = "Quick sessions: collapsed"
+			}
+			prev := m.selectedID
+			m.applySessionsFromMap()
+			if m.selectedID != prev {
+				m.doc = docView{}
+				return m, tea.Batch(m.transcriptCmd(m.selectedID), m.renderSelected(false))
+			}
+			return m, nil
 		case key.Matches(msg, m.keys.ToggleHelp):
 			m.toggleHelpOverlay()
 			return m, nil
 		case key.Matches(msg, m.keys.PageUp):
 			if !m.focusOnList {
 				m.viewport.HalfViewUp()
+				return m, m.maybeLoadOlder()
 			}
 			return m, nil
 		case key.Matches(msg, m.keys.PageDown):
@@ -476,16 +988,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case key.Matches(msg, m.keys.PrevPage):
 			if !m.focusOnList {
-				if strings.TrimSpace(m.searchQuery) != "" && len(m.matchLines) > 0 {
+				if m.highlightQuery() != "" && len(m.matchLines) > 0 {
 					m.jumpToMatch(-1)
 				} else {
 					m.viewport.HalfViewUp()
+					return m, m.maybeLoadOlder()
 				}
 			}
 			return m, nil
 		case key.Matches(msg, m.keys.NextPage):
 			if !m.focusOnList {
-				if strings.TrimSpace(m.searchQuery) != "" && len(m.matchLines) > 0 {
+				if m.highlightQuery() != "" && len(m.matchLines) > 0 {
 					m.jumpToMatch(1)
 				} else {
 					m.viewport.HalfViewDown()
@@ -504,27 +1017,194 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.ToggleEvents):
 			m.includeEvents = !m.includeEvents
 			return m, m.renderSelected(true)
+		case key.Matches(msg, m.keys.ToggleThinking):
+			m.includeThinking = !m.includeThinking
+			return m, m.renderSelected(true)
+		case key.Matches(msg, m.keys.ToggleReasoning):
+			m.includeReasoning = !m.includeReasoning
+			return m, m.renderSelected(true)
+		case key.Matches(msg, m.keys.ToggleSubagents):
+			m.expandSubagents = !m.expandSubagents
+			return m, m.renderSelected(true)
+		case key.Matches(msg, m.keys.ToggleTimestamps):
+			m.showTimestamps = !m.showTimestamps
+			return m, m.renderSelected(true)
+		case key.Matches(msg, m.keys.ToggleSafeRender):
+			if m.selectedID == "" {
+				return m, nil
+			}
+			m.safeOverride[m.selectedID] = !m.safeRenderFor(m.selectedID)
+			if m.safeOverride[m.selectedID] {
+				m.status = "Safe render on for this session"
+			} else {
+				m.status = "Safe render off for this session"
+			}
+			return m, m.renderSelected(false)
+		case key.Matches(msg, m.keys.CycleRole):
+			m.roleFilter = nextRoleFilter(m.roleFilter)
+			m.status = "Transcript roles: " + roleFilterLabel(m.roleFilter)
+			return m, m.renderSelected(true)
 		case key.Matches(msg, m.keys.CycleSource):
 			m.sourceFilter = (m.sourceFilter + 1) % 3
 			m.selectedID = ""
 			m.applySessionsFromMap()
 			m.status = "Source: " + m.sourceFilterLabel()
 			return m, nil
+		case key.Matches(msg, m.keys.PickWorkdir):
+			return m, m.workdirsCmd()
+		case key.Matches(msg, m.keys.Follow):
+			return m, m.toggleFollow()
+		case key.Matches(msg, m.keys.Watch):
+			return m, m.toggleWatch()
+		case key.Matches(msg, m.keys.RefreshSession):
+			if m.selectedID != "" {
+				m.status = "Re-reading session from disk..."
+				return m, m.refreshCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.PickStyle):
+			m.openStylePicker()
+			return m, nil
+		case key.Matches(msg, m.keys.CopyMenu):
+			if m.selectedID != "" {
+				return m, m.withAllMessages(m.selectedID, fullCopyMenu)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.SidePane):
+			saveCmd := m.cycleSidePane()
+			return m, tea.Batch(saveCmd, m.renderSelected(false))
+		case key.Matches(msg, m.keys.CycleView):
+			return m, m.cycleViewMode()
+		case key.Matches(msg, m.keys.Esc):
+			if m.findQuery != "" {
+				m.clearFind()
+				return m, nil
+			}
+			return m, m.closeDoc()
+		case key.Matches(msg, m.keys.Mark):
+			if m.selectedID != "" {
+				m.toggleMark(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Bookmark):
+			if m.selectedID != "" {
+				return m, m.toggleBookmarkCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Alias):
+			m.startAnnotate(annotateAlias)
+			return m, nil
+		case key.Matches(msg, m.keys.Note):
+			m.startAnnotate(annotateNote)
+			return m, nil
+		case key.Matches(msg, m.keys.Tags):
+			m.startAnnotate(annotateTags)
+			return m, nil
+		case key.Matches(msg, m.keys.ViewImage):
+			if m.selectedID != "" {
+				return m, m.withAllMessages(m.selectedID, fullImages)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.FileChanges):
+			if m.selectedID != "" {
+				return m, m.fileChangesCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.IngestIssues):
+			return m, m.ingestIssuesCmd()
+		case key.Matches(msg, m.keys.MergeThread):
+			if m.selectedID != "" {
+				return m, m.threadCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Diff):
+			if len(m.marked) != 2 {
+				m.status = "Mark two sessions with m to diff them"
+				return m, nil
+			}
+			return m, m.diffCmd()
 		case key.Matches(msg, m.keys.Export):
 			if m.selectedID != "" {
 				cmds = append(cmds, m.exportCmd(m.selectedID))
 			}
 			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.ExportCommands):
+			if m.selectedID != "" {
+				cmds = append(cmds, m.exportCommandsCmd(m.selectedID))
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.Replay):
+			if m.selectedID != "" {
+				return m, m.withAllMessages(m.selectedID, fullReplay)
+			}
+			return m, nil
 		case key.Matches(msg, m.keys.Copy):
 			if m.selectedID != "" {
-				cmds = append(cmds, m.copyCmd(m.selectedID))
+				cmds = append(cmds, m.copyCmd(m.selectedID, "PR snippet", buildPRSnippet))
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.CopyTranscript):
+			if m.selectedID != "" {
+				cmds = append(cmds, m.copyTranscriptCmd(m.selectedID))
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.CopySlack):
+			if m.selectedID != "" {
+				cmds = append(cmds, m.copyCmd(m.selectedID, "Slack summary", buildSlackSnippet))
 			}
 			return m, tea.Batch(cmds...)
 		case key.Matches(msg, m.keys.Resume):
 			if m.selectedID != "" {
+				if cmd := requireAgent(m.agents.resume, m.sessions[m.selectedID].Source, "resume", "resume"); cmd != nil {
+					return m, cmd
+				}
 				return m, m.resumeCmd(m.selectedID)
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.ResumeSpawn):
+			if m.selectedID != "" {
+				if cmd := requireAgent(m.agents.resume, m.sessions[m.selectedID].Source, "resume", "resume"); cmd != nil {
+					return m, cmd
+				}
+				return m, m.spawnResumeCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Handoff):
+			if m.selectedID != "" {
+				target := handoffTarget(m.sessions[m.selectedID].Source)
+				if cmd := requireAgent(m.agents.handoff, target, "hand off", "handoff"); cmd != nil {
+					return m, cmd
+				}
+				return m, m.handoffCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.OpenWorkdir):
+			if m.selectedID != "" {
+				return m, m.openWorkdirCmd(m.selectedID)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.MatchBrowser):
+			if strings.TrimSpace(m.searchQuery) == "" {
+				m.status = "Search with / first, then press J to browse the matching messages"
+				return m, nil
+			}
+			if _, ok := semanticQuery(m.searchQuery); ok {
+				m.status = "J browses word matches; search without about: to use it"
+				return m, nil
+			}
+			m.status = "Searching messages..."
+			return m, m.searchMatchesCmd(m.searchQuery)
+		case key.Matches(msg, m.keys.PromptLibrary):
+			return m, m.promptsCmd()
+		case key.Matches(msg, m.keys.CodeBrowser):
+			return m, m.codeBlocksCmd()
+		case key.Matches(msg, m.keys.FollowUps):
+			return m, m.followUpsCmd()
+		case key.Matches(msg, m.keys.OpenFileRef):
+			if m.selectedID != "" {
+				return m, m.withAllMessages(m.selectedID, fullFileRefs)
+			}
+			return m, nil
 		}
 
 		if m.focusOnList {
@@ -534,13 +1214,16 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 			m.selectedID = m.currentSelectedID()
 			if m.selectedID != prev {
+				m.doc = docView{}
 				cmds = append(cmds, m.transcriptCmd(m.selectedID))
 				cmds = append(cmds, m.renderSelected(false))
 			}
+			cmds = append(cmds, m.maybeLoadMore())
 		} else {
 			switch msg.String() {
 			case "up", "k":
 				m.viewport.LineUp(1)
+				cmds = append(cmds, m.maybeLoadOlder())
 			case "down", "j":
 				m.viewport.LineDown(1)
 			}
@@ -563,11 +1246,15 @@ func (m *Model) applySessions(in []index.Session) {
 		m.allSessions[s.ID] = s
 	}
 
-	filtered := m.filterBySource(in)
+	filtered := m.filterByWorkdir(m.filterBySource(in))
 	ordered := m.orderedSessions(filtered)
+	var quick []index.Session
+	if m.quickFoldActive() {
+		ordered, quick = m.splitQuick(ordered)
+	}
 
-	items := make([]list.Item, 0, len(ordered))
-	m.sessions = make(map[string]index.Session, len(ordered))
+	items := make([]list.Item, 0, len(ordered)+len(quick)+1)
+	m.sessions = make(map[string]index.Session, len(ordered)+len(quick))
 	prevGroup := ""
 	groupedMode := m.groupByWorktree && strings.TrimSpace(m.searchQuery) == "" && !m.searchMode
 	for idx, s := range ordered {
@@ -578,13 +1265,24 @@ func (m *Model) applySessions(in []index.Session) {
 			groupDivider = idx > 0 && curGroup != prevGroup
 			prevGroup = curGroup
 		}
-		items = append(items, sessionItem{s: s, groupDivider: groupDivider})
+		items = append(items, sessionItem{s: s, ann: m.annotations[s.ID], cost: m.sessionCost(s), groupDivider: groupDivider, marked: m.isMarked(s.ID), relative: m.cfg.RelativeTime})
+	}
+	if len(quick) > 0 {
+		items = append(items, quickGroupItem{count: len(quick), under: m.cfg.QuickUnder, expanded: m.quickExpanded})
+		for _, s := range quick {
+			m.sessions[s.ID] = s
+			if m.quickExpanded {
+				items = append(items, sessionItem{s: s, ann: m.annotations[s.ID], cost: m.sessionCost(s), marked: m.isMarked(s.ID), relative: m.cfg.RelativeTime})
+			}
+		}
 	}
 	m.list.SetItems(items)
 
-	if len(ordered) == 0 {
+	if len(items) == 0 {
 		m.selectedID = ""
-		if strings.TrimSpace(m.searchQuery) == "" {
+		if m.workdirFilter != "" {
+			m.viewport.SetContent("No sessions found in " + m.workdirFilter + ".\n\nPress `d` to pick another workdir.")
+		} else if strings.TrimSpace(m.searchQuery) == "" {
 			m.viewport.SetContent("No sessions found.\n\nTip: run with --reindex to force rebuilding the index.")
 		} else {
 			m.viewport.SetContent("No sessions matched your search.")
@@ -594,15 +1292,15 @@ func (m *Model) applySessions(in []index.Session) {
 
 	selectIdx := 0
 	if m.selectedID != "" {
-		for idx, s := range ordered {
-			if s.ID == m.selectedID {
+		for idx, it := range items {
+			if item, ok := it.(sessionItem); ok && item.s.ID == m.selectedID {
 				selectIdx = idx
 				break
 			}
 		}
 	}
 	m.list.Select(selectIdx)
-	m.selectedID = ordered[selectIdx].ID
+	m.selectedID = m.currentSelectedID()
 }
 
 func (m *Model) applySessionsFromMap() {
@@ -702,12 +1400,21 @@ func (m *Model) currentSelectedID() string {
 }
 
 func (m *Model) renderSelected(force bool) tea.Cmd {
+	if m.doc.active() {
+		return m.renderDoc(force)
+	}
 	if m.selectedID == "" {
-		m.viewport.SetContent("No session selected")
+		if g, ok := m.list.SelectedItem().(quickGroupItem); ok {
+			m.viewport.SetContent(g.hint())
+		} else {
+			m.viewport.SetContent("No session selected")
+		}
 		m.clearMatches()
 		return nil
 	}
 
+	m.applySourceToggles()
+
 	msgs, ok := m.messages[m.selectedID]
 	if !ok {
 		m.viewport.SetContent("Loading transcript...")
@@ -726,36 +1433,89 @@ func (m *Model) renderSelected(force bool) tea.Cmd {
 	m.renderNonce++
 	nonce := m.renderNonce
 	m.viewport.SetContent("Rendering transcript...")
-	toggles := index.TranscriptToggles{
-		IncludeTools:   m.includeTools,
-		IncludeAborted: m.includeAborted,
-		IncludeEvents:  m.includeEvents,
-	}
+	toggles := m.transcriptToggles()
+	toggles.NumberTurns, toggles.TurnsBefore = true, m.turnsBefore[m.selectedID]
 	wrap := m.viewport.Width - 2
 	if wrap < 20 {
 		wrap = 20
 	}
 	sessionID := m.selectedID
-	source := ""
-	if s, ok := m.sessions[sessionID]; ok {
-		source = s.Source
+	if m.viewMode == viewRaw {
+		return m.rawViewCmd(sessionID, cacheKey, wrap, nonce)
+	}
+	session := m.sessions[sessionID]
+	var subs []index.Subagent
+	if m.expandSubagents {
+		subs = m.subagents[sessionID]
+	}
+	if m.safeRenderFor(sessionID) {
+		msgs, subs = safeMessages(msgs), safeSubagents(subs)
+	}
+	var collapse *collapser
+	if m.collapseAgents {
+		collapse = m.collapser
+	}
+	return m.renderTranscriptCmd(sessionID, cacheKey, msgs, m.partial[sessionID], subs, len(m.subagents[sessionID]), toggles, collapse, wrap, nonce, session, m.similar[sessionID], m.annotations[sessionID].Note, m.glamourStyle, m.viewMode)
+}
+
+// applySourceToggles applies the configured toggle profile when the selection
+// moves to a session of a different source. Manual toggles stick while
+// browsing sessions of the same source.
+func (m *Model) applySourceToggles() {
+	s, ok := m.sessions[m.selectedID]
+	if !ok || s.Source == m.toggleSource {
+		return
+	}
+	m.toggleSource = s.Source
+	p, ok := m.cfg.SourceToggles[s.Source]
+	if !ok {
+		return
+	}
+	for _, t := range []struct {
+		set *bool
+		dst *bool
+	}{
+		{p.Tools, &m.includeTools},
+		{p.Aborted, &m.includeAborted},
+		{p.Events, &m.includeEvents},
+		{p.Thinking, &m.includeThinking},
+		{p.Reasoning, &m.includeReasoning},
+	} {
+		if t.set != nil {
+			*t.dst = *t.set
+		}
 	}
-	return m.renderTranscriptCmd(sessionID, cacheKey, msgs, toggles, m.collapseAgents, wrap, nonce, source)
 }
 
 func (m Model) renderTranscriptCmd(
 	sessionID, cacheKey string,
 	msgs []index.Message,
+	windowed bool,
+	subs []index.Subagent,
+	subagentCount int,
 	toggles index.TranscriptToggles,
-	collapseAgents bool,
+	collapse *collapser,
 	wrap int,
 	nonce int,
-	source string,
+	session index.Session,
+	similar []index.Session,
+	note string,
+	style string,
+	mode viewMode,
 ) tea.Cmd {
 	return func() tea.Msg {
 		filtered := index.FilterMessages(msgs, toggles)
-		md := export.BuildTranscriptMarkdown(msgs, toggles, source)
+		md := export.BuildTranscriptWithSubagents(msgs, subs, toggles, session.Source)
 		md = prependCollapsedEventsHint(md, msgs, toggles)
+		if len(subs) == 0 && subagentCount > 0 {
+			md = fmt.Sprintf("> [Subagent transcripts collapsed (%d). Press `i` to expand them inline.]\n\n", subagentCount) + md
+		}
+		if len(session.SourcePaths) > 1 {
+			md = "> [Duplicate copies collapsed; recorded in:" + formatSourcePaths(session.SourcePaths) + "]\n\n" + md
+		}
+		if windowed {
+			md = windowHint(len(msgs)) + md
+		}
 		if strings.TrimSpace(md) == "" {
 			if hasOnlyBoilerplateConversation(msgs) {
 				md = "_Session contains only environment/turn boilerplate and no conversational turns._"
@@ -763,64 +1523,86 @@ func (m Model) renderTranscriptCmd(
 				md = "_No transcript content with current filters._"
 			}
 		}
-		md = sanitizeMarkdownForDisplay(md, collapseAgents)
-
-		if len(md) > 500_000 {
-			return renderMsg{
-				sessionID: sessionID,
-				cacheKey:  cacheKey,
-				rendered:  md,
-				nonce:     nonce,
-			}
-		}
+		md = prependFilesTouched(md, index.ExtractFilesTouched(msgs))
+		md = prependCommits(md, index.ExtractCommits(msgs))
+		md = prependSimilar(md, similar)
+		md = prependNote(md, note)
+		md = sanitizeMarkdownForDisplay(md, collapse, m.cfg.Display)
 
-		rendered := md
-		r, err := glamour.NewTermRenderer(
-			glamour.WithStandardStyle(config.DefaultGlamourStyle),
-			glamour.WithWordWrap(wrap),
-		)
-		if err != nil {
-			return renderMsg{
-				sessionID: sessionID,
-				cacheKey:  cacheKey,
-				rendered:  md,
-				nonce:     nonce,
-			}
-		}
-		if out, renderErr := r.Render(md); renderErr == nil {
-			rendered = out
-		}
 		return renderMsg{
 			sessionID: sessionID,
 			cacheKey:  cacheKey,
-			rendered:  rendered,
+			rendered:  formatTranscript(md, wrap, style, mode, m.cfg.Display.RenderChars),
 			nonce:     nonce,
 		}
 	}
 }
 
+func formatSourcePaths(paths []string) string {
+	var b strings.Builder
+	for _, p := range paths {
+		b.WriteString(" `" + p + "`")
+	}
+	return b.String()
+}
+
 func (m Model) renderCacheKey(sessionID string) string {
 	return fmt.Sprintf(
-		"%s|w=%d|t=%t|a=%t|e=%t|ag=%t",
+		"%s|n=%d|vm=%s|w=%d|st=%s|t=%t|a=%t|e=%t|th=%t|rs=%t|ro=%s|ts=%t|ag=%t|sa=%t|sf=%t",
 		sessionID,
+		len(m.messages[sessionID]),
+		m.viewMode,
 		m.viewport.Width,
+		m.glamourStyle,
 		m.includeTools,
 		m.includeAborted,
 		m.includeEvents,
+		m.includeThinking,
+		m.includeReasoning,
+		m.roleFilter,
+		m.showTimestamps,
 		m.collapseAgents,
+		m.expandSubagents,
+		m.safeRenderFor(sessionID),
 	)
 }
 
+// safeRenderFor reports whether agent markdown in a session is shown
+// literally: the session's own toggle if set, else the configured default.
+func (m Model) safeRenderFor(sessionID string) bool {
+	if v, ok := m.safeOverride[sessionID]; ok {
+		return v
+	}
+	return m.cfg.SafeRender
+}
+
 func (m Model) highlightCacheKey(cacheKey, query string) string {
 	return cacheKey + "|q=" + strings.ToLower(strings.TrimSpace(query))
 }
 
+// forgetRenders drops every cached render and highlight of a session.
+func (m *Model) forgetRenders(sessionID string) {
+	for key := range m.rendered {
+		if strings.HasPrefix(key, sessionID+"|") {
+			delete(m.rendered, key)
+		}
+	}
+	for key := range m.highlighted {
+		if strings.HasPrefix(key, sessionID+"|") {
+			delete(m.highlighted, key)
+		}
+	}
+}
+
 func (m *Model) refreshViewportFromCache() {
-	if m.selectedID == "" {
-		m.clearMatches()
-		return
+	cacheKey := m.docCacheKey()
+	if !m.doc.active() {
+		if m.selectedID == "" {
+			m.clearMatches()
+			return
+		}
+		cacheKey = m.renderCacheKey(m.selectedID)
 	}
-	cacheKey := m.renderCacheKey(m.selectedID)
 	rendered, ok := m.rendered[cacheKey]
 	if !ok {
 		return
@@ -832,7 +1614,7 @@ func (m *Model) refreshViewportFromCache() {
 
 func (m *Model) setViewportFromRendered(cacheKey, rendered string, gotoTop bool) {
 	content := rendered
-	query := strings.TrimSpace(m.searchQuery)
+	query := m.highlightQuery()
 	if query != "" {
 		hKey := m.highlightCacheKey(cacheKey, query)
 		res, ok := m.highlighted[hKey]
@@ -856,6 +1638,10 @@ func (m *Model) setViewportFromRendered(cacheKey, rendered string, gotoTop bool)
 			m.viewport.SetYOffset(m.clampViewportOffset(m.matchLines[0]))
 		}
 	}
+	m.applyMatchJump(content)
+	if m.following && !m.doc.active() {
+		m.viewport.GotoBottom()
+	}
 }
 
 func (m *Model) setMatchMeta(res highlight.Result) {
@@ -950,7 +1736,7 @@ func hiddenNonMessageEventCount(msgs []index.Message, toggles index.TranscriptTo
 		if typ == "message" && (role == "user" || role == "assistant") {
 			continue
 		}
-		if typ == "user_message" {
+		if typ == "user_message" || typ == "thinking" || typ == "reasoning" || typ == "image" {
 			continue
 		}
 		if strings.Contains(role, "tool") || strings.Contains(typ, "tool") {
@@ -975,134 +1761,75 @@ func isLikelyEnvironmentBoilerplate(content string) bool {
 	return strings.Contains(c, "<environment_context>") && strings.Contains(c, "<cwd>")
 }
 
-func sanitizeMarkdownForDisplay(md string, collapseAgents bool) string {
-	if collapseAgents {
-		md = collapseInitialAgentsBlock(md)
+// sanitizeMarkdownForDisplay prepares markdown for the TUI, clamping long
+// lines and long transcripts to limits; a nil collapser leaves instruction
+// blocks expanded.
+func sanitizeMarkdownForDisplay(md string, c *collapser, limits config.DisplayLimits) string {
+	if c != nil {
+		md = c.collapse(md)
 	}
 	md = stripEmbeddedImageData(md)
-	md = clampLongLines(md, 8000)
-	const maxDisplayChars = 1_000_000
-	if len(md) <= maxDisplayChars {
+	md = clampLongLines(md, limits.LineChars)
+	if limits.TotalChars <= 0 || len(md) <= limits.TotalChars {
 		return md
 	}
-	trimmed := md[:maxDisplayChars]
+	trimmed := md[:limits.TotalChars]
 	trimmed = strings.TrimRight(trimmed, "\n")
 	return trimmed + "\n\n... [transcript truncated for display; use export for full content] ...\n"
 }
 
-func collapseInitialAgentsBlock(md string) string {
-	marker := "# AGENTS.md instructions for "
-	start := strings.Index(md, marker)
-	if start < 0 {
-		return md
-	}
-
-	// Only collapse if this looks like a real AGENTS block with explicit
-	// instructions tags, otherwise leave transcript untouched.
-	if start > 0 && md[start-1] != '\n' {
-		return md
-	}
-	openRel := strings.Index(md[start:], "<INSTRUCTIONS>")
-	if openRel < 0 {
-		return md
-	}
-	openIdx := start + openRel
-	closeRel := strings.Index(md[openIdx:], "</INSTRUCTIONS>")
-	if closeRel < 0 {
-		return md
-	}
-
-	// Only collapse when the referenced repo actually has an AGENTS.md file.
-	if !agentsFileExistsFromMarkerLine(md, start, marker) {
-		return md
-	}
-	end := openIdx + closeRel + len("</INSTRUCTIONS>")
-
-	replacement := "\n> [AGENTS.md instructions collapsed. Press `a` to expand.]\n"
-	return md[:start] + replacement + md[end:]
-}
-
-func agentsFileExistsFromMarkerLine(md string, start int, marker string) bool {
-	lineEnd := strings.Index(md[start:], "\n")
-	if lineEnd < 0 {
-		lineEnd = len(md) - start
-	}
-	line := strings.TrimSpace(md[start : start+lineEnd])
-	path := strings.TrimSpace(strings.TrimPrefix(line, marker))
-	path = strings.Trim(path, "`'\"")
-	if path == "" {
-		return false
-	}
-	st, err := os.Stat(filepath.Join(path, "AGENTS.md"))
-	return err == nil && !st.IsDir()
-}
-
 func stripEmbeddedImageData(s string) string {
-	var b strings.Builder
-	pos := 0
-	for {
-		i := strings.Index(s[pos:], "data:image/")
-		if i < 0 {
-			b.WriteString(s[pos:])
-			break
-		}
-		start := pos + i
-		b.WriteString(s[pos:start])
-
-		rest := s[start:]
-		base64MarkerIdx := strings.Index(rest, ";base64,")
-		if base64MarkerIdx < 0 {
-			b.WriteString("data:image/")
-			pos = start + len("data:image/")
-			continue
-		}
-
-		payloadStart := start + base64MarkerIdx + len(";base64,")
-		j := payloadStart
-		for j < len(s) && isBase64Byte(s[j]) {
-			j++
-		}
-		payloadLen := j - payloadStart
-
-		b.WriteString("[embedded image data omitted: ")
-		b.WriteString(strconv.Itoa(payloadLen))
-		b.WriteString(" base64 chars]")
-		pos = j
-	}
-	return b.String()
-}
-
-func isBase64Byte(c byte) bool {
-	switch {
-	case c >= 'A' && c <= 'Z':
-		return true
-	case c >= 'a' && c <= 'z':
-		return true
-	case c >= '0' && c <= '9':
-		return true
-	case c == '+' || c == '/' || c == '=' || c == '\n' || c == '\r':
-		return true
-	default:
-		return false
-	}
+	return export.ReplaceImageData(s, func(_, payload string) string {
+		return "[embedded image data omitted: " + strconv.Itoa(len(payload)) + " base64 chars, press I to preview]"
+	})
 }
 
+// clampLongLines keeps the first and last max/2 cells of lines wider than
+// max cells. Cuts fall between grapheme clusters, never inside one or
+// inside an escape sequence, and escape sequences from the dropped middle
+// are kept so styles and links around the cut stay balanced.
 func clampLongLines(s string, max int) string {
 	if max <= 0 || len(s) == 0 {
 		return s
 	}
 	lines := strings.Split(s, "\n")
 	for i, line := range lines {
+		// A cell takes at least a byte, so short lines fit.
 		if len(line) <= max {
 			continue
 		}
-		head := line[:max/2]
-		tail := line[len(line)-max/2:]
-		lines[i] = head + "... [line truncated " + strconv.Itoa(len(line)-max) + " chars] ..." + tail
+		if width := ansi.StringWidth(line); width > max {
+			lines[i] = clampLine(line, width, max)
+		}
 	}
 	return strings.Join(lines, "\n")
 }
 
+func clampLine(line string, width, max int) string {
+	var head, middle, tail strings.Builder
+	tailFrom := width - max/2
+	var cells, dropped int
+	inHead := true
+	var state byte
+	for rest := line; len(rest) > 0; {
+		seq, w, n, newState := ansi.DecodeSequence(rest, state, nil)
+		state, rest = newState, rest[n:]
+		inHead = inHead && cells+w <= max/2
+		switch {
+		case inHead:
+			head.WriteString(seq)
+		case cells >= tailFrom:
+			tail.WriteString(seq)
+		case w == 0 && strings.HasPrefix(seq, "\x1b"):
+			middle.WriteString(seq)
+		default:
+			dropped += w
+		}
+		cells += w
+	}
+	return head.String() + "... [line truncated " + strconv.Itoa(dropped) + " chars] ..." + middle.String() + tail.String()
+}
+
 func (m *Model) resize() {
 	if m.width <= 0 || m.height <= 0 {
 		return
@@ -1132,12 +1859,23 @@ func (m Model) View() string {
 	left, right := m.paneWidths()
 	leftPane := panelStyle(m.focusOnList).Width(left).Height(bodyHeight).Render(m.list.View())
 	rightContent := m.viewport.View()
+	if m.replay.active() {
+		rightContent = m.replay.view(m.viewport.Width, m.viewport.Height)
+	}
 	rightPane := panelStyle(!m.focusOnList).Width(right).Height(bodyHeight).Render(rightContent)
 	body := lipgloss.JoinHorizontal(lipgloss.Top, leftPane, rightPane)
+	if side := m.sidePaneWidth(); side > 0 {
+		sideContent := m.sidePaneView(side-4, bodyHeight-2)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, panelStyle(false).Width(side).Height(bodyHeight).Render(sideContent))
+	}
 	if m.helpOverlayActive() {
 		modal := m.shortcutsView(min(m.width-8, 72), bodyHeight-4)
 		body = backdropStyle.Render(body)
 		body = overlayModalCentered(body, modal, m.width, bodyHeight)
+	} else if m.picker.active() {
+		modal := m.picker.view(min(m.width-8, 96), bodyHeight-4)
+		body = backdropStyle.Render(body)
+		body = overlayModalCentered(body, modal, m.width, bodyHeight)
 	}
 
 	return lipgloss.JoinVertical(lipgloss.Left,
@@ -1149,15 +1887,21 @@ func (m Model) View() string {
 func (m Model) statusLine() string {
 	status := ""
 	if m.indexing {
-		status = m.spinner.View() + " indexing..."
+		status = m.spinner.View() + " " + indexProgressLabel(m.indexProgress) + "  "
+	}
+	if m.doc.active() {
+		status = "[" + m.doc.title + "]  "
+	}
+	if m.replay.active() {
+		status = "[replay]  "
 	}
 	if m.selectedID != "" {
 		s := m.sessions[m.selectedID]
-		status = fmt.Sprintf(
+		status += fmt.Sprintf(
 			"session=%s  messages=%d  last=%s  source=%s",
 			shorten(s.ID, 18),
 			s.MessageCount,
-			index.FormatUnix(s.LastActivityTS),
+			formatLast(s.LastActivityTS, m.cfg.RelativeTime),
 			s.Source,
 		)
 	}
@@ -1170,7 +1914,7 @@ func (m Model) statusLine() string {
 		if queryText != "" {
 			status += "  q=" + shorten(queryText, 40)
 		}
-		if strings.TrimSpace(m.searchQuery) != "" {
+		if strings.TrimSpace(m.searchQuery) != "" && m.findQuery == "" {
 			if m.matchCount > 0 {
 				cur := m.matchIndex + 1
 				if cur < 1 {
@@ -1182,27 +1926,71 @@ func (m Model) statusLine() string {
 			}
 		}
 	}
+	if m.following {
+		status += "  [follow]"
+	}
+	if m.showEmpty {
+		status += "  [empty shown]"
+	}
+	status += m.watchLabel()
+	if m.findQuery != "" || m.findMode {
+		status += "  [find]"
+		if m.findQuery != "" {
+			if m.matchCount > 0 {
+				status += fmt.Sprintf("  [match %d/%d]", max(m.matchIndex+1, 1), m.matchCount)
+			} else {
+				status += "  [match 0]"
+			}
+		}
+	}
 	if strings.TrimSpace(m.searchQuery) == "" && !m.searchMode {
 		status += "  [sort: " + m.sortLabel() + "]"
 		status += "  [group: " + m.groupingLabel() + "]"
 	} else {
 		status += "  [order: relevance]"
 	}
+	if m.roleFilter != "" {
+		status += "  [" + roleFilterLabel(m.roleFilter) + "]"
+	}
 	if m.sourceFilter != 0 {
 		status += "  [source: " + m.sourceFilterLabel() + "]"
 	}
+	if m.workdirFilter != "" {
+		status += "  [workdir: " + filepath.Base(m.workdirFilter) + "]"
+	}
 	if m.includeTools {
 		status += "  [tools]"
 	}
 	if m.includeAborted {
 		status += "  [aborted]"
 	}
+	if m.cfg.Scope != "" {
+		status += "  [repo: " + filepath.Base(m.cfg.Scope) + "]"
+	}
 	if m.collapseAgents {
 		status += "  [agents-collapsed]"
 	}
 	if m.includeEvents {
 		status += "  [events]"
 	}
+	if m.includeThinking {
+		status += "  [thinking]"
+	}
+	if m.includeReasoning {
+		status += "  [reasoning]"
+	}
+	if m.showTimestamps {
+		status += "  [times]"
+	}
+	if m.expandSubagents {
+		status += "  [subagents]"
+	}
+	if m.selectedID != "" && m.safeRenderFor(m.selectedID) {
+		status += "  [safe]"
+	}
+	if m.viewMode != viewRendered {
+		status += "  [" + m.viewMode.String() + "]"
+	}
 	if m.rendering {
 		status += "  [rendering]"
 	}
@@ -1212,6 +2000,15 @@ func (m Model) statusLine() string {
 	if m.searchMode {
 		status += "  " + m.search.View()
 	}
+	if m.findMode {
+		status += "  " + m.findInput.View()
+	}
+	if m.gotoMode {
+		status += "  " + m.gotoInput.View()
+	}
+	if m.annotating != annotateNone {
+		status += "  " + m.annotateInput.View()
+	}
 	if strings.TrimSpace(m.status) != "" {
 		status += "  " + shorten(strings.TrimSpace(m.status), 80)
 	}
@@ -1238,21 +2035,62 @@ func (m Model) shortcutsView(maxWidth, maxHeight int) string {
 		{"tab", "toggle focus"},
 		{"enter", "toggle sort"},
 		{"w", "toggle grouping"},
+		{"Q", "expand/collapse quick sessions"},
+		{"Z", "show/hide empty sessions"},
 		{"pgdn", "page down"},
 		{"pgup", "page up"},
 		{"n", "next match/page"},
 		{"p", "prev match/page"},
 		{"/", "search"},
-		{"esc", "clear search"},
+		{"J", "browse matching messages across sessions"},
+		{"P", "prompt library: every distinct prompt, enter copies"},
+		{"K", "code blocks from agent replies, enter copies"},
+		{"!", "follow-ups and TODOs agents left, enter opens"},
+		{"ctrl+f", "find in the open transcript only (n/p step, esc clears)"},
+		{":", "go to turn N (prompts are numbered)"},
+		{"=", "table of contents: jump to a prompt"},
+		{"esc", "clear search/close view"},
 		{"?", "toggle shortcuts"},
 		{"r", "resume session"},
+		{"W", "resume in a new tmux window"},
+		{"H", "continue in the other agent (Claude <-> Codex)"},
+		{"o", "open session workdir"},
+		{"O", "open a file:line from tool output in $EDITOR"},
 		{"x", "export markdown"},
+		{"X", "export shell commands"},
+		{"R", "replay shell commands"},
 		{"c", "copy PR snippet"},
+		{"C", "copy Slack summary: prompt, outcome, export path"},
+		{"Y", "copy the transcript as markdown, as currently filtered"},
+		{"y", "copy session ID, workdir, resume command or commit list"},
 		{"t", "toggle tools"},
 		{"u", "toggle aborted"},
 		{"a", "agents expand/collapse"},
 		{"e", "toggle events"},
+		{"T", "toggle thinking"},
+		{"ctrl+r", "toggle reasoning"},
+		{"i", "toggle inline subagents"},
+		{"@", "toggle turn timestamps"},
+		{"z", "safe render (this session)"},
+		{"U", "transcript roles: all/user/assistant"},
 		{"s", "cycle source filter"},
+		{"d", "pick workdir filter"},
+		{"m", "mark for diff"},
+		{"D", "diff marked sessions"},
+		{"M", "merged thread view"},
+		{"F", "files changed (snapshots)"},
+		{"E", "index problems"},
+		{"S", "pick transcript style"},
+		{"I", "preview image"},
+		{"v", "side pane: outline/stats/off"},
+		{"V", "view: rendered/markdown/raw"},
+		{"L", "reload session from disk"},
+		{"ctrl+t", "follow the session as it is written, pinned to the bottom"},
+		{"ctrl+n", "watch the session: notify on new replies"},
+		{"B", "bookmark session"},
+		{"A", "rename session (alias)"},
+		{"N", "edit session note"},
+		{"#", "edit session tags"},
 		{"q", "quit"},
 	}
 
@@ -1375,24 +2213,39 @@ func maxInt(a, b int) int {
 	return b
 }
 
+// paneWidths splits the width left over by the side pane between the list
+// and the transcript.
 func (m *Model) paneWidths() (int, int) {
-	left := m.width / 3
+	width := m.width
+	if side := m.sidePaneWidth(); side > 0 {
+		width -= side + 2 // pane plus its border
+	}
+	left := width / 3
 	if left < 32 {
 		left = 32
 	}
-	if left > m.width-32 {
-		left = m.width - 32
+	if left > width-32 {
+		left = width - 32
 	}
 	if left < 20 {
 		left = 20
 	}
-	right := m.width - left - 1
+	right := width - left - 1
 	if right < 20 {
 		right = 20
 	}
 	return left, right
 }
 
+// formatLast formats a last-activity time, relative to now when relative
+// is set. It runs at render time so "ago" times stay current.
+func formatLast(ts int64, relative bool) string {
+	if relative {
+		return index.FormatAgo(ts, time.Now())
+	}
+	return index.FormatUnix(ts)
+}
+
 func shorten(s string, n int) string {
 	s = strings.TrimSpace(s)
 	if len(s) <= n {
@@ -1441,6 +2294,29 @@ func (m Model) sourceFilterLabel() string {
 	}
 }
 
+// nextRoleFilter cycles every role -> user prompts -> agent answers.
+func nextRoleFilter(role string) string {
+	switch role {
+	case "":
+		return "user"
+	case "user":
+		return "assistant"
+	default:
+		return ""
+	}
+}
+
+func roleFilterLabel(role string) string {
+	switch role {
+	case "user":
+		return "user only"
+	case "assistant":
+		return "assistant only"
+	default:
+		return "all"
+	}
+}
+
 func (m *Model) filterBySource(in []index.Session) []index.Session {
 	if m.sourceFilter == 0 {
 		return in
@@ -1458,16 +2334,215 @@ func (m *Model) filterBySource(in []index.Session) []index.Session {
 	return out
 }
 
-func buildPRSnippet(session index.Session, msgs []index.Message, exportPath string) string {
+func (m *Model) filterByWorkdir(in []index.Session) []index.Session {
+	if m.workdirFilter == "" {
+		return in
+	}
+	out := make([]index.Session, 0, len(in))
+	for _, s := range in {
+		if s.Workdir == m.workdirFilter {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func (m *Model) openWorkdirPicker(workdirs []index.WorkdirSummary) {
+	items := make([]pickerItem, 0, len(workdirs)+1)
+	items = append(items, pickerItem{label: "All workdirs", detail: "clear filter"})
+	cursor := 0
+	for _, w := range workdirs {
+		if w.Path == m.workdirFilter {
+			cursor = len(items)
+		}
+		items = append(items, pickerItem{
+			label:  w.Path,
+			detail: fmt.Sprintf("%d sessions | last %s", w.SessionCount, index.FormatUnix(w.LastActivityTS)),
+			value:  w.Path,
+		})
+	}
+	m.picker = newPicker(pickerWorkdir, "Filter by workdir", items)
+	m.picker.cursor = cursor
+}
+
+func (m *Model) openStylePicker() {
+	var items []pickerItem
+	if custom := m.cfg.GlamourStyle; filepath.IsAbs(custom) {
+		items = append(items, pickerItem{label: filepath.Base(custom), detail: "custom: " + custom, value: custom})
+	}
+	for _, name := range config.BuiltinGlamourStyles() {
+		if name == styles.AutoStyle {
+			continue
+		}
+		items = append(items, pickerItem{label: name, value: name})
+	}
+	cursor := 0
+	for idx := range items {
+		if items[idx].value == m.glamourStyle {
+			cursor = idx
+			items[idx].detail = strings.TrimSpace("current " + items[idx].detail)
+		}
+	}
+	m.picker = newPicker(pickerStyle, "Transcript style", items)
+	m.picker.cursor = cursor
+}
+
+// resolveAutoStyle pins glamour's "auto" style to dark or light once, before
+// the TUI owns the terminal and background queries would interfere with input.
+func resolveAutoStyle(style string) string {
+	if style != styles.AutoStyle {
+		return style
+	}
+	if lipgloss.HasDarkBackground() {
+		return styles.DarkStyle
+	}
+	return styles.LightStyle
+}
+
+// resolveImageProtocol detects terminal graphics support up front, for the
+// same reason as resolveAutoStyle. Invalid settings are rejected by config.
+func resolveImageProtocol(setting string) termimg.Protocol {
+	p, err := termimg.Resolve(setting)
+	if err != nil {
+		return termimg.None
+	}
+	return p
+}
+
+func (m Model) updatePicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	result, cmd := m.picker.update(msg)
+	switch result {
+	case pickerCancelled:
+		m.picker = picker{}
+	case pickerChosen:
+		item, _ := m.picker.selected()
+		kind := m.picker.kind
+		m.picker = picker{}
+		switch kind {
+		case pickerWorkdir:
+			m.workdirFilter = item.value
+			m.selectedID = ""
+			m.applySessionsFromMap()
+			if m.workdirFilter == "" {
+				m.status = "Workdir: all"
+			} else {
+				m.status = "Workdir: " + m.workdirFilter
+			}
+			return m, tea.Batch(m.transcriptCmd(m.selectedID), m.renderSelected(false))
+		case pickerStyle:
+			if item.value == m.glamourStyle {
+				return m, nil
+			}
+			m.glamourStyle = item.value
+			m.rendered = make(map[string]string)
+			m.highlighted = make(map[string]highlight.Result)
+			m.status = "Style: " + item.label
+			return m, m.renderSelected(true)
+		case pickerImage:
+			images := m.images
+			m.images = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(images) {
+				return m, nil
+			}
+			return m, m.showImage(images[n], n+1, len(images))
+		case pickerCopy:
+			return m, copyTextCmd(item.label, item.value)
+		case pickerMatch:
+			matches := m.matches
+			m.matches = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(matches) {
+				return m, nil
+			}
+			return m, m.jumpToMessage(matches[n])
+		case pickerPrompt:
+			prompts := m.prompts
+			m.prompts = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(prompts) {
+				return m, nil
+			}
+			return m, copyTextCmd("prompt", prompts[n].Text)
+		case pickerCode:
+			blocks := m.codeBlocks
+			m.codeBlocks = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(blocks) {
+				return m, nil
+			}
+			return m, copyTextCmd("code block", blocks[n].Code)
+		case pickerFollowUp:
+			items := m.followUps
+			m.followUps = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(items) {
+				return m, nil
+			}
+			return m, m.jumpToMessage(index.MessageMatch{SessionID: items[n].SessionID, MessageID: items[n].MessageID})
+		case pickerTOC:
+			if n, err := strconv.Atoi(item.value); err == nil {
+				m.gotoTurn(n)
+			}
+			return m, nil
+		case pickerFileRef:
+			refs := m.fileRefs
+			m.fileRefs = nil
+			n, err := strconv.Atoi(item.value)
+			if err != nil || n < 0 || n >= len(refs) {
+				return m, nil
+			}
+			return m, m.openFileRefCmd(refs[n])
+		}
+	}
+	return m, cmd
+}
+
+// sessionCost formats the estimated cost of a session, or "" when it
+// recorded no usage.
+func (m Model) sessionCost(s index.Session) string {
+	if len(s.Usage) == 0 || m.cfg.Pricing == nil {
+		return ""
+	}
+	return pricing.FormatCost(m.cfg.Pricing.Estimate(s.Usage))
+}
+
+// snippetUsage sums up the session's scale for copied snippets ("~42.0k
+// tokens, ~$0.85, 14 tool calls"); "" unless --snippet-usage is on.
+func (m Model) snippetUsage(session index.Session, msgs []index.Message) string {
+	if !m.cfg.SnippetUsage {
+		return ""
+	}
+	var parts []string
+	if len(session.Usage) > 0 {
+		parts = append(parts, "~"+pricing.FormatTokens(index.SumUsage(session.Usage).Total())+" tokens")
+	}
+	if cost := m.sessionCost(session); cost != "" {
+		parts = append(parts, cost)
+	}
+	switch n := index.CountToolCalls(msgs); n {
+	case 0:
+	case 1:
+		parts = append(parts, "1 tool call")
+	default:
+		parts = append(parts, strconv.Itoa(n)+" tool calls")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func buildPRSnippet(session index.Session, msgs []index.Message, exportPath, usage string) string {
 	var b strings.Builder
 	heading := "Codex"
 	if session.Source == "claude" {
 		heading = "Claude"
 	}
 	b.WriteString("### " + heading + " transcript\n\n")
-	b.WriteString("- Session: `" + strings.TrimSpace(session.ID) + "`\n")
+	b.WriteString("- Session: `" + strings.TrimSpace(session.ID) + "` (`agent-trace open " + strings.TrimSpace(session.ID) + "`)\n")
 	b.WriteString("- Export: `" + snippetExportPath(exportPath) + "`\n")
 	b.WriteString("- Notes: " + snippetNotes(session, msgs) + "\n")
+	if usage != "" {
+		b.WriteString("- Usage: " + usage + "\n")
+	}
 	return b.String()
 }
 
@@ -1536,6 +2611,11 @@ var (
 			Foreground(lipgloss.Color("141"))
 	codexDotStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("214"))
+	markedStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("212"))
+	liveStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("42")).
+			Bold(true)
 )
 
 func shortcutsModalStyle() lipgloss.Style {
@@ -1562,29 +2642,70 @@ func panelStyle(active bool) lipgloss.Style {
 }
 
 type keyMap struct {
-	Up             key.Binding
-	Down           key.Binding
-	FocusLeft      key.Binding
-	FocusRight     key.Binding
-	Tab            key.Binding
-	ToggleSort     key.Binding
-	ToggleGrouping key.Binding
-	PageUp         key.Binding
-	PageDown       key.Binding
-	PrevPage       key.Binding
-	NextPage       key.Binding
-	Search         key.Binding
-	Esc            key.Binding
-	ToggleHelp     key.Binding
-	Export         key.Binding
-	Copy           key.Binding
-	ToggleTools    key.Binding
-	ToggleAborted  key.Binding
-	ToggleAgents   key.Binding
-	ToggleEvents   key.Binding
-	CycleSource    key.Binding
-	Resume         key.Binding
-	Quit           key.Binding
+	Up               key.Binding
+	Down             key.Binding
+	FocusLeft        key.Binding
+	FocusRight       key.Binding
+	Tab              key.Binding
+	ToggleSort       key.Binding
+	ToggleGrouping   key.Binding
+	ToggleQuick      key.Binding
+	ToggleEmpty      key.Binding
+	PageUp           key.Binding
+	PageDown         key.Binding
+	PrevPage         key.Binding
+	NextPage         key.Binding
+	Search           key.Binding
+	Esc              key.Binding
+	ToggleHelp       key.Binding
+	Export           key.Binding
+	ExportCommands   key.Binding
+	Replay           key.Binding
+	Copy             key.Binding
+	CopySlack        key.Binding
+	CopyTranscript   key.Binding
+	ToggleTools      key.Binding
+	ToggleAborted    key.Binding
+	ToggleAgents     key.Binding
+	ToggleEvents     key.Binding
+	ToggleThinking   key.Binding
+	ToggleReasoning  key.Binding
+	ToggleSubagents  key.Binding
+	ToggleTimestamps key.Binding
+	CycleSource      key.Binding
+	CycleRole        key.Binding
+	PickWorkdir      key.Binding
+	Mark             key.Binding
+	Diff             key.Binding
+	MergeThread      key.Binding
+	FileChanges      key.Binding
+	IngestIssues     key.Binding
+	PickStyle        key.Binding
+	ViewImage        key.Binding
+	SidePane         key.Binding
+	CycleView        key.Binding
+	RefreshSession   key.Binding
+	Follow           key.Binding
+	Watch            key.Binding
+	ToggleSafeRender key.Binding
+	Bookmark         key.Binding
+	Alias            key.Binding
+	Note             key.Binding
+	Tags             key.Binding
+	CopyMenu         key.Binding
+	Resume           key.Binding
+	ResumeSpawn      key.Binding
+	Handoff          key.Binding
+	OpenWorkdir      key.Binding
+	OpenFileRef      key.Binding
+	MatchBrowser     key.Binding
+	PromptLibrary    key.Binding
+	CodeBrowser      key.Binding
+	FollowUps        key.Binding
+	Find             key.Binding
+	GotoTurn         key.Binding
+	TOC              key.Binding
+	Quit             key.Binding
 }
 
 func defaultKeys() keyMap {
@@ -1617,6 +2738,14 @@ func defaultKeys() keyMap {
 			key.WithKeys("w"),
 			key.WithHelp("w", "toggle grouping"),
 		),
+		ToggleQuick: key.NewBinding(
+			key.WithKeys("Q"),
+			key.WithHelp("Q", "expand quick sessions"),
+		),
+		ToggleEmpty: key.NewBinding(
+			key.WithKeys("Z"),
+			key.WithHelp("Z", "show empty sessions"),
+		),
 		PageUp: key.NewBinding(
 			key.WithKeys("pgup", "b"),
 			key.WithHelp("pgup", "page up"),
@@ -1639,7 +2768,7 @@ func defaultKeys() keyMap {
 		),
 		Esc: key.NewBinding(
 			key.WithKeys("esc"),
-			key.WithHelp("esc", "clear search"),
+			key.WithHelp("esc", "clear search/close view"),
 		),
 		ToggleHelp: key.NewBinding(
 			key.WithKeys("?"),
@@ -1649,10 +2778,26 @@ func defaultKeys() keyMap {
 			key.WithKeys("x"),
 			key.WithHelp("x", "export markdown"),
 		),
+		ExportCommands: key.NewBinding(
+			key.WithKeys("X"),
+			key.WithHelp("X", "export shell commands"),
+		),
+		Replay: key.NewBinding(
+			key.WithKeys("R"),
+			key.WithHelp("R", "replay shell commands"),
+		),
 		Copy: key.NewBinding(
 			key.WithKeys("c"),
 			key.WithHelp("c", "copy PR snippet"),
 		),
+		CopySlack: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "copy Slack summary"),
+		),
+		CopyTranscript: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy transcript"),
+		),
 		ToggleTools: key.NewBinding(
 			key.WithKeys("t"),
 			key.WithHelp("t", "toggle tools"),
@@ -1669,10 +2814,150 @@ func defaultKeys() keyMap {
 			key.WithKeys("e"),
 			key.WithHelp("e", "toggle events"),
 		),
+		ToggleThinking: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "toggle thinking"),
+		),
+		ToggleReasoning: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "toggle reasoning"),
+		),
+		ToggleSubagents: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "toggle inline subagents"),
+		),
+		ToggleTimestamps: key.NewBinding(
+			key.WithKeys("@"),
+			key.WithHelp("@", "toggle timestamps"),
+		),
 		CycleSource: key.NewBinding(
 			key.WithKeys("s"),
 			key.WithHelp("s", "cycle source filter"),
 		),
+		PickWorkdir: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "pick workdir filter"),
+		),
+		Mark: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "mark for diff"),
+		),
+		Diff: key.NewBinding(
+			key.WithKeys("D"),
+			key.WithHelp("D", "diff marked sessions"),
+		),
+		MergeThread: key.NewBinding(
+			key.WithKeys("M"),
+			key.WithHelp("M", "merged thread view"),
+		),
+		FileChanges: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "files changed (snapshots)"),
+		),
+		IngestIssues: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "index problems"),
+		),
+		PickStyle: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "pick transcript style"),
+		),
+		ViewImage: key.NewBinding(
+			key.WithKeys("I"),
+			key.WithHelp("I", "preview image"),
+		),
+		CopyMenu: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy id/workdir/commits"),
+		),
+		ResumeSpawn: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "resume in new window"),
+		),
+		Handoff: key.NewBinding(
+			key.WithKeys("H"),
+			key.WithHelp("H", "hand off to other agent"),
+		),
+		OpenWorkdir: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open workdir"),
+		),
+		Find: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "find in transcript"),
+		),
+		GotoTurn: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "go to turn"),
+		),
+		TOC: key.NewBinding(
+			key.WithKeys("="),
+			key.WithHelp("=", "contents"),
+		),
+		MatchBrowser: key.NewBinding(
+			key.WithKeys("J"),
+			key.WithHelp("J", "browse matches"),
+		),
+		PromptLibrary: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "prompt library"),
+		),
+		CodeBrowser: key.NewBinding(
+			key.WithKeys("K"),
+			key.WithHelp("K", "code blocks"),
+		),
+		FollowUps: key.NewBinding(
+			key.WithKeys("!"),
+			key.WithHelp("!", "follow-ups"),
+		),
+		OpenFileRef: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "open file:line"),
+		),
+		CycleView: key.NewBinding(
+			key.WithKeys("V"),
+			key.WithHelp("V", "view: rendered/markdown/raw"),
+		),
+		SidePane: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "cycle side pane"),
+		),
+		RefreshSession: key.NewBinding(
+			key.WithKeys("L"),
+			key.WithHelp("L", "reload session from disk"),
+		),
+		Follow: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "follow session"),
+		),
+		Watch: key.NewBinding(
+			key.WithKeys("ctrl+n"),
+			key.WithHelp("ctrl+n", "watch session"),
+		),
+		ToggleSafeRender: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "toggle safe render"),
+		),
+		CycleRole: key.NewBinding(
+			key.WithKeys("U"),
+			key.WithHelp("U", "cycle role filter"),
+		),
+		Bookmark: key.NewBinding(
+			key.WithKeys("B"),
+			key.WithHelp("B", "bookmark"),
+		),
+		Alias: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "rename"),
+		),
+		Note: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "edit note"),
+		),
+		Tags: key.NewBinding(
+			key.WithKeys("#"),
+			key.WithHelp("#", "edit tags"),
+		),
 		Resume: key.NewBinding(
 			key.WithKeys("r"),
 			key.WithHelp("r", "resume session"),
@@ -1690,8 +2975,8 @@ func (k keyMap) ShortHelp() []key.Binding {
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.FocusLeft, k.FocusRight, k.Tab, k.ToggleSort, k.ToggleGrouping},
-		{k.PageDown, k.PageUp, k.NextPage, k.PrevPage, k.Search, k.Esc, k.ToggleHelp},
-		{k.Export, k.Copy, k.Resume, k.ToggleTools, k.ToggleAborted, k.ToggleAgents, k.ToggleEvents, k.CycleSource, k.Quit},
+		{k.Up, k.Down, k.FocusLeft, k.FocusRight, k.Tab, k.ToggleSort, k.ToggleGrouping, k.ToggleQuick, k.ToggleEmpty},
+		{k.PageDown, k.PageUp, k.NextPage, k.PrevPage, k.Search, k.MatchBrowser, k.PromptLibrary, k.CodeBrowser, k.FollowUps, k.Find, k.GotoTurn, k.TOC, k.Esc, k.ToggleHelp},
+		{k.Export, k.ExportCommands, k.Replay, k.Copy, k.CopySlack, k.CopyTranscript, k.CopyMenu, k.Resume, k.ResumeSpawn, k.Handoff, k.OpenWorkdir, k.OpenFileRef, k.ToggleTools, k.ToggleAborted, k.ToggleAgents, k.ToggleEvents, k.ToggleThinking, k.ToggleReasoning, k.ToggleSubagents, k.ToggleTimestamps, k.ToggleSafeRender, k.CycleRole, k.CycleSource, k.PickWorkdir, k.Mark, k.Diff, k.MergeThread, k.FileChanges, k.IngestIssues, k.PickStyle, k.ViewImage, k.SidePane, k.CycleView, k.RefreshSession, k.Follow, k.Watch, k.Bookmark, k.Alias, k.Note, k.Tags, k.Quit},
 	}
 }
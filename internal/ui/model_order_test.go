@@ -190,7 +190,7 @@ func TestCollapseInitialAgentsBlock(t *testing.T) {
 		t.Fatalf("write AGENTS.md: %v", err)
 	}
 	in := "## You\n# AGENTS.md instructions for " + dir + "\n<INSTRUCTIONS>\nhello\n</INSTRUCTIONS>\n## Codex\nok\n"
-	out := collapseInitialAgentsBlock(in)
+	out := newCollapser(nil).collapse(in)
 	if !strings.Contains(out, "AGENTS.md instructions collapsed") {
 		t.Fatalf("expected collapsed marker, got: %q", out)
 	}
@@ -201,7 +201,7 @@ func TestCollapseInitialAgentsBlock(t *testing.T) {
 
 func TestCollapseInitialAgentsBlock_NoStructuredBlock(t *testing.T) {
 	in := "## You\nI mentioned # AGENTS.md instructions for /tmp/repo in text\n## Codex\nok\n"
-	out := collapseInitialAgentsBlock(in)
+	out := newCollapser(nil).collapse(in)
 	if out != in {
 		t.Fatalf("expected unchanged markdown when no structured AGENTS block")
 	}
@@ -210,7 +210,7 @@ func TestCollapseInitialAgentsBlock_NoStructuredBlock(t *testing.T) {
 func TestCollapseInitialAgentsBlock_SkipsWhenAgentsFileMissing(t *testing.T) {
 	dir := t.TempDir()
 	in := "## You\n# AGENTS.md instructions for " + dir + "\n<INSTRUCTIONS>\nhello\n</INSTRUCTIONS>\n## Codex\nok\n"
-	out := collapseInitialAgentsBlock(in)
+	out := newCollapser(nil).collapse(in)
 	if out != in {
 		t.Fatalf("expected unchanged markdown when AGENTS.md file does not exist")
 	}
@@ -238,3 +238,19 @@ func TestPrependCollapsedEventsHint_NotShownWhenEnabled(t *testing.T) {
 		t.Fatalf("expected unchanged markdown when events toggle enabled")
 	}
 }
+
+func TestIndexProgressLabel(t *testing.T) {
+	cases := []struct {
+		p    index.IndexProgress
+		want string
+	}{
+		{index.IndexProgress{}, "indexing..."},
+		{index.IndexProgress{Files: 412, TotalFiles: 1890, Messages: 9214, Path: "/home/u/.codex/sessions/rollout-1.jsonl"}, "indexing 412/1890 files, 9214 msgs (rollout-1.jsonl)"},
+		{index.IndexProgress{Files: 3, TotalFiles: 3, Messages: 7}, "indexing 3/3 files, 7 msgs, summarizing sessions..."},
+	}
+	for _, c := range cases {
+		if got := indexProgressLabel(c.p); got != c.want {
+			t.Errorf("indexProgressLabel(%+v) = %q, want %q", c.p, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+	"agent-trace/internal/termimg"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type openMsg struct {
+	dir string
+	err error
+}
+
+// workdirCommand builds the argv that opens the session's workdir. open is
+// the configured open command, expanded like resume commands; the workdir
+// is appended when it is not a template. Without one it tries $VISUAL,
+// $EDITOR, VS Code and then the system file opener.
+func workdirCommand(open *config.CommandTemplate, session index.Session, getenv func(string) string, lookPath func(string) (string, error), goos string) ([]string, error) {
+	dir := session.Workdir
+	if open != nil {
+		argv, err := open.Expand(resumeData{SessionID: session.ID, Workdir: dir, Source: session.Source})
+		if err != nil {
+			return nil, err
+		}
+		if open.Literal() {
+			argv = append(argv, dir)
+		}
+		return argv, nil
+	}
+	editor := ""
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(getenv(env)); v != "" {
+			editor = v
+			break
+		}
+	}
+	if editor == "" {
+		if _, err := lookPath("code"); err == nil {
+			editor = "code"
+		}
+	}
+	if editor == "" {
+		viewer, err := termimg.SelectViewer(goos, lookPath)
+		if err != nil {
+			return nil, errors.New("no open command: set --open-command, $VISUAL or $EDITOR")
+		}
+		return []string{viewer.Path, dir}, nil
+	}
+	return append(strings.Fields(editor), dir), nil
+}
+
+// openWorkdirCmd opens the session's workdir with the configured command,
+// running it in the directory so terminal editors start there too. The TUI
+// is suspended while the command runs.
+func (m Model) openWorkdirCmd(sessionID string) tea.Cmd {
+	dir := m.sessions[sessionID].Workdir
+	if dir == "" {
+		return func() tea.Msg { return statusMsg{text: "Session has no recorded workdir"} }
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return func() tea.Msg { return statusMsg{text: "Workdir no longer exists: " + dir} }
+	}
+	argv, err := workdirCommand(m.cfg.OpenCommand, m.sessions[sessionID], os.Getenv, exec.LookPath, runtime.GOOS)
+	if err != nil {
+		return func() tea.Msg { return openMsg{dir: dir, err: err} }
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Dir = dir
+	m.log.Info("opening workdir", "session", sessionID, "command", argv)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			err = fmt.Errorf("%s: %w", argv[0], err)
+		}
+		return openMsg{dir: dir, err: err}
+	})
+}
@@ -0,0 +1,64 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+)
+
+func TestWorkdirCommand(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(k string) string { return vars[k] }
+	}
+	has := func(names ...string) func(string) (string, error) {
+		return func(name string) (string, error) {
+			for _, n := range names {
+				if n == name {
+					return "/usr/bin/" + name, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+
+	cases := []struct {
+		name     string
+		template string
+		vars     map[string]string
+		lookPath func(string) (string, error)
+		want     []string
+	}{
+		{"placeholder", "tmux new-window -c {{.Workdir}} nvim", nil, has(), []string{"tmux", "new-window", "-c", "/my work", "nvim"}},
+		{"quoted", `open -a "Sublime Text" {{.Workdir}}`, nil, has(), []string{"open", "-a", "Sublime Text", "/my work"}},
+		{"appended", "code -n", nil, has(), []string{"code", "-n", "/my work"}},
+		{"visual first", "", map[string]string{"VISUAL": "hx", "EDITOR": "vi"}, has(), []string{"hx", "/my work"}},
+		{"editor", "", map[string]string{"EDITOR": "emacs -nw"}, has("code"), []string{"emacs", "-nw", "/my work"}},
+		{"vs code", "", nil, has("code", "xdg-open"), []string{"code", "/my work"}},
+		{"system opener", "", nil, has("xdg-open"), []string{"/usr/bin/xdg-open", "/my work"}},
+	}
+	session := index.Session{ID: "s1", Workdir: "/my work"}
+	for _, tc := range cases {
+		var open *config.CommandTemplate
+		if tc.template != "" {
+			ct, err := config.ParseCommandTemplate(tc.template)
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+			open = &ct
+		}
+		got, err := workdirCommand(open, session, env(tc.vars), tc.lookPath, "linux")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := workdirCommand(nil, session, env(nil), has(), "linux"); err == nil {
+		t.Error("expected an error with no command available")
+	}
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// sessionPageSize is how many sessions one list query loads. Scrolling
+// near the end of the unfiltered list loads the next page; search results
+// stay capped at one page, ranked by relevance.
+const sessionPageSize = 500
+
+// loadMoreThreshold is how close to the last row the selection gets before
+// the next page is requested.
+const loadMoreThreshold = 20
+
+// sessionPager tracks how far the browse list has been loaded.
+type sessionPager struct {
+	cursor  index.SessionCursor // last loaded session, in index order
+	loaded  int                 // sessions loaded so far
+	more    bool                // the last page was full, so older sessions may remain
+	loading bool
+}
+
+type moreSessionsMsg struct {
+	after    index.SessionCursor
+	sessions []index.Session
+	err      error
+}
+
+// resetPager records a freshly loaded list. Only the unfiltered list pages.
+func (m *Model) resetPager(query string, sessions []index.Session, limit int) {
+	m.pager = sessionPager{}
+	if strings.TrimSpace(query) != "" || len(sessions) == 0 {
+		return
+	}
+	m.pager.cursor = index.CursorAfter(sessions[len(sessions)-1])
+	m.pager.loaded = len(sessions)
+	m.pager.more = len(sessions) >= limit
+}
+
+// browseLimit is the list size to reload with, so a refresh keeps the pages
+// already scrolled through.
+func (m Model) browseLimit(query string) int {
+	if strings.TrimSpace(query) != "" {
+		return sessionPageSize
+	}
+	return max(sessionPageSize, m.pager.loaded)
+}
+
+// maybeLoadMore requests the next page once the selection nears the end of
+// the list.
+func (m *Model) maybeLoadMore() tea.Cmd {
+	if !m.pager.more || m.pager.loading || m.searchMode || strings.TrimSpace(m.searchQuery) != "" {
+		return nil
+	}
+	if m.list.Index() < len(m.list.Items())-loadMoreThreshold {
+		return nil
+	}
+	m.pager.loading = true
+	after := m.pager.cursor
+	return func() tea.Msg {
+		s, err := m.indexer.ListSessionsPage(&after, sessionPageSize)
+		return moreSessionsMsg{after: after, sessions: s, err: err}
+	}
+}
+
+// applyMoreSessions merges a page into the list, keeping the selection.
+func (m *Model) applyMoreSessions(msg moreSessionsMsg) tea.Cmd {
+	if msg.after != m.pager.cursor {
+		// The list was reloaded while the page was in flight.
+		return nil
+	}
+	m.pager.loading = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = "Could not load more sessions: " + msg.err.Error()
+		m.log.Error("loading more sessions failed", "err", msg.err)
+		return nil
+	}
+	m.pager.more = len(msg.sessions) >= sessionPageSize
+	if len(msg.sessions) == 0 {
+		return nil
+	}
+	m.pager.cursor = index.CursorAfter(msg.sessions[len(msg.sessions)-1])
+	m.pager.loaded += len(msg.sessions)
+	for _, s := range msg.sessions {
+		m.allSessions[s.ID] = s
+	}
+	m.applySessionsFromMap()
+	m.status = fmt.Sprintf("Loaded %d more sessions (%d listed)", len(msg.sessions), m.pager.loaded)
+	// Filters may hide most of the page; keep going until the list has
+	// enough rows below the selection.
+	return m.maybeLoadMore()
+}
@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+func TestListLoadsNextPageNearTheEnd(t *testing.T) {
+	page := func(from, n int) []index.Session {
+		out := make([]index.Session, n)
+		for k := range out {
+			out[k] = index.Session{ID: fmt.Sprintf("s%04d", from+k), MessageCount: 5, LastActivityTS: int64(10_000 - from - k)}
+		}
+		return out
+	}
+	m := Model{
+		list: list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 20),
+		keys: defaultKeys(),
+		log:  logging.Discard(),
+	}
+	first := page(0, sessionPageSize)
+	m.resetPager("", first, sessionPageSize)
+	m.applySessions(first)
+
+	if cmd := m.maybeLoadMore(); cmd != nil {
+		t.Fatal("expected no request while the selection is at the top")
+	}
+	m.list.Select(sessionPageSize - 5)
+	m.selectedID = m.currentSelectedID()
+	if cmd := m.maybeLoadMore(); cmd == nil || !m.pager.loading {
+		t.Fatal("expected the next page to be requested near the end")
+	}
+	if cmd := m.maybeLoadMore(); cmd != nil {
+		t.Fatal("expected one request in flight at a time")
+	}
+
+	// A page for a cursor the list has moved past is dropped.
+	m.applyMoreSessions(moreSessionsMsg{after: index.SessionCursor{ID: "stale"}, sessions: page(900, 3)})
+	if len(m.list.Items()) != sessionPageSize {
+		t.Fatalf("expected the stale page ignored, got %d items", len(m.list.Items()))
+	}
+
+	selected := m.selectedID
+	m.applyMoreSessions(moreSessionsMsg{after: index.CursorAfter(first[len(first)-1]), sessions: page(sessionPageSize, 3)})
+	if n := len(m.list.Items()); n != sessionPageSize+3 {
+		t.Fatalf("expected %d items after the second page, got %d", sessionPageSize+3, n)
+	}
+	if m.selectedID != selected {
+		t.Fatalf("selection moved from %s to %s", selected, m.selectedID)
+	}
+	if m.pager.more || m.pager.loading || m.pager.loaded != sessionPageSize+3 {
+		t.Fatalf("unexpected pager state %+v", m.pager)
+	}
+	if got := m.browseLimit(""); got != sessionPageSize+3 {
+		t.Fatalf("expected reloads to keep every loaded session, limit %d", got)
+	}
+	if got := m.browseLimit("needle"); got != sessionPageSize {
+		t.Fatalf("expected search to stay at one page, limit %d", got)
+	}
+}
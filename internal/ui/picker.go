@@ -0,0 +1,220 @@
+package ui
+
+import (
+	"strings"
+
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type pickerKind int
+
+const (
+	pickerNone pickerKind = iota
+	pickerWorkdir
+	pickerStyle
+	pickerImage
+	pickerCopy
+	pickerFileRef
+	pickerMatch
+	pickerPrompt
+	pickerCode
+	pickerFollowUp
+	pickerTOC
+)
+
+type pickerItem struct {
+	label  string
+	detail string
+	value  string
+}
+
+type pickerResult int
+
+const (
+	pickerPending pickerResult = iota
+	pickerChosen
+	pickerCancelled
+)
+
+// picker is a small type-to-filter modal list used for quick-selection
+// overlays. It only handles keys; the owning model decides what a choice means.
+type picker struct {
+	kind    pickerKind
+	title   string
+	items   []pickerItem
+	visible []int
+	cursor  int
+	filter  textinput.Model
+}
+
+func newPicker(kind pickerKind, title string, items []pickerItem) picker {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.Placeholder = "type to filter..."
+	ti.CharLimit = 256
+	ti.Focus()
+
+	p := picker{kind: kind, title: title, items: items, filter: ti}
+	p.applyFilter()
+	return p
+}
+
+func (p *picker) active() bool {
+	return p.kind != pickerNone
+}
+
+func (p *picker) selected() (pickerItem, bool) {
+	if p.cursor < 0 || p.cursor >= len(p.visible) {
+		return pickerItem{}, false
+	}
+	return p.items[p.visible[p.cursor]], true
+}
+
+func (p *picker) applyFilter() {
+	terms := strings.Fields(strings.ToLower(p.filter.Value()))
+	p.visible = p.visible[:0]
+	for idx, it := range p.items {
+		hay := strings.ToLower(it.label + " " + it.detail + " " + it.value)
+		ok := true
+		for _, t := range terms {
+			if !strings.Contains(hay, t) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			p.visible = append(p.visible, idx)
+		}
+	}
+	if p.cursor >= len(p.visible) {
+		p.cursor = len(p.visible) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+func (p *picker) update(msg tea.KeyMsg) (pickerResult, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		return pickerCancelled, nil
+	case "enter":
+		if _, ok := p.selected(); ok {
+			return pickerChosen, nil
+		}
+		return pickerPending, nil
+	case "up", "ctrl+p":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return pickerPending, nil
+	case "down", "ctrl+n":
+		if p.cursor < len(p.visible)-1 {
+			p.cursor++
+		}
+		return pickerPending, nil
+	case "pgup":
+		p.cursor = max(p.cursor-10, 0)
+		return pickerPending, nil
+	case "pgdown":
+		p.cursor = max(min(p.cursor+10, len(p.visible)-1), 0)
+		return pickerPending, nil
+	}
+
+	before := p.filter.Value()
+	var cmd tea.Cmd
+	p.filter, cmd = p.filter.Update(msg)
+	if p.filter.Value() != before {
+		p.cursor = 0
+		p.applyFilter()
+	}
+	return pickerPending, cmd
+}
+
+func (p picker) view(maxWidth, maxHeight int) string {
+	if maxWidth < 40 {
+		maxWidth = 40
+	}
+	if maxHeight < 8 {
+		maxHeight = 8
+	}
+	innerW := maxWidth - 4
+	// border (2) + padding (2) + title + blank + filter + blank
+	rows := maxHeight - 8
+	if rows < 1 {
+		rows = 1
+	}
+
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+	end := min(start+rows, len(p.visible))
+
+	lines := make([]string, 0, rows)
+	for pos := start; pos < end; pos++ {
+		it := p.items[p.visible[pos]]
+		line := it.label
+		if it.detail != "" {
+			line += "  " + renderDetail(it.detail)
+		}
+		line = ansi.Truncate(line, innerW-2, "…")
+		if pos == p.cursor {
+			lines = append(lines, pickerCursorStyle.Render("▸ ")+line)
+		} else {
+			lines = append(lines, "  "+line)
+		}
+	}
+	if len(p.visible) == 0 {
+		lines = append(lines, pickerDetailStyle.Render("  no matches"))
+	}
+
+	header := shortcutsTitleStyle.Render(p.title + "  (enter select, esc close)")
+	content := lipgloss.NewStyle().
+		Width(innerW).
+		Render(lipgloss.JoinVertical(lipgloss.Left, header, "", p.filter.View(), "", strings.Join(lines, "\n")))
+
+	return shortcutsModalStyle().
+		Width(maxWidth).
+		Height(maxHeight).
+		Render(content)
+}
+
+// renderDetail dims detail text except for search hits, which are
+// highlighted.
+func renderDetail(detail string) string {
+	return renderHits(detail, &pickerDetailStyle)
+}
+
+// renderHits highlights the search hits marked with index.MatchStart and
+// index.MatchEnd in s, rendering the text between them in plain when it is
+// not nil.
+func renderHits(s string, plain *lipgloss.Style) string {
+	var b strings.Builder
+	for {
+		before, rest, ok := strings.Cut(s, index.MatchStart)
+		if before != "" && plain != nil {
+			before = plain.Render(before)
+		}
+		b.WriteString(before)
+		if !ok {
+			return b.String()
+		}
+		hit, after, _ := strings.Cut(rest, index.MatchEnd)
+		b.WriteString(searchMatchStyle.Render(hit))
+		s = after
+	}
+}
+
+var (
+	pickerCursorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("212")).
+				Bold(true)
+	pickerDetailStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("244"))
+)
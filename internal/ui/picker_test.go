@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPickerFiltersAndSelects(t *testing.T) {
+	p := newPicker(pickerWorkdir, "Pick", []pickerItem{
+		{label: "All workdirs"},
+		{label: "/tmp/alpha", value: "/tmp/alpha"},
+		{label: "/tmp/beta", value: "/tmp/beta"},
+	})
+	if len(p.visible) != 3 {
+		t.Fatalf("expected all items visible, got %d", len(p.visible))
+	}
+
+	for _, r := range "bet" {
+		p.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(p.visible) != 1 {
+		t.Fatalf("expected 1 filtered item, got %d", len(p.visible))
+	}
+	res, _ := p.update(tea.KeyMsg{Type: tea.KeyEnter})
+	if res != pickerChosen {
+		t.Fatalf("expected enter to choose, got %v", res)
+	}
+	item, ok := p.selected()
+	if !ok || item.value != "/tmp/beta" {
+		t.Fatalf("unexpected selection: %#v", item)
+	}
+
+	res, _ = p.update(tea.KeyMsg{Type: tea.KeyEsc})
+	if res != pickerCancelled {
+		t.Fatalf("expected esc to cancel, got %v", res)
+	}
+}
+
+func TestWorkdirPickerFiltersSessionList(t *testing.T) {
+	in := []index.Session{
+		{ID: "a", Workdir: "/tmp/alpha", LastActivityTS: 30},
+		{ID: "b", Workdir: "/tmp/beta", LastActivityTS: 20},
+		{ID: "c", Workdir: "/tmp/alpha", LastActivityTS: 10},
+	}
+	m := Model{
+		list: list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 20),
+		keys: defaultKeys(),
+	}
+	m.applySessions(in)
+	m.openWorkdirPicker([]index.WorkdirSummary{
+		{Path: "/tmp/alpha", SessionCount: 2},
+		{Path: "/tmp/beta", SessionCount: 1},
+	})
+
+	m.picker.cursor = 1
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	got := updated.(Model)
+
+	if got.picker.active() {
+		t.Fatalf("expected picker to close after choosing")
+	}
+	if got.workdirFilter != "/tmp/alpha" {
+		t.Fatalf("expected workdir filter /tmp/alpha, got %q", got.workdirFilter)
+	}
+	if ids := ids(sessionsFromItems(got.list.Items())); len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+		t.Fatalf("unexpected filtered sessions: %v", ids)
+	}
+}
+
+func sessionsFromItems(items []list.Item) []index.Session {
+	out := make([]index.Session, 0, len(items))
+	for _, it := range items {
+		out = append(out, it.(sessionItem).s)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptLibraryLimit caps how many prompts the prompt library lists.
+const promptLibraryLimit = 5000
+
+type promptsMsg struct {
+	prompts []index.Prompt
+	err     error
+}
+
+func (m Model) promptsCmd() tea.Cmd {
+	return func() tea.Msg {
+		prompts, err := m.indexer.Prompts(promptLibraryLimit)
+		return promptsMsg{prompts: prompts, err: err}
+	}
+}
+
+// promptItems lists prompts for the picker: when each was last sent and how
+// often, then the prompt on one line.
+func promptItems(prompts []index.Prompt) []pickerItem {
+	items := make([]pickerItem, 0, len(prompts))
+	for n, p := range prompts {
+		label := index.FormatUnix(p.LastTS)
+		if p.Uses > 1 {
+			label += fmt.Sprintf("  %d× in %d sessions", p.Uses, p.Sessions)
+		}
+		items = append(items, pickerItem{label: label, detail: strings.Join(strings.Fields(p.Text), " "), value: strconv.Itoa(n)})
+	}
+	return items
+}
+
+// openPromptLibrary lists every distinct prompt in a picker; choosing one
+// copies it.
+func (m *Model) openPromptLibrary(msg promptsMsg) {
+	if msg.err != nil {
+		m.status = "Prompt library failed: " + msg.err.Error()
+		m.log.Error("prompt library failed", "err", msg.err)
+		return
+	}
+	if len(msg.prompts) == 0 {
+		m.status = "No prompts indexed yet"
+		return
+	}
+	m.prompts = msg.prompts
+	title := strconv.Itoa(len(msg.prompts)) + " distinct prompts, enter copies"
+	if len(msg.prompts) == promptLibraryLimit {
+		title = "Latest " + title
+	}
+	m.picker = newPicker(pickerPrompt, title, promptItems(msg.prompts))
+}
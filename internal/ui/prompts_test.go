@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestPromptItemsShowRepeatsOnOneLine(t *testing.T) {
+	items := promptItems([]index.Prompt{
+		{Text: "review this diff\nfor races", Uses: 3, Sessions: 2},
+		{Text: "run the tests", Uses: 1, Sessions: 1},
+	})
+	if len(items) != 2 {
+		t.Fatalf("got %d items", len(items))
+	}
+	if items[0].label != "n/a  3× in 2 sessions" || items[0].detail != "review this diff for races" || items[0].value != "0" {
+		t.Errorf("first item = %+v", items[0])
+	}
+	if items[1].label != "n/a" {
+		t.Errorf("a prompt sent once should not count uses, got %q", items[1].label)
+	}
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// quickGroupItem is the list row standing in for folded quick sessions:
+// throwaways with fewer conversational messages than --quick-under.
+type quickGroupItem struct {
+	count    int
+	under    int
+	expanded bool
+}
+
+func (g quickGroupItem) Title() string {
+	if g.expanded {
+		return fmt.Sprintf("▾ quick sessions (%d)", g.count)
+	}
+	return fmt.Sprintf("▸ quick sessions (%d)", g.count)
+}
+
+func (g quickGroupItem) Description() string {
+	if g.expanded {
+		return fmt.Sprintf("under %d msgs | Q to collapse", g.under)
+	}
+	return fmt.Sprintf("under %d msgs | Q to expand", g.under)
+}
+
+func (g quickGroupItem) FilterValue() string { return "" }
+
+func (g quickGroupItem) hint() string {
+	return fmt.Sprintf("%d quick sessions with fewer than %d messages are folded here.\n\nPress `Q` to expand or collapse them.", g.count, g.under)
+}
+
+// quickFoldActive reports whether quick sessions are folded in the current
+// list; search results keep their relevance order instead.
+func (m Model) quickFoldActive() bool {
+	return m.cfg.QuickUnder > 0 && strings.TrimSpace(m.searchQuery) == "" && !m.searchMode
+}
+
+// splitQuick separates quick sessions from the rest, keeping order.
+// Bookmarked and marked sessions are never folded away.
+func (m Model) splitQuick(in []index.Session) (keep, quick []index.Session) {
+	for _, s := range in {
+		if s.MessageCount < m.cfg.QuickUnder && !m.annotations[s.ID].Bookmarked && !m.isMarked(s.ID) {
+			quick = append(quick, s)
+			continue
+		}
+		keep = append(keep, s)
+	}
+	return keep, quick
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuickSessionsFoldIntoGroup(t *testing.T) {
+	in := []index.Session{
+		{ID: "long", MessageCount: 12, LastActivityTS: 10},
+		{ID: "quick", MessageCount: 1, LastActivityTS: 30},
+		{ID: "starred", MessageCount: 2, LastActivityTS: 20},
+	}
+	m := Model{
+		cfg:         config.AppConfig{QuickUnder: 3},
+		list:        list.New([]list.Item{}, list.NewDefaultDelegate(), 40, 20),
+		keys:        defaultKeys(),
+		annotations: map[string]index.Annotation{"starred": {SessionID: "starred", Bookmarked: true}},
+	}
+	m.applySessions(in)
+
+	items := m.list.Items()
+	if len(items) != 3 {
+		t.Fatalf("expected two sessions and the group row, got %d items", len(items))
+	}
+	if items[0].(sessionItem).s.ID != "starred" || items[1].(sessionItem).s.ID != "long" {
+		t.Fatalf("unexpected order: %v", items)
+	}
+	if g, ok := items[2].(quickGroupItem); !ok || g.count != 1 || g.expanded {
+		t.Fatalf("expected a collapsed group of 1 at the bottom, got %#v", items[2])
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'Q'}})
+	m = updated.(Model)
+	items = m.list.Items()
+	if len(items) != 4 || items[3].(sessionItem).s.ID != "quick" || !items[2].(quickGroupItem).expanded {
+		t.Fatalf("expected the quick session listed under the expanded group, got %d items", len(items))
+	}
+
+	// Search results are never folded.
+	m.searchQuery = "needle"
+	m.applySessions(in)
+	if len(m.list.Items()) != 3 {
+		t.Fatalf("expected search results unfolded, got %d items", len(m.list.Items()))
+	}
+}
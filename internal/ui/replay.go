@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// replayState steps through a session's recorded shell commands so selected
+// ones can be re-run, one at a time and only after confirmation.
+type replayState struct {
+	sessionID  string
+	workdir    string
+	cmds       []index.ShellCommand
+	cursor     int
+	confirming bool
+	results    map[int]int // command index -> exit code of the replayed run
+}
+
+type replayRunMsg struct {
+	index    int
+	exitCode int
+	err      error
+}
+
+func (r replayState) active() bool {
+	return r.sessionID != ""
+}
+
+func (m *Model) openReplay(sessionID string, msgs []index.Message) {
+	cmds := index.ExtractShellCommands(msgs)
+	if len(cmds) == 0 {
+		m.status = "No shell commands recorded in this session"
+		return
+	}
+	m.replay = replayState{
+		sessionID: sessionID,
+		workdir:   m.sessions[sessionID].Workdir,
+		cmds:      cmds,
+		results:   make(map[int]int),
+	}
+	m.focusOnList = false
+	m.status = "Replay: enter to run, s to skip, esc to leave"
+}
+
+func (m Model) updateReplay(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	r := &m.replay
+	if r.confirming {
+		switch msg.String() {
+		case "y", "Y":
+			r.confirming = false
+			return m, m.replayRunCmd(r.cursor)
+		default:
+			r.confirming = false
+			m.status = "Replay cancelled"
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.replay = replayState{}
+		m.status = "Left replay mode"
+		return m, m.renderSelected(false)
+	case "up", "k":
+		if r.cursor > 0 {
+			r.cursor--
+		}
+	case "down", "j", "s":
+		if r.cursor < len(r.cmds)-1 {
+			r.cursor++
+		}
+	case "enter":
+		dir := r.commandDir(r.cursor)
+		if dir == "" {
+			m.status = "Cannot replay: session has no workdir"
+			return m, nil
+		}
+		if st, err := os.Stat(dir); err != nil || !st.IsDir() {
+			m.status = "Cannot replay: workdir missing: " + dir
+			return m, nil
+		}
+		r.confirming = true
+		m.status = fmt.Sprintf("Run command %d in %s? (y/n)", r.cursor+1, dir)
+	}
+	return m, nil
+}
+
+func (r replayState) commandDir(idx int) string {
+	if idx < 0 || idx >= len(r.cmds) {
+		return ""
+	}
+	if wd := r.cmds[idx].Workdir; wd != "" {
+		return wd
+	}
+	return r.workdir
+}
+
+// replayWrapper runs the script file in $1 in its own shell, so a command
+// ending in a backslash or an open heredoc cannot swallow the lines that
+// report its exit status.
+const replayWrapper = `"$0" "$1"
+status=$?
+printf '\n[agent-trace] exit %d - press enter to return ' "$status"
+read -r _
+exit $status`
+
+// replayCommand writes command to a script file and returns the process
+// that runs it and reports its status, and a func removing the file.
+func replayCommand(command string) (*exec.Cmd, func(), error) {
+	shell := "bash"
+	if _, err := exec.LookPath(shell); err != nil {
+		shell = "sh"
+	}
+	f, err := os.CreateTemp("", "agent-trace-replay-*.sh")
+	if err != nil {
+		return nil, nil, fmt.Errorf("write replay script: %w", err)
+	}
+	cleanup := func() { _ = os.Remove(f.Name()) }
+	_, err = f.WriteString(command + "\n")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("write replay script: %w", err)
+	}
+	return exec.Command(shell, "-c", replayWrapper, shell, f.Name()), cleanup, nil
+}
+
+// replayRunCmd suspends the TUI and runs the command in a shell so its output
+// (and any prompts) stay visible until the user presses enter.
+func (m Model) replayRunCmd(idx int) tea.Cmd {
+	if idx < 0 || idx >= len(m.replay.cmds) {
+		return nil
+	}
+	cmd, cleanup, err := replayCommand(m.replay.cmds[idx].Command)
+	if err != nil {
+		return func() tea.Msg { return replayRunMsg{index: idx, err: err} }
+	}
+	cmd.Dir = m.replay.commandDir(idx)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		cleanup()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return replayRunMsg{index: idx, exitCode: exitErr.ExitCode()}
+		}
+		return replayRunMsg{index: idx, err: err}
+	})
+}
+
+func (m *Model) applyReplayRun(msg replayRunMsg) {
+	if !m.replay.active() {
+		return
+	}
+	if msg.err != nil {
+		m.status = "Replay error: " + msg.err.Error()
+		m.log.Error("replay failed", "command", msg.index+1, "err", msg.err)
+		return
+	}
+	m.replay.results[msg.index] = msg.exitCode
+	m.status = fmt.Sprintf("Command %d exited %d", msg.index+1, msg.exitCode)
+	if msg.index == m.replay.cursor && m.replay.cursor < len(m.replay.cmds)-1 {
+		m.replay.cursor++
+	}
+}
+
+func (r replayState) view(width, height int) string {
+	header := shortcutsTitleStyle.Render(fmt.Sprintf("Replay %d commands", len(r.cmds))) +
+		pickerDetailStyle.Render("  ↑/↓ move · enter run · s skip · esc leave")
+	lines := []string{header, pickerDetailStyle.Render("workdir: " + r.workdir), ""}
+
+	// A command is confirmed with all of its lines in view, not only the
+	// summary line below.
+	var confirm []string
+	if r.confirming && r.cursor < len(r.cmds) {
+		confirm = append(confirm, "", shortcutsTitleStyle.Render(fmt.Sprintf("Run command %d in %s? (y/n)", r.cursor+1, r.commandDir(r.cursor))))
+		wrapped := lipgloss.NewStyle().Width(max(width-4, 10)).Render(strings.TrimRight(r.cmds[r.cursor].Command, "\n"))
+		for _, line := range strings.Split(wrapped, "\n") {
+			confirm = append(confirm, "  "+line)
+		}
+	}
+
+	// Keep the cursor entry in view; each command takes one summary line.
+	rows := max(height-len(lines)-len(confirm)-1, 1)
+	start := 0
+	if r.cursor >= rows {
+		start = r.cursor - rows + 1
+	}
+	for idx := start; idx < len(r.cmds) && idx < start+rows; idx++ {
+		c := r.cmds[idx]
+		recorded := "exit=?"
+		if c.HasExit {
+			recorded = fmt.Sprintf("exit=%d", c.ExitCode)
+		} else if c.Failed {
+			recorded = "failed or not run"
+		}
+		if code, ok := r.results[idx]; ok {
+			recorded += fmt.Sprintf(" → replayed=%d", code)
+		}
+		first := strings.SplitN(strings.TrimSpace(c.Command), "\n", 2)[0]
+		if strings.Contains(strings.TrimSpace(c.Command), "\n") {
+			first += " …"
+		}
+		line := fmt.Sprintf("%3d  %s  %s", idx+1, first, pickerDetailStyle.Render(recorded))
+		line = ansi.Truncate(line, width-2, "…")
+		if idx == r.cursor {
+			line = pickerCursorStyle.Render("▸ ") + line
+		} else {
+			line = "  " + line
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, confirm...)
+	return lipgloss.NewStyle().Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestReplayRequiresConfirmation(t *testing.T) {
+	dir := t.TempDir()
+	m := Model{
+		keys:     defaultKeys(),
+		sessions: map[string]index.Session{"s1": {ID: "s1", Workdir: dir}},
+		messages: map[string][]index.Message{"s1": {
+			{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"ls"}`},
+			{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"pwd"}`},
+		}},
+	}
+	m.openReplay("s1", m.messages["s1"])
+	if !m.replay.active() || len(m.replay.cmds) != 2 {
+		t.Fatalf("expected replay with 2 commands, got %#v", m.replay)
+	}
+
+	updated, cmd := m.updateReplay(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(Model)
+	if !m.replay.confirming || cmd != nil {
+		t.Fatalf("expected enter to ask for confirmation without running")
+	}
+
+	updated, cmd = m.updateReplay(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	m = updated.(Model)
+	if m.replay.confirming || cmd != nil {
+		t.Fatalf("expected n to cancel without running")
+	}
+
+	m.applyReplayRun(replayRunMsg{index: 0, exitCode: 3})
+	if m.replay.results[0] != 3 || m.replay.cursor != 1 {
+		t.Fatalf("expected result recorded and cursor advanced, got %#v", m.replay)
+	}
+}
+
+func TestReplayConfirmationShowsWholeCommand(t *testing.T) {
+	r := replayState{
+		sessionID:  "s1",
+		workdir:    "/repo",
+		cmds:       []index.ShellCommand{{Command: "cat <<'EOF' > notes.txt\nfirst\nsecond\nEOF"}},
+		confirming: true,
+	}
+	view := ansi.Strip(r.view(80, 20))
+	for _, want := range []string{"Run command 1 in /repo? (y/n)", "first", "second", "EOF"} {
+		if !strings.Contains(view, want) {
+			t.Fatalf("expected %q in the confirmation, got:\n%s", want, view)
+		}
+	}
+}
+
+func TestCtrlCQuitsDuringReplay(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.replay = replayState{sessionID: "s1", cmds: []index.ShellCommand{{Command: "ls"}}, confirming: true}
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("expected ctrl+c to quit during replay")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Fatal("expected ctrl+c to quit during replay")
+	}
+
+	m.replay.confirming = false
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	if updated.(Model).replay.active() {
+		t.Fatal("expected q to leave replay")
+	}
+}
+
+func TestReplayCommandReportsStatusOfUnterminatedCommands(t *testing.T) {
+	for command, want := range map[string]int{
+		"(exit 3) \\":         3,
+		"cat <<'EOF'\nexit 0": 0,
+		"exit 4":              4,
+	} {
+		cmd, cleanup, err := replayCommand(command)
+		if err != nil {
+			t.Fatalf("replay command: %v", err)
+		}
+		out, err := cmd.Output()
+		cleanup()
+		code := 0
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("%q: %v", command, err)
+		}
+		if code != want || !strings.Contains(string(out), fmt.Sprintf("[agent-trace] exit %d", want)) {
+			t.Errorf("%q: exit %d, output %q; want %d reported", command, code, out, want)
+		}
+	}
+}
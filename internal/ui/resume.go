@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+)
+
+// resumeData is what resume command templates can refer to.
+type resumeData struct {
+	SessionID string
+	Workdir   string
+	Source    string
+}
+
+// resumeArgv expands the resume command for the session's source. It
+// returns nil when the source has no resume command.
+func resumeArgv(commands map[string]config.CommandTemplate, session index.Session) ([]string, error) {
+	if commands == nil {
+		var err error
+		if commands, err = config.ResumeCommands(nil); err != nil {
+			return nil, err
+		}
+	}
+	tmpl, ok := commands[session.Source]
+	if !ok {
+		return nil, nil
+	}
+	return tmpl.Expand(resumeData{SessionID: session.ID, Workdir: session.Workdir, Source: session.Source})
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// Safe render treats agent-written markdown as untrusted: constructs that
+// could pass for the viewer's own headings and hints, or that glamour would
+// hide, are shown literally instead of rendered. Code blocks are left alone.
+var (
+	atxHeadingRe    = regexp.MustCompile(`^ {0,3}#{1,6}(\s|$)`)
+	setextRe        = regexp.MustCompile(`^ {0,3}(=+|-+)\s*$`)
+	hintQuoteRe     = regexp.MustCompile(`^ {0,3}>\s*\[`)
+	markdownImageRe = regexp.MustCompile(`!\[([^\]]*)\]\(([^)]*)\)`)
+	htmlTagRe       = regexp.MustCompile(`<(/?[A-Za-z][A-Za-z0-9-]*|!--)`)
+	fenceOpenRe     = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})(.*)$")
+	fenceCloseRe    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*$")
+)
+
+// safeMarkdown neutralizes headings, hint-like blockquotes, images and raw
+// HTML outside code fences. Fences follow CommonMark, and one left open is
+// closed at the end so it cannot swallow the turns rendered after it.
+func safeMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	fence := ""
+	for i, line := range lines {
+		if fence != "" {
+			if closesFence(line, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if m := fenceOpenRe.FindStringSubmatch(line); m != nil && !(m[1][0] == '`' && strings.Contains(m[2], "`")) {
+			fence = m[1]
+			continue
+		}
+		switch {
+		case atxHeadingRe.MatchString(line), hintQuoteRe.MatchString(line):
+			line = escapeFirstMarker(line)
+		case setextRe.MatchString(line) && i > 0 && strings.TrimSpace(lines[i-1]) != "":
+			line = escapeFirstMarker(line)
+		}
+		line = markdownImageRe.ReplaceAllString(line, "[image: $1]($2)")
+		line = htmlTagRe.ReplaceAllString(line, `\<$1`)
+		lines[i] = line
+	}
+	if fence != "" {
+		lines = append(lines, fence)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// closesFence reports whether line closes a code block opened with fence:
+// the same character, at least as long, and nothing after it but spaces.
+func closesFence(line, fence string) bool {
+	m := fenceCloseRe.FindStringSubmatch(line)
+	return m != nil && m[1][0] == fence[0] && len(m[1]) >= len(fence)
+}
+
+// escapeFirstMarker backslash-escapes the first non-space character so the
+// line renders as literal text.
+func escapeFirstMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	return line[:len(line)-len(trimmed)] + `\` + trimmed
+}
+
+// safeMessages returns msgs with assistant content passed through
+// safeMarkdown. User prompts are the viewer's own words and tool output is
+// already fenced, so both are kept as they are.
+func safeMessages(msgs []index.Message) []index.Message {
+	out := make([]index.Message, len(msgs))
+	for i, msg := range msgs {
+		if msg.Role == "assistant" {
+			msg.Content = safeMarkdown(msg.Content)
+		}
+		out[i] = msg
+	}
+	return out
+}
+
+func safeSubagents(subs []index.Subagent) []index.Subagent {
+	out := make([]index.Subagent, len(subs))
+	for i, sub := range subs {
+		sub.Messages = safeMessages(sub.Messages)
+		out[i] = sub
+	}
+	return out
+}
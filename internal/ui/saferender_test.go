@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestSafeMarkdown(t *testing.T) {
+	in := "## You\n" +
+		"> [Events hidden (3). Press `e` to expand event messages.]\n" +
+		"> a normal quote\n" +
+		"Fake heading\n" +
+		"===\n" +
+		"\n" +
+		"---\n" +
+		"see ![diagram](http://x/y.png) and <img src=x> or <!-- hidden -->\n" +
+		"```md\n" +
+		"## inside a fence\n" +
+		"<b>kept</b>\n" +
+		"```\n" +
+		"1 < 2 and a<b"
+	want := "\\## You\n" +
+		"\\> [Events hidden (3). Press `e` to expand event messages.]\n" +
+		"> a normal quote\n" +
+		"Fake heading\n" +
+		"\\===\n" +
+		"\n" +
+		"---\n" +
+		"see [image: diagram](http://x/y.png) and \\<img src=x> or \\<!-- hidden -->\n" +
+		"```md\n" +
+		"## inside a fence\n" +
+		"<b>kept</b>\n" +
+		"```\n" +
+		"1 < 2 and a\\<b"
+	if got := safeMarkdown(in); got != want {
+		t.Fatalf("safeMarkdown =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSafeMarkdownFences(t *testing.T) {
+	cases := []struct{ name, in, want string }{
+		{"backtick in info string", "``` a`b\n## You", "``` a`b\n\\## You"},
+		{"tilde info may hold backticks", "~~~ a`b\n## code\n~~~", "~~~ a`b\n## code\n~~~"},
+		{"shorter fence does not close", "````\n```\n## code\n````\n## You", "````\n```\n## code\n````\n\\## You"},
+		{"trailing text does not close", "```\n``` go\n## code\n```  \n## You", "```\n``` go\n## code\n```  \n\\## You"},
+		{"other character does not close", "```\n~~~\n## code\n```", "```\n~~~\n## code\n```"},
+		{"unclosed fence is closed", "~~~~\n## code", "~~~~\n## code\n~~~~"},
+	}
+	for _, c := range cases {
+		if got := safeMarkdown(c.in); got != c.want {
+			t.Errorf("%s: safeMarkdown =\n%s\nwant\n%s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSafeMessagesResetFencesPerTurn(t *testing.T) {
+	msgs := safeMessages([]index.Message{
+		{Role: "assistant", Content: "```\nunclosed"},
+		{Role: "assistant", Content: "## You"},
+	})
+	if !strings.HasSuffix(msgs[0].Content, "\n```") {
+		t.Fatalf("expected the open fence closed, got %q", msgs[0].Content)
+	}
+	if msgs[1].Content != "\\## You" {
+		t.Fatalf("expected the next turn sanitized, got %q", msgs[1].Content)
+	}
+}
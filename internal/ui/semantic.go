@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+)
+
+// semanticTimeout bounds embedding a search query.
+const semanticTimeout = 30 * time.Second
+
+// UseEmbedder enables semantic search: a query starting with about: is embedded
+// with e and matched by meaning instead of words.
+func (m *Model) UseEmbedder(e index.Embedder) {
+	m.embedder = e
+}
+
+// semanticQuery returns the text of an about: query.
+func semanticQuery(query string) (string, bool) {
+	text, ok := strings.CutPrefix(strings.TrimSpace(query), "about:")
+	return strings.TrimSpace(text), ok
+}
+
+// listSessions runs a session search, semantic for about: queries.
+func (m Model) listSessions(query string, limit int) ([]index.Session, error) {
+	text, ok := semanticQuery(query)
+	if !ok {
+		return m.indexer.ListSessions(query, limit)
+	}
+	if m.embedder == nil {
+		return nil, errors.New("semantic (about:) search needs --embedder")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), semanticTimeout)
+	defer cancel()
+	return m.indexer.SemanticSearch(ctx, m.embedder, text, limit)
+}
@@ -0,0 +1,19 @@
+package ui
+
+import "testing"
+
+func TestSemanticQuery(t *testing.T) {
+	if text, ok := semanticQuery("  about: that flaky websocket test "); !ok || text != "that flaky websocket test" {
+		t.Fatalf("semanticQuery = %q, %v", text, ok)
+	}
+	if _, ok := semanticQuery("websocket about:test"); ok {
+		t.Fatal("only a leading about: makes a query semantic")
+	}
+	if _, err := (Model{}).listSessions("about:websocket", 10); err == nil {
+		t.Fatal("expected an error without an embedder")
+	}
+	m := Model{searchQuery: "about:websocket"}
+	if q := m.highlightQuery(); q != "" {
+		t.Fatalf("semantic searches should not highlight, got %q", q)
+	}
+}
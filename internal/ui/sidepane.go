@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+	"agent-trace/internal/pricing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sidePane is the optional third pane shown to the right of the transcript.
+type sidePane int
+
+const (
+	sidePaneNone sidePane = iota
+	sidePaneOutline
+	sidePaneStats
+)
+
+// sidePaneMinWidth is the terminal width below which the side pane is
+// hidden so list and transcript keep usable widths.
+const sidePaneMinWidth = 110
+
+func parseSidePane(s string) sidePane {
+	switch s {
+	case "outline":
+		return sidePaneOutline
+	case "stats":
+		return sidePaneStats
+	}
+	return sidePaneNone
+}
+
+func (p sidePane) String() string {
+	switch p {
+	case sidePaneOutline:
+		return "outline"
+	case sidePaneStats:
+		return "stats"
+	}
+	return ""
+}
+
+// sidePaneWidth is the width of the side pane at the current terminal size,
+// or 0 when it is off or does not fit.
+func (m Model) sidePaneWidth() int {
+	if m.sidePane == sidePaneNone || m.width < sidePaneMinWidth {
+		return 0
+	}
+	return min(max(m.width/5, 28), 44)
+}
+
+// cycleSidePane steps through none, outline and stats, and saves the choice
+// so the next run starts with the same layout.
+func (m *Model) cycleSidePane() tea.Cmd {
+	m.sidePane = (m.sidePane + 1) % 3
+	m.resize()
+	switch {
+	case m.sidePane == sidePaneNone:
+		m.status = "Side pane off"
+	case m.width < sidePaneMinWidth:
+		m.status = fmt.Sprintf("Side pane: %s (hidden below %d columns)", m.sidePane, sidePaneMinWidth)
+	default:
+		m.status = "Side pane: " + m.sidePane.String()
+	}
+	path, st, log := m.cfg.StatePath, m.cfg.State, m.log
+	st.SidePane = m.sidePane.String()
+	if path == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		if err := config.SaveState(path, st); err != nil {
+			log.Error("saving layout failed", "path", path, "err", err)
+			return statusMsg{text: "Could not save layout: " + err.Error()}
+		}
+		return nil
+	}
+}
+
+func (m Model) sidePaneView(width, height int) string {
+	if m.selectedID == "" {
+		return sidePaneTitleStyle.Render(strings.ToUpper(m.sidePane.String()))
+	}
+	var lines []string
+	if m.sidePane == sidePaneStats {
+		lines = m.statsLines()
+	} else {
+		lines = outlineLines(m.messages[m.selectedID])
+	}
+	out := make([]string, 0, height)
+	out = append(out, sidePaneTitleStyle.Render(strings.ToUpper(m.sidePane.String())))
+	if m.partial[m.selectedID] {
+		// Both panes are worked out from the loaded messages alone.
+		out = append(out, sidePaneDimStyle.Render(fmt.Sprintf("latest %d messages only", len(m.messages[m.selectedID]))))
+	}
+	out = append(out, "")
+	for _, line := range lines {
+		if len(out) == height {
+			break
+		}
+		out = append(out, ansi.Truncate(line, width, "…"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// outlineLines lists the user prompts of a transcript as numbered turns.
+func outlineLines(msgs []index.Message) []string {
+	if msgs == nil {
+		return []string{sidePaneDimStyle.Render("loading…")}
+	}
+	var out []string
+	for _, msg := range msgs {
+		if msg.Role != "user" || msg.Type != "message" || isLikelyEnvironmentBoilerplate(msg.Content) {
+			continue
+		}
+		text := strings.Join(strings.Fields(msg.Content), " ")
+		if strings.HasPrefix(strings.ToLower(text), "# agents.md instructions for ") {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%2d. %s", len(out)+1, text))
+	}
+	if len(out) == 0 {
+		return []string{sidePaneDimStyle.Render("no prompts")}
+	}
+	return out
+}
+
+func (m Model) statsLines() []string {
+	s := m.sessions[m.selectedID]
+	msgs := m.messages[m.selectedID]
+	row := func(label, value string) string {
+		return sidePaneDimStyle.Render(fmt.Sprintf("%-9s", label)) + " " + value
+	}
+
+	var first, last int64
+	var prompts, replies int
+	for _, msg := range msgs {
+		if msg.TS.Valid && msg.TS.Int64 > 0 {
+			if first == 0 || msg.TS.Int64 < first {
+				first = msg.TS.Int64
+			}
+			last = max(last, msg.TS.Int64)
+		}
+		if msg.Type != "message" {
+			continue
+		}
+		switch msg.Role {
+		case "user":
+			if !isLikelyEnvironmentBoilerplate(msg.Content) {
+				prompts++
+			}
+		case "assistant":
+			replies++
+		}
+	}
+
+	lines := []string{
+		row("source", s.Source),
+		row("messages", fmt.Sprint(s.MessageCount)),
+	}
+	if msgs != nil {
+		lines = append(lines,
+			row("prompts", fmt.Sprint(prompts)),
+			row("replies", fmt.Sprint(replies)),
+			row("tools", fmt.Sprint(index.CountToolCalls(msgs))),
+		)
+	}
+	if first > 0 {
+		lines = append(lines,
+			row("started", index.FormatUnix(first)),
+			row("duration", (time.Duration(last-first)*time.Second).String()),
+		)
+	}
+	if l := index.SummarizeLatencies(index.ResponseLatencies(msgs)); l.Turns > 0 {
+		lines = append(lines,
+			row("wait", l.Median.String()+" median"),
+			row("  min", l.Min.String()),
+			row("  max", l.Max.String()),
+		)
+	}
+	lines = append(lines, row("last", index.FormatUnix(s.LastActivityTS)))
+	if u := index.SumUsage(s.Usage); u.Total() > 0 {
+		lines = append(lines,
+			"",
+			row("tokens", pricing.FormatTokens(u.Total())),
+			row("  in", pricing.FormatTokens(u.InputTokens)),
+			row("  out", pricing.FormatTokens(u.OutputTokens)),
+			row("  cached", pricing.FormatTokens(u.CacheReadTokens)),
+			row("cost", m.sessionCost(s)),
+		)
+		for _, mu := range s.Usage {
+			lines = append(lines, row("model", mu.Model))
+		}
+	}
+	if s.Workdir != "" {
+		lines = append(lines, "", row("workdir", ""), s.Workdir)
+	}
+	return lines
+}
+
+var (
+	sidePaneTitleStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("212")).
+				Bold(true)
+	sidePaneDimStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("244"))
+)
@@ -0,0 +1,90 @@
+package ui
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestCycleSidePanePersistsAndFits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ui-state.json")
+	m := NewModel(config.AppConfig{StatePath: path}, nil, nil)
+	m.width, m.height = 160, 40
+
+	cmd := m.cycleSidePane()
+	if m.sidePane != sidePaneOutline {
+		t.Fatalf("side pane = %v, want outline", m.sidePane)
+	}
+	cmd()
+	if got := config.LoadState(path).SidePane; got != "outline" {
+		t.Fatalf("saved side pane = %q, want outline", got)
+	}
+
+	left, right := m.paneWidths()
+	if side := m.sidePaneWidth(); side == 0 || left+right+1+side+2 != m.width {
+		t.Fatalf("panes do not fill the width: left=%d right=%d side=%d", left, right, side)
+	}
+
+	m.width = sidePaneMinWidth - 1
+	if m.sidePaneWidth() != 0 {
+		t.Fatal("expected side pane hidden on narrow terminals")
+	}
+
+	restored := NewModel(config.AppConfig{State: config.LoadState(path)}, nil, nil)
+	if restored.sidePane != sidePaneOutline {
+		t.Fatalf("restored side pane = %v, want outline", restored.sidePane)
+	}
+}
+
+func TestOutlineLinesNumbersPrompts(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "<environment_context><cwd>/tmp</cwd></environment_context>"},
+		{Role: "user", Type: "message", Content: "fix the\nflaky test"},
+		{Role: "assistant", Type: "message", Content: "done"},
+		{Role: "user", Type: "message", Content: "now add docs"},
+	}
+	got := strings.Join(outlineLines(msgs), "\n")
+	if got != " 1. fix the flaky test\n 2. now add docs" {
+		t.Fatalf("outline =\n%s", got)
+	}
+}
+
+func TestStatsLinesShowResponseWaits(t *testing.T) {
+	at := func(ts int64) sql.NullInt64 { return sql.NullInt64{Int64: ts, Valid: true} }
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.selectedID = "s1"
+	m.sessions["s1"] = index.Session{ID: "s1", Source: "claude"}
+	m.messages["s1"] = []index.Message{
+		{Role: "user", Type: "message", Content: "fix the test", TS: at(100)},
+		{Role: "assistant", Type: "message", Content: "fixed", TS: at(105)},
+		{Role: "user", Type: "message", Content: "add docs", TS: at(200)},
+		{Role: "assistant", Type: "message", Content: "added", TS: at(275)},
+	}
+	got := ansi.Strip(strings.Join(m.statsLines(), "\n"))
+	for _, want := range []string{"wait      40s median", "  min     5s", "  max     1m15s"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in stats:\n%s", want, got)
+		}
+	}
+}
+
+func TestSidePaneLabelsAWindowedTranscript(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.sidePane = sidePaneStats
+	m.selectedID = "s1"
+	m.sessions["s1"] = index.Session{ID: "s1", Source: "claude"}
+	m.messages["s1"] = []index.Message{{Role: "user", Type: "message", Content: "fix the test"}}
+	if got := ansi.Strip(m.sidePaneView(40, 30)); strings.Contains(got, "only") {
+		t.Fatalf("expected no label on a full transcript:\n%s", got)
+	}
+	m.setPartial("s1", true)
+	if got := ansi.Strip(m.sidePaneView(40, 30)); !strings.Contains(got, "latest 1 messages only") {
+		t.Fatalf("expected a windowed transcript labeled:\n%s", got)
+	}
+}
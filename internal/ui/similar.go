@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"path/filepath"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// similarTextChars bounds each suggestion's preview.
+const similarTextChars = 80
+
+// prependSimilar adds a "Similar sessions:" list above the transcript,
+// naming each session's last activity, repo, summary or first prompt, and
+// the id prefix agent-trace open takes.
+func prependSimilar(md string, similar []index.Session) string {
+	if len(similar) == 0 {
+		return md
+	}
+	var b strings.Builder
+	b.WriteString("> **Similar sessions:**\n")
+	for _, s := range similar {
+		text := s.Summary
+		if text == "" {
+			text = s.Preview
+		}
+		b.WriteString("> - " + index.FormatUnix(s.LastActivityTS))
+		if s.Workdir != "" {
+			b.WriteString(" `" + filepath.Base(s.Workdir) + "`")
+		}
+		if text = shorten(strings.Join(strings.Fields(text), " "), similarTextChars); text != "" {
+			b.WriteString(" " + text)
+		}
+		id := s.ID
+		if len(id) > 8 {
+			id = id[:8]
+		}
+		b.WriteString(" (`" + id + "`)\n")
+	}
+	return b.String() + "\n" + md
+}
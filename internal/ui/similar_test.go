@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestPrependSimilar(t *testing.T) {
+	similar := []index.Session{
+		{ID: "b2222222-0000", LastActivityTS: 1768039200, Workdir: "/src/app", Preview: "the websocket\nreconnect test is flaky"},
+		{ID: "c3333333-0000", Summary: "Updated the release notes."},
+	}
+	want := "> **Similar sessions:**\n" +
+		"> - " + index.FormatUnix(1768039200) + " `app` the websocket reconnect test is flaky (`b2222222`)\n" +
+		"> - n/a Updated the release notes. (`c3333333`)\n\nbody"
+	if got := prependSimilar("body", similar); got != want {
+		t.Errorf("prependSimilar = %q, want %q", got, want)
+	}
+	if got := prependSimilar("body", nil); got != "body" {
+		t.Errorf("prependSimilar without sessions = %q", got)
+	}
+}
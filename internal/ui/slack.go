@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+// maxSlackOutcome bounds the outcome line of the Slack summary.
+const maxSlackOutcome = 280
+
+// slackEscaper escapes the characters Slack mrkdwn reserves for links and
+// mentions, and turns markdown bold into Slack's single-asterisk bold.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "**", "*")
+
+// buildSlackSnippet is buildPRSnippet in Slack mrkdwn: single-asterisk
+// bold, no headings, and the agent's last reply as the outcome.
+func buildSlackSnippet(session index.Session, msgs []index.Message, exportPath, usage string) string {
+	heading := "Codex"
+	if session.Source == "claude" {
+		heading = "Claude"
+	}
+	id := strings.TrimSpace(session.ID)
+	var b strings.Builder
+	b.WriteString("*" + heading + " session* `" + id + "` (`agent-trace open " + id + "`)\n")
+	b.WriteString("*Prompt:* " + slackEscaper.Replace(snippetNotes(session, msgs)) + "\n")
+	if outcome := lastReply(msgs); outcome != "" {
+		b.WriteString("*Outcome:* " + slackEscaper.Replace(shorten(outcome, maxSlackOutcome)) + "\n")
+	}
+	b.WriteString("*Export:* `" + snippetExportPath(exportPath) + "`\n")
+	if usage != "" {
+		b.WriteString("*Usage:* " + usage + "\n")
+	}
+	return b.String()
+}
+
+// lastReply is the agent's final message on one line, or "".
+func lastReply(msgs []index.Message) string {
+	for n := len(msgs) - 1; n >= 0; n-- {
+		m := msgs[n]
+		if m.Role != "assistant" || m.Type != "message" {
+			continue
+		}
+		if text := strings.Join(strings.Fields(m.Content), " "); text != "" {
+			return text
+		}
+	}
+	return ""
+}
@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestBuildSlackSnippet(t *testing.T) {
+	session := index.Session{ID: "s1", Source: "claude", Preview: "fix <the> parser & lexer"}
+	msgs := []index.Message{
+		{Role: "user", Type: "message", Content: "fix <the> parser & lexer"},
+		{Role: "assistant", Type: "message", Content: "Looking."},
+		{Role: "tool", Type: "tool_result", Content: "ok"},
+		{Role: "assistant", Type: "message", Content: "Fixed the **lexer**;\ntests pass."},
+	}
+	got := buildSlackSnippet(session, msgs, "/repo/docs/claude/s1.md", "")
+	want := "*Claude session* `s1` (`agent-trace open s1`)\n" +
+		"*Prompt:* fix &lt;the&gt; parser &amp; lexer\n" +
+		"*Outcome:* Fixed the *lexer*; tests pass.\n" +
+		"*Export:* `docs/claude/s1.md`\n"
+	if got != want {
+		t.Fatalf("unexpected snippet:\n%s", got)
+	}
+	if strings.Contains(got, "#") {
+		t.Fatal("expected no markdown headings")
+	}
+}
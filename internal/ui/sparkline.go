@@ -0,0 +1,32 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"agent-trace/internal/index"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws a session's activity scaled to its busiest bucket, with
+// the lifetime it covers, e.g. "▂▁█▃ 4d". Sessions without timestamps get
+// an empty string.
+func sparkline(a index.Activity) string {
+	if len(a.Counts) == 0 {
+		return ""
+	}
+	peak := 0
+	for _, c := range a.Counts {
+		peak = max(peak, c)
+	}
+	var b strings.Builder
+	for _, c := range a.Counts {
+		if c == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(sparkBlocks[(c*len(sparkBlocks)-1)/peak])
+	}
+	return fmt.Sprintf("%s %d%s", b.String(), a.Span, a.Unit)
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/index"
+)
+
+func TestSparkline(t *testing.T) {
+	cases := []struct {
+		a    index.Activity
+		want string
+	}{
+		{index.Activity{}, ""},
+		{index.Activity{Unit: "h", Span: 1, Counts: []int{3}}, "█ 1h"},
+		{index.Activity{Unit: "d", Span: 4, Counts: []int{1, 0, 8, 4}}, "▁ █▄ 4d"},
+	}
+	for _, c := range cases {
+		if got := sparkline(c.a); got != c.want {
+			t.Errorf("sparkline(%+v) = %q, want %q", c.a, got, c.want)
+		}
+	}
+}
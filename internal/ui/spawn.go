@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"agent-trace/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// defaultSpawnCommand opens a tmux window in the session's workdir.
+const defaultSpawnCommand = "tmux new-window -c {{.Workdir}} {{.Command}}"
+
+type spawnMsg struct {
+	sessionID string
+	err       error
+}
+
+// spawnData is what spawn command templates can refer to. Command is the
+// expanded resume command, quoted for a POSIX shell.
+type spawnData struct {
+	resumeData
+	Command string
+}
+
+// spawnArgv wraps the resume command in the spawn command. Without a
+// configured one it uses a new tmux window, which needs $TMUX.
+func spawnArgv(spawn *config.CommandTemplate, inTmux bool, resume []string, data resumeData) ([]string, error) {
+	if spawn == nil {
+		if !inTmux {
+			return nil, errors.New("not inside tmux; set --spawn-command to resume in another terminal")
+		}
+		ct, err := config.ParseCommandTemplate(defaultSpawnCommand)
+		if err != nil {
+			return nil, err
+		}
+		spawn = &ct
+	}
+	if data.Workdir == "" {
+		data.Workdir = "."
+	}
+	return spawn.Expand(spawnData{resumeData: data, Command: shellJoin(resume)})
+}
+
+// shellJoin quotes args for a POSIX shell.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for n, a := range args {
+		if a != "" && strings.IndexFunc(a, func(r rune) bool {
+			return !(r == '-' || r == '_' || r == '.' || r == '/' || r == '=' || r == ':' || r == ',' || r == '@' ||
+				r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+		}) < 0 {
+			quoted[n] = a
+			continue
+		}
+		quoted[n] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// spawnResumeCmd resumes the session through the spawn command, which is
+// expected to return once the new window or terminal is up, so the TUI
+// keeps running.
+func (m Model) spawnResumeCmd(sessionID string) tea.Cmd {
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	data := resumeData{SessionID: session.ID, Workdir: session.Workdir, Source: session.Source}
+	resume, err := resumeArgv(m.cfg.ResumeCommands, session)
+	if err != nil || resume == nil {
+		return func() tea.Msg {
+			if err == nil {
+				err = fmt.Errorf("no resume command for %s sessions", session.Source)
+			}
+			return spawnMsg{sessionID: sessionID, err: err}
+		}
+	}
+	argv, err := spawnArgv(m.cfg.SpawnCommand, os.Getenv("TMUX") != "", resume, data)
+	if err != nil {
+		return func() tea.Msg { return spawnMsg{sessionID: sessionID, err: err} }
+	}
+	log := m.log
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+		cmd.Dir = session.Workdir
+		log.Info("resuming session in new window", "session", sessionID, "command", argv)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				err = fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+			} else {
+				err = fmt.Errorf("%s: %w", argv[0], err)
+			}
+			return spawnMsg{sessionID: sessionID, err: err}
+		}
+		return spawnMsg{sessionID: sessionID}
+	}
+}
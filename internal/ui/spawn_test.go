@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+
+	"agent-trace/internal/config"
+)
+
+func TestSpawnArgv(t *testing.T) {
+	resume := []string{"claude", "--resume", "abc"}
+	data := resumeData{SessionID: "abc", Workdir: "/src/it's here", Source: "claude"}
+
+	got, err := spawnArgv(nil, true, resume, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"tmux", "new-window", "-c", "/src/it's here", "claude --resume abc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("tmux: got %q, want %q", got, want)
+	}
+	if _, err := spawnArgv(nil, false, resume, data); err == nil {
+		t.Fatal("expected an error outside tmux without a spawn command")
+	}
+
+	ct, err := config.ParseCommandTemplate("kitty --directory {{.Workdir}} sh -c {{.Command}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = spawnArgv(&ct, false, []string{"wrap", "it's", "{{x}}"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"kitty", "--directory", "/src/it's here", "sh", "-c", `wrap 'it'\''s' '{{x}}'`}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("custom: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestApplySourceTogglesOnSourceChange(t *testing.T) {
+	on, off := true, false
+	m := Model{
+		cfg: config.AppConfig{SourceToggles: map[string]config.ToggleProfile{
+			"codex":  {Events: &on},
+			"claude": {Events: &off, Tools: &on},
+		}},
+		sessions: map[string]index.Session{
+			"c1": {ID: "c1", Source: "codex"},
+			"c2": {ID: "c2", Source: "codex"},
+			"k1": {ID: "k1", Source: "claude"},
+		},
+	}
+
+	m.selectedID = "c1"
+	m.applySourceToggles()
+	if !m.includeEvents || m.includeTools {
+		t.Fatalf("expected codex profile applied, got events=%t tools=%t", m.includeEvents, m.includeTools)
+	}
+
+	// A manual toggle survives moving to another session of the same source.
+	m.includeEvents = false
+	m.selectedID = "c2"
+	m.applySourceToggles()
+	if m.includeEvents {
+		t.Fatalf("expected manual toggle to stick within the same source")
+	}
+
+	m.selectedID = "k1"
+	m.applySourceToggles()
+	if m.includeEvents || !m.includeTools {
+		t.Fatalf("expected claude profile applied, got events=%t tools=%t", m.includeEvents, m.includeTools)
+	}
+}
+
+func TestTimestampToggleReachesTranscriptAndCacheKey(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	before := m.renderCacheKey("s1")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'@'}})
+	m = updated.(Model)
+	if !m.transcriptToggles().Timestamps {
+		t.Fatal("expected @ to turn timestamps on")
+	}
+	if m.renderCacheKey("s1") == before {
+		t.Fatal("expected the render cache key to change with timestamps")
+	}
+}
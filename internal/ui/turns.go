@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"agent-trace/internal/export"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// turnHeadingRe matches a numbered prompt heading in transcript markdown.
+var turnHeadingRe = regexp.MustCompile(`^## You \(turn (\d+)\)`)
+
+// startGotoTurn opens the status-bar prompt for a turn number to jump to.
+func (m *Model) startGotoTurn() {
+	if m.selectedID == "" || m.doc.active() {
+		return
+	}
+	m.gotoMode = true
+	m.gotoInput.SetValue("")
+	m.gotoInput.Focus()
+}
+
+// updateGotoTurn edits the turn number; enter jumps, esc cancels.
+func (m Model) updateGotoTurn(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.gotoMode = false
+		m.gotoInput.Blur()
+		return m, nil
+	case "enter":
+		m.gotoMode = false
+		m.gotoInput.Blur()
+		n, err := strconv.Atoi(strings.TrimSpace(m.gotoInput.Value()))
+		if err != nil || n < 1 {
+			m.status = "Turn numbers start at 1"
+			return m, nil
+		}
+		m.gotoTurn(n)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.gotoInput, cmd = m.gotoInput.Update(msg)
+	return m, cmd
+}
+
+// gotoTurn scrolls the transcript to the heading of prompt n.
+func (m *Model) gotoTurn(n int) {
+	id := m.selectedID
+	if before := m.turnsBefore[id]; n <= before {
+		m.status = fmt.Sprintf("Turn %d lies before the loaded part of the transcript; scroll to the top to load it", n)
+		return
+	}
+	rendered, ok := m.rendered[m.renderCacheKey(id)]
+	if !ok {
+		m.status = "The transcript is still rendering"
+		return
+	}
+	line := turnLine(strings.Split(ansi.Strip(rendered), "\n"), n)
+	if line < 0 {
+		m.status = fmt.Sprintf("No turn %d in this view", n)
+		return
+	}
+	m.viewport.SetYOffset(m.clampViewportOffset(line))
+	m.status = fmt.Sprintf("Turn %d", n)
+}
+
+// tocEntry is a numbered prompt in the table of contents.
+type tocEntry struct {
+	turn int
+	text string
+}
+
+// tocEntries lists the numbered prompts of a transcript with their first
+// line, read back from its markdown so they match the headings on screen.
+func tocEntries(md string) []tocEntry {
+	var out []tocEntry
+	lines := strings.Split(md, "\n")
+	for i, line := range lines {
+		m := turnHeadingRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		e := tocEntry{turn: n}
+		for _, next := range lines[i+1:] {
+			if next = strings.TrimSpace(next); next != "" {
+				e.text = next
+				break
+			}
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// openTOC offers the prompts of the open transcript; choosing one scrolls
+// to it.
+func (m *Model) openTOC() {
+	id := m.selectedID
+	msgs, ok := m.messages[id]
+	if id == "" || !ok || m.doc.active() {
+		return
+	}
+	toggles := m.transcriptToggles()
+	toggles.NumberTurns, toggles.TurnsBefore = true, m.turnsBefore[id]
+	entries := tocEntries(export.BuildTranscriptMarkdown(msgs, toggles, m.sessions[id].Source))
+	if len(entries) == 0 {
+		m.status = "No prompts in this view"
+		return
+	}
+	items := make([]pickerItem, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, pickerItem{label: fmt.Sprintf("%3d. %s", e.turn, e.text), value: strconv.Itoa(e.turn)})
+	}
+	title := "Contents"
+	if m.partial[id] {
+		title += " (loaded turns)"
+	}
+	m.picker = newPicker(pickerTOC, title, items)
+}
+
+// turnLine returns the rendered line holding the heading of turn n, or -1.
+func turnLine(lines []string, n int) int {
+	want := "You " + export.TurnLabel(n)
+	for i, line := range lines {
+		if text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#")); strings.HasPrefix(text, want) {
+			return i
+		}
+	}
+	return -1
+}
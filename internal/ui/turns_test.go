@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGotoTurnScrollsToPromptHeading(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.viewport.Width, m.viewport.Height = 40, 2
+	m.selectedID = "s"
+	m.turnsBefore["s"] = 10
+	lines := []string{"  ## You (turn 11)", "", "fix it", "  ## Claude", "", "done", "  ## You (turn 12)", "", "thanks", "", ""}
+	m.rendered[m.renderCacheKey("s")] = strings.Join(lines, "\n")
+	m.refreshViewportFromCache()
+
+	var model tea.Model = m
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{':'}})
+	for _, r := range "12" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(Model)
+	if m.gotoMode || m.viewport.YOffset != 6 {
+		t.Fatalf("goto turn 12: mode %t, offset %d", m.gotoMode, m.viewport.YOffset)
+	}
+
+	m.gotoTurn(3)
+	if m.viewport.YOffset != 6 || !strings.Contains(m.status, "before the loaded part") {
+		t.Fatalf("turn before the window: offset %d, status %q", m.viewport.YOffset, m.status)
+	}
+	m.gotoTurn(20)
+	if !strings.Contains(m.status, "No turn 20") {
+		t.Fatalf("missing turn: status %q", m.status)
+	}
+}
+
+func TestTurnLineSkipsLongerNumbers(t *testing.T) {
+	lines := []string{"## You (turn 10)", "## You (turn 1)"}
+	if got := turnLine(lines, 1); got != 1 {
+		t.Fatalf("turnLine(1) = %d, want 1", got)
+	}
+}
+
+func TestTOCListsPromptsAndJumps(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.viewport.Width, m.viewport.Height = 40, 2
+	m.selectedID = "s"
+	m.messages["s"] = []index.Message{
+		{ID: 1, Role: "user", Type: "message", Content: "fix the flaky test\nit fails on CI"},
+		{ID: 2, Role: "assistant", Type: "message", Content: "fixed"},
+		{ID: 3, Role: "user", Type: "message", Content: "now ship it"},
+	}
+	m.turnsBefore["s"] = 4
+	lines := []string{"## You (turn 5)", "", "fix the flaky test", "## Claude", "", "fixed", "## You (turn 6)", "", "now ship it", "", ""}
+	m.rendered[m.renderCacheKey("s")] = strings.Join(lines, "\n")
+	m.refreshViewportFromCache()
+
+	var model tea.Model = m
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'='}})
+	m = model.(Model)
+	if !m.picker.active() || len(m.picker.items) != 2 || m.picker.items[0].label != "  5. fix the flaky test" {
+		t.Fatalf("contents = %+v", m.picker.items)
+	}
+	for _, r := range "ship" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m = model.(Model); m.picker.active() || m.viewport.YOffset != 6 {
+		t.Fatalf("choosing turn 6: picker %t, offset %d", m.picker.active(), m.viewport.YOffset)
+	}
+}
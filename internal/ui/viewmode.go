@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// viewMode is how the transcript pane shows the selected session.
+type viewMode int
+
+const (
+	viewRendered viewMode = iota // markdown rendered by glamour
+	viewMarkdown                 // the markdown source, wrapped
+	viewRaw                      // the JSONL lines the session was parsed from
+)
+
+// rawViewMaxBytes caps how much of a session's source files the raw view
+// reads.
+const rawViewMaxBytes = 4 << 20
+
+func (v viewMode) String() string {
+	switch v {
+	case viewMarkdown:
+		return "markdown"
+	case viewRaw:
+		return "raw"
+	}
+	return "rendered"
+}
+
+// cycleViewMode steps through rendered, markdown and raw.
+func (m *Model) cycleViewMode() tea.Cmd {
+	m.viewMode = (m.viewMode + 1) % 3
+	m.status = "View: " + m.viewMode.String()
+	return m.renderSelected(false)
+}
+
+// formatTranscript turns transcript markdown into pane content for the
+// rendered or markdown view.
+func formatTranscript(md string, wrap int, style string, mode viewMode, maxChunk int) string {
+	if mode == viewMarkdown {
+		return ansi.Wrap(md, wrap, "")
+	}
+	return renderMarkdown(md, wrap, style, maxChunk)
+}
+
+func (m Model) rawViewCmd(sessionID, cacheKey string, wrap, nonce int) tea.Cmd {
+	return func() tea.Msg {
+		events, truncated, err := m.indexer.RawEvents(sessionID, rawViewMaxBytes)
+		if err != nil {
+			return renderMsg{sessionID: sessionID, cacheKey: cacheKey, nonce: nonce, err: err}
+		}
+		var b strings.Builder
+		path := ""
+		for _, evt := range events {
+			if evt.Path != path {
+				path = evt.Path
+				fmt.Fprintf(&b, "==> %s <==\n\n", path)
+			}
+			fmt.Fprintf(&b, "-- line %d", evt.Line)
+			if evt.Err != "" {
+				fmt.Fprintf(&b, " (does not parse: %s)", evt.Err)
+			}
+			b.WriteString(" --\n")
+			var out bytes.Buffer
+			if evt.Err == "" && json.Indent(&out, []byte(evt.Data), "", "  ") == nil {
+				b.WriteString(out.String())
+			} else {
+				b.WriteString(evt.Data)
+			}
+			b.WriteString("\n\n")
+		}
+		if len(events) == 0 {
+			b.WriteString("No source lines found for this session.\n")
+		}
+		if truncated {
+			fmt.Fprintf(&b, "... [raw view stops after %d MB; open the files above for the rest] ...\n", rawViewMaxBytes>>20)
+		}
+		text := clampLongLines(ansi.Strip(b.String()), m.cfg.Display.LineChars)
+		return renderMsg{sessionID: sessionID, cacheKey: cacheKey, rendered: ansi.Wrap(text, wrap, ""), nonce: nonce}
+	}
+}
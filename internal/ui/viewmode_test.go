@@ -0,0 +1,29 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestViewModeCyclesAndFormats(t *testing.T) {
+	m := Model{}
+	var seen []string
+	for range 3 {
+		m.cycleViewMode()
+		seen = append(seen, m.viewMode.String())
+	}
+	if strings.Join(seen, ",") != "markdown,raw,rendered" {
+		t.Fatalf("unexpected cycle %v", seen)
+	}
+
+	md := "## You\n\nplease **refactor** the parser"
+	if got := formatTranscript(md, 80, "dark", viewMarkdown, 0); got != md {
+		t.Fatalf("expected the markdown source unchanged, got %q", got)
+	}
+	if got := formatTranscript(md, 80, "dark", viewRendered, 0); strings.Contains(got, "**refactor**") {
+		t.Fatalf("expected rendered output, got %q", got)
+	}
+	if got := formatTranscript(strings.Repeat("word ", 30), 20, "", viewMarkdown, 0); strings.Count(got, "\n") < 5 {
+		t.Fatalf("expected markdown wrapped to the pane, got %q", got)
+	}
+}
@@ -0,0 +1,160 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/notify"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxNotifyChars bounds the reply line shown in a notification.
+const maxNotifyChars = 200
+
+// watchTickMsg carries the generation of the check loop that scheduled
+// it, as followTickMsg does.
+type watchTickMsg struct{ gen int }
+
+// watchMsg reports one check of the watched sessions: which gained
+// messages, and the notifications that could not be shown.
+type watchMsg struct {
+	gen     int
+	updated []string
+	err     error
+}
+
+// toggleWatch starts or stops watching the selected session. Watched
+// sessions are checked in the background; a new reply from the agent
+// shows a desktop notification with its first line.
+func (m *Model) toggleWatch() tea.Cmd {
+	if m.selectedID == "" {
+		return nil
+	}
+	if m.watched[m.selectedID] {
+		delete(m.watched, m.selectedID)
+		m.status = "Stopped watching " + shorten(m.selectedID, 18)
+		return nil
+	}
+	first := len(m.watched) == 0
+	m.watched[m.selectedID] = true
+	m.status = "Watching " + shorten(m.selectedID, 18) + ": new replies notify (ctrl+n to stop)"
+	if !first {
+		// The running check loop picks it up.
+		return nil
+	}
+	m.watchGen++
+	return m.watchCmd()
+}
+
+func watchTick(gen int) tea.Cmd {
+	return tea.Tick(followInterval, func(time.Time) tea.Msg { return watchTickMsg{gen: gen} })
+}
+
+// watchCmd reads what was appended to each watched session and notifies
+// about the newest agent reply in it.
+func (m Model) watchCmd() tea.Cmd {
+	if len(m.watched) == 0 {
+		return nil
+	}
+	titles := make(map[string]string, len(m.watched))
+	for id := range m.watched {
+		titles[id] = m.watchTitle(id)
+	}
+	gen := m.watchGen
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg := watchMsg{gen: gen}
+		ids := make([]string, 0, len(titles))
+		for id := range titles {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			n, err := m.indexer.FollowSession(ctx, id)
+			if err != nil {
+				msg.err = err
+				continue
+			}
+			if n == 0 {
+				continue
+			}
+			msg.updated = append(msg.updated, id)
+			msgs, _, err := m.indexer.GetMessagesBefore(id, nil, n)
+			if err != nil {
+				msg.err = err
+				continue
+			}
+			if reply := newestReply(msgs); reply != "" {
+				if err := notify.Send(ctx, titles[id], reply); err != nil {
+					msg.err = err
+				}
+			}
+		}
+		return msg
+	}
+}
+
+func (m *Model) applyWatch(msg watchMsg) tea.Cmd {
+	if len(m.watched) == 0 {
+		return nil
+	}
+	if msg.err != nil {
+		m.log.Warn("watching sessions failed", "err", msg.err)
+		m.status = "Watch: " + msg.err.Error()
+	}
+	// A check from a loop stopped and restarted since still reloads what
+	// it read, but only the current loop schedules the next one.
+	var cmds []tea.Cmd
+	if msg.gen == m.watchGen {
+		cmds = append(cmds, watchTick(msg.gen))
+	}
+	for _, id := range msg.updated {
+		m.forgetRenders(id)
+		delete(m.messages, id)
+		m.setPartial(id, false)
+	}
+	if len(msg.updated) > 0 {
+		cmds = append(cmds, m.sessionsCmd(m.searchQuery))
+	}
+	return tea.Batch(cmds...)
+}
+
+// watchTitle names a session in its notifications.
+func (m Model) watchTitle(id string) string {
+	if alias := m.annotations[id].Alias; alias != "" {
+		return "agent-trace: " + alias
+	}
+	if wd := m.sessions[id].Workdir; wd != "" {
+		return "agent-trace: " + filepath.Base(wd)
+	}
+	return "agent-trace: " + shorten(id, 18)
+}
+
+// newestReply is the first line of the last agent reply in msgs, or "".
+func newestReply(msgs []index.Message) string {
+	for n := len(msgs) - 1; n >= 0; n-- {
+		if msgs[n].Role != "assistant" || msgs[n].Type != "message" {
+			continue
+		}
+		for _, line := range strings.Split(msgs[n].Content, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				return shorten(line, maxNotifyChars)
+			}
+		}
+	}
+	return ""
+}
+
+// watchLabel is the status bar tag for watched sessions.
+func (m Model) watchLabel() string {
+	if len(m.watched) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  [watching %d]", len(m.watched))
+}
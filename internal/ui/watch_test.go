@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/config"
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestWatchTogglesPerSessionAndReloadsUpdated(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.selectedID = "s"
+	m.messages["s"] = []index.Message{{Role: "user", Content: "hi"}}
+
+	var model tea.Model = m
+	model, cmd := model.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	m = model.(Model)
+	if !m.watched["s"] || cmd == nil {
+		t.Fatal("expected ctrl+n to start watching the session")
+	}
+	m.selectedID = "t"
+	if cmd := m.toggleWatch(); cmd != nil || !m.watched["t"] {
+		t.Fatal("expected a second session joined to the running check")
+	}
+	if got := m.watchLabel(); got != "  [watching 2]" {
+		t.Fatalf("watch label = %q", got)
+	}
+
+	if cmd := m.applyWatch(watchMsg{gen: m.watchGen, updated: []string{"s"}}); cmd == nil {
+		t.Fatal("expected another check scheduled")
+	}
+	if _, ok := m.messages["s"]; ok {
+		t.Fatal("expected the updated session reloaded")
+	}
+
+	m.toggleWatch()
+	m.selectedID = "s"
+	m.toggleWatch()
+	if len(m.watched) != 0 || m.watchLabel() != "" {
+		t.Fatalf("expected nothing watched, got %v", m.watched)
+	}
+	if cmd := m.applyWatch(watchMsg{gen: m.watchGen}); cmd != nil {
+		t.Fatal("expected checks to stop once nothing is watched")
+	}
+}
+
+func TestWatchRestartDropsTheOldLoop(t *testing.T) {
+	m := NewModel(config.AppConfig{}, nil, nil)
+	m.selectedID = "s"
+	m.toggleWatch()
+	old := m.watchGen
+	m.toggleWatch()
+	m.toggleWatch()
+	if cmd := m.applyWatch(watchMsg{gen: old}); cmd != nil {
+		t.Fatal("expected the stopped loop's check not to schedule another")
+	}
+	if cmd := m.applyWatch(watchMsg{gen: m.watchGen}); cmd == nil {
+		t.Fatal("expected the current loop to keep checking")
+	}
+	if _, cmd := m.Update(watchTickMsg{gen: old}); cmd != nil {
+		t.Fatal("expected a stale tick dropped")
+	}
+}
+
+func TestNewestReplyIsFirstLineOfLastAssistantMessage(t *testing.T) {
+	msgs := []index.Message{
+		{Role: "assistant", Type: "message", Content: "older"},
+		{Role: "assistant", Type: "message", Content: "\n  Done: tests pass.\nDetails follow."},
+		{Role: "assistant", Type: "tool_use", Content: "run go test"},
+		{Role: "user", Type: "message", Content: "thanks"},
+	}
+	if got := newestReply(msgs); got != "Done: tests pass." {
+		t.Fatalf("newestReply = %q", got)
+	}
+	if got := newestReply(msgs[2:]); got != "" {
+		t.Fatalf("expected no reply without an assistant message, got %q", got)
+	}
+	long := []index.Message{{Role: "assistant", Type: "message", Content: strings.Repeat("x", 500)}}
+	if got := newestReply(long); len([]rune(got)) > maxNotifyChars {
+		t.Fatalf("expected the line shortened, got %d runes", len([]rune(got)))
+	}
+}
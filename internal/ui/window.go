@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+
+	"agent-trace/internal/index"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// transcriptWindow is how many messages a transcript loads at first and per
+// older chunk, so selecting a 10k-message session stays instant.
+const transcriptWindow = 1000
+
+type olderMessagesMsg struct {
+	sessionID string
+	before    int64 // id of the oldest loaded message the chunk precedes
+	msgs      []index.Message
+	older     bool
+	// turnsBefore is how many prompts precede msgs when older is set.
+	turnsBefore int
+	err         error
+}
+
+// setPartial records whether only the newest messages of a session are
+// loaded.
+func (m *Model) setPartial(sessionID string, partial bool) {
+	if !partial {
+		delete(m.partial, sessionID)
+		return
+	}
+	if m.partial == nil {
+		m.partial = make(map[string]bool)
+	}
+	m.partial[sessionID] = true
+}
+
+// maybeLoadOlder fetches the previous chunk of a windowed transcript once it
+// is scrolled to the top.
+func (m *Model) maybeLoadOlder() tea.Cmd {
+	id := m.selectedID
+	if id == "" || m.doc.active() || !m.partial[id] || m.loadingOlder || !m.viewport.AtTop() {
+		return nil
+	}
+	msgs := m.messages[id]
+	if len(msgs) == 0 {
+		return nil
+	}
+	m.loadingOlder = true
+	m.status = "Loading older messages..."
+	first := msgs[0]
+	return func() tea.Msg {
+		older, more, err := m.indexer.GetMessagesBefore(id, &first, transcriptWindow)
+		turnsBefore := 0
+		if err == nil && more && len(older) > 0 {
+			turnsBefore, err = m.indexer.CountPromptsBefore(id, older[0])
+		}
+		return olderMessagesMsg{sessionID: id, before: first.ID, msgs: older, older: more, turnsBefore: turnsBefore, err: err}
+	}
+}
+
+// applyOlderMessages prepends a chunk and re-renders, keeping the line that
+// was at the top in view.
+func (m *Model) applyOlderMessages(msg olderMessagesMsg) tea.Cmd {
+	m.loadingOlder = false
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = "Could not load older messages: " + msg.err.Error()
+		m.log.Error("loading older messages failed", "session", msg.sessionID, "err", msg.err)
+		return nil
+	}
+	loaded := m.messages[msg.sessionID]
+	if len(loaded) == 0 || loaded[0].ID != msg.before {
+		// The transcript was reloaded while the chunk was in flight.
+		return nil
+	}
+	m.messages[msg.sessionID] = append(append(make([]index.Message, 0, len(msg.msgs)+len(loaded)), msg.msgs...), loaded...)
+	m.setPartial(msg.sessionID, msg.older)
+	if m.turnsBefore == nil {
+		m.turnsBefore = make(map[string]int)
+	}
+	m.turnsBefore[msg.sessionID] = msg.turnsBefore
+	m.status = fmt.Sprintf("Loaded %d older messages", len(msg.msgs))
+	if msg.sessionID != m.selectedID {
+		return nil
+	}
+	m.olderAnchor = max(m.viewport.TotalLineCount()-m.viewport.YOffset, 1)
+	return m.renderSelected(false)
+}
+
+// restoreOlderAnchor scrolls a re-rendered transcript so the line that was
+// at the top before older messages arrived is at the top again.
+func (m *Model) restoreOlderAnchor() {
+	if m.olderAnchor == 0 {
+		return
+	}
+	m.viewport.SetYOffset(m.clampViewportOffset(m.viewport.TotalLineCount() - m.olderAnchor))
+	m.olderAnchor = 0
+}
+
+// windowHint heads a transcript that starts mid-session.
+func windowHint(n int) string {
+	return fmt.Sprintf("> [Showing the latest %d messages. Scroll up past the top to load older ones.]\n\n", n)
+}
+
+// fullAction is a transcript action that needs every message of a session,
+// not just the loaded window.
+type fullAction int
+
+const (
+	fullReplay fullAction = iota
+	fullImages
+	fullFileRefs
+	fullCopyMenu
+)
+
+type fullMessagesMsg struct {
+	sessionID string
+	action    fullAction
+	msgs      []index.Message
+	err       error
+}
+
+// withAllMessages runs action on the session's whole transcript, reading it
+// from the index first when only a window is loaded.
+func (m *Model) withAllMessages(sessionID string, action fullAction) tea.Cmd {
+	msgs, ok := m.messages[sessionID]
+	if !ok {
+		m.status = "Transcript not loaded yet"
+		return nil
+	}
+	if !m.partial[sessionID] {
+		return m.runFullAction(sessionID, action, msgs)
+	}
+	m.status = "Loading the full transcript..."
+	idx := m.indexer
+	return func() tea.Msg {
+		msgs, err := idx.GetMessages(sessionID)
+		return fullMessagesMsg{sessionID: sessionID, action: action, msgs: msgs, err: err}
+	}
+}
+
+// applyFullMessages runs the action a full transcript was loaded for, unless
+// another session was selected meanwhile.
+func (m *Model) applyFullMessages(msg fullMessagesMsg) tea.Cmd {
+	if msg.err != nil {
+		m.err = msg.err
+		m.status = "Could not load the full transcript: " + msg.err.Error()
+		m.log.Error("loading full transcript failed", "session", msg.sessionID, "err", msg.err)
+		return nil
+	}
+	if msg.sessionID != m.selectedID {
+		return nil
+	}
+	m.status = ""
+	return m.runFullAction(msg.sessionID, msg.action, msg.msgs)
+}
+
+func (m *Model) runFullAction(sessionID string, action fullAction, msgs []index.Message) tea.Cmd {
+	switch action {
+	case fullReplay:
+		m.openReplay(sessionID, msgs)
+	case fullImages:
+		return m.openImages(msgs)
+	case fullFileRefs:
+		return m.openFileRefs(msgs)
+	case fullCopyMenu:
+		m.openCopyMenu(sessionID, msgs)
+	}
+	return nil
+}
+
+// allMessages returns every message of a session for exports, re-reading
+// the index when only a window is loaded. It runs inside commands.
+func (m Model) allMessages(sessionID string, loaded []index.Message, partial bool) ([]index.Message, error) {
+	if !partial {
+		return loaded, nil
+	}
+	return m.indexer.GetMessages(sessionID)
+}
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"agent-trace/internal/index"
+	"agent-trace/internal/logging"
+)
+
+func TestOlderMessagesPrependToWindow(t *testing.T) {
+	msg := func(id int64) index.Message {
+		return index.Message{ID: id, SessionID: "s1", Role: "user", Content: "m"}
+	}
+	m := Model{
+		messages: map[string][]index.Message{"s1": {msg(3), msg(4)}},
+		log:      logging.Discard(),
+	}
+	m.setPartial("s1", true)
+
+	m.applyOlderMessages(olderMessagesMsg{sessionID: "s1", before: 99, msgs: []index.Message{msg(1)}})
+	if len(m.messages["s1"]) != 2 {
+		t.Fatal("expected a chunk for a reloaded transcript to be dropped")
+	}
+
+	m.loadingOlder = true
+	m.applyOlderMessages(olderMessagesMsg{sessionID: "s1", before: 3, msgs: []index.Message{msg(1), msg(2)}, older: true})
+	var ids []int64
+	for _, mm := range m.messages["s1"] {
+		ids = append(ids, mm.ID)
+	}
+	if len(ids) != 4 || ids[0] != 1 || ids[3] != 4 {
+		t.Fatalf("expected the chunk prepended, got ids %v", ids)
+	}
+	if !m.partial["s1"] || m.loadingOlder {
+		t.Fatalf("expected the transcript to stay windowed and the load finished, partial=%v loading=%v", m.partial["s1"], m.loadingOlder)
+	}
+
+	m.applyOlderMessages(olderMessagesMsg{sessionID: "s1", before: 1, msgs: []index.Message{msg(0)}})
+	if m.partial["s1"] {
+		t.Fatal("expected the transcript complete once no older messages remain")
+	}
+	if got, _ := m.allMessages("s1", m.messages["s1"], m.partial["s1"]); len(got) != 5 {
+		t.Fatalf("expected a complete transcript to be exported as loaded, got %d messages", len(got))
+	}
+	if !strings.Contains(windowHint(1000), "latest 1000 messages") {
+		t.Fatalf("unexpected hint %q", windowHint(1000))
+	}
+}
+
+func TestWindowedActionsUseTheFullTranscript(t *testing.T) {
+	bash := func(cmd string) index.Message {
+		return index.Message{Role: "tool", Type: "tool_use", Source: "claude", Content: `Bash: {"command":"` + cmd + `"}`}
+	}
+	m := Model{
+		selectedID: "s1",
+		sessions:   map[string]index.Session{"s1": {ID: "s1"}},
+		messages:   map[string][]index.Message{"s1": {bash("pwd")}},
+		log:        logging.Discard(),
+	}
+	m.setPartial("s1", true)
+	if cmd := m.withAllMessages("s1", fullReplay); cmd == nil || m.replay.active() {
+		t.Fatal("expected a windowed replay to load the full transcript first")
+	}
+
+	full := fullMessagesMsg{sessionID: "s1", action: fullReplay, msgs: []index.Message{bash("ls"), bash("pwd")}}
+	m.selectedID = "s2"
+	m.applyFullMessages(full)
+	if m.replay.active() {
+		t.Fatal("expected a transcript for another session to be dropped")
+	}
+	m.selectedID = "s1"
+	m.applyFullMessages(full)
+	if !m.replay.active() || len(m.replay.cmds) != 2 {
+		t.Fatalf("expected every recorded command in the replay, got %#v", m.replay.cmds)
+	}
+
+	m.replay = replayState{}
+	m.setPartial("s1", false)
+	m.withAllMessages("s1", fullReplay)
+	if len(m.replay.cmds) != 1 {
+		t.Fatalf("expected a complete transcript used as loaded, got %#v", m.replay.cmds)
+	}
+}